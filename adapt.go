@@ -0,0 +1,66 @@
+package cmd
+
+import "reflect"
+
+// ArgsHandler adapts a function taking only the command's arguments into a
+// Handler.
+func ArgsHandler(f func(args []string) error) Handler {
+	return func(_ *ExecContext, args []string) error {
+		return f(args)
+	}
+}
+
+// ContextHandler adapts a function taking only the execution context into a
+// Handler.
+func ContextHandler(f func(ctx *ExecContext) error) Handler {
+	return func(ctx *ExecContext, _ []string) error {
+		return f(ctx)
+	}
+}
+
+// StructHandler adapts a function taking an arguments struct into a Handler.
+// The struct type T declares its arguments and flags using "arg" and "flag"
+// field tags, as described by RegisterStruct. When the handler is called, a
+// new T is filled from the parsed argument values and passed to f.
+//
+// If the command has no argument or flag specification of its own, the
+// handler parses the command's arguments using the specification declared
+// by T. Use StructArgs to include T's specification in the command's
+// descriptor, so that it appears in the command's usage and completions.
+func StructHandler[T any](f func(args T) error) Handler {
+	return StructContextHandler(func(_ *ExecContext, args T) error {
+		return f(args)
+	})
+}
+
+// StructContextHandler adapts a function taking the execution context and an
+// arguments struct into a Handler. See StructHandler.
+func StructContextHandler[T any](f func(ctx *ExecContext, args T) error) Handler {
+	st := reflect.TypeOf((*T)(nil)).Elem()
+	specArgs, specFlags, fields, specErr := structSpec(st)
+	return func(ctx *ExecContext, args []string) error {
+		if specErr != nil {
+			return specErr
+		}
+
+		values := ctx.Values
+		if c := ctx.Command; c == nil || (c.Args == nil && c.Flags == nil) {
+			spec := &Command{CommandDescriptor: CommandDescriptor{Args: specArgs, Flags: specFlags}}
+			var err error
+			if values, err = spec.ParseArgs(args); err != nil {
+				return err
+			}
+		}
+
+		var v T
+		fillStruct(reflect.ValueOf(&v).Elem(), fields, values)
+		return f(ctx, v)
+	}
+}
+
+// StructArgs returns the argument and flag specifications declared by the
+// "arg" and "flag" field tags of the struct type T.
+func StructArgs[T any]() ([]Arg, []Flag, error) {
+	args, flags, _, err := structSpec(reflect.TypeOf((*T)(nil)).Elem())
+	return args, flags, err
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type copyArgs struct {
+	Src   string   `arg:"src"`
+	Dst   []string `arg:"dst"`
+	Force bool     `flag:"force"`
+}
+
+func TestHandlerAdapters(t *testing.T) {
+	tree := NewTree(TreeDescriptor{Name: "root"})
+	tree.AddCommand(CommandDescriptor{
+		Name: "args",
+		Handler: ArgsHandler(func(args []string) error {
+			return fmt.Errorf("args %v", args)
+		}),
+	})
+	tree.AddCommand(CommandDescriptor{
+		Name: "ctx",
+		Handler: ContextHandler(func(ctx *ExecContext) error {
+			return fmt.Errorf("ctx %s", ctx.Command.Name)
+		}),
+	})
+	tree.AddCommand(CommandDescriptor{
+		Name: "copy",
+		Handler: StructHandler(func(a copyArgs) error {
+			return fmt.Errorf("copy %s %v %v", a.Src, a.Dst, a.Force)
+		}),
+	})
+	args, flags, err := StructArgs[copyArgs]()
+	if err != nil {
+		t.Fatalf("StructArgs failed: %v", err)
+	}
+	tree.AddCommand(CommandDescriptor{
+		Name:  "cp",
+		Args:  args,
+		Flags: flags,
+		Handler: StructContextHandler(func(ctx *ExecContext, a copyArgs) error {
+			return fmt.Errorf("%s %s %v %v", ctx.Command.Name, a.Src, a.Dst, a.Force)
+		}),
+	})
+	tree.AddCommand(CommandDescriptor{
+		Name: "bad",
+		Handler: StructHandler(func(a int) error {
+			return nil
+		}),
+	})
+
+	cases := []struct {
+		line string
+		err  string
+	}{
+		{"args a b", "args [a b]"},
+		{"ctx", "ctx ctx"},
+		{"copy a b c --force", "copy a [b c] true"},
+		{"copy a", "Missing argument 'dst'"},
+		{"cp --force a b", "cp a [b] true"},
+		{"cp a b --bogus", "Unknown flag '--bogus'"},
+		{"bad", "int is not a struct"},
+	}
+
+	for i, c := range cases {
+		var out bytes.Buffer
+		r := NewRunner(tree, strings.NewReader(""), &out)
+		err := r.Execute(c.line)
+		if fmt.Sprint(err) != c.err {
+			t.Errorf("Case %d: expected '%s', got '%v'", i, c.err, err)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestAliases(t *testing.T) {
+	tree := NewTree(TreeDescriptor{Name: "root"})
+	file := tree.AddSubtree(TreeDescriptor{Name: "file"})
+	file.AddCommand(CommandDescriptor{Name: "remove", Brief: "Remove a file", Aliases: []string{"rm", "delete"}})
+	file.AddCommand(CommandDescriptor{Name: "rename"})
+
+	cases := []struct {
+		line     string
+		path     string
+		shortcut bool
+		err      error
+	}{
+		{"file rm", "file remove", false, nil},
+		{"file del", "file remove", false, nil},
+		{"file remove", "file remove", false, nil},
+		{"file r", "", false, ErrAmbiguous},
+	}
+	for i, c := range cases {
+		r, err := tree.Resolve(c.line)
+		if !errors.Is(err, c.err) || r.Path != c.path || r.Shortcut != c.shortcut {
+			t.Errorf("Case %d: expected '%s' %v (%v), got '%s' %v (%v)", i, c.path, c.shortcut, c.err, r.Path, r.Shortcut, err)
+		}
+	}
+
+	var b bytes.Buffer
+	tree.GetHelp(&b, []string{"file", "rm"})
+	if !strings.Contains(b.String(), "Aliases: rm, delete\n") {
+		t.Errorf("Expected aliases in help, got:\n%s", b.String())
+	}
+
+	if err := file.AddShortcut("rm", "rename"); err == nil {
+		t.Errorf("Expected a conflict with alias 'rm'")
+	}
+
+	if _, err := tree.ReplaceCommand("file rm", CommandDescriptor{Aliases: []string{"del"}}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, _, err := tree.Lookup("file rm"); err != ErrNotFound {
+		t.Errorf("Expected removed alias to be not found, got %v", err)
+	}
+	if c, _, _ := tree.LookupCommand("file del"); c == nil || c.Name != "remove" {
+		t.Errorf("Expected 'file del' to find 'remove'")
+	}
+}
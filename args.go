@@ -0,0 +1,370 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// An ArgType parses the string value of a command argument.
+type ArgType interface {
+	Parse(s string) (any, error)
+}
+
+// A Completer provides completion candidates for a partially typed argument.
+// Argument types implementing Completer are used by Autocomplete.
+type Completer interface {
+	Complete(prefix string) []string
+}
+
+// A VarArgType is an argument type whose values may refer to session
+// variables. A runner parses arguments of such types by calling ParseVars
+// with its Var method, rather than by calling Parse.
+type VarArgType interface {
+	ArgType
+	ParseVars(s string, vars func(name string) (string, bool)) (any, error)
+}
+
+// An Arg describes a positional argument accepted by a command.
+type Arg struct {
+	Name      string  // argument name shown in usage and errors
+	Type      ArgType // argument type (nil accepts any string)
+	Optional  bool    // argument may be omitted
+	Variadic  bool    // final argument accepting any number of values
+	Sensitive bool    // value is masked in histories, transcripts and errors
+}
+
+// A Flag describes a named option accepted by a command. On the command line,
+// a flag is given as "--name value" or "--name=value". A flag with no type is
+// a switch, which takes no value and parses to true when present.
+type Flag struct {
+	Name      string  // flag name, without the leading dashes
+	Type      ArgType // flag value type (nil for a switch)
+	Brief     string  // brief description of the flag
+	Sensitive bool    // value is masked in histories, transcripts and errors
+}
+
+// NoArgs is the CommandDescriptor MaxArgs value of a command accepting no
+// arguments.
+const NoArgs = -1
+
+// Errors returned when validating command arguments.
+var (
+	ErrMissingArg  = errors.New("Missing argument")
+	ErrFewArgs     = errors.New("Too few arguments")
+	ErrExtraArgs   = errors.New("Too many arguments")
+	ErrUnknownFlag = errors.New("Unknown flag")
+)
+
+// An ArgError describes a command argument that failed validation.
+type ArgError struct {
+	Name  string // argument name
+	Value string // argument value, if any
+	Err   error  // reason the argument failed validation
+}
+
+func (e *ArgError) Error() string {
+	switch e.Err {
+	case ErrMissingArg:
+		return fmt.Sprintf("%v '%s'", e.Err, e.Name)
+	case ErrExtraArgs:
+		return e.Err.Error()
+	case ErrUnknownFlag:
+		return fmt.Sprintf("%v '%s'", e.Err, e.Value)
+	}
+	return fmt.Sprintf("Invalid argument '%s': %v", e.Name, e.Err)
+}
+
+func (e *ArgError) Unwrap() error {
+	return e.Err
+}
+
+// ParseArgs validates the arguments against the command's argument and flag
+// specifications and constraints, and returns the parsed value of each
+// argument and flag, keyed by name. Omitted optional arguments and flags are
+// absent from the returned map, and the values of a variadic argument are
+// returned as a slice. If the command has no argument or flag
+// specification, ParseArgs returns nil.
+//
+// Flags may appear anywhere among the arguments. An argument of "--" ends
+// flag parsing, so that subsequent arguments beginning with "--" are treated
+// as positional. Arguments of a VarArgType are parsed without variables.
+func (c *Command) ParseArgs(args []string) (map[string]any, error) {
+	return c.parseArgs(args, nil)
+}
+
+// parseArgs parses the arguments as ParseArgs does, passing vars to the
+// argument types that refer to variables.
+func (c *Command) parseArgs(args []string, vars func(name string) (string, bool)) (map[string]any, error) {
+	if c.Args == nil && c.Flags == nil {
+		return nil, nil
+	}
+
+	values := make(map[string]any)
+	args, err := c.parseFlags(args, values, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	i := 0
+	for _, spec := range c.Args {
+		if spec.Variadic {
+			var vs []any
+			for ; i < len(args); i++ {
+				v, err := parseArg(spec, args[i], vars)
+				if err != nil {
+					return nil, err
+				}
+				vs = append(vs, v)
+			}
+			switch {
+			case len(vs) > 0:
+				values[spec.Name] = vs
+			case !spec.Optional:
+				return nil, &ArgError{Name: spec.Name, Err: ErrMissingArg}
+			}
+			break
+		}
+
+		if i >= len(args) {
+			if spec.Optional {
+				continue
+			}
+			return nil, &ArgError{Name: spec.Name, Err: ErrMissingArg}
+		}
+
+		v, err := parseArg(spec, args[i], vars)
+		if err != nil {
+			return nil, err
+		}
+		values[spec.Name] = v
+		i++
+	}
+
+	if i < len(args) {
+		return nil, &ArgError{Value: args[i], Err: ErrExtraArgs}
+	}
+
+	for _, con := range c.Constraints {
+		if err := con.check(values); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+// checkArgCount checks the number of arguments against the command's
+// MinArgs and MaxArgs bounds, returning a *UsageError if it is out of
+// bounds.
+func (c *Command) checkArgCount(args []string) error {
+	switch {
+	case len(args) < c.MinArgs:
+		return &UsageError{Command: c, Err: ErrFewArgs}
+	case c.MaxArgs != 0 && len(args) > max(c.MaxArgs, 0):
+		return &UsageError{Command: c, Err: ErrExtraArgs}
+	}
+	return nil
+}
+
+// parseFlags parses the flags contained in args, storing their values. It
+// returns the remaining positional arguments.
+func (c *Command) parseFlags(args []string, values map[string]any, vars func(name string) (string, bool)) ([]string, error) {
+	if c.Flags == nil {
+		return args, nil
+	}
+
+	var positional []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return append(positional, args[i+1:]...), nil
+		}
+		if !strings.HasPrefix(arg, "--") || len(arg) == 2 {
+			positional = append(positional, arg)
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(arg[2:], "=")
+		f := c.lookupFlag(name)
+		if f == nil {
+			return nil, &ArgError{Name: name, Value: arg, Err: ErrUnknownFlag}
+		}
+
+		if f.Type == nil {
+			if hasValue {
+				v, err := BoolType{}.Parse(value)
+				if err != nil {
+					return nil, &ArgError{Name: "--" + name, Value: value, Err: err}
+				}
+				values[name] = v
+			} else {
+				values[name] = true
+			}
+			continue
+		}
+
+		if !hasValue {
+			if i+1 >= len(args) {
+				return nil, &ArgError{Name: "--" + name, Err: ErrMissingArg}
+			}
+			i++
+			value = args[i]
+		}
+		v, err := parseArgValue(f.Type, value, vars)
+		if err != nil {
+			if f.Sensitive {
+				value, err = Mask, maskError(err, value)
+			}
+			return nil, &ArgError{Name: "--" + name, Value: value, Err: err}
+		}
+		values[name] = v
+	}
+	return positional, nil
+}
+
+func (c *Command) lookupFlag(name string) *Flag {
+	for i := range c.Flags {
+		if c.Flags[i].Name == name {
+			return &c.Flags[i]
+		}
+	}
+	return nil
+}
+
+func parseArg(spec Arg, s string, vars func(name string) (string, bool)) (any, error) {
+	if spec.Type == nil {
+		return s, nil
+	}
+	v, err := parseArgValue(spec.Type, s, vars)
+	if err != nil {
+		if spec.Sensitive {
+			s, err = Mask, maskError(err, s)
+		}
+		return nil, &ArgError{Name: spec.Name, Value: s, Err: err}
+	}
+	return v, nil
+}
+
+// parseArgValue parses a value of the argument type, looking up the variables
+// it refers to if the type is a VarArgType.
+func parseArgValue(typ ArgType, s string, vars func(name string) (string, bool)) (any, error) {
+	if vt, ok := typ.(VarArgType); ok && vars != nil {
+		return vt.ParseVars(s, vars)
+	}
+	return typ.Parse(s)
+}
+
+// argUsage returns a usage string generated from the command's argument
+// specification. Enumerated arguments are shown as a list of their values.
+func (c *Command) argUsage() string {
+	parts := []string{nodePath(c)}
+	for _, f := range c.Flags {
+		switch e, ok := f.Type.(EnumType); {
+		case f.Type == nil:
+			parts = append(parts, "[--"+f.Name+"]")
+		case ok:
+			parts = append(parts, "[--"+f.Name+" "+strings.Join(e.Values, "|")+"]")
+		default:
+			parts = append(parts, "[--"+f.Name+" <value>]")
+		}
+	}
+	for _, spec := range c.Args {
+		name := spec.Name
+		if e, ok := spec.Type.(EnumType); ok {
+			name = strings.Join(e.Values, "|")
+		}
+		switch {
+		case spec.Optional:
+			name = "[" + name + "]"
+		default:
+			name = "<" + name + ">"
+		}
+		if spec.Variadic {
+			name += "..."
+		}
+		parts = append(parts, name)
+	}
+	return strings.Join(parts, " ")
+}
+
+// completeArgs returns completion candidates for the final argument in the
+// line, using the completer of the corresponding argument type. If trailing
+// is true, the line ended with whitespace, and the final argument is an
+// empty one following the line's fields. Each candidate is prefixed by
+// prefix and the preceding arguments.
+func (c *Command) completeArgs(dst []string, prefix, line string, trailing bool) []string {
+	var args []string
+	for field, remain := nextField(line); field != "" || remain != ""; {
+		args = append(args, field)
+		field, remain = nextField(remain)
+	}
+	if trailing {
+		args = append(args, "")
+	}
+	if len(args) == 0 {
+		return dst
+	}
+
+	i := len(args) - 1
+	typ, flagPrefix := c.completionType(args)
+	completer, ok := typ.(Completer)
+	if !ok {
+		return dst
+	}
+
+	for _, arg := range args[:i] {
+		prefix += quoteField(arg) + " "
+	}
+	for _, candidate := range completer.Complete(strings.TrimPrefix(args[i], flagPrefix)) {
+		dst = append(dst, prefix+quoteCandidate(flagPrefix+candidate))
+	}
+	return dst
+}
+
+// completionType returns the type of the final argument, skipping flags and
+// their values as ParseArgs does. If the final argument is a flag written
+// as "--name=value", the type of the flag's value is returned along with the
+// flag's "--name=" prefix.
+func (c *Command) completionType(args []string) (typ ArgType, flagPrefix string) {
+	i, pos, flagsDone := len(args)-1, 0, c.Flags == nil
+	for j := 0; j < i; j++ {
+		arg := args[j]
+		switch {
+		case flagsDone || !strings.HasPrefix(arg, "--"):
+			pos++
+		case arg == "--":
+			flagsDone = true
+		default:
+			name, _, hasValue := strings.Cut(arg[2:], "=")
+			if f := c.lookupFlag(name); f != nil && f.Type != nil && !hasValue {
+				if j+1 == i {
+					return f.Type, ""
+				}
+				j++
+			}
+		}
+	}
+
+	if last := args[i]; !flagsDone && strings.HasPrefix(last, "--") {
+		name, _, hasValue := strings.Cut(last[2:], "=")
+		if f := c.lookupFlag(name); f != nil && hasValue {
+			return f.Type, "--" + name + "="
+		}
+		return nil, ""
+	}
+	if spec, ok := c.argSpec(pos); ok {
+		return spec.Type, ""
+	}
+	return nil, ""
+}
+
+// quoteCandidate quotes a completion candidate containing whitespace. If the
+// candidate is a directory path, its closing quote is omitted so that the
+// user may continue typing the path.
+func quoteCandidate(candidate string) string {
+	q := quoteField(candidate)
+	if q != candidate && strings.HasSuffix(candidate, "/") {
+		return q[:len(q)-1]
+	}
+	return q
+}
@@ -0,0 +1,205 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParseArgs(t *testing.T) {
+	tree := NewTree(TreeDescriptor{Name: "tree"})
+	dump := tree.AddCommand(CommandDescriptor{
+		Name: "dump",
+		Args: []Arg{
+			{Name: "range", Type: RangeType{BitSize: 16}},
+			{Name: "width", Type: UintType{BitSize: 8}, Optional: true},
+		},
+	})
+	poke := tree.AddCommand(CommandDescriptor{
+		Name: "poke",
+		Args: []Arg{
+			{Name: "addr", Type: UintType{BitSize: 16}},
+			{Name: "values", Type: IntType{BitSize: 8}, Variadic: true},
+		},
+	})
+	plain := tree.AddCommand(CommandDescriptor{Name: "plain"})
+
+	cases := []struct {
+		cmd    *Command
+		args   string
+		values string
+		err    string
+	}{
+		{dump, "0x8000..0x80ff", "map[range:{32768 33023}]", ""},
+		{dump, "0x8000..0x80ff $10", "map[range:{32768 33023} width:16]", ""},
+		{dump, "", "", "Missing argument 'range'"},
+		{dump, "0x8000..0x80ff 1 2", "", "Too many arguments"},
+		{dump, "0x8000..0x80ff 256", "", "Invalid argument 'width': value '256' out of range"},
+		{poke, "$d020 1 -2 0x7f", "map[addr:53280 values:[1 -2 127]]", ""},
+		{poke, "$d020", "", "Missing argument 'values'"},
+		{poke, "$d020 x", "", "Invalid argument 'values': invalid number 'x'"},
+		{plain, "a b c", "map[]", ""},
+	}
+
+	for i, c := range cases {
+		values, err := c.cmd.ParseArgs(strings.Fields(c.args))
+		switch {
+		case c.err != "" && (err == nil || err.Error() != c.err):
+			t.Errorf("Case %d: expected error '%s', got '%v'", i, c.err, err)
+		case c.err == "" && err != nil:
+			t.Errorf("Case %d: unexpected error '%v'", i, err)
+		case c.err == "" && fmt.Sprint(values) != c.values:
+			t.Errorf("Case %d: expected %s, got %v", i, c.values, values)
+		}
+	}
+
+	_, err := poke.ParseArgs([]string{"zz"})
+	var argErr *ArgError
+	if !errors.As(err, &argErr) || argErr.Name != "addr" || argErr.Value != "zz" {
+		t.Errorf("unexpected error: %#v", err)
+	}
+	if _, err := dump.ParseArgs(nil); !errors.Is(err, ErrMissingArg) {
+		t.Errorf("expected ErrMissingArg, got %v", err)
+	}
+}
+
+func TestRunnerArgs(t *testing.T) {
+	tree := NewTree(TreeDescriptor{Name: "tree"})
+	tree.AddCommand(CommandDescriptor{
+		Name: "peek",
+		Args: []Arg{{Name: "addr", Type: UintType{BitSize: 16}}},
+		Handler: func(ctx *ExecContext, args []string) error {
+			ctx.Printf("%04X\n", ctx.Values["addr"].(uint64))
+			return nil
+		},
+	})
+
+	out := new(bytes.Buffer)
+	r := NewRunner(tree, strings.NewReader("peek $c000\npeek 0x1ffff\npeek\n"), out)
+	r.Run()
+	want := "> C000\n" +
+		"> Invalid argument 'addr': value '0x1ffff' out of range.\n" +
+		"> Missing argument 'addr'.\n" +
+		"> "
+	if out.String() != want {
+		t.Errorf("output mismatch.\nEXPECTED:\n%q\nGOT:\n%q\n", want, out.String())
+	}
+}
+
+func TestArgCount(t *testing.T) {
+	tree := NewTree(TreeDescriptor{Name: "tree"})
+	handler := func(ctx *ExecContext, args []string) error {
+		ctx.Printf("%d\n", len(args))
+		return nil
+	}
+	tree.AddCommand(CommandDescriptor{Name: "one", Usage: "one <x>", MinArgs: 1, MaxArgs: 1, Handler: handler})
+	tree.AddCommand(CommandDescriptor{Name: "none", MaxArgs: NoArgs, Handler: handler})
+	tree.AddCommand(CommandDescriptor{Name: "some", MinArgs: 2, Handler: handler})
+
+	cases := []struct {
+		line     string
+		expected string
+	}{
+		{"one a", "1\n"},
+		{"one", "Too few arguments.\nUsage: one <x>\n"},
+		{"one a b", "Too many arguments.\nUsage: one <x>\n"},
+		{"none", "0\n"},
+		{"none a", "Too many arguments.\n"},
+		{"some a b c d", "4\n"},
+		{"some a", "Too few arguments.\n"},
+	}
+	for i, c := range cases {
+		out := new(bytes.Buffer)
+		r := NewRunner(tree, strings.NewReader(c.line), out)
+		r.Prompt = ""
+		r.Run()
+		if out.String() != c.expected {
+			t.Errorf("Case %d: expected %q, got %q", i, c.expected, out.String())
+		}
+	}
+}
+
+func buildArgTree() *Tree {
+	tree := NewTree(TreeDescriptor{Name: "tree"})
+	file := tree.AddSubtree(TreeDescriptor{Name: "file"})
+	file.AddCommand(CommandDescriptor{
+		Name: "open",
+		Args: []Arg{
+			{Name: "path"},
+			{Name: "mode", Type: EnumType{Values: []string{"read", "readwrite", "write"}}, Optional: true},
+		},
+	})
+	tree.AddCommand(CommandDescriptor{
+		Name: "set",
+		Args: []Arg{
+			{Name: "flags", Type: EnumType{Values: []string{"carry", "zero", "negative"}}, Variadic: true},
+		},
+	})
+	tree.AddCommand(CommandDescriptor{
+		Name: "wait",
+		Args: []Arg{{Name: "duration", Type: DurationType{}}},
+	})
+	tree.AddCommand(CommandDescriptor{
+		Name: "mode",
+		Args: []Arg{{Name: "mode", Type: EnumType{Values: []string{"read only", "read write"}}}},
+	})
+	return tree
+}
+
+func TestArgUsage(t *testing.T) {
+	tree := buildArgTree()
+
+	cases := []struct {
+		line  string
+		usage string
+	}{
+		{"file open", "Usage: file open <path> [read|readwrite|write]\n"},
+		{"set", "Usage: set <carry|zero|negative>...\n"},
+		{"wait", "Usage: wait <duration>\n"},
+	}
+
+	for i, c := range cases {
+		cmd, _, _ := tree.LookupCommand(c.line)
+		buf := new(bytes.Buffer)
+		cmd.DisplayUsage(buf)
+		if buf.String() != c.usage {
+			t.Errorf("Case %d: expected %q, got %q", i, c.usage, buf.String())
+		}
+	}
+}
+
+func TestArgAutocomplete(t *testing.T) {
+	tree := buildArgTree()
+
+	cases := []struct {
+		line    string
+		matches []string
+	}{
+		{"file open", []string{"file open"}},
+		{"file open x", []string{}},
+		{"file open x r", []string{"file open x read", "file open x readwrite"}},
+		{"fi o \"my file\" w", []string{"file open \"my file\" write"}},
+		{"file open x read y", []string{}},
+		{"set c", []string{"set carry"}},
+		{"set carry z", []string{"set carry zero"}},
+		{"set carry zero ", []string{"set carry zero carry", "set carry zero negative", "set carry zero zero"}},
+		{"file open ", []string{}},
+		{"file open x ", []string{"file open x read", "file open x readwrite", "file open x write"}},
+		{"wait ", []string{}},
+		{"mode re", []string{"mode \"read only\"", "mode \"read write\""}},
+		{"mode \"read ", []string{"mode \"read only\"", "mode \"read write\""}},
+		{"mode \"read w", []string{"mode \"read write\""}},
+		{"mode \"read write\" ", []string{}},
+		{"set carry zero x", []string{}},
+		{"wait 5", []string{}},
+	}
+
+	for i, c := range cases {
+		matches := tree.Autocomplete(c.line)
+		if strings.Join(matches, ",") != strings.Join(c.matches, ",") {
+			t.Errorf("Case %d: Autocomplete(%q) = %q, wanted %q", i, c.line, matches, c.matches)
+		}
+	}
+}
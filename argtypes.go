@@ -0,0 +1,408 @@
+package cmd
+
+import (
+	"fmt"
+	"io/fs"
+	"math/bits"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseUint interprets a string as an unsigned integer that fits into the
+// given bit size. The string may be decimal, or it may carry a radix prefix:
+// "0x" or "$" for hexadecimal, "0b" for binary, or "0o" for octal.
+func ParseUint(s string, bitSize int) (uint64, error) {
+	digits, base := radix(s)
+	v, err := strconv.ParseUint(digits, base, bitSize)
+	if err != nil {
+		return 0, numError(s, err)
+	}
+	return v, nil
+}
+
+// ParseInt interprets a string as a signed integer that fits into the given
+// bit size. The string may begin with a sign, and it accepts the same radix
+// prefixes as ParseUint.
+func ParseInt(s string, bitSize int) (int64, error) {
+	sign := ""
+	if len(s) > 0 && (s[0] == '-' || s[0] == '+') {
+		sign, s = s[:1], s[1:]
+	}
+	digits, base := radix(s)
+	v, err := strconv.ParseInt(sign+digits, base, bitSize)
+	if err != nil {
+		return 0, numError(sign+s, err)
+	}
+	return v, nil
+}
+
+// radix strips a radix prefix from s and returns the remaining digits and
+// their base.
+func radix(s string) (digits string, base int) {
+	switch {
+	case strings.HasPrefix(s, "$"):
+		return s[1:], 16
+	case len(s) > 2 && s[0] == '0':
+		switch s[1] {
+		case 'x', 'X':
+			return s[2:], 16
+		case 'b', 'B':
+			return s[2:], 2
+		case 'o', 'O':
+			return s[2:], 8
+		}
+	}
+	return s, 10
+}
+
+func numError(s string, err error) error {
+	if ne, ok := err.(*strconv.NumError); ok && ne.Err == strconv.ErrRange {
+		return fmt.Errorf("value '%s' out of range", s)
+	}
+	return fmt.Errorf("invalid number '%s'", s)
+}
+
+// A Range is an inclusive range of unsigned integers, such as a range of
+// memory addresses.
+type Range struct {
+	Start uint64
+	End   uint64
+}
+
+// ParseRange interprets a string of the form "start..end" as an inclusive
+// range of unsigned integers. The start and end values are parsed by
+// ParseUint, and the end value may not be less than the start value.
+func ParseRange(s string, bitSize int) (Range, error) {
+	lo, hi, ok := strings.Cut(s, "..")
+	if !ok {
+		return Range{}, fmt.Errorf("invalid range '%s'", s)
+	}
+	start, err := ParseUint(lo, bitSize)
+	if err != nil {
+		return Range{}, err
+	}
+	end, err := ParseUint(hi, bitSize)
+	if err != nil {
+		return Range{}, err
+	}
+	if end < start {
+		return Range{}, fmt.Errorf("range end '%s' precedes start '%s'", hi, lo)
+	}
+	return Range{start, end}, nil
+}
+
+// UintType is an argument type accepting unsigned integers in the formats
+// supported by ParseUint. Parsed values have type uint64.
+type UintType struct {
+	BitSize int // maximum bit size of the value (zero means 64)
+}
+
+// Parse parses an unsigned integer argument.
+func (t UintType) Parse(s string) (any, error) {
+	return ParseUint(s, bitSize(t.BitSize))
+}
+
+// IntType is an argument type accepting signed integers in the formats
+// supported by ParseInt. Parsed values have type int64.
+type IntType struct {
+	BitSize int // maximum bit size of the value (zero means 64)
+}
+
+// Parse parses a signed integer argument.
+func (t IntType) Parse(s string) (any, error) {
+	return ParseInt(s, bitSize(t.BitSize))
+}
+
+// RangeType is an argument type accepting ranges in the format supported by
+// ParseRange. Parsed values have type Range.
+type RangeType struct {
+	BitSize int // maximum bit size of the range's values (zero means 64)
+}
+
+// Parse parses a range argument.
+func (t RangeType) Parse(s string) (any, error) {
+	return ParseRange(s, bitSize(t.BitSize))
+}
+
+func bitSize(n int) int {
+	if n <= 0 {
+		return 64
+	}
+	return n
+}
+
+// DurationType is an argument type accepting durations in the format
+// supported by time.ParseDuration (e.g., "500ms" or "1h30m"). Parsed values
+// have type time.Duration.
+type DurationType struct{}
+
+// Parse parses a duration argument.
+func (DurationType) Parse(s string) (any, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid duration '%s'", s)
+	}
+	return d, nil
+}
+
+// TimeType is an argument type accepting points in time, either relative
+// to the present, as a duration preceded by '+' (e.g., "+5m"), or as a
+// local time of day (e.g., "15:04" or "15:04:05"), which is the next
+// occurrence of that time, or in RFC 3339 format. Parsed values have type
+// time.Time.
+type TimeType struct{}
+
+// Parse parses a time argument.
+func (TimeType) Parse(s string) (any, error) {
+	now := time.Now()
+	if d, ok := strings.CutPrefix(s, "+"); ok {
+		if d, err := time.ParseDuration(d); err == nil {
+			return now.Add(d), nil
+		}
+	}
+	for _, layout := range []string{"15:04", "15:04:05"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			y, m, d := now.Date()
+			t = time.Date(y, m, d, t.Hour(), t.Minute(), t.Second(), 0, time.Local)
+			if !t.After(now) {
+				t = t.AddDate(0, 0, 1)
+			}
+			return t, nil
+		}
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return nil, fmt.Errorf("invalid time '%s'", s)
+}
+
+// sizeUnits maps lower-case byte size suffixes to their multipliers.
+var sizeUnits = map[string]uint64{
+	"":    1,
+	"b":   1,
+	"k":   1 << 10,
+	"kb":  1000,
+	"kib": 1 << 10,
+	"m":   1 << 20,
+	"mb":  1000 * 1000,
+	"mib": 1 << 20,
+	"g":   1 << 30,
+	"gb":  1000 * 1000 * 1000,
+	"gib": 1 << 30,
+	"t":   1 << 40,
+	"tb":  1000 * 1000 * 1000 * 1000,
+	"tib": 1 << 40,
+}
+
+// ParseSize interprets a string as a byte size. The string consists of an
+// unsigned integer in any format supported by ParseUint, followed by an
+// optional case-insensitive unit suffix. The suffixes "KiB", "MiB", "GiB" and
+// "TiB" (or simply "K", "M", "G" and "T") denote powers of 1024, and the
+// suffixes "KB", "MB", "GB" and "TB" denote powers of 1000. Because "B" is a
+// hexadecimal digit, hexadecimal sizes may not use the bare "B" suffix.
+func ParseSize(s string) (uint64, error) {
+	digits, base := radix(s)
+	i := 0
+	for i < len(digits) && digitValue(digits[i]) < base {
+		i++
+	}
+	num, unit := s[:len(s)-len(digits)+i], strings.ToLower(digits[i:])
+
+	m, ok := sizeUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("invalid size '%s'", s)
+	}
+	n, err := ParseUint(num, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size '%s'", s)
+	}
+	hi, v := bits.Mul64(n, m)
+	if hi != 0 {
+		return 0, fmt.Errorf("size '%s' out of range", s)
+	}
+	return v, nil
+}
+
+// digitValue returns the numeric value of a digit character, or 36 if the
+// character isn't a digit in any base.
+func digitValue(c byte) int {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c - '0')
+	case c >= 'a' && c <= 'z':
+		return int(c-'a') + 10
+	case c >= 'A' && c <= 'Z':
+		return int(c-'A') + 10
+	}
+	return 36
+}
+
+// SizeType is an argument type accepting byte sizes in the format supported
+// by ParseSize. Parsed values have type uint64.
+type SizeType struct{}
+
+// Parse parses a byte size argument.
+func (SizeType) Parse(s string) (any, error) {
+	return ParseSize(s)
+}
+
+// StringType is an argument type accepting any string. It gives flags taking
+// a value a type, as a flag of nil type is a switch. Parsed values have type
+// string.
+type StringType struct{}
+
+// Parse parses a string argument.
+func (StringType) Parse(s string) (any, error) {
+	return s, nil
+}
+
+// BoolType is an argument type accepting boolean values: "true", "false",
+// "on", "off", "yes", "no", "1" and "0". Parsed values have type bool.
+type BoolType struct{}
+
+// Parse parses a boolean argument.
+func (BoolType) Parse(s string) (any, error) {
+	switch strings.ToLower(s) {
+	case "true", "on", "yes", "1":
+		return true, nil
+	case "false", "off", "no", "0":
+		return false, nil
+	}
+	return nil, fmt.Errorf("invalid boolean '%s'", s)
+}
+
+// Complete returns the boolean values beginning with prefix.
+func (BoolType) Complete(prefix string) []string {
+	return completeValues([]string{"false", "off", "on", "true"}, prefix)
+}
+
+// EnumType is an argument type accepting one of a fixed set of values. A
+// value may be abbreviated to any unambiguous prefix. Parsed values have
+// type string and always hold the full value.
+type EnumType struct {
+	Values []string // the accepted values
+}
+
+// Parse parses an enumerated argument.
+func (t EnumType) Parse(s string) (any, error) {
+	matches := completeValues(t.Values, s)
+	for _, m := range matches {
+		if m == s {
+			return s, nil
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("'%s' is not one of %s", s, strings.Join(t.Values, ", "))
+	case 1:
+		return matches[0], nil
+	}
+	return nil, fmt.Errorf("'%s' is ambiguous", s)
+}
+
+// Complete returns the enumerated values beginning with prefix.
+func (t EnumType) Complete(prefix string) []string {
+	return completeValues(t.Values, prefix)
+}
+
+// completeValues returns the sorted values beginning with prefix.
+func completeValues(values []string, prefix string) []string {
+	results := []string{}
+	for _, v := range values {
+		if strings.HasPrefix(v, prefix) {
+			results = append(results, v)
+		}
+	}
+	sort.Strings(results)
+	return results
+}
+
+// PathType is an argument type accepting file system paths. Parsed values
+// have type string. Completion candidates are drawn from the entries of the
+// file system, with directory candidates ending in a slash. Hidden entries
+// (those beginning with a dot) are offered only when the partially typed
+// name also begins with a dot.
+type PathType struct {
+	FS  fs.FS  // file system used for completion (nil uses the OS file system)
+	Dir string // directory against which relative OS paths are completed
+}
+
+// Parse parses a path argument.
+func (t PathType) Parse(s string) (any, error) {
+	if s == "" {
+		return nil, fmt.Errorf("empty path")
+	}
+	return s, nil
+}
+
+// Complete returns the paths beginning with prefix.
+func (t PathType) Complete(prefix string) []string {
+	dir, base := path.Split(prefix)
+	entries, err := t.readDir(dir)
+	if err != nil {
+		return []string{}
+	}
+
+	results := []string{}
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasPrefix(name, base) || (name[0] == '.' && !strings.HasPrefix(base, ".")) {
+			continue
+		}
+		candidate := dir + name
+		if t.isDir(dir, e) {
+			candidate += "/"
+		}
+		results = append(results, candidate)
+	}
+	sort.Strings(results)
+	return results
+}
+
+func (t PathType) readDir(dir string) ([]fs.DirEntry, error) {
+	if t.FS != nil {
+		return fs.ReadDir(t.FS, fsDir(dir))
+	}
+	return os.ReadDir(t.osPath(dir))
+}
+
+// isDir returns true if the directory entry is a directory or a symbolic link
+// to a directory.
+func (t PathType) isDir(dir string, e fs.DirEntry) bool {
+	if e.Type()&fs.ModeSymlink == 0 {
+		return e.IsDir()
+	}
+	var fi fs.FileInfo
+	var err error
+	if t.FS != nil {
+		fi, err = fs.Stat(t.FS, path.Join(fsDir(dir), e.Name()))
+	} else {
+		fi, err = os.Stat(filepath.Join(t.osPath(dir), e.Name()))
+	}
+	return err == nil && fi.IsDir()
+}
+
+// fsDir converts a directory prefix into a valid fs.FS directory name.
+func fsDir(dir string) string {
+	if dir = path.Clean(dir); dir == "" || dir == "/" {
+		return "."
+	}
+	return strings.TrimPrefix(dir, "/")
+}
+
+// osPath converts a directory prefix into an OS file path.
+func (t PathType) osPath(dir string) string {
+	p := filepath.FromSlash(dir)
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(t.Dir, p)
+	}
+	if p == "" {
+		return "."
+	}
+	return p
+}
@@ -0,0 +1,231 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestParseUint(t *testing.T) {
+	cases := []struct {
+		s       string
+		bitSize int
+		v       uint64
+		err     string
+	}{
+		{"0", 64, 0, ""},
+		{"1234", 64, 1234, ""},
+		{"0x8000", 16, 0x8000, ""},
+		{"0XfFfF", 16, 0xffff, ""},
+		{"$c000", 16, 0xc000, ""},
+		{"0b1010", 8, 10, ""},
+		{"0o17", 8, 15, ""},
+		{"0x10000", 16, 0, "value '0x10000' out of range"},
+		{"0x", 16, 0, "invalid number '0x'"},
+		{"$", 16, 0, "invalid number '$'"},
+		{"0b102", 8, 0, "invalid number '0b102'"},
+		{"-1", 64, 0, "invalid number '-1'"},
+		{"", 64, 0, "invalid number ''"},
+	}
+
+	for i, c := range cases {
+		v, err := ParseUint(c.s, c.bitSize)
+		switch {
+		case c.err != "" && (err == nil || err.Error() != c.err):
+			t.Errorf("Case %d: expected error '%s', got '%v'", i, c.err, err)
+		case c.err == "" && err != nil:
+			t.Errorf("Case %d: unexpected error '%v'", i, err)
+		case v != c.v:
+			t.Errorf("Case %d: expected %d, got %d", i, c.v, v)
+		}
+	}
+}
+
+func TestParseInt(t *testing.T) {
+	cases := []struct {
+		s       string
+		bitSize int
+		v       int64
+		err     string
+	}{
+		{"-12", 64, -12, ""},
+		{"+12", 64, 12, ""},
+		{"-0x80", 8, -128, ""},
+		{"0x80", 8, 0, "value '0x80' out of range"},
+		{"-$10", 16, -16, ""},
+		{"--1", 16, 0, "invalid number '--1'"},
+	}
+
+	for i, c := range cases {
+		v, err := ParseInt(c.s, c.bitSize)
+		switch {
+		case c.err != "" && (err == nil || err.Error() != c.err):
+			t.Errorf("Case %d: expected error '%s', got '%v'", i, c.err, err)
+		case c.err == "" && err != nil:
+			t.Errorf("Case %d: unexpected error '%v'", i, err)
+		case v != c.v:
+			t.Errorf("Case %d: expected %d, got %d", i, c.v, v)
+		}
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	cases := []struct {
+		s   string
+		r   Range
+		err string
+	}{
+		{"0x8000..0x80FF", Range{0x8000, 0x80ff}, ""},
+		{"$10..$10", Range{0x10, 0x10}, ""},
+		{"0..65535", Range{0, 0xffff}, ""},
+		{"0x8000", Range{}, "invalid range '0x8000'"},
+		{"0..0x10000", Range{}, "value '0x10000' out of range"},
+		{"10..5", Range{}, "range end '5' precedes start '10'"},
+	}
+
+	for i, c := range cases {
+		r, err := ParseRange(c.s, 16)
+		switch {
+		case c.err != "" && (err == nil || err.Error() != c.err):
+			t.Errorf("Case %d: expected error '%s', got '%v'", i, c.err, err)
+		case c.err == "" && err != nil:
+			t.Errorf("Case %d: unexpected error '%v'", i, err)
+		case r != c.r:
+			t.Errorf("Case %d: expected %v, got %v", i, c.r, r)
+		}
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	cases := []struct {
+		s   string
+		v   uint64
+		err string
+	}{
+		{"512", 512, ""},
+		{"512b", 512, ""},
+		{"4K", 4096, ""},
+		{"4KiB", 4096, ""},
+		{"4kb", 4000, ""},
+		{"2MiB", 2 << 20, ""},
+		{"3GB", 3000000000, ""},
+		{"1T", 1 << 40, ""},
+		{"0x10K", 16 << 10, ""},
+		{"$1b", 0x1b, ""},
+		{"0b11KiB", 3 << 10, ""},
+		{"4XB", 0, "invalid size '4XB'"},
+		{"KiB", 0, "invalid size 'KiB'"},
+		{"1.5MiB", 0, "invalid size '1.5MiB'"},
+		{"0xffffffffffffffffK", 0, "size '0xffffffffffffffffK' out of range"},
+	}
+
+	for i, c := range cases {
+		v, err := ParseSize(c.s)
+		switch {
+		case c.err != "" && (err == nil || err.Error() != c.err):
+			t.Errorf("Case %d: expected error '%s', got '%v'", i, c.err, err)
+		case c.err == "" && err != nil:
+			t.Errorf("Case %d: unexpected error '%v'", i, err)
+		case v != c.v:
+			t.Errorf("Case %d: expected %d, got %d", i, c.v, v)
+		}
+	}
+}
+
+func TestArgTypes(t *testing.T) {
+	mode := EnumType{Values: []string{"read", "readwrite", "write"}}
+
+	cases := []struct {
+		typ ArgType
+		s   string
+		v   any
+		err string
+	}{
+		{DurationType{}, "500ms", 500 * time.Millisecond, ""},
+		{DurationType{}, "1h30m", 90 * time.Minute, ""},
+		{DurationType{}, "5", nil, "invalid duration '5'"},
+		{BoolType{}, "on", true, ""},
+		{BoolType{}, "FALSE", false, ""},
+		{BoolType{}, "maybe", nil, "invalid boolean 'maybe'"},
+		{mode, "read", "read", ""},
+		{mode, "readw", "readwrite", ""},
+		{mode, "w", "write", ""},
+		{mode, "re", nil, "'re' is ambiguous"},
+		{mode, "x", nil, "'x' is not one of read, readwrite, write"},
+	}
+
+	for i, c := range cases {
+		v, err := c.typ.Parse(c.s)
+		switch {
+		case c.err != "" && (err == nil || err.Error() != c.err):
+			t.Errorf("Case %d: expected error '%s', got '%v'", i, c.err, err)
+		case c.err == "" && err != nil:
+			t.Errorf("Case %d: unexpected error '%v'", i, err)
+		case v != c.v:
+			t.Errorf("Case %d: expected %v, got %v", i, c.v, v)
+		}
+	}
+}
+
+func TestPathComplete(t *testing.T) {
+	fsys := fstest.MapFS{
+		"main.go":             {},
+		"my docs/notes.txt":   {},
+		"my docs/todo.txt":    {},
+		"prog/a.asm":          {},
+		"prog/b.asm":          {},
+		"project.txt":         {},
+		".hidden":             {},
+		"prog/.git/HEAD":      {},
+		"prog/sub/deep/x.bin": {},
+	}
+	tree := NewTree(TreeDescriptor{Name: "tree"})
+	tree.AddCommand(CommandDescriptor{
+		Name: "load",
+		Args: []Arg{{Name: "file", Type: PathType{FS: fsys}}},
+	})
+
+	cases := []struct {
+		line    string
+		matches []string
+	}{
+		{"load pro", []string{"load prog/", "load project.txt"}},
+		{"load prog/", []string{"load prog/a.asm", "load prog/b.asm", "load prog/sub/"}},
+		{"load prog/.", []string{"load prog/.git/"}},
+		{"load .", []string{"load .hidden"}},
+		{"load m", []string{"load main.go", "load \"my docs/"}},
+		{"load \"my docs/t", []string{"load \"my docs/todo.txt\""}},
+		{"load /prog/a", []string{"load /prog/a.asm"}},
+		{"load x/", []string{}},
+	}
+
+	for i, c := range cases {
+		matches := tree.Autocomplete(c.line)
+		if strings.Join(matches, ",") != strings.Join(c.matches, ",") {
+			t.Errorf("Case %d: Autocomplete(%q) = %q, wanted %q", i, c.line, matches, c.matches)
+		}
+	}
+}
+
+func TestPathCompleteOS(t *testing.T) {
+	dir := t.TempDir()
+	os.Mkdir(filepath.Join(dir, "sub"), 0o777)
+	os.WriteFile(filepath.Join(dir, "sub", "file.txt"), nil, 0o666)
+	os.WriteFile(filepath.Join(dir, "start.txt"), nil, 0o666)
+
+	p := PathType{Dir: dir}
+	if got := p.Complete("s"); strings.Join(got, ",") != "start.txt,sub/" {
+		t.Errorf("unexpected completion: %q", got)
+	}
+	if got := p.Complete("sub/f"); strings.Join(got, ",") != "sub/file.txt" {
+		t.Errorf("unexpected completion: %q", got)
+	}
+
+	abs := filepath.ToSlash(dir) + "/su"
+	if got := (PathType{}).Complete(abs); strings.Join(got, ",") != abs+"b/" {
+		t.Errorf("unexpected completion: %q", got)
+	}
+}
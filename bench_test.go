@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+// benchSizes lists the total number of commands and the depths of the
+// synthetic trees used by the benchmarks.
+var benchSizes = []struct {
+	commands int
+	depth    int
+}{
+	{100, 1}, {100, 2},
+	{1000, 1}, {1000, 2}, {1000, 3},
+	{10000, 1}, {10000, 2}, {10000, 3},
+}
+
+// benchFanout is the number of subtrees of each interior tree in a
+// synthetic tree.
+const benchFanout = 10
+
+// buildBenchTree builds a synthetic tree of the given depth holding n
+// commands, spread evenly over the trees at the deepest level. It returns
+// the tree, the deepest tree's path and the name of one of its commands.
+func buildBenchTree(n, depth int) (tree *Tree, path, command string) {
+	tree = NewTree(TreeDescriptor{Name: "root"})
+	leaves := []*Tree{tree}
+	for d := 1; d < depth; d++ {
+		var next []*Tree
+		for _, t := range leaves {
+			for i := 0; i < benchFanout; i++ {
+				next = append(next, t.AddSubtree(TreeDescriptor{
+					Name:  fmt.Sprintf("tree%02d", i),
+					Brief: "A synthetic subtree",
+				}))
+			}
+		}
+		leaves = next
+	}
+
+	perLeaf := n / len(leaves)
+	for _, t := range leaves {
+		for i := 0; i < perLeaf; i++ {
+			t.AddCommand(CommandDescriptor{
+				Name:  fmt.Sprintf("command%05d", i),
+				Brief: "A synthetic command with a moderately long brief description",
+			})
+		}
+	}
+
+	last := leaves[len(leaves)-1]
+	return tree, last.Path(), fmt.Sprintf("command%05d", perLeaf-1)
+}
+
+// benchName returns the name of a sub-benchmark for a synthetic tree.
+func benchName(commands, depth int) string {
+	return fmt.Sprintf("n=%d/depth=%d", commands, depth)
+}
+
+// benchLine returns a command line invoking the given command of the tree
+// with the given path.
+func benchLine(path, command string) string {
+	return strings.TrimSpace(path + " " + command)
+}
+
+func BenchmarkLookup(b *testing.B) {
+	for _, s := range benchSizes {
+		tree, path, command := buildBenchTree(s.commands, s.depth)
+		line := benchLine(path, command) + " arg1 arg2"
+		b.Run(benchName(s.commands, s.depth), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, _, err := tree.Lookup(line); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkLookupAppend(b *testing.B) {
+	tree, path, command := buildBenchTree(1000, 2)
+	line := benchLine(path, command) + " arg1 arg2"
+	args := make([]string, 0, 8)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, args, _ = tree.LookupAppend(args[:0], line)
+	}
+}
+
+func BenchmarkAutocomplete(b *testing.B) {
+	for _, s := range benchSizes {
+		tree, path, command := buildBenchTree(s.commands, s.depth)
+		line := benchLine(path, command[:len(command)-2])
+		b.Run(benchName(s.commands, s.depth), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				tree.Autocomplete(line)
+			}
+		})
+	}
+}
+
+func BenchmarkAutocompleteAppend(b *testing.B) {
+	tree, path, command := buildBenchTree(1000, 2)
+	line := benchLine(path, command[:len(command)-2])
+	candidates := make([]string, 0, 128)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		candidates = tree.AutocompleteAppend(candidates[:0], line)
+	}
+}
+
+func BenchmarkDisplayHelp(b *testing.B) {
+	for _, s := range benchSizes {
+		tree, path, _ := buildBenchTree(s.commands, s.depth)
+		leaf, _, _ := tree.LookupSubtree(path)
+		if path == "" {
+			leaf = tree
+		}
+		b.Run(benchName(s.commands, s.depth), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				leaf.DisplayHelp(io.Discard)
+			}
+		})
+	}
+}
+
+func BenchmarkDisplayCommandHelp(b *testing.B) {
+	tree, path, command := buildBenchTree(1000, 2)
+	c, _, _ := tree.LookupCommand(benchLine(path, command))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.DisplayHelp(io.Discard)
+	}
+}
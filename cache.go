@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"slices"
+	"strings"
+	"sync/atomic"
+)
+
+// A treeCache holds sorted views of a tree's contents, used by help and
+// shortcut listings. Each view records the generation of the command tree
+// for which it was built; any change to the command tree's structure
+// advances the root tree's generation, invalidating the views of every tree.
+type treeCache struct {
+	gen       atomic.Uint64 // generation of the command tree (root only)
+	nodes     atomic.Pointer[cachedView[Node]]
+	shortcuts atomic.Pointer[cachedView[Shortcut]]
+}
+
+type cachedView[T any] struct {
+	gen   uint64
+	items []T
+}
+
+// invalidate discards the cached views of all trees in the command tree.
+func (t *Tree) invalidate() {
+	t.root().cache.gen.Add(1)
+}
+
+// cachedItems returns the items of a cached view, rebuilding the view if
+// the command tree has changed since it was built. The returned slice is
+// shared and must not be modified.
+func cachedItems[T any](t *Tree, p *atomic.Pointer[cachedView[T]], build func() []T) []T {
+	gen := t.root().cache.gen.Load()
+	if v := p.Load(); v != nil && v.gen == gen {
+		return v.items
+	}
+	v := &cachedView[T]{gen: gen, items: build()}
+	p.Store(v)
+	return v.items
+}
+
+// sortedNodes returns the tree's commands and subtrees, sorted by name.
+func (t *Tree) sortedNodes() []Node {
+	return cachedItems(t, &t.cache.nodes, func() []Node {
+		nodes := make([]Node, 0, len(t.commands)+len(t.subtrees))
+		for _, c := range t.commands {
+			nodes = append(nodes, c)
+		}
+		for _, st := range t.subtrees {
+			nodes = append(nodes, st)
+		}
+		slices.SortStableFunc(nodes, func(a, b Node) int {
+			return strings.Compare(a.name(), b.name())
+		})
+		return nodes
+	})
+}
+
+// sortedShortcuts returns the shortcuts registered on the tree and its
+// descendant subtrees, sorted by their invocation lines.
+func (t *Tree) sortedShortcuts() []Shortcut {
+	return cachedItems(t, &t.cache.shortcuts, func() []Shortcut {
+		results := slices.Clone(t.shortcuts)
+		for _, st := range t.subtrees {
+			results = append(results, st.sortedShortcuts()...)
+		}
+		slices.SortStableFunc(results, func(a, b Shortcut) int {
+			return strings.Compare(a.Line(), b.Line())
+		})
+		return results
+	})
+}
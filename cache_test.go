@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCachedListings(t *testing.T) {
+	tree := NewTree(TreeDescriptor{Name: "root"})
+	file := tree.AddSubtree(TreeDescriptor{Name: "file", Brief: "File commands"})
+	file.AddCommand(CommandDescriptor{Name: "open", Brief: "Open a file"})
+
+	help := func(t *Tree) string {
+		var b bytes.Buffer
+		t.DisplayHelp(&b)
+		return b.String()
+	}
+	lines := func(t *Tree) string {
+		var s []string
+		for _, sc := range t.Shortcuts() {
+			s = append(s, sc.Line())
+		}
+		return strings.Join(s, ",")
+	}
+
+	cases := []struct {
+		mutate    func()
+		help      []string
+		shortcuts string
+	}{
+		{func() {}, []string{"open"}, ""},
+		{func() { file.AddCommand(CommandDescriptor{Name: "close", Brief: "Close a file"}) }, []string{"close", "open"}, ""},
+		{func() { tree.AddShortcut("o", "file open") }, []string{"close", "open"}, "o"},
+		{func() { file.AddShortcut("c", "close") }, []string{"close", "open"}, "file c,o"},
+		{func() { tree.Rename("file open", "load") }, []string{"close", "load"}, "file c,o"},
+		{func() { tree.Rename("file", "doc") }, []string{"close", "load"}, "doc c,o"},
+		{func() { tree.RemoveShortcut("o") }, []string{"close", "load"}, "doc c"},
+	}
+
+	for i, c := range cases {
+		c.mutate()
+		h := help(file)
+		prev := -1
+		for _, name := range c.help {
+			j := strings.Index(h, "    "+name+" ")
+			if j < prev {
+				t.Errorf("Case %d: '%s' missing or out of order in help:\n%s", i, name, h)
+			}
+			prev = j
+		}
+		if got := lines(tree); got != c.shortcuts {
+			t.Errorf("Case %d: expected shortcuts '%s', got '%s'", i, c.shortcuts, got)
+		}
+	}
+
+	// Modifying a returned listing doesn't affect the cached listing.
+	sc := tree.Shortcuts()
+	sc[0].Name = "x"
+	if got := lines(tree); got != "doc c" {
+		t.Errorf("Expected shortcuts 'doc c', got '%s'", got)
+	}
+}
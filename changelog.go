@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Changelog returns a description of the changes to the command surface
+// between two snapshots of a tree, as found by DiffSnapshots, suitable for
+// inclusion in release notes. Each kind of change is listed in a separate
+// section, and each added or changed node is followed by the version
+// recorded by its Since or Changed tag, if any:
+//
+//	Added:
+//	  file open (1.2)
+//	Removed:
+//	  quit
+//	Renamed:
+//	  file shut -> file close
+//	Changed:
+//	  file close (1.3): usage, aliases
+//
+// An empty string is returned if the snapshots are equivalent.
+func Changelog(old, new *Snapshot) string {
+	var b strings.Builder
+	kind := DiffKind(-1)
+	for _, d := range DiffSnapshots(old, new) {
+		if d.Kind != kind {
+			kind = d.Kind
+			title := kind.String()
+			fmt.Fprintf(&b, "%s%s:\n", strings.ToUpper(title[:1]), title[1:])
+		}
+		switch d.Kind {
+		case DiffAdded:
+			fmt.Fprintf(&b, "  %s%s\n", d.Path, versionSuffix(d.Version))
+		case DiffRemoved:
+			fmt.Fprintf(&b, "  %s\n", d.Path)
+		case DiffRenamed:
+			fmt.Fprintf(&b, "  %s -> %s\n", d.OldPath, d.Path)
+		case DiffChanged:
+			fmt.Fprintf(&b, "  %s%s: %s\n", d.Path, versionSuffix(d.Version), strings.Join(d.Fields, ", "))
+		}
+	}
+	return b.String()
+}
+
+func versionSuffix(version string) string {
+	if version == "" {
+		return ""
+	}
+	return " (" + version + ")"
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestChangelog(t *testing.T) {
+	v1 := NewTree(TreeDescriptor{Name: "tree"})
+	v1.AddCommand(CommandDescriptor{Name: "quit", Brief: "quit"})
+	v1.AddCommand(CommandDescriptor{Name: "help", Brief: "show help"})
+	file := v1.AddSubtree(TreeDescriptor{Name: "file"})
+	file.AddCommand(CommandDescriptor{Name: "close", Usage: "file close"})
+	dbg := v1.AddSubtree(TreeDescriptor{Name: "debug"})
+	dbg.AddCommand(CommandDescriptor{Name: "dump"})
+
+	v2 := NewTree(TreeDescriptor{Name: "tree"})
+	v2.AddCommand(CommandDescriptor{Name: "help", Brief: "show help"})
+	file = v2.AddSubtree(TreeDescriptor{Name: "file"})
+	file.AddCommand(CommandDescriptor{Name: "close", Usage: "file close [all]", Aliases: []string{"shut"}, Changed: "1.3"})
+	file.AddCommand(CommandDescriptor{Name: "open", Since: "1.2"})
+	net := v2.AddSubtree(TreeDescriptor{Name: "net", Since: "1.3"})
+	net.AddCommand(CommandDescriptor{Name: "ping"})
+	v2.AddShortcut("h", "help")
+
+	// Snapshots survive serialization.
+	data, err := json.Marshal(NewSnapshot(v1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := new(Snapshot)
+	if err := json.Unmarshal(data, old); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "Added:\n" +
+		"  file open (1.2)\n" +
+		"  net (1.3)\n" +
+		"Removed:\n" +
+		"  debug\n" +
+		"  quit\n" +
+		"Changed:\n" +
+		"  file close (1.3): usage, aliases\n" +
+		"  help: shortcuts\n"
+	if got := Changelog(old, NewSnapshot(v2)); got != want {
+		t.Errorf("unexpected changelog:\n%s\nwanted:\n%s", got, want)
+	}
+	if got := Changelog(NewSnapshot(v2), NewSnapshot(v2)); got != "" {
+		t.Errorf("unexpected changelog of equal snapshots:\n%s", got)
+	}
+}
@@ -4,8 +4,11 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"sort"
+	"slices"
 	"strings"
+	"text/template"
+	"time"
+	"unicode"
 
 	"github.com/beevik/prefixtree/v2"
 )
@@ -16,25 +19,62 @@ type Node interface {
 	Parent() *Tree
 	name() string
 	brief() string
+	tags() []string
+	priority() int
 }
 
 // A TreeDescriptor describes a command tree.
 type TreeDescriptor struct {
-	Name        string // tree name
-	Brief       string // brief description shown in a command list
-	Description string // long description shown with command help
-	Usage       string // usage hint text
-	Data        any    // user-defined data
+	Name        string        // tree name
+	Brief       string        // brief description shown in a command list
+	Description string        // long description shown with command help
+	Usage       string        // usage hint text
+	Data        any           // user-defined data
+	Tags        []string      // labels inherited by descendant nodes
+	Timeout     time.Duration // default execution timeout of descendant commands
+	Priority    int           // preference among names sharing a typed prefix
+	Since       string        // application version that introduced the tree
+
+	// Optional functions evaluated whenever help is displayed, overriding
+	// the Brief and Description text.
+	BriefFunc       func() string
+	DescriptionFunc func() string
+
+	// User-defined data keyed by name, inherited by descendant nodes.
+	Options map[string]any
+
+	// Localized help text, keyed by locale.
+	Localized map[string]LocalizedText
 }
 
 // A Tree contains one or more commands which are grouped together and may be
 // looked up by a shortest unambiguous prefix match.
 type Tree struct {
 	TreeDescriptor
-	commands []*Command
-	parent   *Tree
-	subtrees []*Tree
-	pt       *prefixtree.Tree[Node]
+	commands      []*Command
+	parent        *Tree
+	subtrees      []*Tree
+	shortcuts     []Shortcut
+	phrases       []Shortcut
+	pt            *prefixtree.Tree[Node]
+	locale        string
+	catalog       Catalog
+	labels        Labels
+	hidden        []string
+	globals       *prefixtree.Tree[Node]
+	globalPhrases []Shortcut
+	listTmpl      *template.Template
+	cmdTmpl       *template.Template
+	width         int
+	overflow      Overflow
+	grouped       bool
+	subcmdErrors  bool
+	topics        []*Topic
+	errFormat     ErrorFormatter
+	maxLine       int
+	maxDepth      int
+	observers     []*observer
+	cache         treeCache
 }
 
 func (t *Tree) name() string {
@@ -42,7 +82,25 @@ func (t *Tree) name() string {
 }
 
 func (t *Tree) brief() string {
-	return t.Brief
+	if t.BriefFunc != nil {
+		return t.BriefFunc()
+	}
+	return localize(t, "brief", t.Localized, t.Brief)
+}
+
+func (t *Tree) description() string {
+	if t.DescriptionFunc != nil {
+		return t.DescriptionFunc()
+	}
+	return localize(t, "description", t.Localized, t.Description)
+}
+
+func (t *Tree) tags() []string {
+	return t.Tags
+}
+
+func (t *Tree) priority() int {
+	return t.Priority
 }
 
 // Commands returns the tree's commands.
@@ -52,10 +110,11 @@ func (t *Tree) Commands() []*Command {
 
 // DisplayUsage outputs the tree's usage string.
 func (t *Tree) DisplayUsage(w io.Writer) {
-	if t.Usage != "" {
-		fmt.Fprintf(w, "Usage: %s\n", t.Usage)
+	label := t.message(MsgUsage)
+	if usage := localize(t, "usage", t.Localized, t.Usage); usage != "" {
+		fmt.Fprintf(w, "%s %s\n", label, usage)
 	} else {
-		fmt.Fprintf(w, "Usage: %s [subcommand]\n", t.Name)
+		fmt.Fprintf(w, "%s %s [subcommand]\n", label, t.Name)
 	}
 }
 
@@ -65,6 +124,12 @@ func (t *Tree) Parent() *Tree {
 	return t.parent
 }
 
+// Path returns the space-separated names of the tree and its ancestors,
+// excluding the root. The path of the root tree is empty.
+func (t *Tree) Path() string {
+	return nodePath(t)
+}
+
 // Subtrees returns the tree's subtrees.
 func (t *Tree) Subtrees() []*Tree {
 	return t.subtrees
@@ -72,11 +137,46 @@ func (t *Tree) Subtrees() []*Tree {
 
 // A CommandDescriptor describes a single command within a command tree.
 type CommandDescriptor struct {
-	Name        string // command name
-	Brief       string // brief description shown in a command list
-	Description string // long description shown with command help
-	Usage       string // usage hint text
-	Data        any    // user-defined data
+	Name          string        // command name
+	Brief         string        // brief description shown in a command list
+	Description   string        // long description shown with command help
+	Usage         string        // usage hint text
+	Data          any           // user-defined data
+	Handler       Handler       // function called when the command is executed
+	ResultHandler ResultHandler // function called to compute the command's result
+	Args          []Arg         // optional argument specification
+	Flags         []Flag        // optional flag specification
+	Constraints   []Constraint  // optional argument and flag constraints
+	Tags          []string      // labels used to filter commands
+	Timeout       time.Duration // execution timeout (zero inherits the tree's)
+	RateLimit     RateLimit     // optional limit on executions per runner
+	Priority      int           // preference among names sharing a typed prefix
+	Aliases       []string      // alternative names of the command
+	Since         string        // application version that introduced the command
+	Changed       string        // application version that last changed the command
+	DryRun        bool          // the handler honors ExecContext.DryRun
+
+	// Bounds on the number of arguments, checked before the handler is
+	// called, as a lighter alternative to an argument specification. A
+	// MaxArgs of zero sets no upper bound; use NoArgs to accept none.
+	MinArgs int
+	MaxArgs int
+
+	// Optional functions evaluated whenever help is displayed, overriding
+	// the Brief and Description text.
+	BriefFunc       func() string
+	DescriptionFunc func() string
+
+	// Optional function that displays the command's help, replacing the
+	// default help display.
+	HelpFunc func(w io.Writer, c *Command)
+
+	// User-defined data keyed by name, overriding data inherited from the
+	// command's ancestor trees.
+	Options map[string]any
+
+	// Localized help text, keyed by locale.
+	Localized map[string]LocalizedText
 }
 
 // A Command represents either a single named command or the root of a subtree
@@ -92,32 +192,87 @@ func (c *Command) name() string {
 }
 
 func (c *Command) brief() string {
-	return c.Brief
+	if c.BriefFunc != nil {
+		return c.BriefFunc()
+	}
+	return localize(c, "brief", c.Localized, c.Brief)
+}
+
+func (c *Command) description() string {
+	if c.DescriptionFunc != nil {
+		return c.DescriptionFunc()
+	}
+	return localize(c, "description", c.Localized, c.Description)
+}
+
+func (c *Command) tags() []string {
+	return c.Tags
+}
+
+func (c *Command) priority() int {
+	return c.Priority
 }
 
 // DisplayHelp outputs the help text associated with the command, including
-// its usage, description, and shortcuts.
+// its usage, description, and shortcuts. If the command has a HelpFunc, it
+// is called to display the help instead.
 func (c *Command) DisplayHelp(w io.Writer) {
+	if c.HelpFunc != nil {
+		c.HelpFunc(w, c)
+		return
+	}
+	if tmpl := c.parent.root().cmdTmpl; tmpl != nil {
+		executeHelpTemplate(w, tmpl, newCommandHelpNode(c))
+		return
+	}
 	c.DisplayUsage(w)
 	c.DisplayDescription(w)
+	c.DisplayAliases(w)
 	c.DisplayShortcuts(w)
 }
 
 // DisplayUsage outputs the command's usage string.
 func (c *Command) DisplayUsage(w io.Writer) {
-	if c.Usage != "" {
-		fmt.Fprintf(w, "Usage: %s\n", c.Usage)
+	if usage := localize(c, "usage", c.Localized, c.usage()); usage != "" {
+		fmt.Fprintf(w, "%s %s\n", c.parent.message(MsgUsage), usage)
 	}
 }
 
+// usage returns the command's usage string. If the command has no usage
+// string but has an argument specification, a usage string is generated
+// from the specification. The usage of a command honoring dry runs shows
+// DryRunFlag.
+func (c *Command) usage() string {
+	usage := c.Usage
+	if usage == "" && (c.Args != nil || c.Flags != nil) {
+		usage = c.argUsage()
+	}
+	if c.DryRun && !strings.Contains(usage, DryRunFlag) {
+		if usage == "" {
+			usage = nodePath(c)
+		}
+		usage += " [" + DryRunFlag + "]"
+	}
+	return usage
+}
+
 // DisplayDescription outputs the command's description text. If the
 // command has no description, the commands 'brief' text is output instead.
 func (c *Command) DisplayDescription(w io.Writer) {
-	switch {
-	case c.Description != "":
-		fmt.Fprintf(w, "Description:\n%s\n\n", indentWrap(3, c.Description))
-	case c.Brief != "":
-		fmt.Fprintf(w, "Description:\n%s.\n\n", indentWrap(3, c.Brief))
+	label := c.parent.message(MsgDescription)
+	description := c.description()
+	switch brief := c.brief(); {
+	case description != "":
+		fmt.Fprintf(w, "%s\n%s\n\n", label, Wrap(description, c.parent.helpWrap(3)))
+	case brief != "":
+		fmt.Fprintf(w, "%s\n%s.\n\n", label, Wrap(brief, c.parent.helpWrap(3)))
+	}
+}
+
+// DisplayAliases displays the command's aliases.
+func (c *Command) DisplayAliases(w io.Writer) {
+	if len(c.Aliases) > 0 {
+		fmt.Fprintf(w, "%s %s\n\n", c.parent.message(MsgAliases), strings.Join(c.Aliases, ", "))
 	}
 }
 
@@ -126,9 +281,9 @@ func (c *Command) DisplayShortcuts(w io.Writer) {
 	if c.shortcuts != nil {
 		switch {
 		case len(c.shortcuts) > 1:
-			fmt.Fprintf(w, "Shortcuts: %s\n\n", strings.Join(c.shortcuts, ", "))
+			fmt.Fprintf(w, "%s %s\n\n", c.parent.message(MsgShortcuts), strings.Join(c.shortcuts, ", "))
 		default:
-			fmt.Fprintf(w, "Shortcut: %s\n\n", c.shortcuts[0])
+			fmt.Fprintf(w, "%s %s\n\n", c.parent.message(MsgShortcut), c.shortcuts[0])
 		}
 	}
 }
@@ -138,18 +293,37 @@ func (c *Command) Parent() *Tree {
 	return c.parent
 }
 
-// Shortcuts returns the shortcut strings associated with the command.
+// timeout returns the command's execution timeout, inherited from the
+// nearest tree containing it if the command has none.
+func (c *Command) timeout() time.Duration {
+	if c.Timeout > 0 {
+		return c.Timeout
+	}
+	for t := c.parent; t != nil; t = t.parent {
+		if t.Timeout > 0 {
+			return t.Timeout
+		}
+	}
+	return 0
+}
+
+// CanonicalLine returns the command's full, unabbreviated invocation, such
+// as "file open".
+func (c *Command) CanonicalLine() string {
+	return nodePath(c)
+}
+
+// Shortcuts returns the shortcut strings associated with the command, in
+// sorted order.
 func (c *Command) Shortcuts() []string {
-	sort.Slice(c.shortcuts, func(i, j int) bool {
-		return c.shortcuts[i] < c.shortcuts[j]
-	})
-	return c.shortcuts
+	return slices.Clone(c.shortcuts)
 }
 
 // Errors returned by the cmd package.
 var (
-	ErrAmbiguous = errors.New("Command is ambiguous")
-	ErrNotFound  = errors.New("Command not found")
+	ErrAmbiguous   = errors.New("Command is ambiguous")
+	ErrNotFound    = errors.New("Command not found")
+	ErrLineTooLong = errors.New("Line too long")
 )
 
 // NewTree creates a new command tree with the given title.
@@ -163,7 +337,8 @@ func NewTree(d TreeDescriptor) *Tree {
 	}
 }
 
-// AddCommand adds a command to a command tree.
+// AddCommand adds a command to a command tree. The command may be looked up
+// by its name or by any of its aliases.
 func (t *Tree) AddCommand(d CommandDescriptor) *Command {
 	c := &Command{
 		CommandDescriptor: d,
@@ -172,28 +347,12 @@ func (t *Tree) AddCommand(d CommandDescriptor) *Command {
 	}
 	t.commands = append(t.commands, c)
 	t.pt.Add(c.Name, c)
-	return c
-}
-
-// AddShortcut adds a shortcut to a command in the tree.
-func (t *Tree) AddShortcut(shortcut, target string) error {
-	if len(strings.Fields(shortcut)) != 1 {
-		return errors.New("invalid shortcut")
-	}
-
-	cmd, _, err := t.LookupCommand(target)
-	if err != nil {
-		return err
+	for _, a := range c.Aliases {
+		t.pt.Add(a, c)
 	}
-
-	// Insert shortcut in alphabetical order
-	i := sort.SearchStrings(cmd.shortcuts, shortcut)
-	cmd.shortcuts = append(cmd.shortcuts, "")
-	copy(cmd.shortcuts[i+1:], cmd.shortcuts[i:])
-	cmd.shortcuts[i] = shortcut
-
-	t.pt.Add(shortcut, cmd)
-	return nil
+	t.invalidate()
+	t.emit(CommandAdded{c})
+	return c
 }
 
 // AddSubtree adds a child command tree to an existing command tree.
@@ -207,11 +366,14 @@ func (t *Tree) AddSubtree(d TreeDescriptor) *Tree {
 	}
 	t.subtrees = append(t.subtrees, subtree)
 	t.pt.Add(subtree.Name, subtree)
+	t.invalidate()
+	t.emit(SubtreeAdded{subtree})
 	return subtree
 }
 
 // GetHelp parses the 'help' command's arguments string and displays
-// an appropriate help response.
+// an appropriate help response. If the arguments don't name a command or
+// subtree, they may name a help topic added by AddTopic.
 func (t *Tree) GetHelp(w io.Writer, args []string) error {
 	var n Node
 	switch {
@@ -221,7 +383,11 @@ func (t *Tree) GetHelp(w io.Writer, args []string) error {
 		var err error
 		n, _, err = t.Lookup(strings.Join(args, " "))
 		if err != nil {
-			return err
+			topic := t.lookupTopic(args)
+			if topic == nil {
+				return err
+			}
+			n = topic
 		}
 	}
 
@@ -229,155 +395,270 @@ func (t *Tree) GetHelp(w io.Writer, args []string) error {
 	return nil
 }
 
-func indentWrap(indent int, s string) string {
-	ss := strings.Fields(s)
-	if len(ss) == 0 {
-		return ""
-	}
+// wrapWidth is the default column width at which help text is wrapped.
+const wrapWidth = 80
 
-	counts := make([]int, 0)
-	count := 1
-	l := indent + len(ss[0])
-	for i := 1; i < len(ss); i++ {
-		if l+1+len(ss[i]) < 80 {
-			count++
-			l += 1 + len(ss[i])
-			continue
-		}
+// SetHelpWidth sets the column width at which help text is wrapped for the
+// entire command tree containing t. A width of zero restores the default
+// width of 80 columns.
+func (t *Tree) SetHelpWidth(width int) {
+	t.root().width = width
+}
 
-		counts = append(counts, count)
-		count = 1
-		l = indent + len(ss[i])
-	}
-	counts = append(counts, count)
+// SetHelpOverflow selects how help text words too long to fit on a line,
+// such as URLs, are displayed for the entire command tree containing t. By
+// default, such words are placed on lines of their own.
+func (t *Tree) SetHelpOverflow(o Overflow) {
+	t.root().overflow = o
+}
 
-	var lines []string
-	i := 0
-	for _, c := range counts {
-		line := strings.Repeat(" ", indent) + strings.Join(ss[i:i+c], " ")
-		lines = append(lines, line)
-		i += c
-	}
+// HelpOverflow returns the handling of help text words too long to fit on
+// a line.
+func (t *Tree) HelpOverflow() Overflow {
+	return t.root().overflow
+}
 
-	return strings.Join(lines, "\n")
+// SetGroupedHelp sets whether help for the entire command tree containing t
+// lists subtrees and commands in separate sections, rather than in a single
+// list.
+func (t *Tree) SetGroupedHelp(grouped bool) {
+	t.root().grouped = grouped
+}
+
+// SetMaxLineLength limits the length, in bytes, of the lines accepted by
+// the entire command tree containing t. Longer lines are rejected by Lookup
+// and Resolve with ErrLineTooLong, produce no completions or tokens, and are
+// discarded by a Runner as they are read. A limit of zero, the default,
+// accepts lines of any length. Setting a limit is advisable when lines are
+// read from untrusted sources.
+func (t *Tree) SetMaxLineLength(n int) {
+	t.root().maxLine = n
+}
+
+// MaxLineLength returns the maximum length of the lines accepted by the
+// tree, or zero if the length is unlimited.
+func (t *Tree) MaxLineLength() int {
+	return t.root().maxLine
+}
+
+// tooLong returns true if the line exceeds the tree's maximum line length.
+func (t *Tree) tooLong(line string) bool {
+	n := t.root().maxLine
+	return n > 0 && len(line) > n
+}
+
+// helpWrap returns the options used to wrap help text indented by indent
+// spaces.
+func (t *Tree) helpWrap(indent int) WrapOptions {
+	return WrapOptions{Width: t.HelpWidth(), Indent: indent, Overflow: t.HelpOverflow()}
+}
+
+// HelpWidth returns the column width at which help text is wrapped.
+func (t *Tree) HelpWidth() int {
+	if w := t.root().width; w > 0 {
+		return w
+	}
+	return wrapWidth
 }
 
 // DisplayHelp displays a sorted list of commands (and subtrees) available at
-// the tree's top level.
+// the tree's top level. If grouped help is enabled, subtrees and commands are
+// listed in separate sections.
 func (t *Tree) DisplayHelp(w io.Writer) {
-	nodes := make([]Node, 0)
-	for _, c := range t.commands {
-		nodes = append(nodes, c)
+	if tmpl := t.root().listTmpl; tmpl != nil {
+		executeHelpTemplate(w, tmpl, NewHelpNode(t))
+		return
 	}
-	for _, st := range t.subtrees {
-		nodes = append(nodes, st)
+
+	nodes := t.sortedNodes()
+	if slices.ContainsFunc(nodes, isHidden) {
+		nodes = slices.DeleteFunc(slices.Clone(nodes), isHidden)
 	}
 
-	sort.Slice(nodes, func(i, j int) bool {
-		return nodes[i].name() < nodes[j].name()
-	})
+	if !t.root().grouped {
+		fmt.Fprintf(w, t.message(MsgCommands)+"\n", t.Name)
+		t.displayList(w, nodes, func(n Node) string { return n.name() })
+		fmt.Fprintln(w)
+		t.displayTopics(w)
+		return
+	}
+
+	// Grouped help lists subtrees and commands in separate sections, with
+	// each subtree name followed by a marker.
+	marker := " " + t.message(MsgSubtreeMarker)
+	label := func(n Node) string {
+		if _, ok := n.(*Tree); ok {
+			return n.name() + marker
+		}
+		return n.name()
+	}
 
+	var subtrees, commands []Node
+	for _, n := range nodes {
+		if _, ok := n.(*Tree); ok {
+			subtrees = append(subtrees, n)
+		} else {
+			commands = append(commands, n)
+		}
+	}
+	for _, section := range []struct {
+		key   string
+		nodes []Node
+	}{
+		{MsgSubtreeSection, subtrees},
+		{MsgCommandSection, commands},
+	} {
+		if len(section.nodes) > 0 {
+			fmt.Fprintln(w, t.message(section.key))
+			t.displayList(w, section.nodes, label, nodes...)
+			fmt.Fprintln(w)
+		}
+	}
+	t.displayTopics(w)
+}
+
+// displayList displays the labels and briefs of a list of nodes in two
+// columns. The width of the label column is chosen to fit the labels of all
+// nodes in align, or of the listed nodes if align is empty.
+func (t *Tree) displayList(w io.Writer, nodes []Node, label func(n Node) string, align ...Node) {
+	if len(align) == 0 {
+		align = nodes
+	}
 	maxNameLen := 0
-	for _, e := range nodes {
-		if len(e.name()) > maxNameLen {
-			maxNameLen = len(e.name())
+	for _, e := range align {
+		if n := displayWidth(label(e)); n > maxNameLen {
+			maxNameLen = n
 		}
 	}
 
-	fmt.Fprintf(w, "%s commands:\n", t.Name)
+	// Briefs are wrapped into the second column, with continuation lines
+	// aligned to the first line of the brief.
+	indent := 4 + maxNameLen + 2
+	opts := t.helpWrap(indent)
+	opts.Width = max(opts.Width, indent+wrapWidth/4)
 	for _, e := range nodes {
-		if e.brief() != "" {
-			fmt.Fprintf(w, "    %-*s  %s\n", maxNameLen, e.name(), e.brief())
+		text := Wrap(e.brief(), opts)
+		if text == "" {
+			continue
 		}
+		fmt.Fprintf(w, "    %s  %s\n", padRight(label(e), maxNameLen), text[indent:])
 	}
-	fmt.Fprintln(w)
 }
 
 // Autocomplete builds a list of auto-completion candidates for the provided
-// line of text.
+// line of text. As in a shell, a line ending with whitespace completes the
+// empty token following its final field, so that "file " lists the
+// subtree's children and "file open " the values of the command's first
+// argument.
 func (t *Tree) Autocomplete(line string) []string {
+	return t.AutocompleteAppend([]string{}, line)
+}
+
+// AutocompleteAppend appends the auto-completion candidates for the provided
+// line of text to dst and returns the extended slice. Reusing dst across
+// calls, for instance on every keystroke, avoids allocating a new slice of
+// candidates each time.
+func (t *Tree) AutocompleteAppend(dst []string, line string) []string {
+	if t.tooLong(line) {
+		return dst
+	}
+	// A line ending with whitespace outside a quoted string completes an
+	// empty token following its final field.
+	trailing := endsWithSpace(line)
 	field, remain := nextField(stripLeadingWhitespace(line))
-	pt := t.pt
+	cur := t
 	prefix := ""
-	for {
-		matches := pt.FindKeyValues(field)
+	for first := true; ; first = false {
+		if sc, rest := matchPhrase(cur.phrases, field, remain); sc != nil && (rest != "" || trailing) {
+			return sc.Command.completeArgs(dst, prefix+sc.Name+" ", rest, trailing)
+		}
+
+		matches := visibleMatches(cur.pt.FindKeyValues(field), field)
+		if len(matches) == 0 && first {
+			matches = visibleMatches(t.findGlobals(field), field)
+		}
 		if len(matches) == 0 {
 			break
 		}
 
-		if len(matches) > 1 {
-			if remain != "" {
+		if len(matches) > 1 && (remain != "" || trailing) {
+			m, ok := prioritized(matches)
+			if !ok {
 				break
 			}
-			results := []string{}
+			matches = []prefixtree.KeyValue[Node]{m}
+		}
+
+		if len(matches) > 1 {
 			for _, match := range matches {
-				results = append(results, prefix+match.Key)
+				dst = append(dst, prefix+match.Key)
 			}
-			return results
+			return dst
 		}
 
 		match := matches[0]
-		if _, ok := match.Value.(*Command); ok {
-			if remain != "" {
-				break
+		if c, ok := match.Value.(*Command); ok {
+			if remain != "" || trailing {
+				return c.completeArgs(dst, prefix+match.Key+" ", remain, trailing)
 			}
-			return []string{prefix + match.Key}
+			return append(dst, prefix+match.Key)
 		}
 
 		subtree := match.Value.(*Tree)
-		if remain == "" && field != subtree.Name {
-			return []string{prefix + match.Key}
+		if remain == "" && field != subtree.Name && !trailing {
+			return append(dst, prefix+match.Key)
 		}
 
+		// Completing the subtree's children consumes any trailing
+		// whitespace.
 		prefix += match.Key + " "
-		pt = subtree.pt
+		cur = subtree
+		trailing = trailing && remain != ""
 		field, remain = nextField(remain)
 	}
 
-	return []string{}
+	return dst
+}
+
+// visibleMatches removes hidden nodes from a list of autocompletion matches,
+// unless a node's name was typed in full.
+func visibleMatches(matches []prefixtree.KeyValue[Node], field string) []prefixtree.KeyValue[Node] {
+	results := matches[:0]
+	for _, m := range matches {
+		if m.Key == field || !isHidden(m.Value) {
+			results = append(results, m)
+		}
+	}
+	return results
 }
 
 // Lookup performs a search on a command tree for a command or subtree node
 // matching the line input. If found, it returns the matching node and the
 // remaining unmatched line arguments.
+//
+// The first field of the line is matched against the names of the tree's
+// commands and subtrees and against the shortcuts registered on the tree. If
+// nothing matches, the field is matched against the global shortcuts of the
+// entire command tree. Multi-word shortcuts are matched before the
+// single-word names and shortcuts at the same level, and they must be typed
+// in full. A field equal to a name matches it even if the field is also a
+// prefix of longer names, so adding "runtime" never makes "run" ambiguous.
+// Autocomplete follows the same rule.
+//
+// A field that is a prefix of several names matches the name of the command
+// or subtree whose priority exceeds the priorities of all others, if there
+// is one. Otherwise, Lookup returns ErrAmbiguous.
 func (t *Tree) Lookup(line string) (n Node, args []string, err error) {
-	field, remain := nextField(stripLeadingWhitespace(line))
-
-	args = []string{}
-	if field == "" {
-		return nil, args, ErrNotFound
-	}
-
-	pt := t.pt
-	for {
-		v, err := pt.FindValue(field)
-		switch err {
-		case prefixtree.ErrPrefixAmbiguous:
-			return nil, args, ErrAmbiguous
-		case prefixtree.ErrPrefixNotFound:
-			return nil, args, ErrNotFound
-		}
-
-		if _, ok := v.(*Command); ok {
-			n = v
-			break
-		}
-
-		subtree := v.(*Tree)
-		if remain == "" {
-			n = v
-			break
-		}
-
-		field, remain = nextField(remain)
-		pt = subtree.pt
-	}
+	return t.LookupAppend([]string{}, line)
+}
 
-	for remain != "" {
-		field, remain = nextField(remain)
-		args = append(args, field)
-	}
-	return n, args, nil
+// LookupAppend performs the same search as Lookup, but appends the remaining
+// unmatched line arguments to dst and returns the extended slice. Reusing dst
+// across calls avoids allocating a new slice of arguments for each lookup.
+func (t *Tree) LookupAppend(dst []string, line string) (n Node, args []string, err error) {
+	r := LookupResult{Args: dst}
+	err = t.resolve(line, &r, false)
+	return r.Node, r.Args, err
 }
 
 // LookupCommand performs a search on a command tree for a command matching
@@ -421,16 +702,22 @@ func nextField(s string) (field, remain string) {
 	}
 
 	for i, c := range s {
-		if c == ' ' || c == '\t' {
+		if unicode.IsSpace(c) {
 			return s[:i], stripLeadingWhitespace(s[i:])
 		}
 	}
 	return s, ""
 }
 
+// endsWithSpace returns true if the line ends with whitespace that isn't
+// part of an unterminated quoted string.
+func endsWithSpace(line string) bool {
+	return strings.TrimRightFunc(line, unicode.IsSpace) != line && strings.Count(line, "\"")%2 == 0
+}
+
 func stripLeadingWhitespace(s string) string {
 	for i, c := range s {
-		if c != ' ' && c != '\t' {
+		if !unicode.IsSpace(c) {
 			return s[i:]
 		}
 	}
@@ -2,6 +2,8 @@ package cmd
 
 import (
 	"bytes"
+	"fmt"
+	"io"
 	"strings"
 	"testing"
 )
@@ -192,6 +194,11 @@ func TestAutocomplete(t *testing.T) {
 		{"chi sa", []string{"child sally"}},
 		{"child sally", []string{"child sally"}},
 		{"child sally foo", []string{}},
+		{"chi ", []string{"child grandchild", "child sally", "child steve"}},
+		{"child ", []string{"child grandchild", "child sally", "child steve"}},
+		{"c ", []string{}},
+		{"chi g ", []string{"child grandchild alice", "child grandchild mike"}},
+		{"child sally ", []string{}},
 		{"child st", []string{"child steve"}},
 		{"child steve", []string{"child steve"}},
 		{"child steve foo", []string{}},
@@ -307,3 +314,177 @@ func TestGetHelp(t *testing.T) {
 		}
 	}
 }
+
+func TestHelpFunc(t *testing.T) {
+	targets := []string{"ttyUSB0"}
+	tree := NewTree(TreeDescriptor{Name: "tree"})
+	tree.AddCommand(CommandDescriptor{
+		Name:  "connect",
+		Brief: "connect to a target",
+		HelpFunc: func(w io.Writer, c *Command) {
+			fmt.Fprintf(w, "%s targets: %s\n", c.Name, strings.Join(targets, ", "))
+		},
+	})
+
+	buf := new(bytes.Buffer)
+	tree.GetHelp(buf, []string{"connect"})
+	targets = append(targets, "ttyUSB1")
+	tree.GetHelp(buf, []string{"conn"})
+
+	expected := "connect targets: ttyUSB0\n" +
+		"connect targets: ttyUSB0, ttyUSB1\n"
+	if help := buf.String(); help != expected {
+		t.Errorf("Expected help:\n%s\nGot:\n%s", expected, help)
+	}
+}
+
+func TestBriefFunc(t *testing.T) {
+	port := "none"
+	tree := NewTree(TreeDescriptor{Name: "tree"})
+	tree.AddCommand(CommandDescriptor{
+		Name:      "status",
+		BriefFunc: func() string { return "currently: " + port },
+		DescriptionFunc: func() string {
+			return "Show the connection status. Currently connected to " + port + "."
+		},
+	})
+	dev := tree.AddSubtree(TreeDescriptor{Name: "dev"})
+	dev.BriefFunc = func() string { return "device commands for " + port }
+
+	port = "/dev/ttyUSB0"
+	buf := new(bytes.Buffer)
+	tree.GetHelp(buf, nil)
+	tree.GetHelp(buf, []string{"status"})
+
+	expected := "tree commands:\n" +
+		"    dev     device commands for /dev/ttyUSB0\n" +
+		"    status  currently: /dev/ttyUSB0\n" +
+		"\n" +
+		"Description:\n" +
+		"   Show the connection status. Currently connected to /dev/ttyUSB0.\n" +
+		"\n"
+	if help := buf.String(); help != expected {
+		t.Errorf("Expected help:\n%s\nGot:\n%s", expected, help)
+	}
+}
+
+func TestBriefWrap(t *testing.T) {
+	tree := NewTree(TreeDescriptor{Name: "tree"})
+	tree.AddCommand(CommandDescriptor{
+		Name:  "connect",
+		Brief: "connect to a remote target over a serial line or a network socket, retrying on failure",
+	})
+	tree.AddCommand(CommandDescriptor{Name: "quit", Brief: "quit the application"})
+
+	cases := []struct {
+		width int
+		help  string
+	}{
+		{
+			0,
+			"tree commands:\n" +
+				"    connect  connect to a remote target over a serial line or a network socket,\n" +
+				"             retrying on failure\n" +
+				"    quit     quit the application\n" +
+				"\n",
+		},
+		{
+			50,
+			"tree commands:\n" +
+				"    connect  connect to a remote target over a\n" +
+				"             serial line or a network socket,\n" +
+				"             retrying on failure\n" +
+				"    quit     quit the application\n" +
+				"\n",
+		},
+	}
+
+	for i, c := range cases {
+		tree.SetHelpWidth(c.width)
+		buf := new(bytes.Buffer)
+		tree.DisplayHelp(buf)
+		if help := buf.String(); help != c.help {
+			t.Errorf("Case %d: expected:\n%s\ngot:\n%s", i, c.help, help)
+		}
+	}
+}
+
+func TestGroupedHelp(t *testing.T) {
+	cases := []struct {
+		line string
+		help string
+	}{
+		{
+			"",
+			"Subcommands:\n" +
+				"    file ▸          file commands\n" +
+				"\n" +
+				"Commands:\n" +
+				"    quit            quit the application\n" +
+				"    verylongstring  very long string\n" +
+				"\n",
+		},
+		{
+			"file",
+			"Commands:\n" +
+				"    close  close a file\n" +
+				"    open   open a file\n" +
+				"    read   read a file\n" +
+				"\n",
+		},
+	}
+
+	for i, c := range cases {
+		tree := buildTree()
+		tree.SetGroupedHelp(true)
+		buf := new(bytes.Buffer)
+		tree.GetHelp(buf, strings.Fields(c.line))
+		if help := buf.String(); help != c.help {
+			t.Errorf("Case %d: expected:\n%s\ngot:\n%s", i, c.help, help)
+		}
+	}
+}
+
+func TestExactMatchWins(t *testing.T) {
+	tree := NewTree(TreeDescriptor{Name: "root"})
+	tree.AddCommand(CommandDescriptor{Name: "run",
+		Args: []Arg{{Name: "mode", Type: EnumType{Values: []string{"fast", "slow"}}}}})
+	tree.AddCommand(CommandDescriptor{Name: "runtime"})
+	tree.AddSubtree(TreeDescriptor{Name: "set"}).AddCommand(CommandDescriptor{Name: "x"})
+	tree.AddCommand(CommandDescriptor{Name: "settings"})
+
+	lookups := []struct {
+		line string
+		path string
+		err  error
+	}{
+		{"run", "run", nil},
+		{"run f", "run", nil},
+		{"runt", "runtime", nil},
+		{"ru", "", ErrAmbiguous},
+		{"set x", "set x", nil},
+	}
+	for i, c := range lookups {
+		n, _, err := tree.Lookup(c.line)
+		if err != c.err || (err == nil && nodePath(n) != c.path) {
+			t.Errorf("Lookup case %d: expected '%s' (%v), got %v (%v)", i, c.path, c.err, n, err)
+		}
+	}
+
+	completions := []struct {
+		line     string
+		expected string
+	}{
+		{"run", "run"},
+		{"runt", "runtime"},
+		{"run f", "run fast"},
+		{"set ", "set x"},
+		{"se", "set,settings"},
+	}
+	for i, c := range completions {
+		got := strings.Join(tree.Autocomplete(c.line), ",")
+		if got != c.expected {
+			t.Errorf("Autocomplete case %d: expected '%s', got '%s'", i, c.expected, got)
+		}
+	}
+}
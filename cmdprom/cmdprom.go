@@ -0,0 +1,190 @@
+// Package cmdprom provides an implementation of cmd.Metrics that exposes
+// the measurements of a runner's commands to Prometheus, in its text
+// exposition format. It depends only on the standard library; programs
+// already using the Prometheus client library may instead implement
+// cmd.Metrics with a CounterVec and a HistogramVec labelled by command.
+//
+//	m := new(cmdprom.Metrics)
+//	r.Metrics = m
+//	http.Handle("/metrics", m)
+package cmdprom
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBuckets are the upper bounds, in seconds, of the duration
+// histogram's buckets, if a Metrics has no buckets of its own. They match
+// the default buckets of the Prometheus client libraries.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Metrics counts the executions and errors of commands, and records their
+// durations in histograms, labelled by the command's path. The zero value
+// is ready for use. Metrics must not be copied after first use.
+type Metrics struct {
+	// Namespace is the prefix of the metrics' names. If empty, "cmd" is
+	// used.
+	Namespace string
+
+	// Buckets are the upper bounds, in seconds, of the duration histogram's
+	// buckets, in increasing order. If nil, DefaultBuckets is used.
+	Buckets []float64
+
+	mu        sync.Mutex
+	executed  map[string]uint64
+	errors    map[string]uint64
+	durations map[string]*histogram
+}
+
+// A histogram counts the durations falling in each bucket.
+type histogram struct {
+	counts []uint64 // cumulative count of each bucket
+	count  uint64
+	sum    float64
+}
+
+// IncExecuted counts an execution of the command.
+func (m *Metrics) IncExecuted(path string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.executed == nil {
+		m.executed = make(map[string]uint64)
+	}
+	m.executed[path]++
+}
+
+// IncError counts a failed execution of the command.
+func (m *Metrics) IncError(path string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.errors == nil {
+		m.errors = make(map[string]uint64)
+	}
+	m.errors[path]++
+}
+
+// ObserveDuration records the duration of an execution of the command.
+func (m *Metrics) ObserveDuration(path string, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.durations == nil {
+		m.durations = make(map[string]*histogram)
+	}
+	buckets := m.buckets()
+	h := m.durations[path]
+	if h == nil {
+		h = &histogram{counts: make([]uint64, len(buckets))}
+		m.durations[path] = h
+	}
+	s := d.Seconds()
+	for i, b := range buckets {
+		if s <= b {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += s
+}
+
+func (m *Metrics) buckets() []float64 {
+	if m.Buckets == nil {
+		return DefaultBuckets
+	}
+	return m.Buckets
+}
+
+// WriteTo writes the metrics in the Prometheus text exposition format.
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ns := m.Namespace
+	if ns == "" {
+		ns = "cmd"
+	}
+	cw := &countWriter{w: bufio.NewWriter(w)}
+
+	name := ns + "_commands_executed_total"
+	fmt.Fprintf(cw, "# HELP %s Number of commands executed.\n", name)
+	fmt.Fprintf(cw, "# TYPE %s counter\n", name)
+	for _, path := range sortedKeys(m.executed) {
+		fmt.Fprintf(cw, "%s{command=%s} %d\n", name, quote(path), m.executed[path])
+	}
+
+	name = ns + "_command_errors_total"
+	fmt.Fprintf(cw, "# HELP %s Number of commands that returned an error.\n", name)
+	fmt.Fprintf(cw, "# TYPE %s counter\n", name)
+	for _, path := range sortedKeys(m.errors) {
+		fmt.Fprintf(cw, "%s{command=%s} %d\n", name, quote(path), m.errors[path])
+	}
+
+	name = ns + "_command_duration_seconds"
+	fmt.Fprintf(cw, "# HELP %s Duration of command executions.\n", name)
+	fmt.Fprintf(cw, "# TYPE %s histogram\n", name)
+	buckets := m.buckets()
+	for _, path := range sortedKeys(m.durations) {
+		h, label := m.durations[path], quote(path)
+		for i, b := range buckets {
+			le := strconv.FormatFloat(b, 'g', -1, 64)
+			fmt.Fprintf(cw, "%s_bucket{command=%s,le=\"%s\"} %d\n", name, label, le, h.counts[i])
+		}
+		fmt.Fprintf(cw, "%s_bucket{command=%s,le=\"+Inf\"} %d\n", name, label, h.count)
+		fmt.Fprintf(cw, "%s_sum{command=%s} %s\n", name, label, strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(cw, "%s_count{command=%s} %d\n", name, label, h.count)
+	}
+
+	if cw.err == nil {
+		cw.err = cw.w.Flush()
+	}
+	return cw.n, cw.err
+}
+
+// ServeHTTP serves the metrics to a Prometheus scraper.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteTo(w)
+}
+
+// quote returns a label value quoted and escaped as required by the text
+// exposition format.
+func quote(s string) string {
+	return `"` + labelEscaper.Replace(s) + `"`
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// sortedKeys returns the keys of the map in increasing order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// A countWriter counts the bytes written to a writer, and records the
+// first error.
+type countWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (cw *countWriter) Write(p []byte) (int, error) {
+	if cw.err != nil {
+		return 0, cw.err
+	}
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	cw.err = err
+	return n, err
+}
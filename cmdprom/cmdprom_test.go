@@ -0,0 +1,73 @@
+package cmdprom
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/beevik/cmd"
+)
+
+func TestMetrics(t *testing.T) {
+	m := &Metrics{Namespace: "app", Buckets: []float64{0.1, 1}}
+	m.IncExecuted("file open")
+	m.IncExecuted("file open")
+	m.IncExecuted(`say "hi"`)
+	m.IncError("file open")
+	m.ObserveDuration("file open", 50*time.Millisecond)
+	m.ObserveDuration("file open", 2*time.Second)
+
+	want := `# HELP app_commands_executed_total Number of commands executed.
+# TYPE app_commands_executed_total counter
+app_commands_executed_total{command="file open"} 2
+app_commands_executed_total{command="say \"hi\""} 1
+# HELP app_command_errors_total Number of commands that returned an error.
+# TYPE app_command_errors_total counter
+app_command_errors_total{command="file open"} 1
+# HELP app_command_duration_seconds Duration of command executions.
+# TYPE app_command_duration_seconds histogram
+app_command_duration_seconds_bucket{command="file open",le="0.1"} 1
+app_command_duration_seconds_bucket{command="file open",le="1"} 1
+app_command_duration_seconds_bucket{command="file open",le="+Inf"} 2
+app_command_duration_seconds_sum{command="file open"} 2.05
+app_command_duration_seconds_count{command="file open"} 2
+`
+	var b strings.Builder
+	n, err := m.WriteTo(&b)
+	if err != nil || int(n) != b.Len() {
+		t.Errorf("unexpected result %d, %v", n, err)
+	}
+	if b.String() != want {
+		t.Errorf("output mismatch.\nEXPECTED:\n%s\nGOT:\n%s", want, b.String())
+	}
+}
+
+func TestRunner(t *testing.T) {
+	tree := cmd.NewTree(cmd.TreeDescriptor{Name: "tree"})
+	tree.AddCommand(cmd.CommandDescriptor{
+		Name:    "hello",
+		Handler: func(ctx *cmd.ExecContext, args []string) error { return nil },
+	})
+
+	m := new(Metrics)
+	r := cmd.NewRunner(tree, strings.NewReader("hello\nhello\n"), io.Discard)
+	r.Metrics = m
+	r.Run()
+
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	body := w.Body.String()
+	for _, s := range []string{
+		`cmd_commands_executed_total{command="hello"} 2`,
+		`cmd_command_duration_seconds_count{command="hello"} 2`,
+	} {
+		if !strings.Contains(body, s) {
+			t.Errorf("missing %q in:\n%s", s, body)
+		}
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("unexpected content type %q", ct)
+	}
+}
@@ -0,0 +1,206 @@
+// Package cmdrpc exposes a command tree as an RPC service, so that GUIs
+// and web frontends may drive the same commands as a terminal. The service
+// offers the Lookup, Autocomplete, Execute and Help methods, and is served
+// over JSON-RPC by ServeConn. The service is also described by the
+// protocol buffer definitions in cmd.proto, from which adapters for other
+// RPC systems may be generated.
+package cmdrpc
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"sync"
+
+	"github.com/beevik/cmd"
+	"github.com/beevik/cmd/remote"
+)
+
+// ServiceName is the name under which the service's methods are
+// registered, as in "Command.Execute".
+const ServiceName = "Command"
+
+// A LineRequest holds the command line passed to each method.
+type LineRequest struct {
+	Line string `json:"line"`
+}
+
+// A LookupReply describes the command or subtree named by a line.
+type LookupReply = remote.Lookup
+
+// An AutocompleteReply holds the completions of a line.
+type AutocompleteReply struct {
+	Completions []cmd.Completion `json:"completions"`
+}
+
+// An ExecuteReply holds the result of executing a command line.
+type ExecuteReply struct {
+	Output string `json:"output"`          // output and error messages
+	Error  string `json:"error,omitempty"` // message of the returned error
+	Status int    `json:"status"`          // exit status, as reported by cmd.ExitCode
+}
+
+// A HelpReply holds the help text of a command or subtree.
+type HelpReply struct {
+	Text string `json:"text"`
+}
+
+// A Service answers RPC calls for a single session, executing commands
+// with its own runner, so that the session's current tree and variables
+// persist between calls. Executions are serialized.
+type Service struct {
+	runner *cmd.Runner
+	answer *remote.Server
+	mu     sync.Mutex
+}
+
+// NewService creates a service for a session executing the commands of the
+// tree.
+func NewService(tree *cmd.Tree) *Service {
+	return &Service{
+		runner: cmd.NewRunner(tree, nil, io.Discard),
+		answer: &remote.Server{Tree: tree},
+	}
+}
+
+// Runner returns the runner executing the session's commands, so that its
+// settings may be adjusted before the service is served.
+func (s *Service) Runner() *cmd.Runner {
+	return s.runner
+}
+
+// Lookup describes the command or subtree named by the line.
+func (s *Service) Lookup(req *LineRequest, reply *LookupReply) error {
+	resp, err := s.ask(remote.OpLookup, req.Line)
+	if err != nil {
+		return err
+	}
+	*reply = *resp.Lookup
+	return nil
+}
+
+// Autocomplete returns the completions of the line.
+func (s *Service) Autocomplete(req *LineRequest, reply *AutocompleteReply) error {
+	resp, err := s.ask(remote.OpComplete, req.Line)
+	if err != nil {
+		return err
+	}
+	reply.Completions = resp.Completions
+	return nil
+}
+
+// Help returns the help text of the command or subtree named by the line,
+// or of the tree if the line is blank.
+func (s *Service) Help(req *LineRequest, reply *HelpReply) error {
+	resp, err := s.ask(remote.OpHelp, req.Line)
+	if err != nil {
+		return err
+	}
+	reply.Text = resp.Help
+	return nil
+}
+
+// Execute executes the command line, returning its output. An error
+// returned by the command is reported in the reply rather than as an RPC
+// error, and its message is included in the output as a terminal would
+// display it.
+func (s *Service) Execute(req *LineRequest, reply *ExecuteReply) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	buf := new(bytes.Buffer)
+	r := s.runner
+	r.Out, r.Err = buf, buf
+	err := r.Execute(req.Line)
+	if err != nil && !errors.Is(err, cmd.ErrExit) {
+		reply.Error = r.Tree.ErrorMessage(err)
+		r.DisplayError(buf, err)
+	}
+	reply.Output = buf.String()
+	reply.Status = cmd.ExitCode(err)
+	return nil
+}
+
+// ask answers a request using the session's current tree.
+func (s *Service) ask(op, line string) (remote.Response, error) {
+	s.mu.Lock()
+	s.answer.Tree = s.runner.Current()
+	resp := s.answer.Answer(remote.Request{Op: op, Line: line})
+	s.mu.Unlock()
+	if resp.Error != "" {
+		return resp, errors.New(resp.Error)
+	}
+	return resp, nil
+}
+
+// ServeConn serves the service over JSON-RPC on the connection until the
+// client hangs up.
+func ServeConn(conn io.ReadWriteCloser, s *Service) error {
+	srv := rpc.NewServer()
+	if err := srv.RegisterName(ServiceName, s); err != nil {
+		return err
+	}
+	srv.ServeCodec(jsonrpc.NewServerCodec(conn))
+	return nil
+}
+
+// Serve accepts incoming connections on the listener, serving a new
+// session of the tree's commands on each one. Serve returns the error that
+// stopped the listener.
+func Serve(l net.Listener, tree *cmd.Tree) error {
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go ServeConn(c, NewService(tree))
+	}
+}
+
+// A Client calls the methods of a remote service.
+type Client struct {
+	*rpc.Client
+}
+
+// Dial connects to a service at the network address.
+func Dial(network, addr string) (*Client, error) {
+	c, err := jsonrpc.Dial(network, addr)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{c}, nil
+}
+
+// NewClient creates a client calling a service over the connection.
+func NewClient(conn io.ReadWriteCloser) *Client {
+	return &Client{jsonrpc.NewClient(conn)}
+}
+
+// Lookup describes the command or subtree named by the line.
+func (c *Client) Lookup(line string) (*LookupReply, error) {
+	reply := new(LookupReply)
+	return reply, c.Call(ServiceName+".Lookup", &LineRequest{line}, reply)
+}
+
+// Autocomplete returns the completions of the line.
+func (c *Client) Autocomplete(line string) ([]cmd.Completion, error) {
+	var reply AutocompleteReply
+	err := c.Call(ServiceName+".Autocomplete", &LineRequest{line}, &reply)
+	return reply.Completions, err
+}
+
+// Execute executes the command line in the client's session.
+func (c *Client) Execute(line string) (*ExecuteReply, error) {
+	reply := new(ExecuteReply)
+	return reply, c.Call(ServiceName+".Execute", &LineRequest{line}, reply)
+}
+
+// Help returns the help text of the command or subtree named by the line.
+func (c *Client) Help(line string) (string, error) {
+	var reply HelpReply
+	err := c.Call(ServiceName+".Help", &LineRequest{line}, &reply)
+	return reply.Text, err
+}
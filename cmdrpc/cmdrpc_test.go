@@ -0,0 +1,99 @@
+package cmdrpc
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/beevik/cmd"
+)
+
+func buildTree() *cmd.Tree {
+	tree := cmd.NewTree(cmd.TreeDescriptor{Name: "tree"})
+	tree.AddCommand(cmd.CommandDescriptor{
+		Name:  "echo",
+		Brief: "echo the arguments",
+		Handler: func(ctx *cmd.ExecContext, args []string) error {
+			ctx.Printf("%s\n", strings.Join(args, " "))
+			return nil
+		},
+	})
+	tree.AddCommand(cmd.CommandDescriptor{
+		Name: "fail",
+		Handler: func(ctx *cmd.ExecContext, args []string) error {
+			return &cmd.StatusError{Code: 3, Err: errors.New("Failed")}
+		},
+	})
+	file := tree.AddSubtree(cmd.TreeDescriptor{Name: "file", Brief: "file commands"})
+	file.AddCommand(cmd.CommandDescriptor{
+		Name:  "open",
+		Brief: "open a file",
+		Args:  []cmd.Arg{{Name: "path"}},
+		Handler: func(ctx *cmd.ExecContext, args []string) error {
+			ctx.Printf("opened %s\n", ctx.Values["path"])
+			return nil
+		},
+	})
+	return tree
+}
+
+func newPair(t *testing.T) *Client {
+	sc, cc := net.Pipe()
+	go ServeConn(sc, NewService(buildTree()))
+	c := NewClient(cc)
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestExecute(t *testing.T) {
+	client := newPair(t)
+
+	cases := []struct {
+		line   string
+		output string
+		err    string
+		status int
+	}{
+		{"echo a b", "a b\n", "", 0},
+		{"file open x", "opened x\n", "", 0},
+		{"fail", "Failed.\n", "Failed.", 3},
+		{"bogus", "Command not found.\n", "Command not found.", 1},
+	}
+
+	for i, c := range cases {
+		reply, err := client.Execute(c.line)
+		switch {
+		case err != nil:
+			t.Errorf("Case %d: unexpected error '%v'", i, err)
+		case reply.Output != c.output:
+			t.Errorf("Case %d: expected output %q, got %q", i, c.output, reply.Output)
+		case reply.Error != c.err:
+			t.Errorf("Case %d: expected error %q, got %q", i, c.err, reply.Error)
+		case reply.Status != c.status:
+			t.Errorf("Case %d: expected status %d, got %d", i, c.status, reply.Status)
+		}
+	}
+}
+
+func TestQuery(t *testing.T) {
+	c := newPair(t)
+
+	completions, err := c.Autocomplete("fi")
+	if err != nil || len(completions) != 1 || completions[0].Text != "file" || !completions[0].More {
+		t.Errorf("unexpected completions %v, %v", completions, err)
+	}
+
+	l, err := c.Lookup("file open x")
+	if err != nil || l.Path != "file open" || l.Tree || l.Brief != "open a file" {
+		t.Errorf("unexpected lookup %+v, %v", l, err)
+	}
+	if _, err := c.Lookup("bogus"); err == nil {
+		t.Errorf("expected lookup error")
+	}
+
+	help, err := c.Help("file")
+	if err != nil || !strings.Contains(help, "open a file") {
+		t.Errorf("unexpected help %q, %v", help, err)
+	}
+}
@@ -0,0 +1,113 @@
+// Package cmdtest provides helpers for testing applications built with the
+// cmd package.
+package cmdtest
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/beevik/cmd"
+)
+
+func init() {
+	if flag.Lookup("update") == nil {
+		flag.Bool("update", false, "update golden files")
+	}
+}
+
+// updating returns true if the test binary was run with the -update flag.
+func updating() bool {
+	f := flag.Lookup("update")
+	return f != nil && f.Value.String() == "true"
+}
+
+// HelpDump returns the help displayed for the tree and for each of its
+// commands, subtrees and help topics, as shown by the tree's 'help'
+// command. Each help text is preceded by a header line holding the help
+// command's arguments, and the texts are sorted by their headers.
+func HelpDump(t *cmd.Tree) string {
+	var paths [][]string
+	paths = append(paths, nil)
+	for _, topic := range t.Topics() {
+		paths = append(paths, []string{topic.Name})
+	}
+	for path, n := range t.All() {
+		paths = append(paths, path)
+		if st, ok := n.(*cmd.Tree); ok {
+			for _, topic := range st.Topics() {
+				paths = append(paths, append(slices.Clone(path), topic.Name))
+			}
+		}
+	}
+	slices.SortFunc(paths, func(a, b []string) int {
+		return strings.Compare(strings.Join(a, " "), strings.Join(b, " "))
+	})
+
+	var b bytes.Buffer
+	for _, path := range paths {
+		fmt.Fprintln(&b, strings.TrimSpace("== help "+strings.Join(path, " ")))
+		if err := t.GetHelp(&b, path); err != nil {
+			fmt.Fprintf(&b, "error: %v\n", err)
+		}
+	}
+	return b.String()
+}
+
+// AssertGolden compares got with the contents of the golden file at path,
+// reporting a test error describing the first difference. If the test
+// binary was run with the -update flag, the golden file is written with got
+// instead.
+func AssertGolden(t testing.TB, path, got string) {
+	t.Helper()
+	if updating() {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Creating golden file directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatalf("Writing golden file: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Reading golden file: %v (run with -update to create it)", err)
+	}
+	if diff := Diff(string(want), got); diff != "" {
+		t.Errorf("Output differs from golden file %s (run with -update to accept):\n%s", path, diff)
+	}
+}
+
+// AssertHelp compares the help dump of the tree, as returned by HelpDump,
+// with the golden file at path.
+func AssertHelp(t testing.TB, tree *cmd.Tree, path string) {
+	t.Helper()
+	AssertGolden(t, path, HelpDump(tree))
+}
+
+// Diff describes the first line at which got differs from want, or
+// returns an empty string if they are equal.
+func Diff(want, got string) string {
+	if want == got {
+		return ""
+	}
+	wl, gl := strings.Split(want, "\n"), strings.Split(got, "\n")
+	for i := 0; ; i++ {
+		var w, g string
+		if i < len(wl) {
+			w = wl[i]
+		}
+		if i < len(gl) {
+			g = gl[i]
+		}
+		if w != g || i >= len(wl) || i >= len(gl) {
+			return fmt.Sprintf("line %d:\n  want: %q\n  got:  %q\n", i+1, w, g)
+		}
+	}
+}
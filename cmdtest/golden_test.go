@@ -0,0 +1,43 @@
+package cmdtest
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/beevik/cmd"
+)
+
+func buildTree() *cmd.Tree {
+	tree := cmd.NewTree(cmd.TreeDescriptor{Name: "app"})
+	tree.AddCommand(cmd.CommandDescriptor{Name: "quit", Brief: "Quit the application"})
+	file := tree.AddSubtree(cmd.TreeDescriptor{Name: "file", Brief: "File commands"})
+	file.AddCommand(cmd.CommandDescriptor{
+		Name:        "open",
+		Brief:       "Open a file",
+		Description: "Open the named file for reading.",
+		Args:        []cmd.Arg{{Name: "name"}},
+		Aliases:     []string{"o"},
+	})
+	file.AddTopic("paths", "File path syntax", "Paths are relative to the working directory.")
+	return tree
+}
+
+func TestAssertHelp(t *testing.T) {
+	AssertHelp(t, buildTree(), filepath.Join("testdata", "help.golden"))
+}
+
+func TestDiff(t *testing.T) {
+	cases := []struct {
+		want, got string
+		expected  string
+	}{
+		{"a\nb\n", "a\nb\n", ""},
+		{"a\nb\n", "a\nc\n", "line 2:\n  want: \"b\"\n  got:  \"c\"\n"},
+		{"a\n", "a\nb\n", "line 2:\n  want: \"\"\n  got:  \"b\"\n"},
+	}
+	for i, c := range cases {
+		if got := Diff(c.want, c.got); got != c.expected {
+			t.Errorf("Case %d: expected %q, got %q", i, c.expected, got)
+		}
+	}
+}
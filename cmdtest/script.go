@@ -0,0 +1,181 @@
+package cmdtest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/beevik/cmd"
+)
+
+// A script is a sequence of steps, each consisting of command lines and the
+// output they are expected to produce.
+//
+// In a script file, a step's command lines follow an "-- in --" marker line
+// and its expected output follows a "-- want --" marker line. The marker
+// preceding the first step's command lines may be omitted:
+//
+//	# Comments and blank lines among command lines are ignored.
+//	file open x
+//	-- want --
+//	opened x
+//	-- in --
+//	file close
+//	-- want --
+//	closed
+//
+// A step without a "-- want --" section is executed without checking its
+// output.
+type step struct {
+	in      []string // command lines, including comments
+	want    string   // expected output
+	hasWant bool     // the step checks its output
+}
+
+// Section markers of a script.
+const (
+	inMarker   = "-- in --"
+	wantMarker = "-- want --"
+)
+
+// parseScript splits a script into steps.
+func parseScript(script string) ([]step, error) {
+	var steps []step
+	var cur *step
+	inWant := false
+	for i, line := range strings.SplitAfter(script, "\n") {
+		if line == "" {
+			continue
+		}
+		switch strings.TrimRight(line, "\r\n") {
+		case inMarker:
+			steps = append(steps, step{})
+			cur, inWant = &steps[len(steps)-1], false
+			continue
+		case wantMarker:
+			if cur == nil || inWant {
+				return nil, fmt.Errorf("line %d: '%s' without preceding command lines", i+1, wantMarker)
+			}
+			cur.hasWant, inWant = true, true
+			continue
+		}
+
+		if cur == nil {
+			steps = append(steps, step{})
+			cur = &steps[len(steps)-1]
+		}
+		if inWant {
+			cur.want += line
+		} else {
+			cur.in = append(cur.in, strings.TrimRight(line, "\r\n"))
+		}
+	}
+	return steps, nil
+}
+
+// RunScript executes the script's command lines with the runner, reporting
+// a test error for each step whose output differs from the expected output.
+// The runner's output and error writers are replaced, so that both are
+// compared with the expected output. Errors returned by commands are
+// displayed by the runner's DisplayError method.
+func RunScript(t testing.TB, r *cmd.Runner, script string) {
+	t.Helper()
+	steps, err := parseScript(script)
+	if err != nil {
+		t.Fatalf("Parsing script: %v", err)
+	}
+	for i, s := range steps {
+		got := runStep(r, s)
+		if s.hasWant {
+			if diff := Diff(s.want, got); diff != "" {
+				t.Errorf("Step %d (%s): output differs from want, %s", i+1, firstCommand(s), diff)
+			}
+		}
+	}
+}
+
+// RunScriptFile runs the script in the named file, as RunScript does. If
+// the test binary was run with the -update flag, the file's expected output
+// sections are rewritten with the output actually produced.
+func RunScriptFile(t testing.TB, r *cmd.Runner, path string) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Reading script: %v", err)
+	}
+	if !updating() {
+		RunScript(t, r, string(data))
+		return
+	}
+
+	steps, err := parseScript(string(data))
+	if err != nil {
+		t.Fatalf("Parsing script %s: %v", path, err)
+	}
+	var b strings.Builder
+	for i, s := range steps {
+		if i > 0 {
+			fmt.Fprintln(&b, inMarker)
+		}
+		for _, line := range s.in {
+			fmt.Fprintln(&b, line)
+		}
+		fmt.Fprintln(&b, wantMarker)
+		b.WriteString(runStep(r, s))
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		t.Fatalf("Writing script: %v", err)
+	}
+}
+
+// RunScriptFiles runs each script file matching the glob pattern as a
+// subtest named after the file, using a fresh runner for each script.
+func RunScriptFiles(t *testing.T, pattern string, newRunner func() *cmd.Runner) {
+	t.Helper()
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatalf("No scripts match %s", pattern)
+	}
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			RunScriptFile(t, newRunner(), path)
+		})
+	}
+}
+
+// runStep executes a step's command lines and returns their output.
+func runStep(r *cmd.Runner, s step) string {
+	var out bytes.Buffer
+	r.Out, r.Err = &out, &out
+	for _, line := range s.in {
+		if isComment(line) {
+			continue
+		}
+		if err := r.Execute(line); err != nil && !errors.Is(err, cmd.ErrExit) {
+			r.DisplayError(&out, err)
+		}
+	}
+	return out.String()
+}
+
+// firstCommand returns the first command line of a step.
+func firstCommand(s step) string {
+	for _, line := range s.in {
+		if !isComment(line) {
+			return line
+		}
+	}
+	return ""
+}
+
+func isComment(line string) bool {
+	line = strings.TrimSpace(line)
+	return line == "" || strings.HasPrefix(line, "#")
+}
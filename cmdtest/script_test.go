@@ -0,0 +1,66 @@
+package cmdtest
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/beevik/cmd"
+)
+
+func newScriptRunner() *cmd.Runner {
+	tree := cmd.NewTree(cmd.TreeDescriptor{Name: "app"})
+	file := tree.AddSubtree(cmd.TreeDescriptor{Name: "file"})
+	file.AddCommand(cmd.CommandDescriptor{
+		Name: "open",
+		Args: []cmd.Arg{{Name: "name"}},
+		Handler: func(ctx *cmd.ExecContext, args []string) error {
+			ctx.Printf("opened %s\n", ctx.Values["name"])
+			return nil
+		},
+	})
+	file.AddCommand(cmd.CommandDescriptor{
+		Name: "close",
+		Handler: func(ctx *cmd.ExecContext, args []string) error {
+			ctx.Println("closed")
+			return nil
+		},
+	})
+	return cmd.NewRunner(tree, strings.NewReader(""), nil)
+}
+
+func TestRunScriptFiles(t *testing.T) {
+	RunScriptFiles(t, filepath.Join("testdata", "scripts", "*.txt"), newScriptRunner)
+}
+
+func TestParseScript(t *testing.T) {
+	cases := []struct {
+		script   string
+		expected string
+		err      bool
+	}{
+		{"a\nb\n-- want --\nx\n", "[a b]:x\n", false},
+		{"-- in --\na\n-- want --\n-- in --\nb\n", "[a]:|[b]", false},
+		{"-- want --\nx\n", "", true},
+		{"a\n-- want --\n-- want --\n", "", true},
+	}
+	for i, c := range cases {
+		steps, err := parseScript(c.script)
+		if (err != nil) != c.err {
+			t.Errorf("Case %d: unexpected error %v", i, err)
+			continue
+		}
+		var got []string
+		for _, s := range steps {
+			g := strings.Join(strings.Fields(strings.Join(s.in, " ")), " ")
+			g = "[" + g + "]"
+			if s.hasWant {
+				g += ":" + s.want
+			}
+			got = append(got, g)
+		}
+		if strings.Join(got, "|") != c.expected {
+			t.Errorf("Case %d: expected %q, got %q", i, c.expected, strings.Join(got, "|"))
+		}
+	}
+}
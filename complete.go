@@ -0,0 +1,190 @@
+package cmd
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// A Completion is an auto-completion candidate, along with hints describing
+// how a line editor should proceed once the candidate is accepted.
+type Completion struct {
+	Text string `json:"text"` // the completed line
+
+	// Space is true if a space should be appended to the completed line,
+	// because its final token is complete and further tokens may follow.
+	Space bool `json:"space,omitempty"`
+
+	// More is true if the completed line is likely to be completed further,
+	// as when it names a subtree or a directory, so that an editor may
+	// offer the next candidates immediately.
+	More bool `json:"more,omitempty"`
+}
+
+// Completions returns the auto-completion candidates found by Autocomplete
+// for the line, along with hints for each candidate. A candidate naming a
+// subtree is followed by a space and more completions. A candidate naming a
+// command is followed by a space unless the command accepts no arguments. A
+// candidate completing an argument is followed by a space, unless it ends
+// with a directory, in which case more completions follow.
+func (t *Tree) Completions(line string) []Completion {
+	candidates := t.Autocomplete(line)
+	if len(candidates) == 0 {
+		return nil
+	}
+	completions := make([]Completion, len(candidates))
+	for i, c := range candidates {
+		completions[i] = t.completion(c)
+	}
+	return completions
+}
+
+// Completions returns the auto-completion candidates for the line,
+// looked up from the runner's current tree, as described by
+// Tree.Completions.
+func (r *Runner) Completions(line string) []Completion {
+	return r.Current().Completions(line)
+}
+
+// completion returns the completion hints for a candidate.
+func (t *Tree) completion(candidate string) Completion {
+	comp := Completion{Text: candidate, Space: true}
+	n, args, err := t.Lookup(candidate)
+	if err != nil {
+		return comp
+	}
+	switch n := n.(type) {
+	case *Tree:
+		comp.More = true
+	case *Command:
+		if len(args) == 0 {
+			comp.Space = n.acceptsArgs()
+			break
+		}
+		if last := lastToken(candidate); strings.HasSuffix(last, "/") {
+			comp.Space, comp.More = false, true
+		}
+	}
+	return comp
+}
+
+// acceptsArgs returns false if the command is known to accept no arguments
+// or flags.
+func (c *Command) acceptsArgs() bool {
+	switch {
+	case c.MaxArgs == NoArgs:
+		return false
+	case c.Args != nil || c.Flags != nil:
+		return len(c.Args) > 0 || len(c.Flags) > 0
+	}
+	return true
+}
+
+// A CompletionSession tracks the state of repeated completion requests on
+// the same line, as when a user presses Tab several times in a row. The
+// first request completes the line as far as its candidates agree, and each
+// further request replaces the line with the next candidate in turn.
+//
+// A session remembers the line it last returned. A request for any other
+// line, as when the user has typed in the meantime, starts over.
+type CompletionSession struct {
+	complete   func(line string) []Completion
+	candidates []Completion
+	index      int    // index of the inserted candidate, or -1
+	last       string // the line last returned
+	active     bool   // the last line returned may be cycled
+}
+
+// NewCompletionSession creates a completion session that finds candidates
+// with the complete function, which is typically the Completions method of
+// a tree or runner.
+func NewCompletionSession(complete func(line string) []Completion) *CompletionSession {
+	return &CompletionSession{complete: complete, index: -1}
+}
+
+// Next returns the line with the next completion inserted, and false if the
+// line has no completions. A line with a single candidate is completed
+// directly, followed by a space if the candidate's hints call for one.
+func (s *CompletionSession) Next(line string) (string, bool) {
+	return s.advance(line, 1)
+}
+
+// Prev returns the line with the previous completion inserted, as when a
+// user presses Shift-Tab, and false if the line has no completions.
+func (s *CompletionSession) Prev(line string) (string, bool) {
+	return s.advance(line, -1)
+}
+
+// Candidates returns the candidates of the line being completed, so that a
+// frontend may display them as a menu.
+func (s *CompletionSession) Candidates() []Completion {
+	return s.candidates
+}
+
+// Index returns the index of the candidate last inserted by Next or Prev, or
+// -1 if no candidate has been inserted.
+func (s *CompletionSession) Index() int {
+	return s.index
+}
+
+// Reset discards the session's state, so that the next request starts over.
+func (s *CompletionSession) Reset() {
+	s.candidates, s.index, s.last, s.active = nil, -1, "", false
+}
+
+// advance returns the line with the completion delta candidates away from
+// the current one inserted.
+func (s *CompletionSession) advance(line string, delta int) (string, bool) {
+	if s.active && line == s.last {
+		n := len(s.candidates)
+		if s.index < 0 && delta < 0 {
+			s.index = n - 1
+		} else {
+			s.index = (s.index + delta + n) % n
+		}
+		s.last = s.candidates[s.index].Text
+		return s.last, true
+	}
+
+	s.Reset()
+	s.candidates = s.complete(line)
+	switch len(s.candidates) {
+	case 0:
+		return line, false
+	case 1:
+		s.index = 0
+		return s.candidates[0].line(), true
+	}
+
+	s.active = true
+	if prefix := s.commonPrefix(); len(prefix) > len(line) {
+		s.last = prefix
+		return prefix, true
+	}
+	s.last = line
+	return s.advance(line, delta)
+}
+
+// commonPrefix returns the longest prefix shared by the candidates.
+func (s *CompletionSession) commonPrefix() string {
+	prefix := s.candidates[0].Text
+	for _, c := range s.candidates[1:] {
+		i := 0
+		for i < len(prefix) && i < len(c.Text) && prefix[i] == c.Text[i] {
+			i++
+		}
+		prefix = prefix[:i]
+	}
+	for !utf8.ValidString(prefix) {
+		prefix = prefix[:len(prefix)-1]
+	}
+	return prefix
+}
+
+// line returns the completed line, followed by a space if the completion
+// calls for one.
+func (c Completion) line() string {
+	if c.Space {
+		return c.Text + " "
+	}
+	return c.Text
+}
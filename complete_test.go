@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestCompletions(t *testing.T) {
+	fsys := fstest.MapFS{
+		"main.go":           {},
+		"my docs/notes.txt": {},
+		"prog/a.asm":        {},
+	}
+	tree := buildArgTree()
+	tree.AddCommand(CommandDescriptor{Name: "quit", MaxArgs: NoArgs})
+	tree.AddCommand(CommandDescriptor{Name: "reset", Args: []Arg{}})
+	tree.AddCommand(CommandDescriptor{
+		Name: "load",
+		Args: []Arg{{Name: "file", Type: PathType{FS: fsys}}},
+	})
+
+	cases := []struct {
+		line        string
+		completions []Completion
+	}{
+		{"fi", []Completion{{"file", true, true}}},
+		{"file o", []Completion{{"file open", true, false}}},
+		{"q", []Completion{{"quit", false, false}}},
+		{"res", []Completion{{"reset", false, false}}},
+		{"se", []Completion{{"set", true, false}}},
+		{"set c", []Completion{{"set carry", true, false}}},
+		{"load ", []Completion{
+			{"load main.go", true, false},
+			{"load \"my docs/", false, true},
+			{"load prog/", false, true},
+		}},
+		{"load prog/", []Completion{{"load prog/a.asm", true, false}}},
+		{"bogus", nil},
+	}
+
+	for i, c := range cases {
+		got := tree.Completions(c.line)
+		if len(got) != len(c.completions) {
+			t.Errorf("Case %d: Completions(%q) = %v, wanted %v", i, c.line, got, c.completions)
+			continue
+		}
+		for j := range got {
+			if got[j] != c.completions[j] {
+				t.Errorf("Case %d: Completions(%q)[%d] = %v, wanted %v", i, c.line, j, got[j], c.completions[j])
+			}
+		}
+	}
+}
+
+func TestCompletionSession(t *testing.T) {
+	tree := buildArgTree()
+	s := NewCompletionSession(tree.Completions)
+
+	type step struct {
+		line  string
+		prev  bool
+		want  string
+		ok    bool
+		index int
+	}
+	steps := []step{
+		{"fi", false, "file ", true, 0},
+		{"file open x r", false, "file open x read", true, -1},
+		{"file open x read", false, "file open x read", true, 0},
+		{"file open x read", false, "file open x readwrite", true, 1},
+		{"file open x readwrite", false, "file open x read", true, 0},
+		{"file open x read", true, "file open x readwrite", true, 1},
+		{"file open x ", false, "file open x read", true, 0},
+		{"file open x ", true, "file open x write", true, 2},
+		{"bogus", false, "bogus", false, -1},
+		{"set carry zero ", true, "set carry zero zero", true, 2},
+		{"set carry zero zero", true, "set carry zero negative", true, 1},
+	}
+
+	for i, st := range steps {
+		var got string
+		var ok bool
+		if st.prev {
+			got, ok = s.Prev(st.line)
+		} else {
+			got, ok = s.Next(st.line)
+		}
+		if got != st.want || ok != st.ok || s.Index() != st.index {
+			t.Errorf("Step %d: got (%q, %v) at index %d, wanted (%q, %v) at index %d",
+				i, got, ok, s.Index(), st.want, st.ok, st.index)
+		}
+	}
+
+	s.Reset()
+	if len(s.Candidates()) != 0 || s.Index() != -1 {
+		t.Errorf("Reset left candidates %v at index %d", s.Candidates(), s.Index())
+	}
+}
+
+func TestCompleteFlags(t *testing.T) {
+	tree := NewTree(TreeDescriptor{Name: "tree"})
+	tree.AddCommand(CommandDescriptor{
+		Name: "open",
+		Args: []Arg{
+			{Name: "access", Type: EnumType{Values: []string{"read", "write"}}},
+			{Name: "speed", Type: EnumType{Values: []string{"fast", "slow"}}, Optional: true},
+		},
+		Flags: []Flag{
+			{Name: "verbose"},
+			{Name: "mode", Type: EnumType{Values: []string{"ascii", "hex"}}},
+		},
+	})
+
+	cases := []struct {
+		line        string
+		completions []string
+	}{
+		{"open r", []string{"open read"}},
+		{"open --verbose r", []string{"open --verbose read"}},
+		{"open --verbose=true r", []string{"open --verbose=true read"}},
+		{"open --mode h", []string{"open --mode hex"}},
+		{"open --mode hex r", []string{"open --mode hex read"}},
+		{"open --mode=a", []string{"open --mode=ascii"}},
+		{"open --mode=ascii read s", []string{"open --mode=ascii read slow"}},
+		{"open read --mode hex f", []string{"open read --mode hex fast"}},
+		{"open -- --x f", []string{"open -- --x fast"}},
+		{"open --bogus=x", nil},
+	}
+
+	for i, c := range cases {
+		got := tree.Autocomplete(c.line)
+		if strings.Join(got, ",") != strings.Join(c.completions, ",") {
+			t.Errorf("Case %d: Autocomplete(%q) = %q, wanted %q", i, c.line, got, c.completions)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+)
+
+// HiddenTag is the tag added by a configuration to the commands and subtrees
+// it hides.
+const HiddenTag = "hidden"
+
+// A Config holds user customizations of a command tree and runner, usually
+// read from a configuration file by LoadConfig.
+type Config struct {
+	Prompt          string              // runner prompt (empty keeps the default)
+	HelpWidth       int                 // help display width (zero keeps the default)
+	Locale          string              // help text locale (empty keeps the default)
+	HiddenTags      []string            // additional tags hidden from help
+	Hide            []string            // paths of commands and subtrees to hide
+	Shortcuts       map[string]string   // shortcuts, keyed by shortcut, to command paths
+	GlobalShortcuts map[string]string   // global shortcuts, keyed by shortcut
+	Aliases         map[string][]string // additional aliases, keyed by command path
+	Options         map[string]any      // default options stored in the root tree
+}
+
+// A ConfigError describes an error in a configuration file.
+type ConfigError struct {
+	Line int   // line number of the error (zero if unknown)
+	Err  error // the error
+}
+
+func (e *ConfigError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("Config: %v", e.Err)
+	}
+	return fmt.Sprintf("Config line %d: %v", e.Line, e.Err)
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// LoadConfig reads a configuration file. See ParseConfig for its format.
+func LoadConfig(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseConfig(f)
+}
+
+// ParseConfig reads a configuration in TOML format, such as:
+//
+//	prompt = "dbg> "
+//	help-width = 100
+//	locale = "fr"
+//	hidden-tags = ["experimental"]
+//	hide = ["debug dump"]
+//
+//	[shortcuts]
+//	ls = "file list"
+//
+//	[global-shortcuts]
+//	q = "quit"
+//
+//	[aliases]
+//	"file open" = ["load", "o"]
+//
+//	[options]
+//	color = false
+//	case-sensitive = true
+//
+// Only the subset of TOML needed by configurations is supported: tables,
+// bare and quoted keys, strings, integers, floats, booleans and single-line
+// arrays. Unknown keys and tables are reported as errors.
+func ParseConfig(r io.Reader) (*Config, error) {
+	entries, err := parseTOML(r)
+	if err != nil {
+		return nil, err
+	}
+
+	c := new(Config)
+	for _, e := range entries {
+		if err := c.set(e); err != nil {
+			return nil, &ConfigError{e.line, err}
+		}
+	}
+	return c, nil
+}
+
+// set stores a configuration entry.
+func (c *Config) set(e tomlEntry) error {
+	var ok bool
+	switch e.table + "." + e.key {
+	case ".prompt":
+		c.Prompt, ok = e.value.(string)
+	case ".help-width":
+		var n int64
+		n, ok = e.value.(int64)
+		c.HelpWidth = int(n)
+	case ".locale":
+		c.Locale, ok = e.value.(string)
+	case ".hidden-tags":
+		c.HiddenTags, ok = stringList(e.value)
+	case ".hide":
+		c.Hide, ok = stringList(e.value)
+	default:
+		switch e.table {
+		case "":
+			return fmt.Errorf("unknown setting '%s'", e.key)
+		case "shortcuts":
+			ok = setEntry(&c.Shortcuts, e)
+		case "global-shortcuts":
+			ok = setEntry(&c.GlobalShortcuts, e)
+		case "aliases":
+			var aliases []string
+			if aliases, ok = stringList(e.value); ok {
+				if c.Aliases == nil {
+					c.Aliases = make(map[string][]string)
+				}
+				c.Aliases[e.key] = aliases
+			}
+		case "options":
+			if c.Options == nil {
+				c.Options = make(map[string]any)
+			}
+			c.Options[e.key], ok = e.value, true
+		default:
+			return fmt.Errorf("unknown table '%s'", e.table)
+		}
+	}
+	if !ok {
+		return fmt.Errorf("invalid value for '%s'", e.key)
+	}
+	return nil
+}
+
+// setEntry stores a string entry in a map.
+func setEntry(m *map[string]string, e tomlEntry) bool {
+	s, ok := e.value.(string)
+	if ok {
+		if *m == nil {
+			*m = make(map[string]string)
+		}
+		(*m)[e.key] = s
+	}
+	return ok
+}
+
+// stringList converts a string or an array of strings to a list.
+func stringList(v any) ([]string, bool) {
+	switch v := v.(type) {
+	case string:
+		return []string{v}, true
+	case []any:
+		list := make([]string, 0, len(v))
+		for _, x := range v {
+			s, ok := x.(string)
+			if !ok {
+				return nil, false
+			}
+			list = append(list, s)
+		}
+		return list, true
+	}
+	return nil, false
+}
+
+// Apply applies the configuration to the runner and its tree, as described
+// by ApplyTree. The runner's prompt is replaced if the configuration has
+// one.
+func (c *Config) Apply(r *Runner) error {
+	if c.Prompt != "" {
+		r.Prompt = c.Prompt
+	}
+	return c.ApplyTree(r.Tree)
+}
+
+// ApplyTree applies the configuration to the entire command tree containing
+// t. Paths, shortcut targets and aliases are looked up from the root tree.
+// Settings that can't be applied, such as a shortcut to a command that
+// doesn't exist, are skipped, and an error joining the reasons is returned.
+// Conflicting shortcuts and aliases are reported as *ConflictError values.
+func (c *Config) ApplyTree(t *Tree) error {
+	r := t.root()
+	var errs []error
+	if c.HelpWidth > 0 {
+		r.SetHelpWidth(c.HelpWidth)
+	}
+	if c.Locale != "" {
+		r.SetLocale(c.Locale)
+	}
+
+	hidden := slices.Clone(r.HiddenTags())
+	for _, tag := range c.HiddenTags {
+		if !slices.Contains(hidden, tag) {
+			hidden = append(hidden, tag)
+		}
+	}
+	if len(c.Hide) > 0 && !slices.Contains(hidden, HiddenTag) {
+		hidden = append(hidden, HiddenTag)
+	}
+	r.SetHiddenTags(hidden...)
+	for _, path := range c.Hide {
+		if err := r.hide(path); err != nil {
+			errs = append(errs, fmt.Errorf("hide '%s': %w", path, err))
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(c.Shortcuts)) {
+		if err := r.AddShortcut(name, c.Shortcuts[name]); err != nil {
+			errs = append(errs, fmt.Errorf("shortcut '%s': %w", name, err))
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(c.GlobalShortcuts)) {
+		if err := r.AddGlobalShortcut(name, c.GlobalShortcuts[name]); err != nil {
+			errs = append(errs, fmt.Errorf("global shortcut '%s': %w", name, err))
+		}
+	}
+	for _, path := range slices.Sorted(maps.Keys(c.Aliases)) {
+		if err := r.addAliases(path, c.Aliases[path]); err != nil {
+			errs = append(errs, fmt.Errorf("aliases of '%s': %w", path, err))
+		}
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(c.Options)) {
+		r.SetData(key, c.Options[key])
+	}
+	return errors.Join(errs...)
+}
+
+// hide adds HiddenTag to the command or subtree found at path.
+func (t *Tree) hide(path string) error {
+	n, args, err := t.Lookup(path)
+	if err == nil && len(args) > 0 {
+		err = ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	switch n := n.(type) {
+	case *Command:
+		n.Tags = append(n.Tags, HiddenTag)
+	case *Tree:
+		n.Tags = append(n.Tags, HiddenTag)
+	}
+	t.invalidate()
+	return nil
+}
+
+// addAliases adds aliases to the command found at path. Aliases conflicting
+// with the names of the command's siblings aren't added.
+func (t *Tree) addAliases(path string, aliases []string) error {
+	c, args, err := t.LookupCommand(path)
+	if err == nil && len(args) > 0 {
+		err = ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	keys := c.parent.lookupKeys()
+	for _, a := range aliases {
+		if slices.Contains(keys, a) {
+			errs = append(errs, &ConflictError{c.parent, a, a})
+			continue
+		}
+		c.Aliases = append(c.Aliases, a)
+		keys = append(keys, a)
+	}
+	c.parent.reindex()
+	return errors.Join(errs...)
+}
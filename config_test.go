@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestConfig(t *testing.T) {
+	tree := NewTree(TreeDescriptor{Name: "app"})
+	file := tree.AddSubtree(TreeDescriptor{Name: "file"})
+	file.AddCommand(CommandDescriptor{Name: "open"})
+	file.AddCommand(CommandDescriptor{Name: "list"})
+	file.AddCommand(CommandDescriptor{Name: "raw"})
+	tree.AddCommand(CommandDescriptor{Name: "quit"})
+	debug := tree.AddSubtree(TreeDescriptor{Name: "debug"})
+	debug.AddCommand(CommandDescriptor{Name: "dump"})
+
+	cfg, err := ParseConfig(strings.NewReader(`
+prompt = "dbg> "
+help-width = 60
+hide = ["debug", "file raw", "file missing"]
+
+[shortcuts]
+ls = "file list"
+
+[global-shortcuts]
+x = "quit"
+
+[aliases]
+"file open" = ["load", "list"]
+
+[options]
+color = false
+`))
+	if err != nil {
+		t.Fatalf("ParseConfig: unexpected error %v", err)
+	}
+
+	r := NewRunner(tree, nil, nil)
+	err = cfg.Apply(r)
+	var conflict *ConflictError
+	switch {
+	case !errors.Is(err, ErrNotFound):
+		t.Errorf("Apply: expected error for missing path, got %v", err)
+	case !errors.As(err, &conflict) || conflict.Name != "list":
+		t.Errorf("Apply: expected conflict for alias 'list', got %v", err)
+	}
+
+	if r.Prompt != "dbg> " || tree.HelpWidth() != 60 {
+		t.Errorf("Apply: prompt %q, help width %d", r.Prompt, tree.HelpWidth())
+	}
+	if v, ok := tree.ResolveData("color"); !ok || v != false {
+		t.Errorf("Apply: expected color option false, got %v", v)
+	}
+
+	cases := []struct {
+		line     string
+		expected string
+	}{
+		{"ls", "list"},
+		{"x", "quit"},
+		{"file load", "open"},
+		{"debug dump", "dump"},
+	}
+	for i, c := range cases {
+		cmd, _, err := tree.LookupCommand(c.line)
+		if err != nil || cmd.Name != c.expected {
+			t.Errorf("Case %d: expected %s, got %v", i, c.expected, err)
+		}
+	}
+
+	var help strings.Builder
+	tree.DisplayHelp(&help)
+	if strings.Contains(help.String(), "debug") {
+		t.Errorf("Help: expected debug to be hidden:\n%s", help.String())
+	}
+}
+
+func TestParseConfigErrors(t *testing.T) {
+	cases := []struct {
+		input string
+		err   string
+	}{
+		{"colour = true\n", "Config line 1: unknown setting 'colour'"},
+		{"[keys]\na = \"b\"\n", "Config line 2: unknown table 'keys'"},
+		{"help-width = \"wide\"\n", "Config line 1: invalid value for 'help-width'"},
+		{"[aliases]\nquit = [1]\n", "Config line 2: invalid value for 'quit'"},
+	}
+	for i, c := range cases {
+		_, err := ParseConfig(strings.NewReader(c.input))
+		if err == nil || err.Error() != c.err {
+			t.Errorf("Case %d: expected %q, got %v", i, c.err, err)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ErrConflict is wrapped by all ConflictErrors.
+var ErrConflict = errors.New("Name conflict")
+
+// A ConflictError describes a command name, subtree name or shortcut that
+// equals, or is a prefix of, another name or shortcut in the same tree.
+type ConflictError struct {
+	Tree  *Tree  // tree containing the conflicting names
+	Name  string // the conflicting name or shortcut
+	Other string // the name or shortcut it conflicts with
+}
+
+func (e *ConflictError) Error() string {
+	name, other := e.Name, e.Other
+	if p := nodePath(e.Tree); p != "" {
+		name, other = p+" "+name, p+" "+other
+	}
+	if e.Name == e.Other {
+		return fmt.Sprintf("Duplicate name '%s'", name)
+	}
+	return fmt.Sprintf("'%s' conflicts with '%s'", name, other)
+}
+
+func (e *ConflictError) Unwrap() error {
+	return ErrConflict
+}
+
+// conflicts reports whether one name equals or is a prefix of the other.
+func conflicts(a, b string) bool {
+	return strings.HasPrefix(a, b) || strings.HasPrefix(b, a)
+}
+
+// lookupKeys returns the names and local shortcuts that may be typed to
+// select a node in the tree.
+func (t *Tree) lookupKeys() []string {
+	var keys []string
+	for _, c := range t.commands {
+		keys = append(keys, c.Name)
+		keys = append(keys, c.Aliases...)
+	}
+	for _, st := range t.subtrees {
+		keys = append(keys, st.Name)
+	}
+	for _, sc := range t.shortcuts {
+		if !sc.Global {
+			keys = append(keys, sc.Name)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// globalKeys returns the global shortcuts registered on the tree.
+func (t *Tree) globalKeys() []string {
+	var keys []string
+	for _, sc := range t.shortcuts {
+		if sc.Global {
+			keys = append(keys, sc.Name)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// shortcutConflict returns an error describing the tree's name or shortcut
+// equal to a new shortcut, or nil if there is none.
+func (t *Tree) shortcutConflict(shortcut string, global bool) *ConflictError {
+	keys := t.lookupKeys()
+	if global {
+		keys = append(keys, t.globalKeys()...)
+	}
+	for _, k := range keys {
+		if k == shortcut {
+			return &ConflictError{t, shortcut, k}
+		}
+	}
+	return nil
+}
+
+// Validate checks the entire command tree containing t for names and
+// shortcuts that may make lookups ambiguous or unexpected. It returns an
+// error joining a *ConflictError for each pair of conflicting names, or nil
+// if there are no conflicts. Global shortcuts are checked against the names
+// and shortcuts of the root tree. If the command tree has a maximum depth,
+// an error wrapping ErrMaxDepth is included for each subtree exceeding it.
+func (t *Tree) Validate() error {
+	var errs []error
+	r := t.root()
+	r.validate(&errs)
+	if max := r.maxDepth; max > 0 {
+		r.validateDepth(&errs, 0, max)
+	}
+
+	local, global := r.lookupKeys(), r.globalKeys()
+	for i, g := range global {
+		for _, k := range slices.Concat(local, global[i+1:]) {
+			if g == k || (!strings.Contains(g+k, " ") && conflicts(g, k)) {
+				errs = append(errs, &ConflictError{r, g, k})
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (t *Tree) validate(errs *[]error) {
+	keys := t.lookupKeys()
+	for i, a := range keys {
+		for _, b := range keys[i+1:] {
+			if a == b || (!strings.Contains(a+b, " ") && conflicts(a, b)) {
+				*errs = append(*errs, &ConflictError{t, a, b})
+			}
+		}
+	}
+	for _, st := range t.subtrees {
+		st.validate(errs)
+	}
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestShortcutConflicts(t *testing.T) {
+	tree := NewTree(TreeDescriptor{Name: "tree"})
+	tree.AddCommand(CommandDescriptor{Name: "quit"})
+	tree.AddCommand(CommandDescriptor{Name: "run"})
+	file := tree.AddSubtree(TreeDescriptor{Name: "file"})
+	file.AddCommand(CommandDescriptor{Name: "open"})
+
+	cases := []struct {
+		tree     *Tree
+		shortcut string
+		target   string
+		global   bool
+		err      string
+		added    bool
+	}{
+		{tree, "x", "quit", false, "<nil>", true},
+		{tree, "q", "quit", false, "<nil>", true},
+		{tree, "runner", "quit", false, "<nil>", true},
+		{tree, "run", "quit", false, "Duplicate name 'run'", false},
+		{tree, "x", "run", false, "Duplicate name 'x'", false},
+		{file, "o", "open", false, "<nil>", true},
+		{file, "q", "open", false, "<nil>", true},
+		{tree, "fi", "file open", true, "<nil>", true},
+		{tree, "x", "file open", true, "Duplicate name 'x'", false},
+		{tree, "x now", "file open", false, "<nil>", true},
+	}
+
+	for i, c := range cases {
+		add := c.tree.AddShortcut
+		if c.global {
+			add = c.tree.AddGlobalShortcut
+		}
+		err := add(c.shortcut, c.target)
+		if fmt.Sprint(err) != c.err {
+			t.Errorf("Case %d: expected error '%s', got '%v'", i, c.err, err)
+		}
+		if err != nil && !errors.Is(err, ErrConflict) {
+			t.Errorf("Case %d: error doesn't wrap ErrConflict", i)
+		}
+
+		added := false
+		for _, sc := range c.tree.shortcuts {
+			target, _, _ := c.tree.LookupCommand(c.target)
+			if sc.Name == c.shortcut && sc.Command == target {
+				added = true
+			}
+		}
+		if added != c.added {
+			t.Errorf("Case %d: expected added=%v", i, c.added)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tree := NewTree(TreeDescriptor{Name: "tree"})
+	tree.AddCommand(CommandDescriptor{Name: "run"})
+	tree.AddCommand(CommandDescriptor{Name: "runtime"})
+	tree.AddCommand(CommandDescriptor{Name: "quit"})
+	file := tree.AddSubtree(TreeDescriptor{Name: "file"})
+	file.AddCommand(CommandDescriptor{Name: "open"})
+	file.AddCommand(CommandDescriptor{Name: "close"})
+	file.AddShortcut("c", "close")
+	tree.AddGlobalShortcut("qui", "quit")
+
+	if err := NewTree(TreeDescriptor{Name: "empty"}).Validate(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	err := file.Validate()
+	expected := "'run' conflicts with 'runtime'\n" +
+		"'file c' conflicts with 'file close'\n" +
+		"'qui' conflicts with 'quit'"
+	if fmt.Sprint(err) != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%v", expected, err)
+	}
+
+	var ce *ConflictError
+	if !errors.As(err, &ce) || ce.Tree != tree || ce.Name != "run" {
+		t.Errorf("Unexpected first conflict %+v", ce)
+	}
+}
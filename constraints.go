@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A ConstraintKind identifies the rule enforced by a Constraint.
+type ConstraintKind int
+
+// Constraint kinds.
+const (
+	ExactlyOne  ConstraintKind = iota // exactly one of the names must be given
+	AtMostOne                         // no more than one of the names may be given
+	AtLeastOne                        // one or more of the names must be given
+	Requirement                       // the first name requires all the others
+)
+
+// A Constraint restricts which combinations of a command's arguments and
+// flags may be given together. Constraints are checked by ParseArgs after
+// all arguments and flags have been parsed.
+type Constraint struct {
+	Kind  ConstraintKind
+	Names []string // names of the constrained arguments and flags
+}
+
+// ExactlyOneOf returns a constraint requiring exactly one of the named
+// arguments or flags to be given.
+func ExactlyOneOf(names ...string) Constraint {
+	return Constraint{Kind: ExactlyOne, Names: names}
+}
+
+// AtMostOneOf returns a constraint allowing no more than one of the named
+// arguments or flags to be given.
+func AtMostOneOf(names ...string) Constraint {
+	return Constraint{Kind: AtMostOne, Names: names}
+}
+
+// AtLeastOneOf returns a constraint requiring one or more of the named
+// arguments or flags to be given.
+func AtLeastOneOf(names ...string) Constraint {
+	return Constraint{Kind: AtLeastOne, Names: names}
+}
+
+// Requires returns a constraint requiring that, when the named argument or
+// flag is given, all of the required arguments or flags are also given.
+func Requires(name string, required ...string) Constraint {
+	return Constraint{Kind: Requirement, Names: append([]string{name}, required...)}
+}
+
+// A ConstraintError describes a violated constraint.
+type ConstraintError struct {
+	Constraint Constraint
+	Missing    string // for requirements, the missing argument or flag
+}
+
+func (e *ConstraintError) Error() string {
+	list := quoteNames(e.Constraint.Names)
+	switch e.Constraint.Kind {
+	case ExactlyOne:
+		return fmt.Sprintf("Exactly one of %s is required", list)
+	case AtMostOne:
+		return fmt.Sprintf("Only one of %s may be given", list)
+	case AtLeastOne:
+		return fmt.Sprintf("At least one of %s is required", list)
+	}
+	return fmt.Sprintf("'%s' requires '%s'", e.Constraint.Names[0], e.Missing)
+}
+
+func quoteNames(names []string) string {
+	quoted := make([]string, len(names))
+	for i, n := range names {
+		quoted[i] = "'" + n + "'"
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// check returns an error if the parsed values violate the constraint.
+func (con Constraint) check(values map[string]any) error {
+	count := 0
+	for _, n := range con.Names {
+		if _, ok := values[n]; ok {
+			count++
+		}
+	}
+
+	switch con.Kind {
+	case ExactlyOne:
+		if count != 1 {
+			return &ConstraintError{Constraint: con}
+		}
+	case AtMostOne:
+		if count > 1 {
+			return &ConstraintError{Constraint: con}
+		}
+	case AtLeastOne:
+		if count == 0 {
+			return &ConstraintError{Constraint: con}
+		}
+	case Requirement:
+		if len(con.Names) == 0 {
+			return nil
+		}
+		if _, ok := values[con.Names[0]]; !ok {
+			return nil
+		}
+		for _, n := range con.Names[1:] {
+			if _, ok := values[n]; !ok {
+				return &ConstraintError{Constraint: con, Missing: n}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestConstraints(t *testing.T) {
+	tree := NewTree(TreeDescriptor{Name: "tree"})
+	connect := tree.AddCommand(CommandDescriptor{
+		Name: "connect",
+		Args: []Arg{{Name: "host", Optional: true}},
+		Flags: []Flag{
+			{Name: "serial", Type: PathType{}},
+			{Name: "user"},
+			{Name: "password"},
+			{Name: "timeout", Type: DurationType{}},
+			{Name: "tls"},
+			{Name: "insecure"},
+		},
+		Constraints: []Constraint{
+			ExactlyOneOf("host", "serial"),
+			AtMostOneOf("tls", "insecure"),
+			Requires("password", "user"),
+		},
+	})
+
+	cases := []struct {
+		args   string
+		values string
+		err    string
+	}{
+		{"example.com", "map[host:example.com]", ""},
+		{"--serial /dev/ttyUSB0", "map[serial:/dev/ttyUSB0]", ""},
+		{"--serial=/dev/ttyUSB0 --timeout 5s", "map[serial:/dev/ttyUSB0 timeout:5s]", ""},
+		{"--user --password h", "map[host:h password:true user:true]", ""},
+		{"h --tls", "map[host:h tls:true]", ""},
+		{"h --tls=false", "map[host:h tls:false]", ""},
+		{"-- --tls", "map[host:--tls]", ""},
+		{"", "", "Exactly one of 'host', 'serial' is required"},
+		{"h --serial x", "", "Exactly one of 'host', 'serial' is required"},
+		{"h --tls --insecure", "", "Only one of 'tls', 'insecure' may be given"},
+		{"h --password", "", "'password' requires 'user'"},
+		{"h --bogus", "", "Unknown flag '--bogus'"},
+		{"h --timeout", "", "Missing argument '--timeout'"},
+		{"h --timeout=x", "", "Invalid argument '--timeout': invalid duration 'x'"},
+		{"h --tls=maybe", "", "Invalid argument '--tls': invalid boolean 'maybe'"},
+	}
+
+	for i, c := range cases {
+		values, err := connect.ParseArgs(strings.Fields(c.args))
+		switch {
+		case c.err != "" && (err == nil || err.Error() != c.err):
+			t.Errorf("Case %d: expected error '%s', got '%v'", i, c.err, err)
+		case c.err == "" && err != nil:
+			t.Errorf("Case %d: unexpected error '%v'", i, err)
+		case c.err == "" && fmt.Sprint(values) != c.values:
+			t.Errorf("Case %d: expected %s, got %v", i, c.values, values)
+		}
+	}
+
+	_, err := connect.ParseArgs([]string{"h", "--password"})
+	var ce *ConstraintError
+	if !errors.As(err, &ce) || ce.Constraint.Kind != Requirement || ce.Missing != "user" {
+		t.Errorf("unexpected error: %#v", err)
+	}
+
+	want := "connect [--serial <value>] [--user] [--password] [--timeout <value>] [--tls] [--insecure] [host]"
+	if u := connect.usage(); u != want {
+		t.Errorf("unexpected usage.\nEXPECTED: %s\nGOT: %s", want, u)
+	}
+}
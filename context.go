@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// An ExecContext holds the state associated with a single command execution.
+// It is passed to the command's handler.
+type ExecContext struct {
+	In      io.Reader // piped input, or nil if the command isn't piped into; see Input
+	Out     io.Writer // destination for command output
+	Err     io.Writer // destination for error and diagnostic output
+	Command *Command  // the command being executed
+	Runner  *Runner   // the runner executing the command
+	Session any       // user-defined session data
+
+	// Parsed argument values keyed by argument name, if the command has an
+	// argument specification.
+	Values map[string]any
+
+	// The command line being executed, as entered by the user.
+	Line string
+
+	// If DryRun is true, the handler should report what the command would
+	// do without doing it. It is only set for commands honoring dry runs.
+	DryRun bool
+
+	invoked  string
+	ctx      context.Context
+	progress *progress
+	undo     []func() error
+	input    *bufio.Reader
+}
+
+// Context returns the context governing the command's execution. It is
+// cancelled when the command's timeout expires. Long-running handlers should
+// watch the context and return promptly once it is done.
+func (ctx *ExecContext) Context() context.Context {
+	if ctx.ctx == nil {
+		return context.Background()
+	}
+	return ctx.ctx
+}
+
+// InvokedAs returns the portion of the command line that selected the
+// command, as the user typed it. It may hold abbreviations, a shortcut or
+// a mistyped command name. Use Command.CanonicalLine to obtain the
+// command's full name.
+func (ctx *ExecContext) InvokedAs() string {
+	return ctx.invoked
+}
+
+// invokedAs returns the leading fields of a command line, excluding the
+// trailing nargs argument fields.
+func invokedAs(line string, nargs int) string {
+	var fields []string
+	for field, remain := nextField(stripLeadingWhitespace(line)); field != "" || remain != ""; {
+		fields = append(fields, quoteField(field))
+		field, remain = nextField(remain)
+	}
+	if nargs > len(fields) {
+		nargs = len(fields)
+	}
+	return strings.Join(fields[:len(fields)-nargs], " ")
+}
+
+// Printf formats according to a format specifier and writes to the
+// context's output writer.
+func (ctx *ExecContext) Printf(format string, a ...any) {
+	fmt.Fprintf(ctx.Out, format, a...)
+}
+
+// Println formats its operands using their default formats and writes them
+// to the context's output writer, followed by a newline.
+func (ctx *ExecContext) Println(a ...any) {
+	fmt.Fprintln(ctx.Out, a...)
+}
+
+// Errorf formats according to a format specifier and writes to the
+// context's error writer.
+func (ctx *ExecContext) Errorf(format string, a ...any) {
+	fmt.Fprintf(ctx.Err, format, a...)
+}
+
+// WriteTable writes the formatted table to the context's output writer. If
+// the table's width is zero, it is wrapped at the help width of the tree
+// containing the command.
+func (ctx *ExecContext) WriteTable(t *Table) error {
+	if t.Width <= 0 && ctx.Command != nil {
+		tc := *t
+		tc.Width = ctx.Command.parent.HelpWidth()
+		t = &tc
+	}
+	return t.Write(ctx.Out)
+}
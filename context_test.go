@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestExecContext(t *testing.T) {
+	type session struct{ name string }
+
+	var got *ExecContext
+	tree := NewTree(TreeDescriptor{Name: "tree"})
+	tree.AddCommand(CommandDescriptor{
+		Name: "whoami",
+		Handler: func(ctx *ExecContext, args []string) error {
+			got = ctx
+			ctx.Printf("%s\n", ctx.Session.(*session).name)
+			ctx.Errorf("warning: %s\n", ctx.Command.Name)
+			return nil
+		},
+	})
+
+	out, errOut := new(bytes.Buffer), new(bytes.Buffer)
+	r := NewRunner(tree, strings.NewReader(""), out)
+	r.Err = errOut
+	r.Session = &session{"alice"}
+	if err := r.Execute("who"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	switch {
+	case got == nil:
+		t.Fatalf("handler not called")
+	case got.Runner != r:
+		t.Errorf("context has unexpected runner")
+	case got.Command != tree.Commands()[0]:
+		t.Errorf("context has unexpected command")
+	case out.String() != "alice\n":
+		t.Errorf("unexpected output: %q", out.String())
+	case errOut.String() != "warning: whoami\n":
+		t.Errorf("unexpected error output: %q", errOut.String())
+	}
+}
+
+func TestInvokedAs(t *testing.T) {
+	var got *ExecContext
+	tree := NewTree(TreeDescriptor{Name: "tree"})
+	file := tree.AddSubtree(TreeDescriptor{Name: "file"})
+	file.AddCommand(CommandDescriptor{
+		Name: "open",
+		Handler: func(ctx *ExecContext, args []string) error {
+			got = ctx
+			return nil
+		},
+	})
+	tree.AddShortcut("o", "file open")
+
+	cases := []struct {
+		line    string
+		invoked string
+	}{
+		{"file open a.txt", "file open"},
+		{"  f o a.txt b.txt", "f o"},
+		{"o \"my file.txt\"", "o"},
+		{"\"file\" op", "file op"},
+	}
+
+	r := NewRunner(tree, strings.NewReader(""), new(bytes.Buffer))
+	for i, c := range cases {
+		got = nil
+		if err := r.Execute(c.line); err != nil || got == nil {
+			t.Errorf("Case %d: execution failed: %v", i, err)
+			continue
+		}
+		if s := got.InvokedAs(); s != c.invoked {
+			t.Errorf("Case %d: expected '%s', got '%s'", i, c.invoked, s)
+		}
+		if s := got.Command.CanonicalLine(); s != "file open" {
+			t.Errorf("Case %d: unexpected canonical line '%s'", i, s)
+		}
+		if got.Line != c.line {
+			t.Errorf("Case %d: unexpected line '%s'", i, got.Line)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/beevik/prefixtree/v2"
+)
+
+// Suggest returns the full paths of commands and subtrees whose names closely
+// resemble the first token in the line that doesn't match any command or
+// subtree. Closeness is measured by edit distance, with transposed characters
+// counting as a single edit. Suggest returns nil if every token in the line
+// matches a command or subtree, or if the first unmatched token is merely
+// ambiguous.
+func (t *Tree) Suggest(line string) []string {
+	var suggestions []string
+	for _, s := range t.suggest(line) {
+		suggestions = append(suggestions, nodePath(s.node))
+	}
+	return suggestions
+}
+
+// Correct attempts to correct a mistyped command line. If every unmatched
+// token in the line has exactly one close match, Correct returns the node
+// obtained by substituting the matches, along with the remaining unmatched
+// line arguments.
+func (t *Tree) Correct(line string) (n Node, args []string, ok bool) {
+	for {
+		n, args, err := t.Lookup(line)
+		switch err {
+		case nil:
+			return n, args, true
+		case ErrAmbiguous:
+			return nil, nil, false
+		}
+
+		s := t.suggest(line)
+		if len(s) != 1 {
+			return nil, nil, false
+		}
+		line = s[0].line
+	}
+}
+
+// A suggestion is a node whose name closely resembles an unmatched line
+// token, along with the line obtained by substituting the node's name for
+// the token.
+type suggestion struct {
+	node Node
+	line string
+}
+
+func (t *Tree) suggest(line string) []suggestion {
+	prefix := ""
+	tree := t
+	field, remain := nextField(stripLeadingWhitespace(line))
+	for field != "" {
+		v, err := tree.pt.FindValue(field)
+		if err == prefixtree.ErrPrefixAmbiguous {
+			// Lookup resolves ambiguous prefixes by priority.
+			kv, perr := tree.resolvePriority(field, false)
+			if perr != nil {
+				return nil
+			}
+			v, err = kv.Value, nil
+		}
+		if err == nil {
+			st, ok := v.(*Tree)
+			if !ok {
+				return nil
+			}
+			prefix += quoteField(field) + " "
+			tree = st
+			field, remain = nextField(remain)
+			continue
+		}
+
+		// The field matches nothing, so look for close matches among the
+		// tree's keys.
+		var suggestions []suggestion
+		best := maxEditDistance(field) + 1
+		for _, kv := range tree.pt.FindKeyValues("") {
+			if isHidden(kv.Value) {
+				continue
+			}
+			d := editDistance(field, kv.Key)
+			switch {
+			case d > best:
+				continue
+			case d < best:
+				best, suggestions = d, suggestions[:0]
+			}
+			l := prefix + quoteField(kv.Key)
+			if remain != "" {
+				l += " " + remain
+			}
+			suggestions = append(suggestions, suggestion{kv.Value, l})
+		}
+		if best > maxEditDistance(field) {
+			return nil
+		}
+		sort.Slice(suggestions, func(i, j int) bool {
+			return suggestions[i].line < suggestions[j].line
+		})
+
+		// Shortcuts may cause the same node to be suggested more than once.
+		unique := suggestions[:0]
+		seen := make(map[Node]bool)
+		for _, s := range suggestions {
+			if !seen[s.node] {
+				seen[s.node] = true
+				unique = append(unique, s)
+			}
+		}
+		return unique
+	}
+	return nil
+}
+
+// maxEditDistance returns the largest edit distance at which a name is
+// still considered a close match for the token.
+func maxEditDistance(token string) int {
+	return 1 + len([]rune(token))/5
+}
+
+// editDistance returns the optimal string alignment distance between a and
+// b: the number of rune insertions, deletions, substitutions and adjacent
+// transpositions required to transform a into b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				curr[j] = min(curr[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+	return prev[len(rb)]
+}
+
+// quoteField surrounds the field with quotes if it contains whitespace.
+func quoteField(field string) string {
+	if strings.ContainsFunc(field, unicode.IsSpace) || field == "" {
+		return `"` + field + `"`
+	}
+	return field
+}
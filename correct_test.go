@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestEditDistance(t *testing.T) {
+	cases := []struct {
+		a, b string
+		d    int
+	}{
+		{"", "", 0},
+		{"open", "open", 0},
+		{"opne", "open", 1},
+		{"oen", "open", 1},
+		{"opeen", "open", 1},
+		{"fiel", "file", 1},
+		{"close", "open", 4},
+		{"開け", "開く", 1},
+	}
+
+	for i, c := range cases {
+		if d := editDistance(c.a, c.b); d != c.d {
+			t.Errorf("Case %d: editDistance(%q, %q) = %d, wanted %d", i, c.a, c.b, d, c.d)
+		}
+	}
+}
+
+func TestSuggestAndCorrect(t *testing.T) {
+	tree := buildTree()
+
+	cases := []struct {
+		line    string
+		suggest []string
+		correct string
+		args    []string
+	}{
+		{"file open", nil, "file open", []string{}},
+		{"fiel open", []string{"file"}, "file open", []string{}},
+		{"file opne x", []string{"file open"}, "file open", []string{"x"}},
+		{"fiel opne x", []string{"file"}, "file open", []string{"x"}},
+		{"file reda", []string{"file read"}, "file read", []string{}},
+		{"file r", nil, "", nil},
+		{"file xyzzy", nil, "", nil},
+		{"qit", []string{"quit"}, "quit", []string{}},
+		{"file rn", []string{"file run"}, "file run", []string{}},
+	}
+
+	for i, c := range cases {
+		suggest := tree.Suggest(c.line)
+		if strings.Join(suggest, ",") != strings.Join(c.suggest, ",") {
+			t.Errorf("Case %d: Suggest(%q) = %q, wanted %q", i, c.line, suggest, c.suggest)
+		}
+
+		n, args, ok := tree.Correct(c.line)
+		switch {
+		case ok != (c.correct != ""):
+			t.Errorf("Case %d: Correct(%q) returned ok=%v", i, c.line, ok)
+		case ok && nodePath(n) != c.correct:
+			t.Errorf("Case %d: Correct(%q) = %q, wanted %q", i, c.line, nodePath(n), c.correct)
+		case ok && strings.Join(args, ",") != strings.Join(c.args, ","):
+			t.Errorf("Case %d: Correct(%q) args = %q, wanted %q", i, c.line, args, c.args)
+		}
+	}
+
+	tree = NewTree(TreeDescriptor{Name: "tree"})
+	tree.AddCommand(CommandDescriptor{Name: "step"})
+	tree.AddCommand(CommandDescriptor{Name: "stop"})
+	if s := tree.Suggest("stap"); strings.Join(s, ",") != "step,stop" {
+		t.Errorf("unexpected suggestions: %q", s)
+	}
+	if _, _, ok := tree.Correct("stap"); ok {
+		t.Errorf("ambiguous correction unexpectedly applied")
+	}
+
+	// Prefixes resolved by priority are followed as Lookup follows them.
+	tree = NewTree(TreeDescriptor{Name: "tree"})
+	file := tree.AddSubtree(TreeDescriptor{Name: "file", Priority: 1})
+	file.AddCommand(CommandDescriptor{Name: "open"})
+	tree.AddCommand(CommandDescriptor{Name: "find"})
+	if s := tree.Suggest("fi opne"); strings.Join(s, ",") != "file open" {
+		t.Errorf("unexpected suggestions: %q", s)
+	}
+	if n, _, ok := tree.Correct("fi opne"); !ok || nodePath(n) != "file open" {
+		t.Errorf("prioritized prefix not corrected")
+	}
+}
+
+func TestRunnerAutocorrect(t *testing.T) {
+	cases := []struct {
+		mode   Autocorrect
+		input  string
+		output string
+	}{
+		{AutocorrectOff, "ehco hi\n", "> Command not found.\n> "},
+		{AutocorrectApply, "ehco hi\n", "> Assuming 'echo'.\nhi\n> "},
+		{AutocorrectApply, "fial\n", "> Assuming 'fail'.\nFailed.\n> "},
+		{AutocorrectConfirm, "ehco hi\ny\n", "> Did you mean 'echo'? [y/N] hi\n> "},
+		{AutocorrectConfirm, "ehco hi\nn\n", "> Did you mean 'echo'? [y/N] Command not found.\n> "},
+		{AutocorrectConfirm, "zzzz\n", "> Command not found.\n> "},
+	}
+
+	for i, c := range cases {
+		out := new(bytes.Buffer)
+		r := NewRunner(buildRunnerTree(), strings.NewReader(c.input), out)
+		r.Autocorrect = c.mode
+		if err := r.Run(); err != nil {
+			t.Errorf("Case %d: unexpected error: %v\n", i, err)
+		}
+		if out.String() != c.output {
+			t.Errorf("Case %d: output mismatch.\nEXPECTED:\n%q\nGOT:\n%q\n", i, c.output, out.String())
+		}
+	}
+}
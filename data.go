@@ -0,0 +1,32 @@
+package cmd
+
+// SetData stores a keyed value in the tree's options, where it may be
+// resolved by the tree and all of its descendants.
+func (t *Tree) SetData(key string, value any) {
+	if t.Options == nil {
+		t.Options = make(map[string]any)
+	}
+	t.Options[key] = value
+}
+
+// ResolveData returns the value stored under key in the tree's options. If
+// the tree has no such value, its ancestors are searched, nearest first. The
+// returned bool is false if no tree holds the key.
+func (t *Tree) ResolveData(key string) (any, bool) {
+	for ; t != nil; t = t.parent {
+		if v, ok := t.Options[key]; ok {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// ResolveData returns the value stored under key in the command's options.
+// If the command has no such value, the trees containing it are searched,
+// nearest first. The returned bool is false if no node holds the key.
+func (c *Command) ResolveData(key string) (any, bool) {
+	if v, ok := c.Options[key]; ok {
+		return v, true
+	}
+	return c.parent.ResolveData(key)
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestResolveData(t *testing.T) {
+	root := NewTree(TreeDescriptor{
+		Name:    "root",
+		Options: map[string]any{"radix": 10, "owner": "root"},
+	})
+	mem := root.AddSubtree(TreeDescriptor{Name: "mem"})
+	mem.SetData("radix", 16)
+	dump := mem.AddCommand(CommandDescriptor{Name: "dump"})
+	poke := mem.AddCommand(CommandDescriptor{
+		Name:    "poke",
+		Options: map[string]any{"radix": 2},
+	})
+	quit := root.AddCommand(CommandDescriptor{Name: "quit"})
+
+	cases := []struct {
+		resolve func(key string) (any, bool)
+		key     string
+		result  string
+	}{
+		{dump.ResolveData, "radix", "16 true"},
+		{dump.ResolveData, "owner", "root true"},
+		{dump.ResolveData, "missing", "<nil> false"},
+		{poke.ResolveData, "radix", "2 true"},
+		{quit.ResolveData, "radix", "10 true"},
+		{mem.ResolveData, "radix", "16 true"},
+		{root.ResolveData, "radix", "10 true"},
+	}
+
+	for i, c := range cases {
+		v, ok := c.resolve(c.key)
+		if result := fmt.Sprintf("%v %v", v, ok); result != c.result {
+			t.Errorf("Case %d: expected '%s', got '%s'", i, c.result, result)
+		}
+	}
+}
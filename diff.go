@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// A DiffKind identifies the kind of change described by a Difference.
+type DiffKind int
+
+// Kinds of differences between trees.
+const (
+	DiffAdded   DiffKind = iota // node found only in the new tree
+	DiffRemoved                 // node found only in the old tree
+	DiffRenamed                 // node renamed without other changes
+	DiffChanged                 // node whose recorded fields changed
+)
+
+func (k DiffKind) String() string {
+	switch k {
+	case DiffAdded:
+		return "added"
+	case DiffRemoved:
+		return "removed"
+	case DiffRenamed:
+		return "renamed"
+	case DiffChanged:
+		return "changed"
+	}
+	return fmt.Sprintf("DiffKind(%d)", int(k))
+}
+
+// A Difference describes a change to a command or subtree between an old
+// and a new tree.
+type Difference struct {
+	Kind    DiffKind
+	Path    string // path of the node, in the new tree unless removed
+	OldPath string // path of a renamed node in the old tree
+	Tree    bool   // the node is a subtree
+	Version string // the node's Since tag if added, or its Changed tag if changed
+
+	// Names of the changed fields: "kind", "brief", "description", "usage",
+	// "aliases", "args", "flags" or "shortcuts".
+	Fields []string
+}
+
+func (d Difference) String() string {
+	kind := "command"
+	if d.Tree {
+		kind = "subtree"
+	}
+	switch d.Kind {
+	case DiffRenamed:
+		return fmt.Sprintf("renamed %s '%s' to '%s'", kind, d.OldPath, d.Path)
+	case DiffChanged:
+		return fmt.Sprintf("changed %s '%s': %s", kind, d.Path, strings.Join(d.Fields, ", "))
+	}
+	return fmt.Sprintf("%v %s '%s'", d.Kind, kind, d.Path)
+}
+
+// Diff returns the differences between the commands and subtrees of two
+// trees, as recorded by their snapshots. See DiffSnapshots.
+func Diff(a, b *Tree) []Difference {
+	return DiffSnapshots(NewSnapshot(a), NewSnapshot(b))
+}
+
+// DiffSnapshots returns the differences between the commands and subtrees
+// of two tree snapshots, ordered by kind and path. The contents of an added
+// or removed subtree aren't reported separately. A node removed and added
+// under the same parent with a different name but otherwise identical
+// contents is reported as renamed.
+func DiffSnapshots(old, new *Snapshot) []Difference {
+	before, after := old.nodes(), new.nodes()
+
+	// only returns true if the node at path is found in a but not b, and
+	// its parent is found in both.
+	only := func(a, b map[string]snapshotNode, path string) bool {
+		return !hasKey(b, path) && (!hasKey(a, parentPath(path)) || hasKey(b, parentPath(path)))
+	}
+
+	var diffs, added []Difference
+	for _, path := range sortedKeys(after) {
+		n := after[path]
+		switch o, ok := before[path]; {
+		case !ok:
+			if only(after, before, path) {
+				added = append(added, Difference{Kind: DiffAdded, Path: path, Tree: n.tree, Version: n.Since})
+			}
+		default:
+			if fields := changedFields(o, n); len(fields) > 0 {
+				diffs = append(diffs, Difference{Kind: DiffChanged, Path: path, Tree: n.tree, Fields: fields, Version: n.Changed})
+			}
+		}
+	}
+
+	for _, path := range sortedKeys(before) {
+		if !only(before, after, path) {
+			continue
+		}
+		o := before[path]
+		i := slices.IndexFunc(added, func(d Difference) bool {
+			return parentPath(d.Path) == parentPath(path) && sameContents(o, after[d.Path])
+		})
+		if i < 0 {
+			diffs = append(diffs, Difference{Kind: DiffRemoved, Path: path, Tree: o.tree})
+			continue
+		}
+		diffs = append(diffs, Difference{Kind: DiffRenamed, Path: added[i].Path, OldPath: path, Tree: o.tree})
+		added = slices.Delete(added, i, i+1)
+	}
+
+	diffs = append(diffs, added...)
+	slices.SortStableFunc(diffs, func(a, b Difference) int {
+		if a.Kind != b.Kind {
+			return int(a.Kind) - int(b.Kind)
+		}
+		return strings.Compare(a.Path, b.Path)
+	})
+	return diffs
+}
+
+// A snapshotNode is a command or subtree recorded in a snapshot.
+type snapshotNode struct {
+	*NodeSnapshot
+	tree bool
+}
+
+// nodes returns the snapshot's commands and subtrees, other than the root,
+// keyed by path.
+func (s *Snapshot) nodes() map[string]snapshotNode {
+	nodes := make(map[string]snapshotNode)
+	var walk func(n *NodeSnapshot, prefix string)
+	walk = func(n *NodeSnapshot, prefix string) {
+		for _, c := range n.Commands {
+			nodes[prefix+c.Name] = snapshotNode{c, false}
+		}
+		for _, st := range n.Subtrees {
+			nodes[prefix+st.Name] = snapshotNode{st, true}
+			walk(st, prefix+st.Name+" ")
+		}
+	}
+	if s != nil && s.Root != nil {
+		walk(s.Root, "")
+	}
+	return nodes
+}
+
+// changedFields returns the names of the recorded fields that differ
+// between two snapshots of a node.
+func changedFields(a, b snapshotNode) []string {
+	var fields []string
+	if a.tree != b.tree {
+		fields = append(fields, "kind")
+	}
+	if a.Brief != b.Brief {
+		fields = append(fields, "brief")
+	}
+	if a.Description != b.Description {
+		fields = append(fields, "description")
+	}
+	if a.Usage != b.Usage {
+		fields = append(fields, "usage")
+	}
+	if !slices.Equal(a.Aliases, b.Aliases) {
+		fields = append(fields, "aliases")
+	}
+	if !reflect.DeepEqual(a.Args, b.Args) || a.MinArgs != b.MinArgs || a.MaxArgs != b.MaxArgs {
+		fields = append(fields, "args")
+	}
+	if !reflect.DeepEqual(a.Flags, b.Flags) {
+		fields = append(fields, "flags")
+	}
+	if !slices.Equal(a.Shortcuts, b.Shortcuts) {
+		fields = append(fields, "shortcuts")
+	}
+	return fields
+}
+
+// sameContents returns true if two snapshots of nodes are equal, other than
+// by name.
+func sameContents(a, b snapshotNode) bool {
+	x, y := *a.NodeSnapshot, *b.NodeSnapshot
+	x.Name, y.Name = "", ""
+	return a.tree == b.tree && reflect.DeepEqual(x, y)
+}
+
+// parentPath returns the path of the parent of the node at path.
+func parentPath(path string) string {
+	if i := strings.LastIndexByte(path, ' '); i >= 0 {
+		return path[:i]
+	}
+	return ""
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func hasKey[V any](m map[string]V, k string) bool {
+	_, ok := m[k]
+	return ok
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	a := NewTree(TreeDescriptor{Name: "tree"})
+	a.AddCommand(CommandDescriptor{Name: "quit", Brief: "quit"})
+	a.AddCommand(CommandDescriptor{Name: "ls", Brief: "list files"})
+	a.AddCommand(CommandDescriptor{Name: "run", Usage: "run <prog>"})
+	mem := a.AddSubtree(TreeDescriptor{Name: "mem"})
+	mem.AddCommand(CommandDescriptor{Name: "dump"})
+	a.AddShortcut("q", "quit")
+
+	b := NewTree(TreeDescriptor{Name: "tree"})
+	b.AddCommand(CommandDescriptor{Name: "quit", Brief: "exit the program", Changed: "2.0"})
+	b.AddCommand(CommandDescriptor{Name: "list", Brief: "list files"})
+	b.AddCommand(CommandDescriptor{Name: "run", Usage: "run <prog> [args]"})
+	b.AddCommand(CommandDescriptor{Name: "step", Since: "2.0"})
+	memory := b.AddSubtree(TreeDescriptor{Name: "memory"})
+	memory.AddCommand(CommandDescriptor{Name: "dump"})
+	b.AddShortcut("x", "quit")
+
+	want := []Difference{
+		{Kind: DiffAdded, Path: "step", Version: "2.0"},
+		{Kind: DiffRenamed, Path: "list", OldPath: "ls"},
+		{Kind: DiffRenamed, Path: "memory", OldPath: "mem", Tree: true},
+		{Kind: DiffChanged, Path: "quit", Fields: []string{"brief", "shortcuts"}, Version: "2.0"},
+		{Kind: DiffChanged, Path: "run", Fields: []string{"usage"}},
+	}
+	got := Diff(a, b)
+	if len(got) != len(want) {
+		t.Fatalf("Diff returned %v, wanted %v", got, want)
+	}
+	for i := range got {
+		g, w := got[i], want[i]
+		if g.Kind != w.Kind || g.Path != w.Path || g.OldPath != w.OldPath || g.Tree != w.Tree ||
+			!slices.Equal(g.Fields, w.Fields) || g.Version != w.Version {
+			t.Errorf("Case %d: got %+v, wanted %+v", i, g, w)
+		}
+	}
+
+	strs := []string{
+		"added command 'step'",
+		"renamed command 'ls' to 'list'",
+		"renamed subtree 'mem' to 'memory'",
+		"changed command 'quit': brief, shortcuts",
+		"changed command 'run': usage",
+	}
+	for i, s := range strs {
+		if got[i].String() != s {
+			t.Errorf("Case %d: String() = %q, wanted %q", i, got[i].String(), s)
+		}
+	}
+
+	if d := Diff(a, a); len(d) != 0 {
+		t.Errorf("unexpected differences of equal trees: %v", d)
+	}
+	if d := Diff(b, a); len(d) != 5 || d[0].Kind != DiffRemoved || d[0].Path != "step" {
+		t.Errorf("unexpected reverse differences: %v", d)
+	}
+}
@@ -0,0 +1,35 @@
+package cmd
+
+import "errors"
+
+// ErrNoDryRun is returned when a runner in dry-run mode is asked to execute
+// a command that doesn't honor dry runs.
+var ErrNoDryRun = errors.New("Command does not support dry run")
+
+// DryRunFlag is the argument which, when given to a command honoring dry
+// runs, causes the command to be executed in dry-run mode. The flag may
+// appear anywhere among the command's arguments preceding a "--" argument.
+// It is passed as an ordinary argument to other commands.
+const DryRunFlag = "--dry-run"
+
+// stripDryRun returns the arguments without any DryRunFlag preceding a
+// "--" argument, and whether the flag was found.
+func stripDryRun(args []string) ([]string, bool) {
+	found := false
+	out := args[:0:0]
+	for i, arg := range args {
+		if arg == "--" {
+			out = append(out, args[i:]...)
+			break
+		}
+		if arg == DryRunFlag {
+			found = true
+			continue
+		}
+		out = append(out, arg)
+	}
+	if !found {
+		return args, false
+	}
+	return out, true
+}
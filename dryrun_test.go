@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func buildDryRunTree() *Tree {
+	tree := NewTree(TreeDescriptor{Name: "tree"})
+	handler := func(ctx *ExecContext, args []string) error {
+		ctx.Printf("%v %q\n", ctx.DryRun, args)
+		return nil
+	}
+	flash := tree.AddSubtree(TreeDescriptor{Name: "flash"})
+	flash.AddCommand(CommandDescriptor{
+		Name:    "erase",
+		Args:    []Arg{{Name: "bank", Type: UintType{BitSize: 8}}},
+		DryRun:  true,
+		Handler: handler,
+	})
+	flash.AddCommand(CommandDescriptor{Name: "write", DryRun: true, Handler: handler})
+	tree.AddCommand(CommandDescriptor{Name: "echo", Handler: handler})
+	return tree
+}
+
+func TestDryRun(t *testing.T) {
+	tree := buildDryRunTree()
+
+	cases := []struct {
+		dryRun bool
+		line   string
+		output string
+		err    error
+	}{
+		{false, "flash erase 1", "false [\"1\"]\n", nil},
+		{false, "flash erase --dry-run 1", "true [\"1\"]\n", nil},
+		{false, "flash erase 1 --dry-run", "true [\"1\"]\n", nil},
+		{false, "flash write a -- --dry-run", "false [\"a\" \"--\" \"--dry-run\"]\n", nil},
+		{false, "echo --dry-run", "false [\"--dry-run\"]\n", nil},
+		{true, "flash write a", "true [\"a\"]\n", nil},
+		{true, "echo a", "", ErrNoDryRun},
+	}
+
+	for i, c := range cases {
+		out := new(bytes.Buffer)
+		r := NewRunner(tree, nil, out)
+		r.DryRun = c.dryRun
+		err := r.Execute(c.line)
+		switch {
+		case !errors.Is(err, c.err):
+			t.Errorf("Case %d: expected error %v, got %v", i, c.err, err)
+		case out.String() != c.output:
+			t.Errorf("Case %d: expected %q, got %q", i, c.output, out.String())
+		}
+	}
+}
+
+func TestDryRunUsage(t *testing.T) {
+	tree := buildDryRunTree()
+
+	cases := []struct {
+		line  string
+		usage string
+	}{
+		{"flash erase", "Usage: flash erase <bank> [--dry-run]\n"},
+		{"flash write", "Usage: flash write [--dry-run]\n"},
+		{"echo", ""},
+	}
+
+	for i, c := range cases {
+		cmd, _, _ := tree.LookupCommand(c.line)
+		buf := new(bytes.Buffer)
+		cmd.DisplayUsage(buf)
+		if buf.String() != c.usage {
+			t.Errorf("Case %d: expected %q, got %q", i, c.usage, buf.String())
+		}
+	}
+}
+
+func TestDryRunRateLimit(t *testing.T) {
+	tree := NewTree(TreeDescriptor{Name: "tree"})
+	tree.AddCommand(CommandDescriptor{
+		Name:      "erase",
+		DryRun:    true,
+		RateLimit: RateLimit{Count: 1, Per: 1 << 62},
+		Handler:   func(ctx *ExecContext, args []string) error { return nil },
+	})
+
+	r := NewRunner(tree, nil, io.Discard)
+	var errs []string
+	for _, line := range []string{"erase --dry-run", "erase --dry-run", "erase", "erase"} {
+		errs = append(errs, fmt.Sprint(r.Execute(line)))
+	}
+	if got := strings.Join(errs, ","); !strings.HasPrefix(got, "<nil>,<nil>,<nil>,Rate limit exceeded") {
+		t.Errorf("unexpected results: %s", got)
+	}
+}
@@ -0,0 +1,33 @@
+package cmd
+
+// An ErrorFormatter produces the message displayed for an error returned
+// while executing a command line.
+type ErrorFormatter func(err error) string
+
+// SetErrorFormatter sets the function producing the messages displayed by
+// a Runner for errors returned while executing command lines, for the entire
+// command tree containing t. The errors themselves are unchanged, so they
+// may still be tested with errors.Is and errors.As. A nil formatter restores
+// the default messages.
+func (t *Tree) SetErrorFormatter(f ErrorFormatter) {
+	t.root().errFormat = f
+}
+
+// ErrorMessage returns the message displayed for an error. If the command
+// tree has an error formatter, the message is produced by the formatter.
+// Otherwise, the message is the error's text followed by a period. If the
+// tree's catalog provides a translation for the error's text in the
+// selected locale, the translation replaces the error's text.
+func (t *Tree) ErrorMessage(err error) string {
+	r := t.root()
+	if r.errFormat != nil {
+		return r.errFormat(err)
+	}
+	msg := err.Error()
+	if r.catalog != nil && r.locale != "" {
+		if tr, ok := r.catalog.Message(r.locale, msg); ok {
+			msg = tr
+		}
+	}
+	return msg + "."
+}
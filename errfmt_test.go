@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestErrorMessage(t *testing.T) {
+	tree := NewTree(TreeDescriptor{Name: "root"})
+	tree.AddCommand(CommandDescriptor{Name: "open"})
+	tree.AddCommand(CommandDescriptor{Name: "over"})
+	tree.SetCatalog(CatalogFunc(func(locale, key string) (string, bool) {
+		if key == ErrNotFound.Error() {
+			return "Commande introuvable", true
+		}
+		return "", false
+	}))
+
+	run := func(input string) string {
+		var out bytes.Buffer
+		r := NewRunner(tree, strings.NewReader(input), &out)
+		r.Prompt = ""
+		r.Run()
+		return out.String()
+	}
+
+	if got := run("bogus\no\n"); got != "Command not found.\nCommand is ambiguous.\n" {
+		t.Errorf("Unexpected default messages: %q", got)
+	}
+
+	tree.SetLocale("fr")
+	if got := run("bogus\n"); got != "Commande introuvable.\n" {
+		t.Errorf("Unexpected translated message: %q", got)
+	}
+
+	tree.SetErrorFormatter(func(err error) string {
+		switch {
+		case errors.Is(err, ErrNotFound):
+			return "error: unknown command"
+		case errors.Is(err, ErrAmbiguous):
+			return "error: ambiguous command"
+		}
+		return "error: " + err.Error()
+	})
+	if got := run("bogus\no\n"); got != "error: unknown command\nerror: ambiguous command\n" {
+		t.Errorf("Unexpected formatted messages: %q", got)
+	}
+}
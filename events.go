@@ -0,0 +1,122 @@
+package cmd
+
+import "slices"
+
+// An Event describes a change to a command tree. Events are reported to the
+// observers registered by Observe. The concrete type of an Event is one of
+// the event types defined by this package.
+type Event interface {
+	// Tree returns the tree that was changed.
+	Tree() *Tree
+
+	event()
+}
+
+// A CommandAdded event reports a command added by AddCommand.
+type CommandAdded struct {
+	Command *Command
+}
+
+// A CommandRemoved event reports a command removed by RemoveCommand. The
+// command's shortcuts are removed first, and reported by ShortcutRemoved
+// events.
+type CommandRemoved struct {
+	Command *Command
+	Parent  *Tree // tree from which the command was removed
+}
+
+// A CommandReplaced event reports a command whose descriptor was replaced
+// by ReplaceCommand.
+type CommandReplaced struct {
+	Command *Command
+	Old     CommandDescriptor // the command's previous descriptor
+}
+
+// A NodeRenamed event reports a command or subtree renamed by Rename.
+type NodeRenamed struct {
+	Node    Node
+	OldName string
+}
+
+// A ShortcutAdded event reports a shortcut added by AddShortcut or
+// AddGlobalShortcut.
+type ShortcutAdded struct {
+	Shortcut Shortcut
+}
+
+// A ShortcutRemoved event reports a removed shortcut.
+type ShortcutRemoved struct {
+	Shortcut Shortcut
+}
+
+// A SubtreeRemoved event reports a subtree removed by RemoveSubtree. Shortcuts
+// to the subtree's commands are removed first, and reported by
+// ShortcutRemoved events.
+type SubtreeRemoved struct {
+	Subtree *Tree
+	Parent  *Tree // tree from which the subtree was removed
+}
+
+// A SubtreeAdded event reports a subtree added by AddSubtree.
+type SubtreeAdded struct {
+	Subtree *Tree
+}
+
+// A SubtreeMounted event reports a tree mounted by Mount.
+type SubtreeMounted struct {
+	Subtree *Tree
+}
+
+func (e CommandAdded) Tree() *Tree    { return e.Command.parent }
+func (e CommandRemoved) Tree() *Tree  { return e.Parent }
+func (e CommandReplaced) Tree() *Tree { return e.Command.parent }
+func (e NodeRenamed) Tree() *Tree     { return e.Node.Parent() }
+func (e ShortcutAdded) Tree() *Tree   { return e.Shortcut.Tree }
+func (e ShortcutRemoved) Tree() *Tree { return e.Shortcut.Tree }
+func (e SubtreeAdded) Tree() *Tree    { return e.Subtree.parent }
+func (e SubtreeMounted) Tree() *Tree  { return e.Subtree.parent }
+func (e SubtreeRemoved) Tree() *Tree  { return e.Parent }
+
+func (CommandAdded) event()    {}
+func (CommandRemoved) event()  {}
+func (CommandReplaced) event() {}
+func (NodeRenamed) event()     {}
+func (ShortcutAdded) event()   {}
+func (ShortcutRemoved) event() {}
+func (SubtreeAdded) event()    {}
+func (SubtreeMounted) event()  {}
+func (SubtreeRemoved) event()  {}
+
+// An observer is a function registered by Observe.
+type observer struct {
+	f func(e Event)
+}
+
+// Observe registers a function that is called after each change to the
+// command tree containing t, wherever the change occurs, and returns a
+// function that unregisters it. Observers are called synchronously by the
+// method making the change, in the order they were registered, and must
+// not modify the command tree.
+//
+// When the command tree is mounted in another command tree by Mount, its
+// observers are registered with the other command tree.
+func (t *Tree) Observe(f func(e Event)) (stop func()) {
+	r := t.root()
+	o := &observer{f}
+	r.observers = append(r.observers, o)
+	return func() {
+		r := t.root()
+		r.observers = slices.DeleteFunc(r.observers, func(x *observer) bool { return x == o })
+	}
+}
+
+// emit reports an event to the observers of the command tree.
+func (t *Tree) emit(e Event) {
+	r := t.root()
+	if len(r.observers) == 0 {
+		return
+	}
+	for _, o := range slices.Clone(r.observers) {
+		o.f(e)
+	}
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func describeEvent(e Event) string {
+	switch e := e.(type) {
+	case CommandAdded:
+		return "CommandAdded " + nodePath(e.Command)
+	case CommandRemoved:
+		return "CommandRemoved " + e.Command.Name + " from '" + e.Tree().Path() + "'"
+	case CommandReplaced:
+		return "CommandReplaced " + nodePath(e.Command) + " was " + e.Old.Brief
+	case NodeRenamed:
+		return "NodeRenamed " + e.OldName + " to " + nodePath(e.Node)
+	case ShortcutAdded:
+		return "ShortcutAdded " + e.Shortcut.Name
+	case ShortcutRemoved:
+		return "ShortcutRemoved " + e.Shortcut.Name
+	case SubtreeAdded:
+		return "SubtreeAdded " + nodePath(e.Subtree)
+	case SubtreeMounted:
+		return "SubtreeMounted " + nodePath(e.Subtree)
+	}
+	return fmt.Sprintf("%T", e)
+}
+
+func TestObserve(t *testing.T) {
+	tree := NewTree(TreeDescriptor{Name: "app"})
+	var events []string
+	stop := tree.Observe(func(e Event) {
+		events = append(events, describeEvent(e))
+	})
+
+	file := tree.AddSubtree(TreeDescriptor{Name: "file"})
+	file.AddCommand(CommandDescriptor{Name: "open", Brief: "Open"})
+	file.AddShortcut("o", "open")
+	tree.AddGlobalShortcut("fo", "file open")
+	file.ReplaceCommand("open", CommandDescriptor{Brief: "Open a file"})
+	tree.Rename("file open", "load")
+
+	plugin := NewTree(TreeDescriptor{Name: "plugin"})
+	var pluginEvents int
+	plugin.Observe(func(e Event) { pluginEvents++ })
+	plugin.AddCommand(CommandDescriptor{Name: "run"})
+	tree.Mount(plugin)
+	plugin.AddCommand(CommandDescriptor{Name: "stop"})
+
+	tree.RemoveCommand("file load")
+	file.RemoveShortcut("o")
+
+	stop()
+	tree.AddCommand(CommandDescriptor{Name: "quit"})
+
+	expected := []string{
+		"SubtreeAdded file",
+		"CommandAdded file open",
+		"ShortcutAdded o",
+		"ShortcutAdded fo",
+		"CommandReplaced file open was Open",
+		"NodeRenamed open to file load",
+		"SubtreeMounted plugin",
+		"CommandAdded plugin stop",
+		"ShortcutRemoved fo",
+		"ShortcutRemoved o",
+		"CommandRemoved load from 'file'",
+	}
+	if strings.Join(events, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected events:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(events, "\n"))
+	}
+	// Once mounted, the plugin's observer receives the events of the
+	// command tree in which it was mounted.
+	if pluginEvents != 7 {
+		t.Errorf("Expected 7 plugin events, got %d", pluginEvents)
+	}
+}
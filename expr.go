@@ -0,0 +1,258 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ExprType is an argument type accepting integer expressions, such as
+// "0x8000+idx*2", which are evaluated by EvalUint or, if Signed is true, by
+// EvalInt. Names in an expression refer to the session variables of the
+// runner executing the command. Parsed values have type uint64, or int64 if
+// Signed is true.
+type ExprType struct {
+	BitSize int  // maximum bit size of the value (zero means 64)
+	Signed  bool // evaluate a signed value
+}
+
+// Parse parses an expression argument that doesn't refer to variables.
+func (t ExprType) Parse(s string) (any, error) {
+	return t.ParseVars(s, nil)
+}
+
+// ParseVars parses an expression argument, looking up the variables it
+// refers to.
+func (t ExprType) ParseVars(s string, vars func(name string) (string, bool)) (any, error) {
+	if t.Signed {
+		return EvalInt(s, t.BitSize, vars)
+	}
+	return EvalUint(s, t.BitSize, vars)
+}
+
+// EvalUint evaluates an integer expression whose value must fit into the
+// given bit size, where zero means 64, as an unsigned integer. An expression
+// combines numbers, in the formats supported by ParseUint, and variable
+// names with the following operators, listed by decreasing precedence:
+//
+//	unary -, +, ~
+//	*, /, %
+//	+, -
+//	<<, >>
+//	&
+//	^
+//	|
+//
+// Parentheses group subexpressions. Expressions are evaluated using 64-bit
+// two's complement arithmetic. The value of a variable is obtained from
+// vars, which may be nil, and is parsed by ParseUint, or by ParseInt if it
+// begins with '-'.
+func EvalUint(s string, bitSize int, vars func(name string) (string, bool)) (uint64, error) {
+	v, err := evalExpr(s, false, vars)
+	if err != nil {
+		return 0, err
+	}
+	if bitSize <= 0 {
+		bitSize = 64
+	}
+	if u := uint64(v); bitSize < 64 && u>>bitSize != 0 {
+		return 0, fmt.Errorf("value '%s' out of range", s)
+	}
+	return uint64(v), nil
+}
+
+// EvalInt evaluates an integer expression whose value must fit into the
+// given bit size, where zero means 64, as a signed integer. It accepts the
+// same expressions as EvalUint, but divides and shifts right as for signed
+// integers.
+func EvalInt(s string, bitSize int, vars func(name string) (string, bool)) (int64, error) {
+	v, err := evalExpr(s, true, vars)
+	if err != nil {
+		return 0, err
+	}
+	if bitSize <= 0 {
+		bitSize = 64
+	}
+	if bitSize < 64 && (v < -1<<(bitSize-1) || v >= 1<<(bitSize-1)) {
+		return 0, fmt.Errorf("value '%s' out of range", s)
+	}
+	return v, nil
+}
+
+// Errors returned when evaluating an expression.
+var (
+	errEndOfExpr    = errors.New("unexpected end of expression")
+	errDivByZero    = errors.New("division by zero")
+	errMissingParen = errors.New("missing ')'")
+)
+
+// exprLevels lists the binary operators by increasing precedence.
+var exprLevels = [][]string{{"|"}, {"^"}, {"&"}, {"<<", ">>"}, {"+", "-"}, {"*", "/", "%"}}
+
+// An exprParser evaluates an expression by recursive descent.
+type exprParser struct {
+	toks   []string
+	pos    int
+	signed bool
+	vars   func(name string) (string, bool)
+}
+
+func evalExpr(s string, signed bool, vars func(name string) (string, bool)) (int64, error) {
+	p := &exprParser{toks: tokenizeExpr(s), signed: signed, vars: vars}
+	v, err := p.binary(0)
+	if err != nil {
+		return 0, err
+	}
+	if p.pos < len(p.toks) {
+		return 0, fmt.Errorf("unexpected '%s' in expression", p.toks[p.pos])
+	}
+	return v, nil
+}
+
+// tokenizeExpr splits an expression into numbers, names and operators.
+func tokenizeExpr(s string) []string {
+	var toks []string
+	for i := 0; i < len(s); {
+		switch c := s[i]; {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '$' || isVarByte(c, false):
+			j := i + 1
+			for j < len(s) && isVarByte(s[j], false) {
+				j++
+			}
+			toks, i = append(toks, s[i:j]), j
+		case (c == '<' || c == '>') && i+1 < len(s) && s[i+1] == c:
+			toks, i = append(toks, s[i:i+2]), i+2
+		default:
+			toks, i = append(toks, s[i:i+1]), i+1
+		}
+	}
+	return toks
+}
+
+func (p *exprParser) peek() string {
+	if p.pos < len(p.toks) {
+		return p.toks[p.pos]
+	}
+	return ""
+}
+
+func (p *exprParser) next() string {
+	tok := p.peek()
+	p.pos++
+	return tok
+}
+
+// binary evaluates the operations of the given precedence level and above.
+func (p *exprParser) binary(level int) (int64, error) {
+	if level == len(exprLevels) {
+		return p.unary()
+	}
+	x, err := p.binary(level + 1)
+	for err == nil && slices.Contains(exprLevels[level], p.peek()) {
+		op := p.next()
+		var y int64
+		if y, err = p.binary(level + 1); err == nil {
+			x, err = p.apply(op, x, y)
+		}
+	}
+	return x, err
+}
+
+func (p *exprParser) unary() (int64, error) {
+	switch tok := p.next(); tok {
+	case "":
+		return 0, errEndOfExpr
+	case "-", "+", "~":
+		v, err := p.unary()
+		switch tok {
+		case "-":
+			v = -v
+		case "~":
+			v = ^v
+		}
+		return v, err
+	case "(":
+		v, err := p.binary(0)
+		if err == nil && p.next() != ")" {
+			err = errMissingParen
+		}
+		return v, err
+	default:
+		return p.operand(tok)
+	}
+}
+
+// operand evaluates a number or variable name.
+func (p *exprParser) operand(tok string) (int64, error) {
+	switch {
+	case tok[0] == '$' || '0' <= tok[0] && tok[0] <= '9':
+		v, err := ParseUint(tok, 64)
+		return int64(v), err
+	case !validVarName(tok):
+		return 0, fmt.Errorf("unexpected '%s' in expression", tok)
+	}
+
+	var value string
+	var ok bool
+	if p.vars != nil {
+		value, ok = p.vars(tok)
+	}
+	if !ok {
+		return 0, fmt.Errorf("undefined variable '%s'", tok)
+	}
+	v, err := parseVarValue(strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("variable '%s': %v", tok, err)
+	}
+	return v, nil
+}
+
+// parseVarValue parses the value of a variable referred to by an
+// expression.
+func parseVarValue(value string) (int64, error) {
+	if strings.HasPrefix(value, "-") {
+		return ParseInt(value, 64)
+	}
+	v, err := ParseUint(value, 64)
+	return int64(v), err
+}
+
+func (p *exprParser) apply(op string, x, y int64) (int64, error) {
+	switch op {
+	case "|":
+		return x | y, nil
+	case "^":
+		return x ^ y, nil
+	case "&":
+		return x & y, nil
+	case "<<":
+		return x << uint64(y), nil
+	case ">>":
+		if p.signed {
+			return x >> uint64(y), nil
+		}
+		return int64(uint64(x) >> uint64(y)), nil
+	case "+":
+		return x + y, nil
+	case "-":
+		return x - y, nil
+	case "*":
+		return x * y, nil
+	}
+
+	if y == 0 {
+		return 0, errDivByZero
+	}
+	switch {
+	case op == "/" && p.signed:
+		return x / y, nil
+	case op == "/":
+		return int64(uint64(x) / uint64(y)), nil
+	case p.signed:
+		return x % y, nil
+	}
+	return int64(uint64(x) % uint64(y)), nil
+}
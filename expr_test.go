@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestEvalUint(t *testing.T) {
+	vars := map[string]string{"idx": "3", "base": "$c000", "neg": "-1", "name": "abc"}
+	lookup := func(name string) (string, bool) {
+		v, ok := vars[name]
+		return v, ok
+	}
+
+	cases := []struct {
+		s       string
+		bitSize int
+		v       uint64
+		err     string
+	}{
+		{"0x8000+idx*2", 16, 0x8006, ""},
+		{"base | 0x12", 16, 0xc012, ""},
+		{"(1 + 2) * 3", 64, 9, ""},
+		{"1 + 2 * 3", 64, 7, ""},
+		{"1 << 4 | 1", 64, 17, ""},
+		{"0xff & ~0x0f ^ 0b1", 64, 0xf1, ""},
+		{"17 % 5 - -1", 64, 3, ""},
+		{"-1 >> 60", 64, 15, ""},
+		{"-1 / 2", 64, 1<<63 - 1, ""},
+		{"idx + neg", 8, 2, ""},
+		{"0x8000 + idx", 0, 0x8003, ""},
+		{"-1", 0, 1<<64 - 1, ""},
+		{"0xff + 1", 8, 0, "value '0xff + 1' out of range"},
+		{"-1", 8, 0, "value '-1' out of range"},
+		{"1 / (idx - 3)", 64, 0, "division by zero"},
+		{"count + 1", 64, 0, "undefined variable 'count'"},
+		{"name", 64, 0, "variable 'name': invalid number 'abc'"},
+		{"0xzz", 64, 0, "invalid number '0xzz'"},
+		{"(1 + 2", 64, 0, "missing ')'"},
+		{"1 +", 64, 0, "unexpected end of expression"},
+		{"1 2", 64, 0, "unexpected '2' in expression"},
+		{"1 # 2", 64, 0, "unexpected '#' in expression"},
+		{"", 64, 0, "unexpected end of expression"},
+	}
+
+	for i, c := range cases {
+		v, err := EvalUint(c.s, c.bitSize, lookup)
+		switch {
+		case c.err != "" && (err == nil || err.Error() != c.err):
+			t.Errorf("Case %d: expected error '%s', got '%v'", i, c.err, err)
+		case c.err == "" && err != nil:
+			t.Errorf("Case %d: unexpected error '%v'", i, err)
+		case v != c.v:
+			t.Errorf("Case %d: expected %d, got %d", i, c.v, v)
+		}
+	}
+}
+
+func TestEvalInt(t *testing.T) {
+	cases := []struct {
+		s       string
+		bitSize int
+		v       int64
+		err     string
+	}{
+		{"-7 / 2", 64, -3, ""},
+		{"-7 % 2", 64, -1, ""},
+		{"-16 >> 2", 64, -4, ""},
+		{"-0x80", 8, -128, ""},
+		{"5", 0, 5, ""},
+		{"-1 << 63", 0, -1 << 63, ""},
+		{"0x80", 8, 0, "value '0x80' out of range"},
+		{"x", 8, 0, "undefined variable 'x'"},
+	}
+
+	for i, c := range cases {
+		v, err := EvalInt(c.s, c.bitSize, nil)
+		switch {
+		case c.err != "" && (err == nil || err.Error() != c.err):
+			t.Errorf("Case %d: expected error '%s', got '%v'", i, c.err, err)
+		case c.err == "" && err != nil:
+			t.Errorf("Case %d: unexpected error '%v'", i, err)
+		case v != c.v:
+			t.Errorf("Case %d: expected %d, got %d", i, c.v, v)
+		}
+	}
+}
+
+func TestExprType(t *testing.T) {
+	tree := NewTree(TreeDescriptor{Name: "tree"})
+	tree.AddCommand(SetCommand())
+	tree.AddCommand(CommandDescriptor{
+		Name:  "peek",
+		Args:  []Arg{{Name: "addr", Type: ExprType{BitSize: 16}}},
+		Flags: []Flag{{Name: "offset", Type: ExprType{Signed: true}}},
+		Handler: func(ctx *ExecContext, args []string) error {
+			ctx.Printf("%#04x", ctx.Values["addr"].(uint64))
+			if off, ok := ctx.Values["offset"].(int64); ok {
+				ctx.Printf(" %+d", off)
+			}
+			ctx.Println()
+			return nil
+		},
+	})
+
+	out := new(bytes.Buffer)
+	r := NewRunner(tree, strings.NewReader("set idx 4\npeek 0x8000+idx*2 --offset=-idx\npeek idx2\n"), out)
+	if err := r.Run(); err != nil {
+		t.Fatal(err)
+	}
+	exp := "> > 0x8008 -4\n> Invalid argument 'addr': undefined variable 'idx2'.\n> "
+	if out.String() != exp {
+		t.Errorf("expected %q, got %q", exp, out.String())
+	}
+
+	if _, err := (ExprType{}).Parse("idx"); err == nil {
+		t.Error("expected error parsing variable without a runner")
+	}
+	for _, typ := range []ExprType{{BitSize: 16}, {Signed: true}} {
+		if got := newTypeSnapshot(typ).argType(); got != typ {
+			t.Errorf("snapshot of %v restored as %v", typ, got)
+		}
+	}
+}
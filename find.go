@@ -0,0 +1,38 @@
+package cmd
+
+import "reflect"
+
+// A Match is a node found by searching a command tree.
+type Match struct {
+	Path string // space-separated names leading from the root to the node
+	Node Node   // the matching command or subtree
+}
+
+// FindAll searches all commands and subtrees descending from the tree and
+// returns those for which pred returns true, in depth-first order.
+func (t *Tree) FindAll(pred func(n Node) bool) []Match {
+	var matches []Match
+	for _, n := range t.All() {
+		if pred(n) {
+			matches = append(matches, Match{nodePath(n), n})
+		}
+	}
+	return matches
+}
+
+// FindByData searches all commands and subtrees descending from the tree and
+// returns those whose descriptor's Data field equals data.
+func (t *Tree) FindByData(data any) []Match {
+	if data != nil && !reflect.TypeOf(data).Comparable() {
+		return nil
+	}
+	return t.FindAll(func(n Node) bool {
+		switch n := n.(type) {
+		case *Command:
+			return n.Data == data
+		case *Tree:
+			return n.Data == data
+		}
+		return false
+	})
+}
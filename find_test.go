@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestFindAll(t *testing.T) {
+	root := buildTagTree()
+	matches := root.FindAll(func(n Node) bool {
+		return strings.HasPrefix(n.name(), "p")
+	})
+
+	var paths []string
+	for _, m := range matches {
+		paths = append(paths, m.Path)
+	}
+	expected := "[net ping net probe]"
+	if s := fmt.Sprint(paths); s != expected {
+		t.Errorf("Expected %s, got %s", expected, s)
+	}
+}
+
+func TestFindByData(t *testing.T) {
+	root := NewTree(TreeDescriptor{Name: "root"})
+	root.AddCommand(CommandDescriptor{Name: "a", Data: 1})
+	root.AddCommand(CommandDescriptor{Name: "b", Data: 2})
+	sub := root.AddSubtree(TreeDescriptor{Name: "sub", Data: 2})
+	sub.AddCommand(CommandDescriptor{Name: "c", Data: 2})
+	sub.AddCommand(CommandDescriptor{Name: "d", Data: []int{2}})
+	sub.AddCommand(CommandDescriptor{Name: "e"})
+
+	cases := []struct {
+		data  any
+		paths string
+	}{
+		{1, "[a]"},
+		{2, "[b sub sub c]"},
+		{"2", "[]"},
+		{nil, "[sub e]"},
+		{[]int{2}, "[]"},
+	}
+
+	for i, c := range cases {
+		var paths []string
+		for _, m := range root.FindByData(c.data) {
+			paths = append(paths, m.Path)
+		}
+		if s := fmt.Sprint(paths); s != c.paths {
+			t.Errorf("Case %d: expected %s, got %s", i, c.paths, s)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+// buildFuzzTree builds a tree exercising prefixes, subtrees, shortcuts,
+// aliases, priorities and argument completion.
+func buildFuzzTree() *Tree {
+	tree := NewTree(TreeDescriptor{Name: "root"})
+	file := tree.AddSubtree(TreeDescriptor{Name: "file", Brief: "File commands"})
+	file.AddCommand(CommandDescriptor{Name: "open", Aliases: []string{"o"},
+		Args: []Arg{{Name: "mode", Type: EnumType{Values: []string{"read", "write", "read write"}}}}})
+	file.AddCommand(CommandDescriptor{Name: "close"})
+	tree.AddCommand(CommandDescriptor{Name: "step", Priority: 1})
+	tree.AddCommand(CommandDescriptor{Name: "stack"})
+	tree.AddCommand(CommandDescriptor{Name: "ünïcode"})
+	tree.AddShortcut("fo", "file open")
+	tree.AddShortcut("bp add", "file close")
+	tree.AddGlobalShortcut("q", "step")
+	tree.SetMaxLineLength(256)
+	return tree
+}
+
+var fuzzSeeds = []string{
+	"", " ", "file open read", "fi o \"read write\"", "\"", "\"file", "\"\"",
+	"bp add x", "s", "q", "ü", "\xff\xfe", "file\x00open", "\t\n\v", "file open \"re",
+	"fo r", strings.Repeat("a ", 200),
+}
+
+func FuzzLookup(f *testing.F) {
+	for _, s := range fuzzSeeds {
+		f.Add(s)
+	}
+	tree := buildFuzzTree()
+	f.Fuzz(func(t *testing.T, line string) {
+		n, args, err := tree.Lookup(line)
+		if err == nil && n == nil {
+			t.Errorf("Lookup(%q) returned no node and no error", line)
+		}
+		if err != nil && len(args) != 0 {
+			t.Errorf("Lookup(%q) returned arguments with error %v", line, err)
+		}
+		r, rerr := tree.Resolve(line)
+		if rerr == nil && (err != nil || r.Node != n) {
+			t.Errorf("Resolve(%q) disagrees with Lookup", line)
+		}
+	})
+}
+
+func FuzzAutocomplete(f *testing.F) {
+	for _, s := range fuzzSeeds {
+		f.Add(s)
+	}
+	tree := buildFuzzTree()
+	f.Fuzz(func(t *testing.T, line string) {
+		tree.Autocomplete(line)
+		tree.Hint(line)
+	})
+}
+
+func FuzzTokenize(f *testing.F) {
+	for _, s := range fuzzSeeds {
+		f.Add(s)
+	}
+	tree := buildFuzzTree()
+	f.Fuzz(func(t *testing.T, line string) {
+		end := 0
+		for _, s := range tree.Tokenize(line) {
+			if s.Start < end || s.End <= s.Start || s.End > len(line) {
+				t.Fatalf("Tokenize(%q): invalid span %+v", line, s)
+			}
+			if !strings.Contains(line[s.Start:s.End], s.Text) {
+				t.Fatalf("Tokenize(%q): span text %q not within token", line, s.Text)
+			}
+			end = s.End
+		}
+	})
+}
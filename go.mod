@@ -1,5 +1,5 @@
 module github.com/beevik/cmd
 
-go 1.21
+go 1.23
 
 require github.com/beevik/prefixtree/v2 v2.0.1
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+)
+
+// HelpTemplateFuncs holds functions that may be used by help templates. Add
+// them to a template with Funcs before parsing it.
+//
+//	wrap n s     wraps s to 80 columns, indenting each line by n spaces
+//	join a sep   joins the strings in a, separated by sep
+//	pad s n      pads s with trailing spaces to a width of n columns
+var HelpTemplateFuncs = template.FuncMap{
+	"wrap": func(indent int, s string) string {
+		return IndentWrap(wrapWidth, indent, s)
+	},
+	"join": strings.Join,
+	"pad":  padRight,
+}
+
+// SetHelpTemplates sets the templates used to display help for the entire
+// command tree containing t. The list template displays a tree's commands
+// and subtrees, and the command template displays the help for a single
+// command. Each template is executed with a *HelpNode describing the tree or
+// command. A nil template restores the default help display.
+func (t *Tree) SetHelpTemplates(list, command *template.Template) {
+	r := t.root()
+	r.listTmpl, r.cmdTmpl = list, command
+}
+
+func executeHelpTemplate(w io.Writer, tmpl *template.Template, h *HelpNode) {
+	if err := tmpl.Execute(w, h); err != nil {
+		fmt.Fprintf(w, "%v\n", err)
+	}
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestHelpTemplates(t *testing.T) {
+	list := template.Must(template.New("list").Funcs(HelpTemplateFuncs).Parse(
+		`[{{.Name}}]
+{{range .Subtrees}}{{pad .Name 6}}> {{.Brief}}
+{{end}}{{range .Commands}}{{pad .Name 6}}  {{.Brief}}
+{{end}}`))
+	command := template.Must(template.New("command").Funcs(HelpTemplateFuncs).Parse(
+		`{{.Path}}: {{.Brief}}{{if .Shortcuts}} (aka {{join .Shortcuts ", "}}){{end}}
+{{if .Description}}{{wrap 2 .Description}}
+{{end}}`))
+
+	cases := []struct {
+		line string
+		help string
+	}{
+		{
+			"",
+			"[tree]\n" +
+				"file  > file commands\n" +
+				"quit    quit the application\n" +
+				"verylongstring  very long string\n",
+		},
+		{
+			"file open",
+			"file open: open a file (aka dd, f, xx, yy, zz)\n",
+		},
+		{
+			"file read",
+			"file read: read a file\n" +
+				"  read file description.\n",
+		},
+	}
+
+	for i, c := range cases {
+		tree := buildTree()
+		tree.SetHelpTemplates(list, command)
+		buf := new(bytes.Buffer)
+		tree.GetHelp(buf, strings.Fields(c.line))
+		if help := buf.String(); help != c.help {
+			t.Errorf("Case %d: expected:\n%s\ngot:\n%s", i, c.help, help)
+		}
+	}
+
+	tree := buildTree()
+	tree.SetHelpTemplates(list, command)
+	tree.SetHelpTemplates(nil, nil)
+	buf := new(bytes.Buffer)
+	tree.GetHelp(buf, []string{"quit"})
+	if help := buf.String(); !strings.HasPrefix(help, "Description:") {
+		t.Errorf("Default help not restored:\n%s", help)
+	}
+}
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// A heredoc is the multi-line argument of a command line, introduced by a
+// "<<MARKER" field and ended by a line holding only the marker.
+type heredoc struct {
+	marker string
+	body   string
+}
+
+type heredocKey struct{}
+
+// heredocMarker searches the line for an unquoted "<<MARKER" field, where
+// the marker consists of letters, digits and underscores. It returns the
+// marker and the index of the end of the field.
+func heredocMarker(line string) (marker string, end int, ok bool) {
+	quoted := false
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '"':
+			quoted = !quoted
+		case quoted || !strings.HasPrefix(line[i:], "<<"):
+		case i > 0 && !unicode.IsSpace(rune(line[i-1])):
+		default:
+			j := i + 2
+			for j < len(line) && isMarkerByte(line[j]) {
+				j++
+			}
+			if j > i+2 && (j == len(line) || unicode.IsSpace(rune(line[j]))) {
+				return line[i+2 : j], j, true
+			}
+		}
+	}
+	return "", 0, false
+}
+
+func isMarkerByte(c byte) bool {
+	return c == '_' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+// splitHeredoc separates the command line holding a "<<MARKER" field from
+// the heredoc lines following it, which end at a line holding only the
+// marker or at the end of the line. It returns a nil heredoc if the line
+// has no marker.
+func splitHeredoc(line string) (cmdline string, h *heredoc) {
+	marker, end, ok := heredocMarker(line)
+	if !ok {
+		return line, nil
+	}
+	h = &heredoc{marker: marker}
+	nl := strings.IndexByte(line[end:], '\n')
+	if nl < 0 {
+		return line, h
+	}
+
+	var body []string
+	for _, l := range strings.Split(line[end+nl+1:], "\n") {
+		if h.isEnd(l) {
+			break
+		}
+		body = append(body, l)
+	}
+	h.body = strings.Join(body, "\n")
+	return line[:end+nl], h
+}
+
+// isEnd returns true if the line ends the heredoc.
+func (h *heredoc) isEnd(line string) bool {
+	return strings.TrimSpace(line) == h.marker
+}
+
+// replace returns a copy of the arguments in which the "<<MARKER" argument
+// is replaced by the heredoc's body.
+func (h *heredoc) replace(args []string) []string {
+	for i, arg := range args {
+		if arg == "<<"+h.marker {
+			args = append([]string(nil), args...)
+			args[i] = h.body
+			break
+		}
+	}
+	return args
+}
+
+// withHeredoc returns a context carrying the heredoc to the command whose
+// arguments hold its marker.
+func withHeredoc(ctx context.Context, h *heredoc) context.Context {
+	if h == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, heredocKey{}, h)
+}
+
+// heredocArgs replaces the marker among the arguments by the body of the
+// heredoc carried by the context, if any.
+func heredocArgs(ctx context.Context, args []string) []string {
+	if h, ok := ctx.Value(heredocKey{}).(*heredoc); ok {
+		return h.replace(args)
+	}
+	return args
+}
+
+// readHeredoc reads the lines of the heredoc introduced by the command
+// line, if any, appending them to the line. The heredoc ends at a line
+// holding only its marker, which is read after displaying
+// ContinuationPrompt, or at the end of the input.
+func (r *Runner) readHeredoc(line string) (string, error) {
+	marker, _, ok := heredocMarker(line)
+	if !ok {
+		return line, nil
+	}
+	h := &heredoc{marker: marker}
+	for {
+		next, err := r.readLine(r.ContinuationPrompt)
+		switch {
+		case err == io.EOF:
+			return line, nil
+		case err != nil:
+			return "", err
+		}
+		line += "\n" + next
+		if h.isEnd(next) {
+			return line, nil
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func buildHeredocTree() *Tree {
+	tree := NewTree(TreeDescriptor{Name: "tree"})
+	tree.AddCommand(EchoCommand())
+	tree.AddCommand(CommandDescriptor{
+		Name: "write",
+		Args: []Arg{{Name: "name"}, {Name: "text"}},
+		Handler: func(ctx *ExecContext, args []string) error {
+			ctx.Printf("%s=%q\n", args[0], args[1])
+			return nil
+		},
+	})
+	return tree
+}
+
+func TestSplitHeredoc(t *testing.T) {
+	cases := []struct {
+		line    string
+		cmdline string
+		marker  string
+		body    string
+	}{
+		{"write a", "write a", "", ""},
+		{"write a <<EOF", "write a <<EOF", "EOF", ""},
+		{"write a <<EOF\nx\n y\nEOF", "write a <<EOF", "EOF", "x\n y"},
+		{"write a <<END_1 | upper\nx\nEND_1\nignored", "write a <<END_1 | upper", "END_1", "x"},
+		{"write a <<EOF\nx", "write a <<EOF", "EOF", "x"},
+		{"write a \"<<EOF\"", "write a \"<<EOF\"", "", ""},
+		{"write a x<<EOF", "write a x<<EOF", "", ""},
+		{"write a <<", "write a <<", "", ""},
+		{"write a <<E-F", "write a <<E-F", "", ""},
+	}
+
+	for i, c := range cases {
+		cmdline, h := splitHeredoc(c.line)
+		if cmdline != c.cmdline {
+			t.Errorf("Case %d: expected line %q, got %q", i, c.cmdline, cmdline)
+		}
+		switch {
+		case h == nil && c.marker != "":
+			t.Errorf("Case %d: expected heredoc %q", i, c.marker)
+		case h != nil && (h.marker != c.marker || h.body != c.body):
+			t.Errorf("Case %d: expected %q/%q, got %q/%q", i, c.marker, c.body, h.marker, h.body)
+		}
+	}
+}
+
+func TestHeredoc(t *testing.T) {
+	cases := []struct {
+		heredoc bool
+		input   string
+		output  string
+	}{
+		{true, "write a <<EOF\nx | y > z &\n  $v\nEOF\necho done\n", "> ... ... ... a=\"x | y > z &\\n  $v\"\n> done\n> "},
+		{true, "write a <<EOF\nx\n", "> ... ... a=\"x\"\n> "},
+		{true, "write a <<EOF\nEOF\n", "> ... a=\"\"\n> "},
+		{false, "write a <<EOF\nEOF\n", "> a=\"<<EOF\"\n> Command not found.\n> "},
+	}
+
+	for i, c := range cases {
+		out := new(bytes.Buffer)
+		r := NewRunner(buildHeredocTree(), strings.NewReader(c.input), out)
+		r.Heredoc = c.heredoc
+		r.Pipes, r.Redirect, r.Background = true, true, true
+		if err := r.Run(); err != nil {
+			t.Errorf("Case %d: unexpected error: %v", i, err)
+		}
+		if out.String() != c.output {
+			t.Errorf("Case %d: expected %q, got %q", i, c.output, out.String())
+		}
+	}
+}
+
+func TestHeredocScript(t *testing.T) {
+	out := new(bytes.Buffer)
+	r := NewRunner(buildHeredocTree(), nil, out)
+	r.Heredoc = true
+	script := "repeat 2\n  write a <<EOF\n  end\nEOF\nend\necho done\n"
+	if err := r.RunScript(strings.NewReader(script)); err != nil {
+		t.Fatal(err)
+	}
+	if exp := "a=\"  end\"\na=\"  end\"\ndone\n"; out.String() != exp {
+		t.Errorf("expected %q, got %q", exp, out.String())
+	}
+
+	out.Reset()
+	if err := r.Execute("write b <<X\n1\n2\nX"); err != nil {
+		t.Fatal(err)
+	}
+	if exp := "b=\"1\\n2\"\n"; out.String() != exp {
+		t.Errorf("expected %q, got %q", exp, out.String())
+	}
+}
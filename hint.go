@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// A Hint describes the likely completion of a partially typed line, for
+// display as ghost text following the cursor or in a hint bar below the
+// prompt.
+type Hint struct {
+	Suffix string // text completing the line's final token
+	Node   Node   // command or subtree named by the completed line, or nil
+	Usage  string // usage summary of the command, if any
+	Brief  string // brief description of the command or subtree, if any
+}
+
+// Hint returns the most likely completion of the line, using the candidates
+// found by Autocomplete. If the candidates share a common prefix extending
+// the line's final token, the hint's suffix holds the remainder of the
+// prefix. The hint also describes the command or subtree named by the
+// completed line, or by the line itself if it can't be completed.
+func (t *Tree) Hint(line string) Hint {
+	var h Hint
+	if candidates := t.Autocomplete(line); len(candidates) > 0 {
+		h.Suffix = completionSuffix(line, candidates)
+	}
+
+	n, _, err := t.Lookup(line + h.Suffix)
+	if err != nil {
+		return h
+	}
+	h.Node, h.Brief = n, n.brief()
+	if c, ok := n.(*Command); ok {
+		h.Usage = localize(c, "usage", c.Localized, c.usage())
+	}
+	return h
+}
+
+// completionSuffix returns the text that extends the final token of the
+// line to the longest common prefix of the candidates' final tokens. Tokens
+// are compared as typed, including any quotes.
+func completionSuffix(line string, candidates []string) string {
+	partial := ""
+	if !endsWithSpace(line) {
+		partial = lastToken(line)
+	}
+
+	common := ""
+	for i, c := range candidates {
+		word := lastToken(c)
+		if !strings.HasPrefix(word, partial) {
+			return ""
+		}
+		if i == 0 {
+			common = word
+			continue
+		}
+		n := 0
+		for n < len(common) && n < len(word) && common[n] == word[n] {
+			n++
+		}
+		common = common[:n]
+	}
+	for len(common) > len(partial) && !utf8.ValidString(common[len(partial):]) {
+		common = common[:len(common)-1]
+	}
+	return common[len(partial):]
+}
+
+// lastToken returns the final token of the line, as typed, including any
+// quotes.
+func lastToken(line string) string {
+	spans := splitSpans(line)
+	if len(spans) == 0 {
+		return ""
+	}
+	s := spans[len(spans)-1]
+	return line[s.Start:s.End]
+}
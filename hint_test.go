@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"testing"
+)
+
+func TestHint(t *testing.T) {
+	tree := NewTree(TreeDescriptor{Name: "root"})
+	file := tree.AddSubtree(TreeDescriptor{Name: "file", Brief: "File commands"})
+	file.AddCommand(CommandDescriptor{Name: "open", Brief: "Open a file", Usage: "file open <name>"})
+	file.AddCommand(CommandDescriptor{Name: "close", Brief: "Close a file"})
+	file.AddCommand(CommandDescriptor{Name: "copy", Brief: "Copy a file",
+		Args: []Arg{{Name: "src"}, {Name: "mode", Type: EnumType{Values: []string{"binary", "text"}}}}})
+	tree.AddCommand(CommandDescriptor{Name: "quit", Brief: "Quit"})
+	tree.AddCommand(CommandDescriptor{Name: "mode", Brief: "Set the mode",
+		Args: []Arg{{Name: "mode", Type: EnumType{Values: []string{"read only", "read write"}}}}})
+
+	cases := []struct {
+		line   string
+		suffix string
+		usage  string
+		brief  string
+	}{
+		{"", "", "", ""},
+		{"fi", "le", "", "File commands"},
+		{"file op", "en", "file open <name>", "Open a file"},
+		{"fi op", "en", "file open <name>", "Open a file"},
+		{"file c", "", "", ""},
+		{"file co", "py", "file copy <src> <binary|text>", "Copy a file"},
+		{"file copy x b", "inary", "file copy <src> <binary|text>", "Copy a file"},
+		{"file open x", "", "file open <name>", "Open a file"},
+		{"bogus", "", "", ""},
+		{"mode re", "", "mode <read only|read write>", "Set the mode"},
+		{"mode \"read o", "nly\"", "mode <read only|read write>", "Set the mode"},
+		{"mode \"read only\"", "", "mode <read only|read write>", "Set the mode"},
+	}
+
+	for i, c := range cases {
+		h := tree.Hint(c.line)
+		if h.Suffix != c.suffix || h.Usage != c.usage || h.Brief != c.brief {
+			t.Errorf("Case %d: expected {%q %q %q}, got {%q %q %q}", i,
+				c.suffix, c.usage, c.brief, h.Suffix, h.Usage, h.Brief)
+		}
+	}
+}
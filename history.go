@@ -0,0 +1,253 @@
+package cmd
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// A History records the command lines executed by a runner, so that they
+// may be recalled or suggested while typing. A History is safe for
+// concurrent use.
+//
+// A history may be persisted in a file, which is typically loaded before
+// the runner starts and saved once it stops:
+//
+//	h := &cmd.History{Max: 1000, IgnoreSpace: true}
+//	h.Load(path)
+//	r.History = h
+//	r.Run()
+//	h.Save(path)
+type History struct {
+	// Max limits the number of lines retained. When the limit is reached,
+	// the oldest lines are discarded. Zero leaves the history unlimited.
+	Max int
+
+	// If EraseDups is true, earlier occurrences of a line are removed when
+	// the line is added.
+	EraseDups bool
+
+	// If IgnoreSpace is true, lines beginning with a space are not
+	// recorded, so that a user may keep a line out of the history.
+	IgnoreSpace bool
+
+	// Lines matching Ignore, if not nil, are not recorded. It typically
+	// excludes lines containing passwords.
+	Ignore *regexp.Regexp
+
+	mu      sync.Mutex
+	lines   []string
+	pending int // number of lines added since the history was last stored
+}
+
+// Add appends a line to the history. Blank lines, lines repeating the most
+// recent line and lines excluded by the history's settings are not
+// recorded.
+func (h *History) Add(line string) {
+	switch {
+	case strings.TrimSpace(line) == "":
+		return
+	case h.IgnoreSpace && strings.HasPrefix(line, " "):
+		return
+	case h.Ignore != nil && h.Ignore.MatchString(line):
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if n := len(h.lines); n > 0 && h.lines[n-1] == line {
+		return
+	}
+	if h.EraseDups {
+		h.lines = slices.DeleteFunc(h.lines, func(l string) bool { return l == line })
+	}
+	h.lines = append(h.lines, line)
+	h.pending++
+	h.trim()
+}
+
+// trim discards the oldest lines exceeding the history's limit.
+func (h *History) trim() {
+	if h.Max > 0 && len(h.lines) > h.Max {
+		h.lines = append(h.lines[:0], h.lines[len(h.lines)-h.Max:]...)
+	}
+	h.pending = min(h.pending, len(h.lines))
+}
+
+// Lines returns the lines in the history, oldest first.
+func (h *History) Lines() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]string(nil), h.lines...)
+}
+
+// Len returns the number of lines in the history.
+func (h *History) Len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.lines)
+}
+
+// Clear removes all lines from the history.
+func (h *History) Clear() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lines, h.pending = nil, 0
+}
+
+// Suggest returns the previously executed lines beginning with prefix,
+// most recent first. Each line appears once, and a line equal to prefix is
+// omitted, since it would add nothing to the input. Unlike Autocomplete,
+// which completes the current token from the command tree, Suggest offers
+// entire lines.
+func (h *History) Suggest(prefix string) []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var lines []string
+	seen := make(map[string]bool)
+	for i := len(h.lines) - 1; i >= 0; i-- {
+		l := h.lines[i]
+		if l == prefix || seen[l] || !strings.HasPrefix(l, prefix) {
+			continue
+		}
+		seen[l] = true
+		lines = append(lines, l)
+	}
+	return lines
+}
+
+// Suggestion returns the most recently executed line beginning with prefix,
+// as displayed by an autosuggesting line editor. It returns false if there
+// is no such line or if prefix is empty.
+func (h *History) Suggestion(prefix string) (string, bool) {
+	if prefix == "" {
+		return "", false
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i := len(h.lines) - 1; i >= 0; i-- {
+		if l := h.lines[i]; l != prefix && strings.HasPrefix(l, prefix) {
+			return l, true
+		}
+	}
+	return "", false
+}
+
+// Load reads the history file at path, adding its lines to the history as
+// Add does. A missing file is treated as an empty history. The loaded lines
+// are considered stored, so they aren't written again by Append.
+func (h *History) Load(path string) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	h.mu.Lock()
+	pending := h.pending
+	h.mu.Unlock()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		h.Add(unescapeHistory(scanner.Text()))
+	}
+	h.mu.Lock()
+	h.pending = min(pending, len(h.lines))
+	h.mu.Unlock()
+	return scanner.Err()
+}
+
+// Save rewrites the history file at path with the lines in the history.
+// The file is replaced atomically, so that an interrupted save doesn't
+// lose the previous history.
+func (h *History) Save(path string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if err := writeHistory(f, h.lines); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return err
+	}
+	h.pending = 0
+	return nil
+}
+
+// Append appends the lines added to the history since it was last loaded,
+// saved or appended to the history file at path, creating the file if
+// necessary. Unlike Save, Append preserves lines written to the file by
+// other sessions, but doesn't apply the history's limit to the file.
+func (h *History) Append(path string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	if err := writeHistory(f, h.lines[len(h.lines)-h.pending:]); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	h.pending = 0
+	return nil
+}
+
+// writeHistory writes lines to a history file, one per line.
+func writeHistory(f *os.File, lines []string) error {
+	w := bufio.NewWriter(f)
+	for _, l := range lines {
+		w.WriteString(escapeHistory(l))
+		w.WriteByte('\n')
+	}
+	return w.Flush()
+}
+
+// historyEscaper escapes the line breaks of command lines spanning several
+// input lines, so that each command line occupies one line of a history
+// file.
+var historyEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+// escapeHistory returns the line as written to a history file.
+func escapeHistory(line string) string {
+	return historyEscaper.Replace(line)
+}
+
+// unescapeHistory returns the command line read from a history file line.
+func unescapeHistory(line string) string {
+	if !strings.Contains(line, `\`) {
+		return line
+	}
+	var b strings.Builder
+	for i := 0; i < len(line); i++ {
+		if line[i] == '\\' && i+1 < len(line) {
+			i++
+			if line[i] == 'n' {
+				b.WriteByte('\n')
+				continue
+			}
+		}
+		b.WriteByte(line[i])
+	}
+	return b.String()
+}
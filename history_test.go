@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestHistory(t *testing.T) {
+	h := &History{Max: 4}
+	for _, line := range []string{"echo a", "echo a", "  ", "file open x", "echo b", "echo a", "fail"} {
+		h.Add(line)
+	}
+	if got := strings.Join(h.Lines(), ","); got != "file open x,echo b,echo a,fail" {
+		t.Errorf("unexpected lines: %q", got)
+	}
+
+	h.Add("echo b")
+	h.Add("echo c")
+	cases := []struct {
+		prefix     string
+		suggest    []string
+		suggestion string
+	}{
+		{"echo", []string{"echo c", "echo b", "echo a"}, "echo c"},
+		{"echo b", []string{}, ""},
+		{"f", []string{"fail"}, "fail"},
+		{"x", []string{}, ""},
+		{"", []string{"echo c", "echo b", "fail", "echo a"}, ""},
+	}
+
+	for i, c := range cases {
+		got := h.Suggest(c.prefix)
+		if strings.Join(got, ",") != strings.Join(c.suggest, ",") {
+			t.Errorf("Case %d: Suggest(%q) = %q, wanted %q", i, c.prefix, got, c.suggest)
+		}
+		s, ok := h.Suggestion(c.prefix)
+		if s != c.suggestion || ok != (c.suggestion != "") {
+			t.Errorf("Case %d: Suggestion(%q) = %q, %v, wanted %q", i, c.prefix, s, ok, c.suggestion)
+		}
+	}
+
+	h.Clear()
+	if h.Len() != 0 {
+		t.Errorf("Clear left %d lines", h.Len())
+	}
+}
+
+func TestRunnerHistory(t *testing.T) {
+	r := NewRunner(buildRunnerTree(), strings.NewReader("echo a\n\nfoo\necho a\nquit\necho b\n"), io.Discard)
+	r.History = new(History)
+	r.Run()
+	if got := strings.Join(r.History.Lines(), ","); got != "echo a,foo,echo a,quit" {
+		t.Errorf("unexpected history: %q", got)
+	}
+}
+
+func TestHistoryControl(t *testing.T) {
+	h := &History{EraseDups: true, IgnoreSpace: true, Ignore: regexp.MustCompile(`--password`)}
+	for _, line := range []string{"echo a", "echo b", " secret", "connect --password x", "echo a", "echo c", "echo b"} {
+		h.Add(line)
+	}
+	if got := strings.Join(h.Lines(), ","); got != "echo a,echo c,echo b" {
+		t.Errorf("unexpected lines: %q", got)
+	}
+}
+
+func TestHistoryFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+
+	h := new(History)
+	if err := h.Load(path); err != nil {
+		t.Fatalf("Load of missing file: %v", err)
+	}
+	h.Add("echo a")
+	h.Add("echo \"x\ny\" \\z")
+	if err := h.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	h.Add("echo b")
+	if err := h.Append(path); err != nil {
+		t.Fatal(err)
+	}
+	h.Add("echo c")
+	if err := h.Append(path); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if want := "echo a\necho \"x\\ny\" \\\\z\necho b\necho c\n"; string(data) != want {
+		t.Errorf("unexpected file contents %q, wanted %q", data, want)
+	}
+
+	h2 := &History{Max: 3}
+	if err := h2.Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(h2.Lines(), ","); got != "echo \"x\ny\" \\z,echo b,echo c" {
+		t.Errorf("unexpected loaded lines: %q", got)
+	}
+	h2.Add("echo d")
+	if err := h2.Append(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := h2.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	data, _ = os.ReadFile(path)
+	if want := "echo b\necho c\necho d\n"; string(data) != want {
+		t.Errorf("unexpected file contents %q, wanted %q", data, want)
+	}
+}
@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// A HelpNode is a serializable description of a command or subtree, used by
+// the help handler to report the command hierarchy.
+type HelpNode struct {
+	Name        string      `json:"name"`
+	Path        string      `json:"path"`
+	Brief       string      `json:"brief,omitempty"`
+	Description string      `json:"description,omitempty"`
+	Usage       string      `json:"usage,omitempty"`
+	Aliases     []string    `json:"aliases,omitempty"`
+	Shortcuts   []string    `json:"shortcuts,omitempty"`
+	Commands    []*HelpNode `json:"commands,omitempty"`
+	Subtrees    []*HelpNode `json:"subtrees,omitempty"`
+}
+
+// NewHelpNode builds a serializable description of the tree and all of its
+// descendants. Nodes hidden by the tree's hidden tags are omitted.
+func NewHelpNode(t *Tree) *HelpNode {
+	h := &HelpNode{
+		Name:        t.Name,
+		Path:        nodePath(t),
+		Brief:       t.brief(),
+		Description: t.description(),
+		Usage:       localize(t, "usage", t.Localized, t.Usage),
+	}
+	for _, n := range t.sortedNodes() {
+		if isHidden(n) {
+			continue
+		}
+		switch n := n.(type) {
+		case *Command:
+			h.Commands = append(h.Commands, newCommandHelpNode(n))
+		case *Tree:
+			h.Subtrees = append(h.Subtrees, NewHelpNode(n))
+		}
+	}
+	return h
+}
+
+func newCommandHelpNode(c *Command) *HelpNode {
+	return &HelpNode{
+		Name:        c.Name,
+		Path:        nodePath(c),
+		Brief:       c.brief(),
+		Description: c.description(),
+		Usage:       localize(c, "usage", c.Localized, c.usage()),
+		Aliases:     slices.Clone(c.Aliases),
+		Shortcuts:   c.Shortcuts(),
+	}
+}
+
+// nodePath returns the space-separated names of the node and all of its
+// ancestors, excluding the root tree.
+func nodePath(n Node) string {
+	var names []string
+	for ; n.Parent() != nil; n = n.Parent() {
+		names = append(names, n.name())
+	}
+	for i, j := 0, len(names)-1; i < j; i, j = i+1, j-1 {
+		names[i], names[j] = names[j], names[i]
+	}
+	return strings.Join(names, " ")
+}
+
+// NewHelpHandler returns an http.Handler that serves a browsable reference
+// of the tree's command hierarchy. The reference is served as HTML unless
+// the request's "format" query parameter is "json" or its Accept header
+// prefers "application/json", in which case it is served as JSON.
+func NewHelpHandler(t *Tree) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := NewHelpNode(t)
+		if wantsJSON(r) {
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			enc.Encode(h)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		helpTemplate.Execute(w, newHelpPage(t, h))
+	})
+}
+
+// A helpPage is the data of the HTML help template: a node of the help
+// reference, along with the labels used to display it.
+type helpPage struct {
+	*HelpNode
+	Labels Labels
+}
+
+// newHelpPage returns the help page of the node, whose labels are resolved
+// by the tree.
+func newHelpPage(t *Tree, h *HelpNode) helpPage {
+	return helpPage{h, Labels{
+		Usage:     t.message(MsgUsage),
+		Shortcuts: t.message(MsgShortcuts),
+		Aliases:   t.message(MsgAliases),
+	}}
+}
+
+// Sub returns the help page of a descendant node, displayed with the same
+// labels.
+func (p helpPage) Sub(h *HelpNode) helpPage {
+	return helpPage{h, p.Labels}
+}
+
+func wantsJSON(r *http.Request) bool {
+	switch r.URL.Query().Get("format") {
+	case "json":
+		return true
+	case "html":
+		return false
+	}
+	return strings.HasPrefix(r.Header.Get("Accept"), "application/json")
+}
+
+var helpTemplate = template.Must(template.New("help").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Name}} commands</title>
+<style>
+body { font-family: sans-serif; }
+dt { font-family: monospace; font-weight: bold; margin-top: 1em; }
+pre { margin: 0.25em 0; }
+</style>
+</head>
+<body>
+<h1>{{.Name}} commands</h1>
+{{template "tree" .}}
+</body>
+</html>
+{{define "tree"}}<dl>
+{{- range .Commands}}
+<dt id="{{.Path}}">{{.Path}}</dt>
+<dd>
+{{- if .Usage}}<pre>{{$.Labels.Usage}} {{.Usage}}</pre>{{end}}
+{{- if .Description}}<p>{{.Description}}</p>{{else if .Brief}}<p>{{.Brief}}.</p>{{end}}
+{{- if .Aliases}}<p>{{$.Labels.Aliases}} {{range $i, $a := .Aliases}}{{if $i}}, {{end}}{{$a}}{{end}}</p>{{end}}
+{{- if .Shortcuts}}<p>{{$.Labels.Shortcuts}} {{range $i, $s := .Shortcuts}}{{if $i}}, {{end}}{{$s}}{{end}}</p>{{end}}
+</dd>
+{{- end}}
+{{- range .Subtrees}}
+<dt id="{{.Path}}">{{.Path}}</dt>
+<dd>
+{{- if .Usage}}<pre>{{$.Labels.Usage}} {{.Usage}}</pre>{{end}}
+{{- if .Description}}<p>{{.Description}}</p>{{else if .Brief}}<p>{{.Brief}}.</p>{{end}}
+{{template "tree" ($.Sub .)}}
+</dd>
+{{- end}}
+</dl>{{end}}
+`))
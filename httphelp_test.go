@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHelpHandler(t *testing.T) {
+	h := NewHelpHandler(buildTree())
+
+	cases := []struct {
+		url         string
+		accept      string
+		contentType string
+	}{
+		{"/", "", "text/html"},
+		{"/?format=html", "application/json", "text/html"},
+		{"/?format=json", "", "application/json"},
+		{"/", "application/json", "application/json"},
+	}
+	for i, c := range cases {
+		req := httptest.NewRequest("GET", c.url, nil)
+		if c.accept != "" {
+			req.Header.Set("Accept", c.accept)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		ct := rec.Header().Get("Content-Type")
+		if !strings.HasPrefix(ct, c.contentType) {
+			t.Errorf("Case %d: expected content type '%s', got '%s'\n", i, c.contentType, ct)
+		}
+	}
+}
+
+func TestHelpHandlerJSON(t *testing.T) {
+	h := NewHelpHandler(buildTree())
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/?format=json", nil))
+
+	var root HelpNode
+	if err := json.Unmarshal(rec.Body.Bytes(), &root); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(root.Commands) != 2 || len(root.Subtrees) != 1 {
+		t.Fatalf("unexpected root contents: %d commands, %d subtrees",
+			len(root.Commands), len(root.Subtrees))
+	}
+
+	file := root.Subtrees[0]
+	if file.Path != "file" {
+		t.Errorf("expected subtree path 'file', got '%s'", file.Path)
+	}
+
+	var open *HelpNode
+	for _, c := range file.Commands {
+		if c.Name == "open" {
+			open = c
+		}
+	}
+	switch {
+	case open == nil:
+		t.Errorf("command 'file open' missing")
+	case open.Path != "file open":
+		t.Errorf("expected path 'file open', got '%s'", open.Path)
+	case strings.Join(open.Shortcuts, ",") != "dd,f,xx,yy,zz":
+		t.Errorf("unexpected shortcuts: %v", open.Shortcuts)
+	}
+}
+
+func TestHelpNodeLocalized(t *testing.T) {
+	tree := NewTree(TreeDescriptor{
+		Name:      "tree",
+		Usage:     "tree <command>",
+		Localized: map[string]LocalizedText{"fr": {Usage: "tree <commande>"}},
+	})
+	tree.AddCommand(CommandDescriptor{
+		Name:      "open",
+		Brief:     "Open a file",
+		Usage:     "open <file>",
+		Localized: map[string]LocalizedText{"fr": {Brief: "Ouvrir un fichier", Usage: "open <fichier>"}},
+	})
+	tree.SetLocale("fr")
+
+	h := NewHelpNode(tree)
+	if h.Usage != "tree <commande>" {
+		t.Errorf("unexpected tree usage '%s'", h.Usage)
+	}
+	if open := h.Commands[0]; open.Brief != "Ouvrir un fichier" || open.Usage != "open <fichier>" {
+		t.Errorf("unexpected command help: '%s', '%s'", open.Brief, open.Usage)
+	}
+}
+
+func TestHelpHandlerLabels(t *testing.T) {
+	tree := buildTree()
+	tree.AddCommand(CommandDescriptor{Name: "run", Usage: "run <prog>", Aliases: []string{"exec", "go"}})
+	tree.SetLabels(Labels{Usage: "Syntaxe :", Shortcuts: "Raccourcis :", Aliases: "Alias :"})
+
+	rec := httptest.NewRecorder()
+	NewHelpHandler(tree).ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	body := rec.Body.String()
+	for _, s := range []string{"<pre>Syntaxe : run &lt;prog&gt;</pre>", "<p>Raccourcis : dd, f, xx, yy, zz</p>", "<p>Alias : exec, go</p>"} {
+		if !strings.Contains(body, s) {
+			t.Errorf("expected '%s' in help page:\n%s", s, body)
+		}
+	}
+	if strings.Contains(body, "Usage:") || strings.Contains(body, "Shortcuts:") || strings.Contains(body, "Aliases:") {
+		t.Errorf("unexpected default label in help page:\n%s", body)
+	}
+}
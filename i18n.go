@@ -0,0 +1,177 @@
+package cmd
+
+// Keys identifying the fixed labels used in help output. They are passed to
+// a Catalog to obtain translations of the labels.
+const (
+	MsgUsage       = "Usage:"
+	MsgDescription = "Description:"
+	MsgShortcut    = "Shortcut:"
+	MsgShortcuts   = "Shortcuts:"
+	MsgAliases     = "Aliases:"
+	MsgCommands    = "%s commands:"
+
+	MsgSubtreeSection = "Subcommands:"
+	MsgCommandSection = "Commands:"
+	MsgSubtreeMarker  = "▸"
+	MsgTopicSection   = "Additional help topics:"
+)
+
+// Labels holds the fixed labels used in help output. Empty fields use the
+// default English labels.
+type Labels struct {
+	Usage       string // label preceding a usage string
+	Description string // label preceding a description
+	Shortcut    string // label preceding a single shortcut
+	Shortcuts   string // label preceding a list of shortcuts
+	Aliases     string // label preceding a list of aliases
+	Commands    string // command list heading; %s is replaced by the tree name
+
+	// Labels used by grouped help.
+	SubtreeSection string // heading of the subtree section
+	CommandSection string // heading of the command section
+	SubtreeMarker  string // marker following each subtree name
+
+	TopicSection string // heading of the help topic list
+}
+
+// SetLabels sets the fixed labels used to display help text for the entire
+// command tree containing t. Catalog translations of the labels take
+// precedence over the label set.
+func (t *Tree) SetLabels(l Labels) {
+	t.root().labels = l
+}
+
+// Labels returns the fixed labels used to display help text.
+func (t *Tree) Labels() Labels {
+	return t.root().labels
+}
+
+// A Catalog provides translated help text for a locale.
+//
+// Translations of fixed labels are requested using the Msg* keys. Translations
+// of a node's help text are requested using the node's full path followed by
+// ".brief", ".description" or ".usage" (e.g., "file open.brief").
+type Catalog interface {
+	Message(locale, key string) (msg string, ok bool)
+}
+
+// A CatalogFunc is an adapter that allows an ordinary function to be used as
+// a Catalog.
+type CatalogFunc func(locale, key string) (msg string, ok bool)
+
+// Message calls f(locale, key).
+func (f CatalogFunc) Message(locale, key string) (msg string, ok bool) {
+	return f(locale, key)
+}
+
+// LocalizedText holds a node's help text translated for a single locale.
+// Empty fields fall back to the node's catalog translation or its
+// untranslated descriptor text.
+type LocalizedText struct {
+	Brief       string
+	Description string
+	Usage       string
+}
+
+// SetLocale selects the locale used to display help text for the entire
+// command tree containing t. An empty locale displays untranslated text.
+func (t *Tree) SetLocale(locale string) {
+	t.root().locale = locale
+}
+
+// Locale returns the locale used to display help text.
+func (t *Tree) Locale() string {
+	return t.root().locale
+}
+
+// SetCatalog sets the catalog used to translate help text for the entire
+// command tree containing t.
+func (t *Tree) SetCatalog(c Catalog) {
+	t.root().catalog = c
+}
+
+// root returns the root of the command tree containing t.
+func (t *Tree) root() *Tree {
+	for t.parent != nil {
+		t = t.parent
+	}
+	return t
+}
+
+// message returns the translation of a fixed label. If no translation is
+// available, the label from the tree's label set is returned, or the key
+// itself if the label set doesn't override it.
+func (t *Tree) message(key string) string {
+	r := t.root()
+	if r.catalog != nil && r.locale != "" {
+		if msg, ok := r.catalog.Message(r.locale, key); ok {
+			return msg
+		}
+	}
+
+	var label string
+	switch key {
+	case MsgUsage:
+		label = r.labels.Usage
+	case MsgDescription:
+		label = r.labels.Description
+	case MsgShortcut:
+		label = r.labels.Shortcut
+	case MsgShortcuts:
+		label = r.labels.Shortcuts
+	case MsgAliases:
+		label = r.labels.Aliases
+	case MsgCommands:
+		label = r.labels.Commands
+	case MsgSubtreeSection:
+		label = r.labels.SubtreeSection
+	case MsgCommandSection:
+		label = r.labels.CommandSection
+	case MsgSubtreeMarker:
+		label = r.labels.SubtreeMarker
+	case MsgTopicSection:
+		label = r.labels.TopicSection
+	}
+	if label != "" {
+		return label
+	}
+	return key
+}
+
+// localize returns the translation of one of a node's help text fields.
+// Translations supplied by the node's descriptor take precedence over those
+// supplied by the tree's catalog.
+func localize(n Node, field string, localized map[string]LocalizedText, text string) string {
+	var r *Tree
+	switch n := n.(type) {
+	case *Tree:
+		r = n.root()
+	case *Command:
+		r = n.parent.root()
+	}
+	if r.locale == "" {
+		return text
+	}
+
+	if lt, ok := localized[r.locale]; ok {
+		var s string
+		switch field {
+		case "brief":
+			s = lt.Brief
+		case "description":
+			s = lt.Description
+		case "usage":
+			s = lt.Usage
+		}
+		if s != "" {
+			return s
+		}
+	}
+
+	if r.catalog != nil {
+		if msg, ok := r.catalog.Message(r.locale, nodePath(n)+"."+field); ok {
+			return msg
+		}
+	}
+	return text
+}
@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func buildLocalizedTree() *Tree {
+	tree := buildTree()
+	tree.AddCommand(CommandDescriptor{
+		Name:  "status",
+		Brief: "show status",
+		Usage: "status",
+		Localized: map[string]LocalizedText{
+			"ja": {Brief: "状態を表示", Usage: "status [詳細]"},
+		},
+	})
+
+	catalog := map[string]string{
+		MsgUsage:               "使い方:",
+		MsgDescription:         "説明:",
+		MsgShortcuts:           "ショートカット:",
+		MsgCommands:            "%s コマンド:",
+		"file.brief":           "ファイルコマンド",
+		"file open.brief":      "ファイルを開く",
+		"quit.description":     "アプリケーションを終了する。",
+		"status.brief":         "unused",
+		"verylongstring.brief": "とても長い文字列",
+	}
+	tree.SetCatalog(CatalogFunc(func(locale, key string) (string, bool) {
+		if locale != "ja" {
+			return "", false
+		}
+		msg, ok := catalog[key]
+		return msg, ok
+	}))
+	return tree
+}
+
+func TestLocalizedHelp(t *testing.T) {
+	cases := []struct {
+		locale string
+		line   string
+		help   string
+	}{
+		{
+			"ja",
+			"",
+			"tree コマンド:\n" +
+				"    file            ファイルコマンド\n" +
+				"    quit            quit the application\n" +
+				"    status          状態を表示\n" +
+				"    verylongstring  とても長い文字列\n" +
+				"\n",
+		},
+		{
+			"ja",
+			"file open",
+			"説明:\n" +
+				"   ファイルを開く.\n" +
+				"\n" +
+				"ショートカット: dd, f, xx, yy, zz\n" +
+				"\n",
+		},
+		{
+			"ja",
+			"quit",
+			"説明:\n" +
+				"   アプリケーションを終了する。\n" +
+				"\n",
+		},
+		{
+			"ja",
+			"status",
+			"使い方: status [詳細]\n" +
+				"説明:\n" +
+				"   状態を表示.\n" +
+				"\n",
+		},
+		{
+			"fr",
+			"status",
+			"Usage: status\n" +
+				"Description:\n" +
+				"   show status.\n" +
+				"\n",
+		},
+	}
+
+	for i, c := range cases {
+		tree := buildLocalizedTree()
+		tree.Subtrees()[0].SetLocale(c.locale)
+		if tree.Locale() != c.locale {
+			t.Errorf("Case %d: locale not applied to root tree", i)
+		}
+
+		buf := new(bytes.Buffer)
+		tree.GetHelp(buf, strings.Fields(c.line))
+		if buf.String() != c.help {
+			t.Errorf("Case %d: unexpected result.\nEXPECTED:\n%s\nGOT:\n%s\n", i, c.help, buf.String())
+		}
+	}
+}
+
+func TestLabels(t *testing.T) {
+	tree := buildTree()
+	tree.SetLabels(Labels{
+		Description: "About:",
+		Shortcuts:   "Aliases:",
+		Commands:    "Commands in %s:",
+	})
+
+	cases := []struct {
+		line string
+		help string
+	}{
+		{
+			"file",
+			"Commands in file:\n" +
+				"    close  close a file\n" +
+				"    open   open a file\n" +
+				"    read   read a file\n" +
+				"\n",
+		},
+		{
+			"file open",
+			"About:\n" +
+				"   open a file.\n" +
+				"\n" +
+				"Aliases: dd, f, xx, yy, zz\n" +
+				"\n",
+		},
+	}
+
+	for i, c := range cases {
+		buf := new(bytes.Buffer)
+		tree.GetHelp(buf, strings.Fields(c.line))
+		if buf.String() != c.help {
+			t.Errorf("Case %d: unexpected result.\nEXPECTED:\n%s\nGOT:\n%s\n", i, c.help, buf.String())
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	tree.DisplayUsage(buf)
+	if buf.String() != "Usage: tree [subcommand]\n" {
+		t.Errorf("unexpected usage: %q", buf.String())
+	}
+}
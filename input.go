@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+)
+
+// EOFMarker is the line ending the script read by the command returned by
+// ScriptCommand from its input.
+const EOFMarker = "EOF"
+
+// Input returns the reader from which the command reads its input. If the
+// command is piped into, it is the output of the previous command of the
+// pipeline. Otherwise, it is the runner's interactive input, whose lines are
+// read through the runner's line reader if it has one. Input returns an
+// empty reader if the command has no input.
+//
+// The reader shares its buffer with ReadLine and ReadLines, so that calls
+// to them may be mixed.
+func (ctx *ExecContext) Input() io.Reader {
+	switch {
+	case ctx.In != nil:
+		return ctx.piped()
+	case ctx.Runner == nil:
+		return strings.NewReader("")
+	case ctx.Runner.LineReader != nil:
+		return &lineInput{r: ctx.Runner}
+	case ctx.Runner.In == nil:
+		return strings.NewReader("")
+	}
+	return ctx.Runner.input()
+}
+
+// ReadLine reads the next line of the command's input, stripping its line
+// terminator. If the command reads the runner's interactive input, the
+// prompt is displayed first. It returns io.EOF once the input is exhausted.
+func (ctx *ExecContext) ReadLine(prompt string) (string, error) {
+	switch {
+	case ctx.In != nil:
+		line, err := ctx.piped().ReadString('\n')
+		if err == io.EOF && line != "" {
+			err = nil
+		}
+		return strings.TrimRight(line, "\r\n"), err
+	case ctx.Runner == nil:
+		return "", io.EOF
+	case ctx.Runner.LineReader == nil && ctx.Runner.In == nil:
+		return "", io.EOF
+	}
+	return ctx.Runner.readLine(prompt)
+}
+
+// ReadLines reads lines of the command's input as by ReadLine, until a line
+// holding only the marker or the end of the input. The marker line isn't
+// returned. If the marker is empty, lines are read until the end of the
+// input.
+func (ctx *ExecContext) ReadLines(prompt, marker string) ([]string, error) {
+	var lines []string
+	for {
+		line, err := ctx.ReadLine(prompt)
+		switch {
+		case err == io.EOF:
+			return lines, nil
+		case err != nil:
+			return lines, err
+		case marker != "" && strings.TrimSpace(line) == marker:
+			return lines, nil
+		}
+		lines = append(lines, line)
+	}
+}
+
+// piped returns the buffered reader of the command's piped input.
+func (ctx *ExecContext) piped() *bufio.Reader {
+	if ctx.input == nil {
+		ctx.input = bufio.NewReader(ctx.In)
+	}
+	return ctx.input
+}
+
+// A lineInput reads the runner's interactive input through its line reader.
+type lineInput struct {
+	r   *Runner
+	buf string
+}
+
+func (li *lineInput) Read(p []byte) (int, error) {
+	if li.buf == "" {
+		line, err := li.r.readLine("")
+		if err != nil {
+			return 0, err
+		}
+		li.buf = line + "\n"
+	}
+	n := copy(p, li.buf)
+	li.buf = li.buf[n:]
+	return n, nil
+}
+
+// ScriptCommand returns the descriptor of a command that runs a script, as
+// by Runner.RunScript. The script is read from a file or, if the file is
+// "-", from the command's input until a line holding only EOFMarker.
+func ScriptCommand() CommandDescriptor {
+	return CommandDescriptor{
+		Name:  "script",
+		Brief: "Run a script",
+		Args:  []Arg{{Name: "file"}},
+		Handler: func(ctx *ExecContext, args []string) error {
+			if args[0] != "-" {
+				f, err := os.Open(args[0])
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				return ctx.Runner.RunScript(f)
+			}
+
+			lines, err := ctx.ReadLines(ctx.Runner.ContinuationPrompt, EOFMarker)
+			if err != nil {
+				return err
+			}
+			return ctx.Runner.RunScript(strings.NewReader(strings.Join(lines, "\n")))
+		},
+	}
+}
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func buildInputTree() *Tree {
+	tree := NewTree(TreeDescriptor{Name: "tree"})
+	tree.AddCommand(EchoCommand())
+	tree.AddCommand(ScriptCommand())
+	tree.AddCommand(CommandDescriptor{
+		Name: "note",
+		Handler: func(ctx *ExecContext, args []string) error {
+			lines, err := ctx.ReadLines("note> ", ".")
+			ctx.Printf("%d lines: %s\n", len(lines), strings.Join(lines, "|"))
+			return err
+		},
+	})
+	tree.AddCommand(CommandDescriptor{
+		Name: "cat",
+		Handler: func(ctx *ExecContext, args []string) error {
+			_, err := io.Copy(ctx.Out, ctx.Input())
+			return err
+		},
+	})
+	tree.AddCommand(CommandDescriptor{
+		Name: "first",
+		Handler: func(ctx *ExecContext, args []string) error {
+			line, err := ctx.ReadLine("")
+			if err != nil {
+				return err
+			}
+			ctx.Println("first:", line)
+			_, err = io.Copy(ctx.Out, ctx.Input())
+			return err
+		},
+	})
+	return tree
+}
+
+func TestReadLines(t *testing.T) {
+	cases := []struct {
+		input  string
+		pipes  bool
+		output string
+	}{
+		{"note\na\nb\n.\necho done\n", false, "> note> note> note> 2 lines: a|b\n> done\n> "},
+		{"note\na\n", false, "> note> note> 1 lines: a\n> "},
+		{"echo x | note\n", true, "> 1 lines: x\n> "},
+		{"echo x | first\n", true, "> first: x\n> "},
+		{"cat\nhello\n", false, "> hello\n> "},
+		{"echo a | cat | cat\n", true, "> a\n> "},
+		{"script -\necho 1\necho 2\nEOF\necho 3\n", false, "> ... ... ... 1\n2\n> 3\n> "},
+	}
+
+	for i, c := range cases {
+		out := new(bytes.Buffer)
+		r := NewRunner(buildInputTree(), strings.NewReader(c.input), out)
+		r.Pipes = c.pipes
+		if err := r.Run(); err != nil {
+			t.Errorf("Case %d: unexpected error: %v", i, err)
+		}
+		if out.String() != c.output {
+			t.Errorf("Case %d: expected %q, got %q", i, c.output, out.String())
+		}
+	}
+}
+
+func TestInputLineReader(t *testing.T) {
+	lr := &fakeLineReader{lines: []string{"cat", "a", "b"}}
+	out := new(bytes.Buffer)
+	r := NewRunner(buildInputTree(), nil, out)
+	r.LineReader = lr
+	if err := r.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "a\nb\n" {
+		t.Errorf("unexpected output %q", out.String())
+	}
+
+	ctx := &ExecContext{}
+	if _, err := ctx.ReadLine("> "); err != io.EOF {
+		t.Errorf("expected EOF, got %v", err)
+	}
+	if b, _ := io.ReadAll(ctx.Input()); len(b) != 0 {
+		t.Errorf("unexpected input %q", b)
+	}
+}
+
+func TestScriptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "script")
+	if err := os.WriteFile(path, []byte("echo a\necho b\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	out := new(bytes.Buffer)
+	r := NewRunner(buildInputTree(), nil, out)
+	if err := r.Execute("script " + path); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "a\nb\n" {
+		t.Errorf("unexpected output %q", out.String())
+	}
+	if err := r.Execute("script " + path + ".missing"); err == nil {
+		t.Error("expected error for missing script")
+	}
+}
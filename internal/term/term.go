@@ -0,0 +1,3 @@
+// Package term controls the modes of terminals, for the interactive
+// features of the cmd and lineedit packages.
+package term
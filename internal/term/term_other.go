@@ -0,0 +1,28 @@
+//go:build !(linux || darwin || dragonfly || freebsd || netbsd || openbsd || windows)
+
+package term
+
+import "errors"
+
+var errUnsupported = errors.New("raw terminal mode not supported")
+
+// IsTerminal returns false, since terminals aren't supported on this
+// platform.
+func IsTerminal(fd uintptr) bool {
+	return false
+}
+
+// MakeRaw fails, since terminals aren't supported on this platform.
+func MakeRaw(fd uintptr) (restore func(), err error) {
+	return nil, errUnsupported
+}
+
+// DisableEcho fails, since terminals aren't supported on this platform.
+func DisableEcho(fd uintptr) (restore func(), err error) {
+	return nil, errUnsupported
+}
+
+// EnableANSI fails, since terminals aren't supported on this platform.
+func EnableANSI(fd uintptr) (restore func(), err error) {
+	return nil, errUnsupported
+}
@@ -0,0 +1,24 @@
+package term
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIsTerminalFile(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "file"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if IsTerminal(f.Fd()) {
+		t.Error("file reported as a terminal")
+	}
+	if _, err := MakeRaw(f.Fd()); err == nil {
+		t.Error("file put into raw mode")
+	}
+	if _, err := DisableEcho(f.Fd()); err == nil {
+		t.Error("file echo disabled")
+	}
+}
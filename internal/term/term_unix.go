@@ -0,0 +1,77 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package term
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// getTermios returns the terminal attributes of the file descriptor.
+func getTermios(fd uintptr) (*syscall.Termios, error) {
+	t := new(syscall.Termios)
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, ioctlGetTermios, uintptr(unsafe.Pointer(t))); errno != 0 {
+		return nil, errno
+	}
+	return t, nil
+}
+
+// setTermios sets the terminal attributes of the file descriptor.
+func setTermios(fd uintptr, t *syscall.Termios) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, ioctlSetTermios, uintptr(unsafe.Pointer(t))); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// IsTerminal returns true if the file descriptor refers to a terminal.
+func IsTerminal(fd uintptr) bool {
+	_, err := getTermios(fd)
+	return err == nil
+}
+
+// MakeRaw puts the terminal into raw mode, in which keys are read as they
+// are pressed without being echoed, and returns a function restoring the
+// terminal's previous mode.
+func MakeRaw(fd uintptr) (restore func(), err error) {
+	old, err := getTermios(fd)
+	if err != nil {
+		return nil, err
+	}
+	raw := *old
+	raw.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP |
+		syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+	raw.Oflag &^= syscall.OPOST
+	raw.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	raw.Cflag &^= syscall.CSIZE | syscall.PARENB
+	raw.Cflag |= syscall.CS8
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+	if err := setTermios(fd, &raw); err != nil {
+		return nil, err
+	}
+	return func() { setTermios(fd, old) }, nil
+}
+
+// DisableEcho stops the terminal from echoing the characters typed, while
+// still reading whole lines, and returns a function restoring the terminal's
+// previous mode.
+func DisableEcho(fd uintptr) (restore func(), err error) {
+	old, err := getTermios(fd)
+	if err != nil {
+		return nil, err
+	}
+	t := *old
+	t.Lflag &^= syscall.ECHO
+	t.Lflag |= syscall.ICANON | syscall.ISIG
+	t.Iflag |= syscall.ICRNL
+	if err := setTermios(fd, &t); err != nil {
+		return nil, err
+	}
+	return func() { setTermios(fd, old) }, nil
+}
+
+// EnableANSI does nothing, since terminals interpret ANSI escape sequences.
+func EnableANSI(fd uintptr) (restore func(), err error) {
+	return func() {}, nil
+}
@@ -0,0 +1,78 @@
+package term
+
+import "syscall"
+
+// Console modes, as documented for SetConsoleMode.
+const (
+	enableProcessedInput            = 0x0001
+	enableLineInput                 = 0x0002
+	enableEchoInput                 = 0x0004
+	enableVirtualTerminalInput      = 0x0200
+	enableProcessedOutput           = 0x0001
+	enableVirtualTerminalProcessing = 0x0004
+)
+
+var procSetConsoleMode = syscall.NewLazyDLL("kernel32.dll").NewProc("SetConsoleMode")
+
+// setConsoleMode sets the mode of a console handle.
+func setConsoleMode(h syscall.Handle, mode uint32) error {
+	r, _, err := procSetConsoleMode.Call(uintptr(h), uintptr(mode))
+	if r == 0 {
+		return err
+	}
+	return nil
+}
+
+// IsTerminal returns true if the handle refers to a console.
+func IsTerminal(fd uintptr) bool {
+	var mode uint32
+	return syscall.GetConsoleMode(syscall.Handle(fd), &mode) == nil
+}
+
+// MakeRaw puts the console input into raw mode, in which keys are read as
+// they are pressed without being echoed, and returns a function restoring
+// the console's previous mode. Special keys are reported as the escape
+// sequences of an ANSI terminal.
+func MakeRaw(fd uintptr) (restore func(), err error) {
+	h := syscall.Handle(fd)
+	var old uint32
+	if err := syscall.GetConsoleMode(h, &old); err != nil {
+		return nil, err
+	}
+	raw := old&^(enableProcessedInput|enableLineInput|enableEchoInput) | enableVirtualTerminalInput
+	if err := setConsoleMode(h, raw); err != nil {
+		return nil, err
+	}
+	return func() { setConsoleMode(h, old) }, nil
+}
+
+// DisableEcho stops the console from echoing the characters typed, while
+// still reading whole lines, and returns a function restoring the console's
+// previous mode.
+func DisableEcho(fd uintptr) (restore func(), err error) {
+	h := syscall.Handle(fd)
+	var old uint32
+	if err := syscall.GetConsoleMode(h, &old); err != nil {
+		return nil, err
+	}
+	mode := old&^enableEchoInput | enableProcessedInput | enableLineInput
+	if err := setConsoleMode(h, mode); err != nil {
+		return nil, err
+	}
+	return func() { setConsoleMode(h, old) }, nil
+}
+
+// EnableANSI makes the console output interpret the escape sequences of an
+// ANSI terminal, and returns a function restoring the console's previous
+// mode.
+func EnableANSI(fd uintptr) (restore func(), err error) {
+	h := syscall.Handle(fd)
+	var old uint32
+	if err := syscall.GetConsoleMode(h, &old); err != nil {
+		return nil, err
+	}
+	if err := setConsoleMode(h, old|enableProcessedOutput|enableVirtualTerminalProcessing); err != nil {
+		return nil, err
+	}
+	return func() { setConsoleMode(h, old) }, nil
+}
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"sync"
+)
+
+// ErrInterrupted is returned by a command whose execution was interrupted.
+var ErrInterrupted = errors.New("Interrupted")
+
+// A foreground tracks the cancellation function of the command line being
+// executed by a runner's Execute method.
+type foreground struct {
+	mu     sync.Mutex
+	cancel context.CancelCauseFunc
+}
+
+// foreground returns the context of a command line executed by Execute,
+// which is cancelled by Interrupt, and a function to be called once the
+// line's execution completes.
+func (r *Runner) foreground() (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	r.fg.mu.Lock()
+	prev := r.fg.cancel
+	r.fg.cancel = cancel
+	r.fg.mu.Unlock()
+
+	return ctx, func() {
+		r.fg.mu.Lock()
+		r.fg.cancel = prev
+		r.fg.mu.Unlock()
+		cancel(nil)
+	}
+}
+
+// Interrupt cancels the context of the command being executed by Execute,
+// so that handlers polling ExecContext.CheckInterrupt abort with
+// ErrInterrupted. It returns false if no command is being executed.
+// Background jobs aren't interrupted; use Kill to cancel them.
+func (r *Runner) Interrupt() bool {
+	r.fg.mu.Lock()
+	defer r.fg.mu.Unlock()
+	if r.fg.cancel == nil {
+		return false
+	}
+	r.fg.cancel(ErrInterrupted)
+	return true
+}
+
+// InterruptOnSignal calls Interrupt each time the process receives an
+// interrupt signal, until the returned stop function is called. While
+// enabled, interrupt signals don't terminate the process.
+func (r *Runner) InterruptOnSignal() (stop func()) {
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, os.Interrupt)
+	go func() {
+		for {
+			select {
+			case <-ch:
+				r.Interrupt()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+}
+
+// Interrupted returns true if the command's execution has been interrupted,
+// its timeout has expired or, for a background job, the job has been killed.
+func (ctx *ExecContext) Interrupted() bool {
+	return ctx.Context().Err() != nil
+}
+
+// CheckInterrupt returns ErrTimeout if the command's timeout has expired,
+// ErrInterrupted if its execution has otherwise been interrupted, and nil
+// if it may continue. It is cheap enough to be called on each iteration of
+// a handler's loop:
+//
+//	for addr := start; addr < end; addr++ {
+//		if err := ctx.CheckInterrupt(); err != nil {
+//			return err
+//		}
+//		...
+//	}
+func (ctx *ExecContext) CheckInterrupt() error {
+	if ctx.ctx == nil {
+		return nil
+	}
+	select {
+	case <-ctx.ctx.Done():
+		return interruptError(ctx.ctx)
+	default:
+		return nil
+	}
+}
+
+// interruptError returns the error reported for a command whose context is
+// done.
+func interruptError(ctx context.Context) error {
+	if errors.Is(context.Cause(ctx), context.DeadlineExceeded) {
+		return ErrTimeout
+	}
+	return ErrInterrupted
+}
+
+// handlerError returns the error reported for a command whose handler
+// returned err. A context error returned by a handler whose context is
+// done is reported consistently with CheckInterrupt.
+func handlerError(ctx context.Context, err error) error {
+	if err != nil && ctx.Err() != nil && errors.Is(err, ctx.Err()) {
+		return interruptError(ctx)
+	}
+	return err
+}
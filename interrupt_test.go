@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInterrupt(t *testing.T) {
+	started := make(chan struct{}, 1)
+	tree := NewTree(TreeDescriptor{Name: "app"})
+	tree.AddCommand(CommandDescriptor{
+		Name: "search",
+		Handler: func(ctx *ExecContext, args []string) error {
+			started <- struct{}{}
+			for {
+				if err := ctx.CheckInterrupt(); err != nil {
+					return err
+				}
+			}
+		},
+	})
+	tree.AddCommand(CommandDescriptor{
+		Name: "wait",
+		Handler: func(ctx *ExecContext, args []string) error {
+			started <- struct{}{}
+			<-ctx.Context().Done()
+			return ctx.Context().Err()
+		},
+	})
+	tree.AddCommand(CommandDescriptor{
+		Name:    "slow",
+		Timeout: 10 * time.Millisecond,
+		Handler: func(ctx *ExecContext, args []string) error {
+			for !ctx.Interrupted() {
+			}
+			return ctx.CheckInterrupt()
+		},
+	})
+	tree.AddCommand(CommandDescriptor{
+		Name: "quick",
+		Handler: func(ctx *ExecContext, args []string) error {
+			return ctx.CheckInterrupt()
+		},
+	})
+
+	r := NewRunner(tree, nil, &strings.Builder{})
+	if r.Interrupt() {
+		t.Errorf("Interrupt: expected false while idle")
+	}
+
+	cases := []struct {
+		line      string
+		interrupt bool
+		expected  error
+	}{
+		{"search", true, ErrInterrupted},
+		{"wait", true, ErrInterrupted},
+		{"slow", false, ErrTimeout},
+		{"quick", false, nil},
+	}
+	for i, c := range cases {
+		if c.interrupt {
+			go func() {
+				<-started
+				r.Interrupt()
+			}()
+		}
+		if err := r.Execute(c.line); err != c.expected {
+			t.Errorf("Case %d: expected %v, got %v", i, c.expected, err)
+		}
+	}
+}
+
+func TestHandlerError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := handlerError(ctx, context.Canceled); err != context.Canceled {
+		t.Errorf("Expected context.Canceled before cancellation, got %v", err)
+	}
+	cancel()
+	if err := handlerError(ctx, context.Canceled); err != ErrInterrupted {
+		t.Errorf("Expected ErrInterrupted, got %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import "iter"
+
+// All returns an iterator over all commands and subtrees descending from the
+// tree, in depth-first order. Each node is yielded with its path, which
+// holds the names leading from the tree to the node. A subtree is yielded
+// before its descendants.
+func (t *Tree) All() iter.Seq2[[]string, Node] {
+	return func(yield func([]string, Node) bool) {
+		t.all(nil, yield)
+	}
+}
+
+func (t *Tree) all(path []string, yield func([]string, Node) bool) bool {
+	for _, c := range t.commands {
+		if !yield(appendPath(path, c.Name), c) {
+			return false
+		}
+	}
+	for _, st := range t.subtrees {
+		p := appendPath(path, st.Name)
+		if !yield(p, st) || !st.all(p, yield) {
+			return false
+		}
+	}
+	return true
+}
+
+// appendPath returns a new path consisting of path followed by name, so that
+// yielded paths may be retained by the caller.
+func appendPath(path []string, name string) []string {
+	p := make([]string, len(path)+1)
+	copy(p, path)
+	p[len(path)] = name
+	return p
+}
+
+// CommandsIter returns an iterator over the tree's commands.
+func (t *Tree) CommandsIter() iter.Seq[*Command] {
+	return func(yield func(*Command) bool) {
+		for _, c := range t.commands {
+			if !yield(c) {
+				return
+			}
+		}
+	}
+}
+
+// SubtreesIter returns an iterator over the tree's subtrees.
+func (t *Tree) SubtreesIter() iter.Seq[*Tree] {
+	return func(yield func(*Tree) bool) {
+		for _, st := range t.subtrees {
+			if !yield(st) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestAll(t *testing.T) {
+	root := buildTagTree()
+
+	var paths []string
+	for path, n := range root.All() {
+		if path[len(path)-1] != n.name() {
+			t.Errorf("Path %v doesn't end with node name %s", path, n.name())
+		}
+		paths = append(paths, strings.Join(path, " "))
+	}
+	expected := "[list lint wipe net net ping net probe]"
+	if s := fmt.Sprint(paths); s != expected {
+		t.Errorf("Expected %s, got %s", expected, s)
+	}
+
+	paths = paths[:0]
+	for path := range root.All() {
+		if len(path) > 1 {
+			break
+		}
+		paths = append(paths, strings.Join(path, " "))
+	}
+	expected = "[list lint wipe net]"
+	if s := fmt.Sprint(paths); s != expected {
+		t.Errorf("Expected %s after break, got %s", expected, s)
+	}
+}
+
+func TestCommandsIter(t *testing.T) {
+	root := buildTagTree()
+
+	var names []string
+	for c := range root.CommandsIter() {
+		names = append(names, c.Name)
+	}
+	for st := range root.SubtreesIter() {
+		names = append(names, st.Name)
+	}
+	expected := "[list lint wipe net]"
+	if s := fmt.Sprint(names); s != expected {
+		t.Errorf("Expected %s, got %s", expected, s)
+	}
+}
@@ -0,0 +1,250 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode"
+)
+
+// ErrNoJob is returned when referring to a background job that doesn't
+// exist.
+var ErrNoJob = errors.New("No such job")
+
+// A Job is a command running in the background, started by Runner.Start.
+// Output written by the job's command is collected by the job and displayed
+// when the job's completion is reported.
+type Job struct {
+	ID      int       // job number, unique within the runner
+	Line    string    // the command line being executed, with sensitive arguments masked
+	Started time.Time // time the job was started
+
+	cancel context.CancelFunc
+	done   chan struct{}
+	out    syncBuffer
+	err    error
+	killed atomic.Bool
+}
+
+// Wait waits for the job to complete and returns the error returned by its
+// command.
+func (j *Job) Wait() error {
+	<-j.done
+	return j.err
+}
+
+// Running returns true if the job's command hasn't yet returned.
+func (j *Job) Running() bool {
+	select {
+	case <-j.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// Output returns the output written by the job's command so far.
+func (j *Job) Output() string {
+	return j.out.String()
+}
+
+// Status returns a short description of the job's state: "Running", "Done",
+// "Killed" or "Failed: " followed by the job's error.
+func (j *Job) Status() string {
+	switch {
+	case j.Running():
+		return "Running"
+	case j.killed.Load():
+		return "Killed"
+	case j.err != nil:
+		return fmt.Sprintf("Failed: %v", j.err)
+	}
+	return "Done"
+}
+
+// A jobList tracks a runner's background jobs.
+type jobList struct {
+	mu   sync.Mutex
+	jobs []*Job
+	next int
+}
+
+// Start executes the command line as a background job and returns without
+// waiting for the command to complete. The line is looked up immediately,
+// and an error is returned if it doesn't name a command. If the runner allows
+// redirection, the line may redirect the job's output to a file.
+//
+// The job's command is cancelled through its context when the job is killed.
+// References to session variables in the line are expanded by ExpandVars.
+// If the runner allows heredocs, the line may hold a heredoc, as described
+// by Execute.
+func (r *Runner) Start(line string) (*Job, error) {
+	var h *heredoc
+	if r.Heredoc {
+		line, h = splitHeredoc(line)
+	}
+	return r.start(r.ExpandVars(line), h)
+}
+
+func (r *Runner) start(line string, h *heredoc) (*Job, error) {
+	cmdline, target, appending, redirect := line, "", false, false
+	if r.Redirect {
+		if c, t, a, ok := parseRedirect(line); ok {
+			if t == "" {
+				return nil, ErrRedirect
+			}
+			cmdline, target, appending, redirect = c, t, a, true
+		}
+	}
+	if _, _, err := r.Current().LookupCommand(cmdline); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(withHeredoc(context.Background(), h))
+	j := &Job{
+		Line:    r.Current().MaskLine(strings.TrimSpace(line)),
+		Started: time.Now(),
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+
+	r.jobs.mu.Lock()
+	r.jobs.next++
+	j.ID = r.jobs.next
+	r.jobs.jobs = append(r.jobs.jobs, j)
+	r.jobs.mu.Unlock()
+
+	go func() {
+		defer close(j.done)
+		defer cancel()
+		if redirect {
+			j.err = r.executeRedirect(ctx, cmdline, target, appending, &j.out)
+		} else {
+			j.err = r.execute(ctx, cmdline, &j.out, &j.out)
+		}
+	}()
+	return j, nil
+}
+
+// Jobs returns the runner's background jobs whose completion hasn't yet
+// been reported.
+func (r *Runner) Jobs() []*Job {
+	r.jobs.mu.Lock()
+	defer r.jobs.mu.Unlock()
+	return append([]*Job(nil), r.jobs.jobs...)
+}
+
+// Kill cancels the context of the background job with the given ID. A
+// command that ignores its context continues to run until it returns.
+func (r *Runner) Kill(id int) error {
+	r.jobs.mu.Lock()
+	defer r.jobs.mu.Unlock()
+	for _, j := range r.jobs.jobs {
+		if j.ID == id && j.Running() {
+			j.killed.Store(true)
+			j.cancel()
+			return nil
+		}
+	}
+	return ErrNoJob
+}
+
+// DisplayJobs displays the status of each of the runner's background jobs.
+func (r *Runner) DisplayJobs(w io.Writer) {
+	for _, j := range r.Jobs() {
+		fmt.Fprintf(w, "[%d] %-8s %s\n", j.ID, j.Status(), j.Line)
+	}
+}
+
+// ReportJobs displays the output and status of each background job that has
+// completed since the last report, and stops tracking those jobs. Run calls
+// ReportJobs before displaying each prompt.
+func (r *Runner) ReportJobs(w io.Writer) {
+	r.jobs.mu.Lock()
+	var finished []*Job
+	running := r.jobs.jobs[:0]
+	for _, j := range r.jobs.jobs {
+		if j.Running() {
+			running = append(running, j)
+		} else {
+			finished = append(finished, j)
+		}
+	}
+	clear(r.jobs.jobs[len(running):])
+	r.jobs.jobs = running
+	r.jobs.mu.Unlock()
+
+	for _, j := range finished {
+		io.WriteString(w, j.Output())
+		fmt.Fprintf(w, "[%d] %s  %s\n", j.ID, j.Status(), j.Line)
+	}
+}
+
+// parseBackground checks whether the line ends with an unquoted '&'. If so,
+// it returns the command line preceding it.
+func parseBackground(line string) (cmdline string, ok bool) {
+	line = strings.TrimRightFunc(line, unicode.IsSpace)
+	if !strings.HasSuffix(line, "&") || strings.Count(line, "\"")%2 != 0 {
+		return line, false
+	}
+	return line[:len(line)-1], true
+}
+
+// JobsCommand returns the descriptor of a command that lists the runner's
+// background jobs.
+func JobsCommand() CommandDescriptor {
+	return CommandDescriptor{
+		Name:  "jobs",
+		Brief: "List background jobs",
+		Handler: func(ctx *ExecContext, args []string) error {
+			ctx.Runner.DisplayJobs(ctx.Out)
+			return nil
+		},
+	}
+}
+
+// KillCommand returns the descriptor of a command that kills one of the
+// runner's background jobs.
+func KillCommand() CommandDescriptor {
+	return CommandDescriptor{
+		Name:  "kill",
+		Brief: "Kill a background job",
+		Args:  []Arg{{Name: "job", Type: UintType{}}},
+		Handler: func(ctx *ExecContext, args []string) error {
+			return ctx.Runner.Kill(int(ctx.Values["job"].(uint64)))
+		},
+	}
+}
+
+// A syncBuffer is a bytes.Buffer that is safe for concurrent use.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// take returns the buffer's contents and empties it.
+func (b *syncBuffer) take() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s := b.buf.String()
+	b.buf.Reset()
+	return s
+}
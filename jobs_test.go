@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func buildJobTree(release chan struct{}) *Tree {
+	tree := NewTree(TreeDescriptor{Name: "tree"})
+	tree.AddCommand(JobsCommand())
+	tree.AddCommand(KillCommand())
+	tree.AddCommand(CommandDescriptor{
+		Name: "trace",
+		Handler: func(ctx *ExecContext, args []string) error {
+			ctx.Printf("tracing %s\n", strings.Join(args, " "))
+			select {
+			case <-release:
+				return nil
+			case <-ctx.Context().Done():
+				return ctx.Context().Err()
+			}
+		},
+	})
+	tree.AddCommand(CommandDescriptor{
+		Name: "fail",
+		Handler: func(ctx *ExecContext, args []string) error {
+			return errors.New("boom")
+		},
+	})
+	return tree
+}
+
+func TestBackgroundJobs(t *testing.T) {
+	release := make(chan struct{})
+	var out bytes.Buffer
+	r := NewRunner(buildJobTree(release), strings.NewReader(""), &out)
+	r.Background = true
+
+	if err := r.Execute("trace cpu &"); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if err := r.Execute("trace mem&"); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if _, err := r.Start("missing"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+	j3, _ := r.Start("fail")
+	j3.Wait()
+
+	if err := r.Execute("jobs"); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if err := r.Execute("kill 1"); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if err := r.Execute("kill 9"); err != ErrNoJob {
+		t.Errorf("Expected ErrNoJob, got %v", err)
+	}
+
+	jobs := r.Jobs()
+	jobs[0].Wait()
+	close(release)
+	jobs[1].Wait()
+	r.ReportJobs(&out)
+
+	expected := "[1] trace cpu\n" +
+		"[2] trace mem\n" +
+		"[1] Running  trace cpu\n" +
+		"[2] Running  trace mem\n" +
+		"[3] Failed: boom fail\n" +
+		"tracing cpu\n" +
+		"[1] Killed  trace cpu\n" +
+		"tracing mem\n" +
+		"[2] Done  trace mem\n" +
+		"[3] Failed: boom  fail\n"
+	if out.String() != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, out.String())
+	}
+	if len(r.Jobs()) != 0 {
+		t.Errorf("Reported jobs still tracked")
+	}
+}
@@ -0,0 +1,486 @@
+// Package lineedit provides a minimal line editor for interactive command
+// runners, supporting cursor movement, history recall and Tab completion
+// from a command tree. It depends only on the standard library, so that
+// small tools get a working interactive shell without pulling in a
+// readline library. Applications using their own line editor need not
+// import it.
+//
+// The editor is attached to a runner, which then reads its command lines
+// through the editor:
+//
+//	r := cmd.NewRunner(tree, os.Stdin, os.Stdout)
+//	lineedit.New(os.Stdin, os.Stdout).Attach(r)
+//	r.Run()
+package lineedit
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/beevik/cmd"
+	"github.com/beevik/cmd/internal/term"
+)
+
+// An Editor reads lines typed at a terminal, allowing them to be edited
+// before they are returned. If the editor's input isn't a terminal, lines
+// are read without editing.
+//
+// The editor recognizes the following keys:
+//
+//	Left, Ctrl-B       move the cursor left
+//	Right, Ctrl-F      move the cursor right, or accept the suggestion
+//	Home, Ctrl-A       move the cursor to the start of the line
+//	End, Ctrl-E        move the cursor to the end of the line
+//	Backspace          delete the character before the cursor
+//	Delete             delete the character under the cursor
+//	Ctrl-D             delete the character under the cursor, or end the
+//	                   input if the line is empty
+//	Ctrl-K             delete from the cursor to the end of the line
+//	Ctrl-U             delete from the start of the line to the cursor
+//	Ctrl-W             delete the word before the cursor
+//	Up, Ctrl-P         recall the previous line from the history
+//	Down, Ctrl-N       recall the next line from the history
+//	Tab, Shift-Tab     complete the line, cycling through the candidates
+//	Ctrl-L             clear the screen
+//	Ctrl-C             abandon the line
+type Editor struct {
+	// Complete, if not nil, returns the completions of the line when Tab
+	// is pressed.
+	Complete func(line string) []cmd.Completion
+
+	// History, if not nil, holds the lines recalled by the Up and Down
+	// keys.
+	History *cmd.History
+
+	// If Autosuggest is true, the most recent history line beginning with
+	// the typed line is displayed dimmed after the cursor, and may be
+	// accepted with the Right key.
+	Autosuggest bool
+
+	in      *bufio.Reader
+	out     io.Writer
+	term    *os.File // the input, if it is a terminal
+	termOut *os.File // the output, if it is a terminal
+	raw     bool     // the input delivers keys as they're typed
+}
+
+// New creates a line editor reading keys from in and echoing the line
+// being edited to out. If in is a terminal, it is switched to raw mode
+// while each line is read. On Windows, the console is also made to
+// interpret the ANSI escape sequences written by the editor.
+func New(in io.Reader, out io.Writer) *Editor {
+	e := &Editor{in: bufio.NewReader(in), out: out}
+	if f, ok := in.(*os.File); ok && term.IsTerminal(f.Fd()) {
+		e.term = f
+	}
+	if f, ok := out.(*os.File); ok && term.IsTerminal(f.Fd()) {
+		e.termOut = f
+	}
+	return e
+}
+
+// NewTerminal creates a line editor for a terminal that isn't attached to
+// the process, such as a terminal emulator connected over the network. The
+// input is expected to deliver keys as they're typed, and the output to
+// interpret ANSI escape sequences, so lines are always edited.
+func NewTerminal(in io.Reader, out io.Writer) *Editor {
+	return &Editor{in: bufio.NewReader(in), out: out, raw: true}
+}
+
+// Attach makes the runner read its command lines through the editor,
+// which completes them from the runner's current tree and recalls them
+// from the runner's history. A history is created for the runner if it
+// has none.
+func (e *Editor) Attach(r *cmd.Runner) {
+	if r.History == nil {
+		r.History = new(cmd.History)
+	}
+	e.History = r.History
+	e.Complete = r.Completions
+	r.LineReader = e
+}
+
+// ReadLine displays the prompt and returns the line typed after it. It
+// returns io.EOF when the input is exhausted or when Ctrl-D is pressed on
+// an empty line. A line abandoned with Ctrl-C is returned empty.
+func (e *Editor) ReadLine(prompt string) (string, error) {
+	if e.raw {
+		return e.edit(prompt)
+	}
+	if e.term == nil {
+		return e.readPlain(prompt)
+	}
+	restore, err := e.makeRaw()
+	if err != nil {
+		return e.readPlain(prompt)
+	}
+	defer restore()
+	return e.edit(prompt)
+}
+
+// ReadSecret displays the prompt and returns the line typed after it,
+// without echoing the line. It returns io.EOF when the input is exhausted
+// or when Ctrl-D is pressed on an empty line, and cmd.ErrInterrupted when
+// Ctrl-C is pressed.
+func (e *Editor) ReadSecret(prompt string) (string, error) {
+	if e.raw {
+		return e.readSecret(prompt)
+	}
+	if e.term == nil {
+		return e.readPlain(prompt)
+	}
+	restore, err := e.makeRaw()
+	if err != nil {
+		return e.readPlain(prompt)
+	}
+	defer restore()
+	return e.readSecret(prompt)
+}
+
+// makeRaw puts the editor's terminal into raw mode, and returns a function
+// restoring its previous mode.
+func (e *Editor) makeRaw() (restore func(), err error) {
+	restoreIn, err := term.MakeRaw(e.term.Fd())
+	if err != nil {
+		return nil, err
+	}
+	if e.termOut == nil {
+		return restoreIn, nil
+	}
+	restoreOut, err := term.EnableANSI(e.termOut.Fd())
+	if err != nil {
+		return restoreIn, nil
+	}
+	return func() { restoreOut(); restoreIn() }, nil
+}
+
+// readPlain reads a line without editing it.
+func (e *Editor) readPlain(prompt string) (string, error) {
+	fmt.Fprint(e.out, prompt)
+	line, err := e.in.ReadString('\n')
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+	return strings.TrimRight(line, "\r\n"), err
+}
+
+// Keys decoded from escape sequences.
+const (
+	keyUp rune = -(iota + 1)
+	keyDown
+	keyLeft
+	keyRight
+	keyHome
+	keyEnd
+	keyDelete
+	keyBackTab
+	keyUnknown
+)
+
+// Control keys.
+const (
+	ctrlA     = 0x01
+	ctrlB     = 0x02
+	ctrlC     = 0x03
+	ctrlD     = 0x04
+	ctrlE     = 0x05
+	ctrlF     = 0x06
+	ctrlH     = 0x08
+	tab       = 0x09
+	ctrlK     = 0x0b
+	ctrlL     = 0x0c
+	ctrlN     = 0x0e
+	ctrlP     = 0x10
+	ctrlU     = 0x15
+	ctrlW     = 0x17
+	escape    = 0x1b
+	backspace = 0x7f
+)
+
+// A state is the state of a line being edited.
+type state struct {
+	prompt  string
+	buf     []rune
+	pos     int      // cursor position in buf
+	history []string // history lines, oldest first
+	hpos    int      // position of the recalled history line
+	saved   string   // the typed line, while history is recalled
+}
+
+// edit reads and edits a line typed at a terminal in raw mode.
+func (e *Editor) edit(prompt string) (string, error) {
+	s := &state{prompt: prompt}
+	if e.History != nil {
+		s.history = e.History.Lines()
+	}
+	s.hpos = len(s.history)
+	complete := cmd.NewCompletionSession(e.completions)
+
+	e.refresh(s, true)
+	for {
+		k, err := e.readKey()
+		if err != nil {
+			if err == io.EOF && len(s.buf) > 0 {
+				break
+			}
+			return "", err
+		}
+
+		switch k {
+		case '\r', '\n':
+			e.refresh(s, false)
+			io.WriteString(e.out, "\r\n")
+			return string(s.buf), nil
+		case ctrlC:
+			io.WriteString(e.out, "^C\r\n")
+			return "", nil
+		case ctrlD:
+			if len(s.buf) == 0 {
+				io.WriteString(e.out, "\r\n")
+				return "", io.EOF
+			}
+			s.delete(s.pos, s.pos+1)
+		case keyDelete:
+			s.delete(s.pos, s.pos+1)
+		case backspace, ctrlH:
+			s.delete(s.pos-1, s.pos)
+		case ctrlK:
+			s.delete(s.pos, len(s.buf))
+		case ctrlU:
+			s.delete(0, s.pos)
+		case ctrlW:
+			s.delete(s.wordStart(), s.pos)
+		case keyLeft, ctrlB:
+			s.pos = max(s.pos-1, 0)
+		case keyRight, ctrlF:
+			if s.pos < len(s.buf) {
+				s.pos++
+			} else if l, ok := e.suggestion(s); ok {
+				s.set(l)
+			}
+		case keyHome, ctrlA:
+			s.pos = 0
+		case keyEnd, ctrlE:
+			s.pos = len(s.buf)
+		case keyUp, ctrlP:
+			s.recall(s.hpos - 1)
+		case keyDown, ctrlN:
+			s.recall(s.hpos + 1)
+		case tab, keyBackTab:
+			next := complete.Next
+			if k == keyBackTab {
+				next = complete.Prev
+			}
+			if l, ok := next(string(s.buf)); ok {
+				s.set(l)
+			} else {
+				io.WriteString(e.out, "\a")
+			}
+		case ctrlL:
+			io.WriteString(e.out, "\x1b[H\x1b[2J")
+		default:
+			if k >= ' ' && unicode.IsPrint(k) {
+				s.insert(k)
+			}
+		}
+		e.refresh(s, true)
+	}
+
+	io.WriteString(e.out, "\r\n")
+	return string(s.buf), nil
+}
+
+// readSecret reads a line typed at a terminal in raw mode, without echoing
+// it.
+func (e *Editor) readSecret(prompt string) (string, error) {
+	io.WriteString(e.out, prompt)
+	var buf []rune
+	for {
+		k, err := e.readKey()
+		if err != nil {
+			if err == io.EOF && len(buf) > 0 {
+				break
+			}
+			return "", err
+		}
+		switch k {
+		case '\r', '\n':
+			io.WriteString(e.out, "\r\n")
+			return string(buf), nil
+		case ctrlC:
+			io.WriteString(e.out, "^C\r\n")
+			return "", cmd.ErrInterrupted
+		case ctrlD:
+			if len(buf) == 0 {
+				io.WriteString(e.out, "\r\n")
+				return "", io.EOF
+			}
+		case backspace, ctrlH:
+			buf = buf[:max(len(buf)-1, 0)]
+		case ctrlU:
+			buf = buf[:0]
+		default:
+			if k >= ' ' && unicode.IsPrint(k) {
+				buf = append(buf, k)
+			}
+		}
+	}
+	io.WriteString(e.out, "\r\n")
+	return string(buf), nil
+}
+
+// completions returns the completions of the line, if the editor completes
+// lines.
+func (e *Editor) completions(line string) []cmd.Completion {
+	if e.Complete == nil {
+		return nil
+	}
+	return e.Complete(line)
+}
+
+// suggestion returns the history line suggested for the line being edited.
+func (e *Editor) suggestion(s *state) (string, bool) {
+	if !e.Autosuggest || e.History == nil || s.pos < len(s.buf) {
+		return "", false
+	}
+	return e.History.Suggestion(string(s.buf))
+}
+
+// refresh redraws the prompt and the line being edited, followed by the
+// line's suggestion if requested, and places the cursor.
+func (e *Editor) refresh(s *state, suggest bool) {
+	var b strings.Builder
+	b.WriteByte('\r')
+	b.WriteString(s.prompt)
+	b.WriteString(string(s.buf))
+	back := len(s.buf) - s.pos
+	if suggest {
+		if l, ok := e.suggestion(s); ok {
+			rest := []rune(l)[len(s.buf):]
+			fmt.Fprintf(&b, "\x1b[2m%s\x1b[0m", string(rest))
+			back += len(rest)
+		}
+	}
+	b.WriteString("\x1b[K")
+	if back > 0 {
+		fmt.Fprintf(&b, "\x1b[%dD", back)
+	}
+	io.WriteString(e.out, b.String())
+}
+
+// readKey reads the next key pressed, decoding escape sequences.
+func (e *Editor) readKey() (rune, error) {
+	r, _, err := e.in.ReadRune()
+	if err != nil || r != escape {
+		return r, err
+	}
+
+	b, err := e.in.ReadByte()
+	if err != nil {
+		return keyUnknown, err
+	}
+	if b != '[' && b != 'O' {
+		return keyUnknown, nil
+	}
+	var params []byte
+	for {
+		c, err := e.in.ReadByte()
+		if err != nil {
+			return keyUnknown, err
+		}
+		if c >= 0x40 && c <= 0x7e {
+			return decodeSequence(string(params), c), nil
+		}
+		params = append(params, c)
+	}
+}
+
+// decodeSequence returns the key of an escape sequence, given its
+// parameters and final byte.
+func decodeSequence(params string, final byte) rune {
+	switch final {
+	case 'A':
+		return keyUp
+	case 'B':
+		return keyDown
+	case 'C':
+		return keyRight
+	case 'D':
+		return keyLeft
+	case 'H':
+		return keyHome
+	case 'F':
+		return keyEnd
+	case 'Z':
+		return keyBackTab
+	case '~':
+		switch params {
+		case "1", "7":
+			return keyHome
+		case "4", "8":
+			return keyEnd
+		case "3":
+			return keyDelete
+		}
+	}
+	return keyUnknown
+}
+
+// insert inserts a rune at the cursor.
+func (s *state) insert(r rune) {
+	s.buf = append(s.buf, 0)
+	copy(s.buf[s.pos+1:], s.buf[s.pos:])
+	s.buf[s.pos] = r
+	s.pos++
+}
+
+// delete deletes the runes between positions i and j, moving the cursor
+// to i.
+func (s *state) delete(i, j int) {
+	i, j = max(i, 0), min(j, len(s.buf))
+	if i >= j {
+		return
+	}
+	s.buf = append(s.buf[:i], s.buf[j:]...)
+	s.pos = i
+}
+
+// set replaces the line, moving the cursor to its end.
+func (s *state) set(line string) {
+	s.buf = []rune(line)
+	s.pos = len(s.buf)
+}
+
+// wordStart returns the position of the start of the word preceding the
+// cursor.
+func (s *state) wordStart() int {
+	i := s.pos
+	for i > 0 && unicode.IsSpace(s.buf[i-1]) {
+		i--
+	}
+	for i > 0 && !unicode.IsSpace(s.buf[i-1]) {
+		i--
+	}
+	return i
+}
+
+// recall replaces the line with the history line at position i. Moving
+// past the most recent history line restores the typed line.
+func (s *state) recall(i int) {
+	if i < 0 || i > len(s.history) || i == s.hpos {
+		return
+	}
+	if s.hpos == len(s.history) {
+		s.saved = string(s.buf)
+	}
+	s.hpos = i
+	if i == len(s.history) {
+		s.set(s.saved)
+	} else {
+		s.set(s.history[i])
+	}
+}
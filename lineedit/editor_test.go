@@ -0,0 +1,141 @@
+package lineedit
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/beevik/cmd"
+)
+
+func buildTree() *cmd.Tree {
+	tree := cmd.NewTree(cmd.TreeDescriptor{Name: "tree"})
+	tree.AddCommand(cmd.CommandDescriptor{Name: "quit", MaxArgs: cmd.NoArgs})
+	tree.AddCommand(cmd.CommandDescriptor{Name: "echo"})
+	file := tree.AddSubtree(cmd.TreeDescriptor{Name: "file"})
+	file.AddCommand(cmd.CommandDescriptor{Name: "open"})
+	file.AddCommand(cmd.CommandDescriptor{Name: "opus"})
+	return tree
+}
+
+func newEditor(keys string) *Editor {
+	h := new(cmd.History)
+	h.Add("echo one")
+	h.Add("file open x")
+	return &Editor{
+		Complete: buildTree().Completions,
+		History:  h,
+		in:       bufio.NewReader(strings.NewReader(keys)),
+		out:      io.Discard,
+	}
+}
+
+func TestEdit(t *testing.T) {
+	cases := []struct {
+		keys    string
+		line    string
+		suggest bool
+		err     error
+	}{
+		{"echo hi\r", "echo hi", false, nil},
+		{"echo hi", "echo hi", false, nil},
+		{"ecoh\x7f\x7fho\r", "echo", false, nil},
+		{"cho\x01e\r", "echo", false, nil},
+		{"ac\x1b[Db\x1b[C\x1b[Cd\r", "abcd", false, nil},
+		{"abcd\x1b[H\x1b[3~\x1b[F\x08\r", "bc", false, nil},
+		{"abcd\x02\x02\x0b\r", "ab", false, nil},
+		{"abcd\x02\x15\r", "d", false, nil},
+		{"file open x\x17\x17\r", "file ", false, nil},
+		{"ab\x02\x04\r", "a", false, nil},
+		{"\x04", "", false, io.EOF},
+		{"junk\x03", "", false, nil},
+		{"\x1b[A\r", "file open x", false, nil},
+		{"\x1b[A\x1b[A\x1b[A\r", "echo one", false, nil},
+		{"new\x10\x0e\r", "new", false, nil},
+		{"fi\t\r", "file ", false, nil},
+		{"fi\top\t\r", "file open", false, nil},
+		{"fi\top\t\t\r", "file opus", false, nil},
+		{"fi\top\t\t\t\r", "file open", false, nil},
+		{"fi\top\t\x1b[Z\r", "file opus", false, nil},
+		{"x\t\r", "x", false, nil},
+		{"ec\x1b[C\r", "ec", false, nil},
+		{"ec\x1b[C\r", "echo one", true, nil},
+		{"\x1bxa\r", "a", false, nil},
+	}
+
+	for i, c := range cases {
+		e := newEditor(c.keys)
+		e.Autosuggest = c.suggest
+		line, err := e.edit("> ")
+		if line != c.line || err != c.err {
+			t.Errorf("Case %d: got %q, %v, wanted %q, %v", i, line, err, c.line, c.err)
+		}
+	}
+}
+
+func TestAttach(t *testing.T) {
+	out := new(strings.Builder)
+	r := cmd.NewRunner(buildTree(), nil, out)
+	tree := r.Tree
+	tree.AddCommand(cmd.CommandDescriptor{
+		Name: "say",
+		Handler: func(ctx *cmd.ExecContext, args []string) error {
+			ctx.Println(strings.Join(args, " "))
+			return nil
+		},
+	})
+
+	New(strings.NewReader("say a\nsay b\n"), out).Attach(r)
+	if err := r.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); got != "> a\n> b\n> " {
+		t.Errorf("unexpected output: %q", got)
+	}
+	if got := strings.Join(r.History.Lines(), ","); got != "say a,say b" {
+		t.Errorf("unexpected history: %q", got)
+	}
+}
+
+func TestReadSecret(t *testing.T) {
+	cases := []struct {
+		keys   string
+		secret string
+		err    error
+	}{
+		{"hunter2\r", "hunter2", nil},
+		{"huntx\x7fer2\r", "hunter2", nil},
+		{"abc\x15xyz\r", "xyz", nil},
+		{"abc\x03", "", cmd.ErrInterrupted},
+		{"\x04", "", io.EOF},
+		{"abc", "abc", nil},
+	}
+
+	for i, c := range cases {
+		out := new(strings.Builder)
+		e := newEditor(c.keys)
+		e.out = out
+		s, err := e.readSecret("Password: ")
+		if s != c.secret || err != c.err {
+			t.Errorf("Case %d: got %q, %v, wanted %q, %v", i, s, err, c.secret, c.err)
+		}
+		if strings.Contains(out.String(), "abc") || strings.Contains(out.String(), "hunter") {
+			t.Errorf("Case %d: secret echoed: %q", i, out.String())
+		}
+	}
+}
+
+func TestNewTerminal(t *testing.T) {
+	out := new(strings.Builder)
+	e := NewTerminal(strings.NewReader("ecx\x7fho\rpw\r"), out)
+	if line, err := e.ReadLine("> "); line != "echo" || err != nil {
+		t.Errorf("unexpected line %q, %v", line, err)
+	}
+	if s, err := e.ReadSecret("Password: "); s != "pw" || err != nil {
+		t.Errorf("unexpected secret %q, %v", s, err)
+	}
+	if !strings.Contains(out.String(), "\x1b[K") || strings.Contains(out.String(), "pw") {
+		t.Errorf("unexpected output: %q", out.String())
+	}
+}
@@ -0,0 +1,276 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/beevik/prefixtree/v2"
+)
+
+// A LookupResult describes how a line was matched to a node of a command
+// tree.
+type LookupResult struct {
+	Node      Node     // the matched command or subtree
+	Path      string   // full, unabbreviated path of the node
+	Tokens    []string // fields of the line matched to the node, as typed
+	Shortcut  bool     // the line used a shortcut to reach the node
+	Prefix    bool     // the line abbreviated a name or shortcut
+	Priority  bool     // an ambiguous prefix was resolved by priority
+	Args      []string // fields of the line following the matched fields
+	Remainder string   // unparsed text of the line following the matched fields
+}
+
+// ErrUnterminatedQuote is returned by Resolve when a line contains a quoted
+// string lacking a closing quote.
+var ErrUnterminatedQuote = errors.New("Unterminated quoted string")
+
+// A PositionError describes a token of a line that couldn't be resolved,
+// so that its location may be indicated to the user.
+type PositionError struct {
+	Offset int    // byte offset of the token in the line
+	Token  string // the token, as typed, including any quotes
+	Err    error  // reason the token couldn't be resolved
+}
+
+func (e *PositionError) Error() string {
+	if e.Token == "" {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%v '%s'", e.Err, e.Token)
+}
+
+func (e *PositionError) Unwrap() error {
+	return e.Err
+}
+
+// ErrUnknownSubcommand is wrapped by a *SubcommandError.
+var ErrUnknownSubcommand = errors.New("Unknown subcommand")
+
+// A SubcommandError is returned by lookups of a line naming a subtree
+// followed by a field that matches none of the subtree's commands and
+// subtrees, if enabled by SetSubcommandErrors. It wraps both
+// ErrUnknownSubcommand and ErrNotFound.
+type SubcommandError struct {
+	Tree *Tree  // the subtree named by the line
+	Name string // the unmatched field
+}
+
+func (e *SubcommandError) Error() string {
+	return fmt.Sprintf("%v '%s %s'", ErrUnknownSubcommand, e.Tree.Path(), e.Name)
+}
+
+func (e *SubcommandError) Unwrap() []error {
+	return []error{ErrUnknownSubcommand, ErrNotFound}
+}
+
+// SetSubcommandErrors sets whether lookups in the entire command tree
+// containing t report a line naming a subtree followed by an unknown field,
+// such as "file xyzzy", with a *SubcommandError rather than ErrNotFound.
+// The error carries the subtree, so that its commands may be listed as
+// guidance. A Runner displays the subtree's help after the error message.
+func (t *Tree) SetSubcommandErrors(enabled bool) {
+	t.root().subcmdErrors = enabled
+}
+
+// Resolve performs the same search as Lookup, but returns a detailed
+// description of the match, suitable for highlighting the matched portion
+// of the line or for recording the command executed.
+//
+// Unlike Lookup, Resolve rejects lines containing unterminated quoted
+// strings. Errors are returned as a *PositionError identifying the
+// offending token and wrapping ErrNotFound, ErrAmbiguous,
+// ErrUnterminatedQuote, ErrLineTooLong or a *SubcommandError.
+func (t *Tree) Resolve(line string) (LookupResult, error) {
+	if t.tooLong(line) {
+		return LookupResult{}, &PositionError{t.MaxLineLength(), "", ErrLineTooLong}
+	}
+
+	spans := splitSpans(line)
+	for _, s := range spans {
+		if s.Kind == SpanQuoted && (s.End-s.Start < 2 || line[s.End-1] != '"') {
+			return LookupResult{}, &PositionError{s.Start, line[s.Start:s.End], ErrUnterminatedQuote}
+		}
+	}
+
+	r := LookupResult{Args: []string{}}
+	if err := t.resolve(line, &r, true); err != nil {
+		// The lookup fails at the field following the matched tokens.
+		if i := len(r.Tokens); i < len(spans) {
+			s := spans[i]
+			return LookupResult{}, &PositionError{s.Start, line[s.Start:s.End], err}
+		}
+		return LookupResult{}, &PositionError{len(line), "", err}
+	}
+	r.Path = nodePath(r.Node)
+	return r, nil
+}
+
+// resolve looks up the line, filling in the node and arguments of the
+// result. If detail is true, the result's tokens, shortcut and prefix
+// fields and remainder are filled in as well.
+func (t *Tree) resolve(line string, r *LookupResult, detail bool) error {
+	if t.tooLong(line) {
+		return ErrLineTooLong
+	}
+	field, remain := nextField(stripLeadingWhitespace(line))
+	if field == "" {
+		return ErrNotFound
+	}
+
+	cur := t
+	for first := true; ; first = false {
+		if sc, rest := matchPhrase(cur.phrases, field, remain); sc != nil {
+			r.Node, r.Shortcut = sc.Command, true
+			if detail {
+				r.Tokens = appendPhraseTokens(r.Tokens, sc, field, remain)
+			}
+			remain = rest
+			break
+		}
+
+		kv, err := cur.pt.FindKeyValue(field)
+		global := false
+		if err == prefixtree.ErrPrefixNotFound && first {
+			if sc, rest := matchPhrase(t.root().globalPhrases, field, remain); sc != nil {
+				r.Node, r.Shortcut = sc.Command, true
+				if detail {
+					r.Tokens = appendPhraseTokens(r.Tokens, sc, field, remain)
+				}
+				remain = rest
+				break
+			}
+			kv, err = t.findGlobal(field)
+			global = true
+		}
+		if err == prefixtree.ErrPrefixAmbiguous {
+			kv, err = cur.resolvePriority(field, global)
+			r.Priority = r.Priority || err == nil
+		}
+		switch err {
+		case prefixtree.ErrPrefixAmbiguous:
+			return ErrAmbiguous
+		case prefixtree.ErrPrefixNotFound:
+			if !first && t.root().subcmdErrors {
+				return &SubcommandError{cur, field}
+			}
+			return ErrNotFound
+		}
+
+		if detail {
+			r.Tokens = append(r.Tokens, field)
+			r.Shortcut = r.Shortcut || isShortcut(kv, cur, global)
+			r.Prefix = r.Prefix || field != kv.Key
+		}
+
+		if _, ok := kv.Value.(*Command); ok {
+			r.Node = kv.Value
+			break
+		}
+
+		subtree := kv.Value.(*Tree)
+		if remain == "" {
+			r.Node = subtree
+			break
+		}
+
+		field, remain = nextField(remain)
+		cur = subtree
+	}
+
+	if detail {
+		r.Remainder = remain
+	}
+	for remain != "" {
+		field, remain = nextField(remain)
+		r.Args = append(r.Args, field)
+	}
+	return nil
+}
+
+// appendPhraseTokens appends the fields of the line matched by a multi-word
+// shortcut. The line is given as its first field and the remainder.
+func appendPhraseTokens(tokens []string, sc *Shortcut, field, remain string) []string {
+	tokens = append(tokens, field)
+	for n := strings.Count(sc.Name, " "); n > 0; n-- {
+		field, remain = nextField(remain)
+		tokens = append(tokens, field)
+	}
+	return tokens
+}
+
+// Expansions returns the sorted names of the tree's commands and subtrees,
+// and the shortcuts registered on the tree, that begin with the prefix. If
+// none do, the global shortcuts beginning with the prefix are returned. A
+// prefix with more than one expansion is ambiguous, unless it equals one of
+// them exactly. Hidden commands and subtrees are omitted unless named in
+// full.
+func (t *Tree) Expansions(prefix string) []string {
+	matches := visibleMatches(t.pt.FindKeyValues(prefix), prefix)
+	if len(matches) == 0 {
+		matches = visibleMatches(t.findGlobals(prefix), prefix)
+	}
+	names := make([]string, 0, len(matches))
+	for _, m := range matches {
+		names = append(names, m.Key)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// resolvePriority resolves a prefix matching several names of the tree, or
+// several global shortcuts, to the match with the highest priority.
+func (t *Tree) resolvePriority(prefix string, global bool) (prefixtree.KeyValue[Node], error) {
+	matches := t.pt.FindKeyValues(prefix)
+	if global {
+		matches = t.findGlobals(prefix)
+	}
+	if m, ok := prioritized(matches); ok {
+		return m, nil
+	}
+	return prefixtree.KeyValue[Node]{}, prefixtree.ErrPrefixAmbiguous
+}
+
+// prioritized returns the match whose node has a priority exceeding the
+// priorities of all other matched nodes. Matches of the same node, such as
+// its name and one of its shortcuts, count as a single match, preferring
+// the name. A prefix matching a single node isn't resolved by priority.
+func prioritized(matches []prefixtree.KeyValue[Node]) (prefixtree.KeyValue[Node], bool) {
+	best, tie, others := -1, false, false
+	for i, m := range matches {
+		if best < 0 {
+			best = i
+			continue
+		}
+		if m.Value == matches[best].Value {
+			if m.Key == m.Value.name() {
+				best = i
+			}
+			continue
+		}
+		others = true
+		switch p, bp := m.Value.priority(), matches[best].Value.priority(); {
+		case p > bp:
+			best, tie = i, false
+		case p == bp:
+			tie = true
+		}
+	}
+	if best < 0 || tie || !others {
+		return prefixtree.KeyValue[Node]{}, false
+	}
+	return matches[best], true
+}
+
+// isShortcut returns true if a key matched while looking up a field from the
+// tree is a shortcut, rather than the name or an alias of a node.
+func isShortcut(kv prefixtree.KeyValue[Node], t *Tree, global bool) bool {
+	if global || kv.Value.Parent() != t {
+		return true
+	}
+	if c, ok := kv.Value.(*Command); ok && slices.Contains(c.Aliases, kv.Key) {
+		return false
+	}
+	return kv.Key != kv.Value.name()
+}
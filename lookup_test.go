@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	tree := NewTree(TreeDescriptor{Name: "root"})
+	file := tree.AddSubtree(TreeDescriptor{Name: "file"})
+	file.AddCommand(CommandDescriptor{Name: "open"})
+	file.AddCommand(CommandDescriptor{Name: "close"})
+	tree.AddCommand(CommandDescriptor{Name: "quit"})
+	tree.AddShortcut("o", "file open")
+	tree.AddShortcut("fo now", "file open")
+	tree.AddGlobalShortcut("q", "quit")
+
+	cases := []struct {
+		tree      *Tree
+		line      string
+		path      string
+		tokens    string
+		shortcut  bool
+		prefix    bool
+		args      string
+		remainder string
+	}{
+		{tree, "file open a b", "file open", "file|open", false, false, "a|b", "a b"},
+		{tree, "  fi op  \"a b\"", "file open", "fi|op", false, true, "a b", "\"a b\""},
+		{tree, "fil", "file", "fil", false, true, "", ""},
+		{tree, "o x", "file open", "o", true, false, "x", "x"},
+		{tree, "fo now x", "file open", "fo|now", true, false, "x", "x"},
+		{file, "q", "quit", "q", true, false, "", ""},
+		{tree, "qui", "quit", "qui", false, true, "", ""},
+	}
+
+	for i, c := range cases {
+		r, err := c.tree.Resolve(c.line)
+		if err != nil {
+			t.Errorf("Case %d: unexpected error: %v", i, err)
+			continue
+		}
+		if r.Path != c.path || r.Path != nodePath(r.Node) {
+			t.Errorf("Case %d: expected path '%s', got '%s'", i, c.path, r.Path)
+		}
+		if got := strings.Join(r.Tokens, "|"); got != c.tokens {
+			t.Errorf("Case %d: expected tokens '%s', got '%s'", i, c.tokens, got)
+		}
+		if r.Shortcut != c.shortcut || r.Prefix != c.prefix {
+			t.Errorf("Case %d: expected shortcut=%v prefix=%v, got %v %v", i, c.shortcut, c.prefix, r.Shortcut, r.Prefix)
+		}
+		if got := strings.Join(r.Args, "|"); got != c.args {
+			t.Errorf("Case %d: expected args '%s', got '%s'", i, c.args, got)
+		}
+		if r.Remainder != c.remainder {
+			t.Errorf("Case %d: expected remainder '%s', got '%s'", i, c.remainder, r.Remainder)
+		}
+	}
+
+	errs := []struct {
+		line   string
+		offset int
+		token  string
+		err    error
+	}{
+		{"", 0, "", ErrNotFound},
+		{"  bogus x", 2, "bogus", ErrNotFound},
+		{"file \"zz\" x", 5, "\"zz\"", ErrNotFound},
+		{"fi  x", 4, "x", ErrNotFound},
+		{"file open \"a b", 10, "\"a b", ErrUnterminatedQuote},
+		{"file open \"", 10, "\"", ErrUnterminatedQuote},
+	}
+	for i, c := range errs {
+		_, err := tree.Resolve(c.line)
+		var perr *PositionError
+		if !errors.As(err, &perr) || !errors.Is(err, c.err) || perr.Offset != c.offset || perr.Token != c.token {
+			t.Errorf("Error case %d: expected %v at %d '%s', got %#v", i, c.err, c.offset, c.token, err)
+		}
+	}
+}
+
+func TestExpansions(t *testing.T) {
+	tree := NewTree(TreeDescriptor{Name: "root"})
+	file := tree.AddSubtree(TreeDescriptor{Name: "file"})
+	file.AddCommand(CommandDescriptor{Name: "open"})
+	file.AddCommand(CommandDescriptor{Name: "close"})
+	file.AddCommand(CommandDescriptor{Name: "copy"})
+	file.AddCommand(CommandDescriptor{Name: "cut", Tags: []string{"secret"}})
+	tree.AddCommand(CommandDescriptor{Name: "quit"})
+	tree.AddCommand(CommandDescriptor{Name: "quiet"})
+	tree.AddShortcut("fo", "file open")
+	tree.AddGlobalShortcut("q", "quit")
+	tree.SetHiddenTags("secret")
+
+	cases := []struct {
+		tree     *Tree
+		prefix   string
+		expected string
+	}{
+		{tree, "f", "file|fo"},
+		{tree, "qu", "quiet|quit"},
+		{tree, "q", "quiet|quit"},
+		{tree, "x", ""},
+		{file, "c", "close|copy"},
+		{file, "cut", "cut"},
+		{file, "q", "q"},
+		{file, "", "close|copy|open"},
+	}
+
+	for i, c := range cases {
+		got := strings.Join(c.tree.Expansions(c.prefix), "|")
+		if got != c.expected {
+			t.Errorf("Case %d: expected '%s', got '%s'", i, c.expected, got)
+		}
+	}
+}
+
+func TestPriority(t *testing.T) {
+	tree := NewTree(TreeDescriptor{Name: "root"})
+	tree.AddCommand(CommandDescriptor{Name: "step", Priority: 10,
+		Args: []Arg{{Name: "mode", Type: EnumType{Values: []string{"into", "over"}}}}})
+	tree.AddCommand(CommandDescriptor{Name: "stack"})
+	tree.AddCommand(CommandDescriptor{Name: "status"})
+	tree.AddCommand(CommandDescriptor{Name: "dump"})
+	tree.AddCommand(CommandDescriptor{Name: "disasm"})
+	tree.AddShortcut("ss", "step")
+
+	cases := []struct {
+		line     string
+		path     string
+		priority bool
+		err      error
+	}{
+		{"s", "step", true, nil},
+		{"sta", "", false, ErrAmbiguous},
+		{"stat", "status", false, nil},
+		{"d", "", false, ErrAmbiguous},
+		{"step", "step", false, nil},
+	}
+
+	for i, c := range cases {
+		r, err := tree.Resolve(c.line)
+		if !errors.Is(err, c.err) || r.Path != c.path || r.Priority != c.priority {
+			t.Errorf("Case %d: expected '%s' %v (%v), got '%s' %v (%v)", i, c.path, c.priority, c.err, r.Path, r.Priority, err)
+		}
+	}
+
+	if got := strings.Join(tree.Autocomplete("s o"), ","); got != "step over" {
+		t.Errorf("Expected completion 'step over', got '%s'", got)
+	}
+	if got := tree.Tokenize("s")[0].Kind; got != SpanCommand {
+		t.Errorf("Expected command span, got %v", got)
+	}
+}
+
+func TestSubcommandErrors(t *testing.T) {
+	tree := NewTree(TreeDescriptor{Name: "app"})
+	file := tree.AddSubtree(TreeDescriptor{Name: "file"})
+	file.AddCommand(CommandDescriptor{Name: "open", Brief: "Open a file"})
+	tree.AddCommand(CommandDescriptor{Name: "quit"})
+
+	cases := []struct {
+		line    string
+		enabled bool
+		subtree *Tree
+	}{
+		{"file xyzzy", false, nil},
+		{"file xyzzy", true, file},
+		{"xyzzy", true, nil},
+		{"file", true, nil},
+	}
+	for i, c := range cases {
+		tree.SetSubcommandErrors(c.enabled)
+		_, _, err := tree.Lookup(c.line)
+		var serr *SubcommandError
+		switch {
+		case c.subtree == nil && errors.As(err, &serr):
+			t.Errorf("Case %d: unexpected subcommand error %v", i, err)
+		case c.subtree != nil && (!errors.As(err, &serr) || serr.Tree != c.subtree || serr.Name != "xyzzy"):
+			t.Errorf("Case %d: expected subcommand error, got %v", i, err)
+		case c.subtree != nil && (!errors.Is(err, ErrNotFound) || !errors.Is(err, ErrUnknownSubcommand)):
+			t.Errorf("Case %d: expected error to wrap ErrNotFound and ErrUnknownSubcommand", i)
+		}
+	}
+
+	var out strings.Builder
+	r := NewRunner(tree, strings.NewReader("file xyzzy\n"), &out)
+	r.Prompt = ""
+	r.Run()
+	if !strings.HasPrefix(out.String(), "Unknown subcommand 'file xyzzy'.\n") || !strings.Contains(out.String(), "Open a file") {
+		t.Errorf("Unexpected output %q", out.String())
+	}
+}
@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"strings"
+)
+
+// Mask replaces the values of sensitive arguments and flags in masked
+// command lines.
+const Mask = "******"
+
+// MaskArgs returns a copy of the command-line arguments with the values of
+// the command's sensitive arguments and flags replaced by Mask. Arguments
+// are returned unchanged if the command has no sensitive arguments or
+// flags.
+func (c *Command) MaskArgs(args []string) []string {
+	if !c.hasSensitive() {
+		return args
+	}
+
+	masked := make([]string, len(args))
+	pos := 0
+	flags := c.Flags != nil
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		masked[i] = arg
+		switch {
+		case flags && arg == "--":
+			flags = false
+			continue
+		case flags && strings.HasPrefix(arg, "--") && len(arg) > 2:
+			name, _, hasValue := strings.Cut(arg[2:], "=")
+			f := c.lookupFlag(name)
+			switch {
+			case f == nil || !f.Sensitive:
+			case hasValue:
+				masked[i] = "--" + name + "=" + Mask
+			case f.Type != nil && i+1 < len(args):
+				i++
+				masked[i] = Mask
+			}
+			continue
+		}
+
+		if spec, ok := c.argSpec(pos); ok && spec.Sensitive {
+			masked[i] = Mask
+		}
+		pos++
+	}
+	return masked
+}
+
+// MaskLine returns the line with the values of the sensitive arguments and
+// flags of the command it names replaced by Mask, as the line should
+// appear in histories, transcripts and logs. The line is returned unchanged
+// if it doesn't name a command with sensitive arguments or flags.
+func (t *Tree) MaskLine(line string) string {
+	c, args, err := t.LookupCommand(line)
+	if err != nil || !c.hasSensitive() {
+		return line
+	}
+	spans := splitSpans(line)
+	if len(spans) < len(args) {
+		return line
+	}
+	spans = spans[len(spans)-len(args):]
+
+	var b strings.Builder
+	last := 0
+	for i, m := range c.MaskArgs(args) {
+		if m != args[i] {
+			b.WriteString(line[last:spans[i].Start])
+			b.WriteString(m)
+			last = spans[i].End
+		}
+	}
+	b.WriteString(line[last:])
+	return b.String()
+}
+
+// hasSensitive returns true if any of the command's arguments or flags is
+// sensitive.
+func (c *Command) hasSensitive() bool {
+	for _, a := range c.Args {
+		if a.Sensitive {
+			return true
+		}
+	}
+	for _, f := range c.Flags {
+		if f.Sensitive {
+			return true
+		}
+	}
+	return false
+}
+
+// argSpec returns the specification of the positional argument at index i.
+func (c *Command) argSpec(i int) (Arg, bool) {
+	switch {
+	case i < len(c.Args):
+		return c.Args[i], true
+	case len(c.Args) > 0 && c.Args[len(c.Args)-1].Variadic:
+		return c.Args[len(c.Args)-1], true
+	}
+	return Arg{}, false
+}
+
+// A maskedError is an error whose message has a sensitive value replaced
+// by Mask.
+type maskedError struct {
+	err   error
+	value string
+}
+
+func (e *maskedError) Error() string {
+	return strings.ReplaceAll(e.err.Error(), e.value, Mask)
+}
+
+func (e *maskedError) Unwrap() error {
+	return e.err
+}
+
+// maskError returns err with occurrences of the sensitive value in its
+// message replaced by Mask.
+func maskError(err error, value string) error {
+	if value == "" {
+		return err
+	}
+	return &maskedError{err, value}
+}
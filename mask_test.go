@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func buildMaskTree() *Tree {
+	tree := NewTree(TreeDescriptor{Name: "tree"})
+	tree.AddCommand(CommandDescriptor{
+		Name: "login",
+		Args: []Arg{{Name: "user"}, {Name: "password", Sensitive: true}},
+	})
+	tree.AddCommand(CommandDescriptor{
+		Name: "connect",
+		Args: []Arg{{Name: "host"}},
+		Flags: []Flag{
+			{Name: "password", Type: PathType{}, Sensitive: true},
+			{Name: "pin", Type: IntType{}, Sensitive: true},
+			{Name: "verbose"},
+		},
+		Handler: func(ctx *ExecContext, args []string) error { return nil },
+	})
+	tree.AddCommand(CommandDescriptor{
+		Name: "keys",
+		Args: []Arg{{Name: "key", Sensitive: true, Variadic: true}},
+	})
+	tree.AddCommand(CommandDescriptor{Name: "echo"})
+	tree.AddShortcut("lg", "login")
+	return tree
+}
+
+func TestMaskLine(t *testing.T) {
+	tree := buildMaskTree()
+
+	cases := []struct {
+		line   string
+		masked string
+	}{
+		{"login alice s3cret", "login alice ******"},
+		{"  login  alice   \"my secret\" ", "  login  alice   ****** "},
+		{"lg alice s3cret", "lg alice ******"},
+		{"login alice", "login alice"},
+		{"connect --password s3cret db", "connect --password ****** db"},
+		{"connect db --password=s3cret --verbose", "connect db --password=****** --verbose"},
+		{"connect --verbose db -- --password", "connect --verbose db -- --password"},
+		{"keys a b c", "keys ****** ****** ******"},
+		{"echo s3cret", "echo s3cret"},
+		{"bogus s3cret", "bogus s3cret"},
+	}
+
+	for i, c := range cases {
+		if got := tree.MaskLine(c.line); got != c.masked {
+			t.Errorf("Case %d: MaskLine(%q) = %q, wanted %q", i, c.line, got, c.masked)
+		}
+	}
+}
+
+func TestMaskErrors(t *testing.T) {
+	tree := buildMaskTree()
+	c, args, _ := tree.LookupCommand("connect db --pin 12x4")
+	_, err := c.ParseArgs(args)
+	if err == nil || strings.Contains(err.Error(), "12x4") {
+		t.Errorf("unexpected error: %v", err)
+	}
+	var ae *ArgError
+	if !errors.As(err, &ae) || ae.Value != Mask {
+		t.Errorf("unexpected argument error: %#v", err)
+	}
+}
+
+func TestMaskRunner(t *testing.T) {
+	transcript := new(bytes.Buffer)
+	r := NewRunner(buildMaskTree(), strings.NewReader("connect db --password s3cret\necho hi\n"), io.Discard)
+	r.History = new(History)
+	r.Transcript = transcript
+	r.Run()
+
+	if got := strings.Join(r.History.Lines(), ","); got != "connect db --password ******,echo hi" {
+		t.Errorf("unexpected history: %q", got)
+	}
+	if strings.Contains(transcript.String(), "s3cret") {
+		t.Errorf("transcript contains secret: %q", transcript.String())
+	}
+}
+
+type unlockCmd struct {
+	Key string `arg:"key,sensitive"`
+}
+
+func (c *unlockCmd) Run(ctx *ExecContext) error {
+	return nil
+}
+
+func TestMaskStruct(t *testing.T) {
+	tree := NewTree(TreeDescriptor{Name: "tree"})
+	app := struct {
+		Unlock unlockCmd `cmd:"unlock"`
+	}{}
+	if err := RegisterStruct(tree, &app); err != nil {
+		t.Fatal(err)
+	}
+	if got := tree.MaskLine("unlock abc"); got != "unlock ******" {
+		t.Errorf("unexpected masked line: %q", got)
+	}
+}
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"errors"
+	"time"
+)
+
+// Metrics receives measurements of the commands executed by a runner, so
+// that their usage and duration may be monitored. Commands are identified
+// by their canonical path, as in "file open". Implementations must be safe
+// for concurrent use, since background jobs report their measurements
+// concurrently.
+type Metrics interface {
+	// IncExecuted counts an execution of the command.
+	IncExecuted(path string)
+
+	// ObserveDuration records the time taken by an execution of the
+	// command.
+	ObserveDuration(path string, d time.Duration)
+
+	// IncError counts an execution of the command that returned an error.
+	IncError(path string)
+}
+
+// recordMetrics reports the execution of the command, started at the
+// given time and returning the error, to the runner's metrics.
+func (r *Runner) recordMetrics(path string, start time.Time, err error) {
+	r.Metrics.IncExecuted(path)
+	r.Metrics.ObserveDuration(path, time.Since(start))
+	if err != nil && !errors.Is(err, ErrExit) {
+		r.Metrics.IncError(path)
+	}
+}
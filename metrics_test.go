@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeMetrics struct {
+	mu        sync.Mutex
+	executed  map[string]int
+	errors    map[string]int
+	durations map[string]int
+}
+
+func newFakeMetrics() *fakeMetrics {
+	return &fakeMetrics{
+		executed:  make(map[string]int),
+		errors:    make(map[string]int),
+		durations: make(map[string]int),
+	}
+}
+
+func (m *fakeMetrics) IncExecuted(path string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.executed[path]++
+}
+
+func (m *fakeMetrics) ObserveDuration(path string, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.durations[path]++
+}
+
+func (m *fakeMetrics) IncError(path string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.errors[path]++
+}
+
+func TestMetrics(t *testing.T) {
+	tree := NewTree(TreeDescriptor{Name: "tree"})
+	file := tree.AddSubtree(TreeDescriptor{Name: "file"})
+	file.AddCommand(CommandDescriptor{
+		Name:    "open",
+		Aliases: []string{"o"},
+		Handler: func(ctx *ExecContext, args []string) error { return nil },
+	})
+	tree.AddCommand(CommandDescriptor{
+		Name:    "fail",
+		MaxArgs: NoArgs,
+		Handler: func(ctx *ExecContext, args []string) error { return errors.New("Failed") },
+	})
+	tree.AddCommand(CommandDescriptor{
+		Name:    "quit",
+		Handler: func(ctx *ExecContext, args []string) error { return ErrExit },
+	})
+	tree.AddCommand(CommandDescriptor{
+		Name:    "secret",
+		Tags:    []string{"admin"},
+		Handler: func(ctx *ExecContext, args []string) error { return nil },
+	})
+
+	m := newFakeMetrics()
+	r := NewRunner(tree, nil, io.Discard)
+	r.Metrics = m
+	r.DisabledTags = []string{"admin"}
+	for _, line := range []string{"file open", "file o", "fail", "fail x", "quit", "secret", "bogus"} {
+		r.Execute(line)
+	}
+
+	cases := []struct {
+		m        map[string]int
+		expected string
+	}{
+		{m.executed, "map[fail:2 file open:2 quit:1]"},
+		{m.durations, "map[fail:2 file open:2 quit:1]"},
+		{m.errors, "map[fail:2]"},
+	}
+	for i, c := range cases {
+		if got := fmt.Sprint(c.m); got != c.expected {
+			t.Errorf("Case %d: expected %s, got %s", i, c.expected, got)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"errors"
+	"slices"
+	"sync"
+)
+
+// Errors returned by a ModuleManager.
+var (
+	ErrModuleLoaded   = errors.New("Module already loaded")
+	ErrModuleNotFound = errors.New("Module not loaded")
+)
+
+// A CommandModule is a set of commands that may be loaded into a command
+// tree at runtime by a ModuleManager.
+type CommandModule interface {
+	// Name returns the module's unique name.
+	Name() string
+
+	// Register adds the module's commands, subtrees and shortcuts to the
+	// tree.
+	Register(parent *Tree) error
+
+	// Unregister is called before the module's nodes are removed from the
+	// tree, so that the module may release its resources.
+	Unregister(parent *Tree) error
+}
+
+// A ModuleManager loads command modules into a tree and unloads them,
+// tracking the commands, subtrees and shortcuts added by each module so
+// that unloading removes them.
+type ModuleManager struct {
+	tree    *Tree
+	mu      sync.Mutex
+	modules []*loadedModule
+}
+
+// A loadedModule is a module loaded by a ModuleManager and the nodes it
+// added.
+type loadedModule struct {
+	module    CommandModule
+	commands  []*Command
+	subtrees  []*Tree
+	shortcuts []Shortcut
+}
+
+// NewModuleManager creates a module manager that loads modules into the
+// tree.
+func NewModuleManager(tree *Tree) *ModuleManager {
+	return &ModuleManager{tree: tree}
+}
+
+// Load registers the module with the manager's tree. If registration fails,
+// the nodes added by the module are removed and the error is returned.
+func (m *ModuleManager) Load(mod CommandModule) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.find(mod.Name()) >= 0 {
+		return ErrModuleLoaded
+	}
+
+	l := &loadedModule{module: mod}
+	stop := m.tree.Observe(l.track)
+	err := mod.Register(m.tree)
+	stop()
+	if err != nil {
+		l.remove()
+		return err
+	}
+	m.modules = append(m.modules, l)
+	return nil
+}
+
+// Unload unregisters the named module and removes the commands, subtrees
+// and shortcuts it added. Nodes the module added and that have since been
+// removed are skipped. The nodes are removed even if the module's
+// Unregister method returns an error, which Unload then returns.
+func (m *ModuleManager) Unload(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i := m.find(name)
+	if i < 0 {
+		return ErrModuleNotFound
+	}
+
+	l := m.modules[i]
+	m.modules = slices.Delete(m.modules, i, i+1)
+	err := l.module.Unregister(m.tree)
+	l.remove()
+	return err
+}
+
+// Modules returns the loaded modules, in the order they were loaded.
+func (m *ModuleManager) Modules() []CommandModule {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	mods := make([]CommandModule, len(m.modules))
+	for i, l := range m.modules {
+		mods[i] = l.module
+	}
+	return mods
+}
+
+func (m *ModuleManager) find(name string) int {
+	return slices.IndexFunc(m.modules, func(l *loadedModule) bool {
+		return l.module.Name() == name
+	})
+}
+
+// track records the nodes added to the tree while the module registers.
+// Nodes added within subtrees added by the module aren't recorded, since
+// they are removed along with the subtrees.
+func (l *loadedModule) track(e Event) {
+	switch e := e.(type) {
+	case CommandAdded:
+		if !l.contains(e.Command.parent) {
+			l.commands = append(l.commands, e.Command)
+		}
+	case SubtreeAdded:
+		if !l.contains(e.Subtree.parent) {
+			l.subtrees = append(l.subtrees, e.Subtree)
+		}
+	case SubtreeMounted:
+		if !l.contains(e.Subtree.parent) {
+			l.subtrees = append(l.subtrees, e.Subtree)
+		}
+	case ShortcutAdded:
+		l.shortcuts = append(l.shortcuts, e.Shortcut)
+	}
+}
+
+// contains returns true if t is, or is contained in, a subtree added by the
+// module.
+func (l *loadedModule) contains(t *Tree) bool {
+	for ; t != nil; t = t.parent {
+		if slices.Contains(l.subtrees, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// remove removes the nodes added by the module that are still attached to
+// the command tree, in the reverse order of their addition.
+func (l *loadedModule) remove() {
+	for _, sc := range slices.Backward(l.shortcuts) {
+		if slices.Contains(sc.Tree.shortcuts, sc) {
+			removed := sc.Tree.removeShortcuts(func(x Shortcut) bool { return x == sc })
+			sc.Tree.emitRemoved(removed)
+		}
+	}
+	for _, c := range slices.Backward(l.commands) {
+		if slices.Contains(c.parent.commands, c) {
+			c.remove()
+		}
+	}
+	for _, st := range slices.Backward(l.subtrees) {
+		if st.parent != nil && slices.Contains(st.parent.subtrees, st) {
+			st.remove()
+		}
+	}
+}
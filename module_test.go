@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type testModule struct {
+	name         string
+	registerErr  error
+	unregistered bool
+}
+
+func (m *testModule) Name() string { return m.name }
+
+func (m *testModule) Register(parent *Tree) error {
+	mem := parent.AddSubtree(TreeDescriptor{Name: m.name})
+	mem.AddCommand(CommandDescriptor{Name: "read"})
+	mem.AddCommand(CommandDescriptor{Name: "write"})
+	parent.AddGlobalShortcut(m.name[:1]+"r", m.name+" read")
+
+	file, _, _ := parent.LookupSubtree("file")
+	file.AddCommand(CommandDescriptor{Name: m.name + "dump"})
+	file.AddShortcut("o", "open")
+	return m.registerErr
+}
+
+func (m *testModule) Unregister(parent *Tree) error {
+	m.unregistered = true
+	return nil
+}
+
+func newModuleTree() *Tree {
+	tree := NewTree(TreeDescriptor{Name: "app"})
+	file := tree.AddSubtree(TreeDescriptor{Name: "file"})
+	file.AddCommand(CommandDescriptor{Name: "open"})
+	tree.AddCommand(CommandDescriptor{Name: "quit"})
+	return tree
+}
+
+// treeContents returns a description of the tree's nodes and shortcuts.
+func treeContents(t *Tree) string {
+	var parts []string
+	var walk func(t *Tree)
+	walk = func(t *Tree) {
+		for _, n := range t.sortedNodes() {
+			parts = append(parts, nodePath(n))
+			if st, ok := n.(*Tree); ok {
+				walk(st)
+			}
+		}
+	}
+	walk(t)
+	for _, sc := range t.Shortcuts() {
+		parts = append(parts, "@"+sc.Name)
+	}
+	return strings.Join(parts, ",")
+}
+
+func TestModuleManager(t *testing.T) {
+	tree := newModuleTree()
+	before := treeContents(tree)
+	m := NewModuleManager(tree)
+
+	mem := &testModule{name: "mem"}
+	if err := m.Load(mem); err != nil {
+		t.Fatalf("Load: unexpected error %v", err)
+	}
+	expected := "file,file memdump,file open,mem,mem read,mem write,quit,@o,@mr"
+	if got := treeContents(tree); got != expected {
+		t.Errorf("Loaded: expected %q, got %q", expected, got)
+	}
+	if err := m.Load(&testModule{name: "mem"}); err != ErrModuleLoaded {
+		t.Errorf("Load: expected ErrModuleLoaded, got %v", err)
+	}
+	if mods := m.Modules(); len(mods) != 1 || mods[0] != mem {
+		t.Errorf("Modules: unexpected %v", mods)
+	}
+
+	tree.RemoveCommand("mem write")
+	if err := m.Unload("mem"); err != nil {
+		t.Errorf("Unload: unexpected error %v", err)
+	}
+	if got := treeContents(tree); got != before {
+		t.Errorf("Unloaded: expected %q, got %q", before, got)
+	}
+	if !mem.unregistered {
+		t.Errorf("Unload: module not unregistered")
+	}
+	if err := m.Unload("mem"); err != ErrModuleNotFound {
+		t.Errorf("Unload: expected ErrModuleNotFound, got %v", err)
+	}
+
+	failing := &testModule{name: "io", registerErr: errors.New("No device")}
+	if err := m.Load(failing); err == nil || err.Error() != "No device" {
+		t.Errorf("Load: expected registration error, got %v", err)
+	}
+	if got := treeContents(tree); got != before {
+		t.Errorf("Failed load: expected %q, got %q", before, got)
+	}
+	if len(m.Modules()) != 0 {
+		t.Errorf("Failed load: expected no modules, got %v", m.Modules())
+	}
+}
+
+func TestRemoveSubtree(t *testing.T) {
+	tree := newModuleTree()
+	tree.AddGlobalShortcut("fo", "file open")
+	if err := tree.RemoveSubtree("quit"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound removing a command, got %v", err)
+	}
+	if err := tree.RemoveSubtree("fi"); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if got := treeContents(tree); got != "quit" {
+		t.Errorf("Expected only quit, got %q", got)
+	}
+
+	file := NewTree(TreeDescriptor{Name: "file"})
+	if err := tree.Mount(file); err != nil {
+		t.Errorf("Expected name to be reusable, got %v", err)
+	}
+}
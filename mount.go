@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// Errors returned when mounting trees.
+var (
+	ErrCycle    = errors.New("Tree would contain itself")
+	ErrMounted  = errors.New("Tree already has a parent")
+	ErrMaxDepth = errors.New("Maximum tree depth exceeded")
+)
+
+// SetMaxDepth limits the depth of the subtrees of the entire command tree
+// containing t, as reported by Depth. Mount refuses to exceed the limit, and
+// Validate reports subtrees exceeding it. A limit of zero, the default,
+// leaves the depth unlimited.
+func (t *Tree) SetMaxDepth(n int) {
+	t.root().maxDepth = n
+}
+
+// MaxDepth returns the maximum depth of the command tree, or zero if the
+// depth is unlimited.
+func (t *Tree) MaxDepth() int {
+	return t.root().maxDepth
+}
+
+// Depth returns the number of ancestors of the tree. The depth of the root
+// tree is zero.
+func (t *Tree) Depth() int {
+	d := 0
+	for p := t.parent; p != nil; p = p.parent {
+		d++
+	}
+	return d
+}
+
+// height returns the number of levels of subtrees below the tree.
+func (t *Tree) height() int {
+	h := 0
+	for _, st := range t.subtrees {
+		h = max(h, st.height()+1)
+	}
+	return h
+}
+
+// Mount adds an existing command tree, created by NewTree, to t as a
+// subtree. The mounted tree keeps its commands, subtrees and shortcuts; its
+// global shortcuts become global shortcuts of t's command tree. Settings of
+// the mounted tree that apply to an entire command tree, such as its locale
+// and help width, are replaced by those of t's command tree.
+//
+// Mount fails without modifying either tree if sub already has a parent,
+// if sub is the root of t's command tree, if sub's name is already used in
+// t, or if the mounted tree would exceed the command tree's maximum depth.
+func (t *Tree) Mount(sub *Tree) error {
+	switch {
+	case sub.parent != nil:
+		return ErrMounted
+	case sub == t.root():
+		return ErrCycle
+	case slices.Contains(t.lookupKeys(), sub.Name):
+		return &ConflictError{t, sub.Name, sub.Name}
+	}
+	if max := t.MaxDepth(); max > 0 && t.Depth()+1+sub.height() > max {
+		return ErrMaxDepth
+	}
+
+	// Global shortcuts are registered on the root of the command tree.
+	var globals []Shortcut
+	sub.removeShortcuts(func(sc Shortcut) bool {
+		if sc.Global {
+			globals = append(globals, sc)
+		}
+		return sc.Global
+	})
+
+	observers := sub.observers
+	sub.observers = nil
+	sub.parent = t
+	t.subtrees = append(t.subtrees, sub)
+	t.pt.Add(sub.Name, sub)
+	sub.resetCache()
+	t.invalidate()
+
+	r := t.root()
+	for _, sc := range globals {
+		sc.Tree = r
+		sc.Command.addShortcut(sc.Name)
+		r.shortcuts = append(r.shortcuts, sc)
+		r.indexShortcut(sc)
+	}
+	r.observers = append(r.observers, observers...)
+	t.emit(SubtreeMounted{sub})
+	return nil
+}
+
+// resetCache discards the cached views of the tree and its descendants.
+func (t *Tree) resetCache() {
+	t.cache.nodes.Store(nil)
+	t.cache.shortcuts.Store(nil)
+	for _, st := range t.subtrees {
+		st.resetCache()
+	}
+}
+
+// validateDepth appends an error for each subtree exceeding the maximum
+// depth of the command tree.
+func (t *Tree) validateDepth(errs *[]error, depth, max int) {
+	for _, st := range t.subtrees {
+		if depth+1 > max {
+			*errs = append(*errs, fmt.Errorf("%w: '%s'", ErrMaxDepth, st.Path()))
+			continue
+		}
+		st.validateDepth(errs, depth+1, max)
+	}
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMount(t *testing.T) {
+	tree := NewTree(TreeDescriptor{Name: "root"})
+	tree.AddSubtree(TreeDescriptor{Name: "file"})
+
+	sub := NewTree(TreeDescriptor{Name: "net"})
+	sub.AddCommand(CommandDescriptor{Name: "ping"})
+	tcp := sub.AddSubtree(TreeDescriptor{Name: "tcp"})
+	tcp.AddCommand(CommandDescriptor{Name: "dial"})
+	sub.AddShortcut("p", "ping")
+	sub.AddGlobalShortcut("d", "tcp dial")
+
+	tree.SetMaxDepth(2)
+	cases := []struct {
+		tree *Tree
+		sub  *Tree
+		err  error
+	}{
+		{tree, tree, ErrCycle},
+		{tree, tcp, ErrMounted},
+		{tree, NewTree(TreeDescriptor{Name: "file"}), ErrConflict},
+		{tree.Subtrees()[0], sub, ErrMaxDepth},
+		{tree, sub, nil},
+		{tree, sub, ErrMounted},
+	}
+	for i, c := range cases {
+		if err := c.tree.Mount(c.sub); !errors.Is(err, c.err) {
+			t.Errorf("Case %d: expected %v, got %v", i, c.err, err)
+		}
+	}
+
+	lookups := []struct {
+		tree *Tree
+		line string
+		path string
+	}{
+		{tree, "net ping", "net ping"},
+		{tree, "net p", "net ping"},
+		{tree, "d", "net tcp dial"},
+		{tree.Subtrees()[0], "d", "net tcp dial"},
+	}
+	for i, c := range lookups {
+		n, _, err := c.tree.Lookup(c.line)
+		if err != nil || nodePath(n) != c.path {
+			t.Errorf("Lookup case %d: expected '%s', got %v (%v)", i, c.path, n, err)
+		}
+	}
+	if tcp.Depth() != 2 {
+		t.Errorf("Expected depth 2, got %d", tcp.Depth())
+	}
+
+	tcp.AddSubtree(TreeDescriptor{Name: "opts"})
+	if err := tree.Validate(); !errors.Is(err, ErrMaxDepth) {
+		t.Errorf("Expected ErrMaxDepth from Validate, got %v", err)
+	}
+}
@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"errors"
+	"slices"
+	"strings"
+)
+
+// Rename renames the command or subtree found at oldPath, which is looked
+// up from t, to newName. The node keeps its shortcuts, its position among
+// its siblings and, for a subtree, its children. Rename fails without
+// modifying the tree if oldPath doesn't name a node or if newName is
+// already used by one of the node's siblings.
+//
+// To keep the old name usable, add it as a shortcut once the node has been
+// renamed:
+//
+//	tree.Rename("file ls", "list")
+//	tree.AddShortcut("file ls", "file list")
+func (t *Tree) Rename(oldPath, newName string) error {
+	if len(strings.Fields(newName)) != 1 || strings.Contains(newName, "\"") {
+		return errors.New("invalid name")
+	}
+
+	n, args, err := t.Lookup(oldPath)
+	if err != nil {
+		return err
+	}
+	if len(args) > 0 {
+		return ErrNotFound
+	}
+
+	parent := n.Parent()
+	if parent == nil {
+		return errors.New("cannot rename the root tree")
+	}
+	if n.name() != newName {
+		for _, k := range parent.lookupKeys() {
+			if k == newName {
+				return &ConflictError{parent, newName, k}
+			}
+		}
+	}
+
+	oldName := n.name()
+	switch n := n.(type) {
+	case *Command:
+		n.Name = newName
+	case *Tree:
+		n.Name = newName
+	}
+	parent.reindex()
+	t.emit(NodeRenamed{n, oldName})
+	return nil
+}
+
+// ReplaceCommand replaces the descriptor of the command found at path, which
+// is looked up from t, and returns the command's previous descriptor. The
+// command keeps its shortcuts and its position in the tree, so that existing
+// references to the *Command remain valid. If the new descriptor has no
+// name, the command keeps its current name; otherwise the command is renamed
+// as described by Rename.
+//
+// To wrap a command rather than override it, call the previous descriptor's
+// handler from the new handler.
+func (t *Tree) ReplaceCommand(path string, d CommandDescriptor) (CommandDescriptor, error) {
+	c, args, err := t.LookupCommand(path)
+	if err != nil {
+		return CommandDescriptor{}, err
+	}
+	if len(args) > 0 {
+		return CommandDescriptor{}, ErrNotFound
+	}
+
+	old := c.CommandDescriptor
+	if d.Name == "" {
+		d.Name = old.Name
+	}
+	if d.Name != old.Name {
+		if err := c.parent.Rename(old.Name, d.Name); err != nil {
+			return CommandDescriptor{}, err
+		}
+	}
+	c.CommandDescriptor = d
+	if !slices.Equal(d.Aliases, old.Aliases) {
+		c.parent.reindex()
+	}
+	t.emit(CommandReplaced{c, old})
+	return old, nil
+}
+
+// RemoveCommand removes the command found at path, which is looked up from
+// t, along with all shortcuts to the command.
+func (t *Tree) RemoveCommand(path string) error {
+	c, args, err := t.LookupCommand(path)
+	if err != nil {
+		return err
+	}
+	if len(args) > 0 {
+		return ErrNotFound
+	}
+	c.remove()
+	return nil
+}
+
+// RemoveSubtree removes the subtree found at path, which is looked up from
+// t, along with all shortcuts to the subtree's commands. The removed
+// subtree keeps its contents and may be mounted again by Mount.
+func (t *Tree) RemoveSubtree(path string) error {
+	st, args, err := t.LookupSubtree(path)
+	if err != nil {
+		return err
+	}
+	if len(args) > 0 || st.parent == nil {
+		return ErrNotFound
+	}
+	st.remove()
+	return nil
+}
+
+// remove removes the command from its tree, along with all shortcuts to
+// the command.
+func (c *Command) remove() {
+	parent := c.parent
+	parent.removeShortcutsTo(func(x *Command) bool { return x == c })
+	parent.commands = slices.DeleteFunc(parent.commands, func(x *Command) bool { return x == c })
+	parent.reindex()
+	parent.emit(CommandRemoved{c, parent})
+}
+
+// remove detaches the subtree from its parent, removing all shortcuts to
+// the subtree's commands.
+func (t *Tree) remove() {
+	parent := t.parent
+	parent.removeShortcutsTo(func(c *Command) bool { return c.within(t) })
+	parent.subtrees = slices.DeleteFunc(parent.subtrees, func(x *Tree) bool { return x == t })
+	parent.reindex()
+	t.parent = nil
+	t.resetCache()
+	parent.emit(SubtreeRemoved{t, parent})
+}
+
+// removeShortcutsTo removes the shortcuts, anywhere in the command tree, to
+// commands matching the predicate.
+func (t *Tree) removeShortcutsTo(match func(c *Command) bool) {
+	trees := make(map[*Tree]bool)
+	for _, sc := range t.root().sortedShortcuts() {
+		if match(sc.Command) {
+			trees[sc.Tree] = true
+		}
+	}
+	var removed []Shortcut
+	for st := range trees {
+		removed = append(removed, st.removeShortcuts(func(sc Shortcut) bool { return match(sc.Command) })...)
+	}
+	slices.SortFunc(removed, func(a, b Shortcut) int { return strings.Compare(a.Name, b.Name) })
+	t.emitRemoved(removed)
+}
+
+// within returns true if the command is contained in the tree, directly or
+// through a subtree.
+func (c *Command) within(t *Tree) bool {
+	for p := c.parent; p != nil; p = p.parent {
+		if p == t {
+			return true
+		}
+	}
+	return false
+}
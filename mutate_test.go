@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestRename(t *testing.T) {
+	tree := buildTree()
+
+	cases := []struct {
+		old, name string
+		err       string
+	}{
+		{"file open", "load", "<nil>"},
+		{"fi", "disk", "<nil>"},
+		{"disk read", "close", "Duplicate name 'disk close'"},
+		{"disk missing", "x", "Command not found"},
+		{"quit now", "x", "Command not found"},
+		{"quit", "two words", "invalid name"},
+		{"quit", "quit", "<nil>"},
+	}
+	for i, c := range cases {
+		if err := tree.Rename(c.old, c.name); fmt.Sprint(err) != c.err {
+			t.Errorf("Case %d: expected '%s', got '%v'", i, c.err, err)
+		}
+	}
+
+	lookups := []struct {
+		line string
+		path string
+	}{
+		{"disk load", "disk load"},
+		{"di l", "disk load"},
+		{"f", "disk load"},
+		{"dd", "disk load"},
+		{"disk read", "disk read"},
+		{"file", "<nil>"},
+		{"disk open", "<nil>"},
+	}
+	for i, c := range lookups {
+		path := "<nil>"
+		if n, _, err := tree.Lookup(c.line); err == nil {
+			path = nodePath(n)
+		}
+		if path != c.path {
+			t.Errorf("Lookup %d: expected %s, got %s", i, c.path, path)
+		}
+	}
+
+	// Deprecation flow: the old name becomes a shortcut to the renamed
+	// command.
+	disk, _, _ := tree.LookupSubtree("disk")
+	disk.AddShortcut("open", "load")
+	if c, _, _ := tree.LookupCommand("disk open"); c == nil || c.Name != "load" {
+		t.Errorf("Old name doesn't resolve to renamed command")
+	}
+}
+
+func TestReplaceCommand(t *testing.T) {
+	tree := buildTree()
+	open, _, _ := tree.LookupCommand("file open")
+	open.Handler = func(ctx *ExecContext, args []string) error {
+		ctx.Printf("open %v\n", args)
+		return nil
+	}
+
+	old, err := tree.ReplaceCommand("file open", CommandDescriptor{
+		Brief: "open a file, with logging",
+	})
+	if err != nil {
+		t.Fatalf("ReplaceCommand failed: %v", err)
+	}
+	open.Handler = func(ctx *ExecContext, args []string) error {
+		ctx.Printf("log: open\n")
+		return old.Handler(ctx, args)
+	}
+
+	var out bytes.Buffer
+	r := NewRunner(tree, strings.NewReader(""), &out)
+	if err := r.Execute("f a.txt"); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if s := out.String(); s != "log: open\nopen [a.txt]\n" {
+		t.Errorf("Unexpected output %q", s)
+	}
+
+	c, _, _ := tree.LookupCommand("file open")
+	switch {
+	case c != open:
+		t.Errorf("Replaced command is a new *Command")
+	case c.Brief != "open a file, with logging" || c.Data != nil:
+		t.Errorf("Unexpected descriptor %+v", c.CommandDescriptor)
+	case old.Brief != "open a file" || old.Data != "open":
+		t.Errorf("Unexpected old descriptor %+v", old)
+	case fmt.Sprint(c.Shortcuts()) != "[dd f xx yy zz]":
+		t.Errorf("Unexpected shortcuts %v", c.Shortcuts())
+	}
+
+	if _, err := tree.ReplaceCommand("file open", CommandDescriptor{Name: "close"}); err == nil {
+		t.Errorf("Expected conflict replacing with a sibling's name")
+	}
+	if _, err := tree.ReplaceCommand("file", CommandDescriptor{}); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound replacing a subtree, got %v", err)
+	}
+	if _, err := tree.ReplaceCommand("file open", CommandDescriptor{Name: "load"}); err != nil {
+		t.Errorf("Unexpected error renaming: %v", err)
+	}
+	if c, _, _ := tree.LookupCommand("file load"); c != open {
+		t.Errorf("Renamed command not found")
+	}
+}
+
+func TestRemoveCommand(t *testing.T) {
+	tree := NewTree(TreeDescriptor{Name: "app"})
+	file := tree.AddSubtree(TreeDescriptor{Name: "file"})
+	file.AddCommand(CommandDescriptor{Name: "open"})
+	file.AddCommand(CommandDescriptor{Name: "close"})
+	file.AddShortcut("o", "open")
+	tree.AddShortcut("fo", "file open")
+	tree.AddGlobalShortcut("g", "file open")
+
+	cases := []struct {
+		path string
+		err  error
+	}{
+		{"file", ErrNotFound},
+		{"file open extra", ErrNotFound},
+		{"file o", nil},
+		{"file open", ErrNotFound},
+	}
+	for i, c := range cases {
+		if err := tree.RemoveCommand(c.path); err != c.err {
+			t.Errorf("Case %d: expected %v, got %v", i, c.err, err)
+		}
+	}
+
+	if len(tree.Shortcuts()) != 0 {
+		t.Errorf("Expected no shortcuts, got %v", tree.Shortcuts())
+	}
+	if n, _, err := tree.Lookup("file o"); err == nil {
+		t.Errorf("Expected removed command not to be found, got %s", nodePath(n))
+	}
+	if c, _, err := tree.LookupCommand("file c"); err != nil || c.Name != "close" {
+		t.Errorf("Expected remaining command to be found, got %v", err)
+	}
+}
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// A Pager displays command output one screen at a time. Output that fits
+// on a single screen is displayed without paging.
+type Pager interface {
+	// Page writes text to w, reading navigation commands from 'in' as
+	// needed.
+	Page(w io.Writer, in io.Reader, text string) error
+}
+
+// NewPager returns a pager that runs the program named by the PAGER
+// environment variable, or an internal TextPager if PAGER is not set.
+func NewPager() Pager {
+	if pager := os.Getenv("PAGER"); pager != "" {
+		return &ExecPager{Command: pager}
+	}
+	return &TextPager{}
+}
+
+// A TextPager is a simple internal pager. After each screen of output, it
+// displays a "--More--" prompt and reads a line of input. An empty line
+// advances the output by one line, a line containing "q" stops paging, and
+// any other line advances the output by a full screen.
+type TextPager struct {
+	Height int // lines per screen (zero uses $LINES, or 24 if not set)
+}
+
+// Page writes text to w one screen at a time.
+func (p *TextPager) Page(w io.Writer, in io.Reader, text string) error {
+	lines := splitLines(text)
+	height := pageHeight(p.Height)
+	if len(lines) <= height {
+		_, err := io.WriteString(w, text)
+		return err
+	}
+
+	rd := bufio.NewReader(in)
+	count := max(height-1, 1)
+	for i := 0; i < len(lines); {
+		n := min(count, len(lines)-i)
+		for _, line := range lines[i : i+n] {
+			if _, err := io.WriteString(w, line); err != nil {
+				return err
+			}
+		}
+		if i += n; i >= len(lines) {
+			break
+		}
+
+		fmt.Fprint(w, "--More--")
+		resp, err := rd.ReadString('\n')
+		if err != nil && resp == "" {
+			fmt.Fprintln(w)
+			return nil
+		}
+		switch strings.TrimRight(resp, "\r\n") {
+		case "q", "Q":
+			return nil
+		case "":
+			count = 1
+		default:
+			count = max(height-1, 1)
+		}
+	}
+	return nil
+}
+
+// An ExecPager pages output through an external program such as "less".
+type ExecPager struct {
+	Command string // pager command line, e.g. "less -R"
+	Height  int    // lines per screen (zero uses $LINES, or 24 if not set)
+}
+
+// Page runs the external pager with text as its input. If the output is
+// an *os.File, such as a terminal, the pager writes to it directly.
+func (p *ExecPager) Page(w io.Writer, in io.Reader, text string) error {
+	fields := strings.Fields(p.Command)
+	if len(fields) == 0 || len(splitLines(text)) <= pageHeight(p.Height) {
+		_, err := io.WriteString(w, text)
+		return err
+	}
+
+	c := exec.Command(fields[0], fields[1:]...)
+	c.Stdin = strings.NewReader(text)
+	c.Stdout = w
+	c.Stderr = os.Stderr
+	return c.Run()
+}
+
+// pageHeight returns the number of lines per screen.
+func pageHeight(height int) int {
+	if height > 0 {
+		return height
+	}
+	if n, err := strconv.Atoi(os.Getenv("LINES")); err == nil && n > 1 {
+		return n
+	}
+	return 24
+}
+
+// splitLines splits text into lines, each retaining its newline.
+func splitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestTextPager(t *testing.T) {
+	text := "1\n2\n3\n4\n5\n6\n7\n"
+
+	cases := []struct {
+		height int
+		input  string
+		output string
+	}{
+		{10, "", text},
+		{7, "", text},
+		{4, " \n \n", "1\n2\n3\n--More--4\n5\n6\n--More--7\n"},
+		{4, "\n\n\n\n", "1\n2\n3\n--More--4\n--More--5\n--More--6\n--More--7\n"},
+		{4, "\nq\n", "1\n2\n3\n--More--4\n--More--"},
+		{4, "", "1\n2\n3\n--More--\n"},
+	}
+
+	for i, c := range cases {
+		out := new(bytes.Buffer)
+		p := &TextPager{Height: c.height}
+		if err := p.Page(out, strings.NewReader(c.input), text); err != nil {
+			t.Errorf("Case %d: unexpected error: %v\n", i, err)
+		}
+		if out.String() != c.output {
+			t.Errorf("Case %d: output mismatch.\nEXPECTED:\n%q\nGOT:\n%q\n", i, c.output, out.String())
+		}
+	}
+}
+
+func TestRunnerPager(t *testing.T) {
+	tree := NewTree(TreeDescriptor{Name: "tree"})
+	tree.AddCommand(CommandDescriptor{
+		Name: "count",
+		Handler: func(ctx *ExecContext, args []string) error {
+			for i := 1; i <= 5; i++ {
+				ctx.Println(i)
+			}
+			return nil
+		},
+	})
+
+	out := new(bytes.Buffer)
+	r := NewRunner(tree, strings.NewReader("count\n \n \ncount\nq\n"), out)
+	r.Pager = &TextPager{Height: 3}
+	if err := r.Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := "> 1\n2\n--More--3\n4\n--More--5\n> 1\n2\n--More--> "
+	if out.String() != want {
+		t.Errorf("output mismatch.\nEXPECTED:\n%q\nGOT:\n%q\n", want, out.String())
+	}
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+)
+
+// ErrPipe is returned when a pipeline has an empty command line.
+var ErrPipe = errors.New("Missing command in pipeline")
+
+// parsePipeline splits the line at each unquoted '|'. It returns false if
+// the line holds no pipe.
+func parsePipeline(line string) (stages []string, ok bool) {
+	start, quoted := 0, false
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '"':
+			quoted = !quoted
+		case '|':
+			if !quoted {
+				stages = append(stages, line[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if stages == nil {
+		return nil, false
+	}
+	return append(stages, line[start:]), true
+}
+
+// executePipeline executes the command lines of a pipeline concurrently,
+// connecting the output of each to the input of the next. The commands
+// are looked up before any is executed.
+func (r *Runner) executePipeline(ctx context.Context, stages []string, w, ew io.Writer) error {
+	for _, stage := range stages {
+		if strings.TrimSpace(stage) == "" {
+			return ErrPipe
+		}
+		if _, _, err := r.Current().Lookup(stage); err != nil {
+			return err
+		}
+	}
+
+	// The pipe feeding stage i is read by readers[i] and written by
+	// writers[i-1].
+	readers := make([]*io.PipeReader, len(stages))
+	writers := make([]*io.PipeWriter, len(stages))
+	for i := 1; i < len(stages); i++ {
+		readers[i], writers[i-1] = io.Pipe()
+	}
+
+	ew = &lockedWriter{w: ew}
+	errs := make([]error, len(stages))
+	var wg sync.WaitGroup
+	for i, stage := range stages {
+		var in io.Reader
+		if readers[i] != nil {
+			in = readers[i]
+		}
+		out := w
+		if writers[i] != nil {
+			out = writers[i]
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = r.executeInput(ctx, stage, in, out, ew)
+			if writers[i] != nil {
+				writers[i].CloseWithError(errs[i])
+			}
+			if readers[i] != nil {
+				// Unblock the previous command if it is still writing.
+				readers[i].CloseWithError(io.ErrClosedPipe)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil && !errors.Is(err, io.ErrClosedPipe) {
+			return err
+		}
+	}
+	return nil
+}
+
+// A lockedWriter serializes writes to a writer shared by the commands of a
+// pipeline.
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (lw *lockedWriter) Write(p []byte) (int, error) {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	return lw.w.Write(p)
+}
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func buildPipeTree() *Tree {
+	tree := NewTree(TreeDescriptor{Name: "tree"})
+	tree.AddCommand(CommandDescriptor{
+		Name: "echo",
+		Handler: func(ctx *ExecContext, args []string) error {
+			ctx.Println(strings.Join(args, " "))
+			return nil
+		},
+	})
+	tree.AddCommand(CommandDescriptor{
+		Name: "dump",
+		Handler: func(ctx *ExecContext, args []string) error {
+			for _, s := range []string{"A9 00", "8D 20 D0", "A9 01", "60"} {
+				ctx.Println(s)
+			}
+			return nil
+		},
+	})
+	tree.AddCommand(CommandDescriptor{
+		Name: "yes",
+		Handler: func(ctx *ExecContext, args []string) error {
+			for {
+				if _, err := fmt.Fprintln(ctx.Out, "y"); err != nil {
+					return err
+				}
+			}
+		},
+	})
+	tree.AddCommand(CommandDescriptor{
+		Name: "find",
+		Args: []Arg{{Name: "text"}},
+		Handler: func(ctx *ExecContext, args []string) error {
+			if ctx.In == nil {
+				return errors.New("No input")
+			}
+			s := bufio.NewScanner(ctx.In)
+			for s.Scan() {
+				if strings.Contains(s.Text(), args[0]) {
+					ctx.Println(s.Text())
+				}
+			}
+			return s.Err()
+		},
+	})
+	tree.AddCommand(CommandDescriptor{
+		Name: "head",
+		Args: []Arg{{Name: "n", Type: UintType{}}},
+		Handler: func(ctx *ExecContext, args []string) error {
+			s := bufio.NewScanner(ctx.In)
+			for n := ctx.Values["n"].(uint64); n > 0 && s.Scan(); n-- {
+				ctx.Println(s.Text())
+			}
+			return nil
+		},
+	})
+	tree.AddCommand(CommandDescriptor{
+		Name: "fail",
+		Handler: func(ctx *ExecContext, args []string) error {
+			return errors.New("Failed")
+		},
+	})
+	return tree
+}
+
+func TestPipeline(t *testing.T) {
+	tree := buildPipeTree()
+
+	cases := []struct {
+		pipes  bool
+		line   string
+		output string
+		err    string
+	}{
+		{true, "dump | find A9", "A9 00\nA9 01\n", ""},
+		{true, `dump | find "A9 00"`, "A9 00\n", ""},
+		{true, "dump | find 0 | find 1", "A9 01\n", ""},
+		{true, `echo "a|b" | find a`, "a|b\n", ""},
+		{true, "yes | head 2", "y\ny\n", ""},
+		{true, "find x", "", "No input"},
+		{true, "fail | find x", "", "Failed"},
+		{true, "dump | fail", "", "Failed"},
+		{true, "dump | bogus", "", "Command not found"},
+		{true, "dump |", "", "Missing command in pipeline"},
+		{false, "echo a | find a", "a | find a\n", ""},
+	}
+
+	for i, c := range cases {
+		out := new(bytes.Buffer)
+		r := NewRunner(tree, nil, out)
+		r.Pipes = c.pipes
+		err := r.Execute(c.line)
+		switch {
+		case c.err != "" && (err == nil || err.Error() != c.err):
+			t.Errorf("Case %d: expected error '%s', got '%v'", i, c.err, err)
+		case c.err == "" && err != nil:
+			t.Errorf("Case %d: unexpected error '%v'", i, err)
+		case out.String() != c.output:
+			t.Errorf("Case %d: expected %q, got %q", i, c.output, out.String())
+		}
+	}
+}
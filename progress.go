@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// A ProgressMode value selects how a Runner displays the progress reported
+// by command handlers.
+type ProgressMode int
+
+// Progress display modes.
+const (
+	ProgressAuto ProgressMode = iota // line on terminals, log otherwise
+	ProgressLine                     // a single, continually updated line
+	ProgressLog                      // periodic log lines
+	ProgressOff                      // progress isn't displayed
+)
+
+// defaultProgressInterval is the minimum time between progress log lines.
+const defaultProgressInterval = time.Second
+
+// progressWidth is the width of the bar drawn by a progress line.
+const progressWidth = 20
+
+// SetProgress reports the progress of a long-running command. The fraction
+// ranges from 0 to 1; a negative fraction indicates that the amount of
+// remaining work is unknown. The message briefly describes the current
+// stage of the work.
+//
+// The runner displays progress on the context's error writer, either as a
+// single line updated in place (on terminals) or as periodic log lines. A
+// progress line is cleared when the command's handler returns.
+func (ctx *ExecContext) SetProgress(fraction float64, message string) {
+	if ctx.progress == nil {
+		ctx.progress = newProgress(ctx)
+	}
+	ctx.progress.update(fraction, message)
+}
+
+// finishProgress clears any progress line displayed for the command.
+func (ctx *ExecContext) finishProgress() {
+	if ctx.progress != nil {
+		ctx.progress.finish()
+	}
+}
+
+type progress struct {
+	w        io.Writer
+	mode     ProgressMode
+	interval time.Duration
+	last     time.Time // time of the last log line
+	spin     int       // spinner frame for unknown progress
+	drawn    bool      // a progress line is displayed
+}
+
+func newProgress(ctx *ExecContext) *progress {
+	p := &progress{w: ctx.Err, mode: ProgressOff, interval: defaultProgressInterval}
+	if r := ctx.Runner; r != nil {
+		p.mode = r.ProgressMode
+		if r.ProgressInterval > 0 {
+			p.interval = r.ProgressInterval
+		}
+	}
+	if p.mode == ProgressAuto {
+		p.mode = ProgressLog
+		if isTerminal(p.w) {
+			p.mode = ProgressLine
+		}
+	}
+	return p
+}
+
+func (p *progress) update(fraction float64, message string) {
+	if fraction > 1 {
+		fraction = 1
+	}
+
+	switch p.mode {
+	case ProgressLine:
+		fmt.Fprintf(p.w, "\r%s %s\x1b[K", p.bar(fraction), message)
+		p.drawn = true
+
+	case ProgressLog:
+		now := time.Now()
+		if !p.last.IsZero() && now.Sub(p.last) < p.interval && fraction != 1 {
+			return
+		}
+		p.last = now
+		if fraction < 0 {
+			fmt.Fprintf(p.w, "Progress: %s\n", message)
+		} else {
+			fmt.Fprintf(p.w, "Progress: %3.0f%% %s\n", fraction*100, message)
+		}
+	}
+}
+
+// bar draws a progress bar, or a spinner if the fraction is negative.
+func (p *progress) bar(fraction float64) string {
+	if fraction < 0 {
+		frame := `|/-\`[p.spin%4]
+		p.spin++
+		return string(frame)
+	}
+	n := int(fraction * progressWidth)
+	return fmt.Sprintf("[%s%s] %3.0f%%", strings.Repeat("#", n),
+		strings.Repeat(" ", progressWidth-n), fraction*100)
+}
+
+func (p *progress) finish() {
+	if p.drawn {
+		fmt.Fprint(p.w, "\r\x1b[K")
+		p.drawn = false
+	}
+}
+
+// isTerminal returns true if the writer is a character device, such as a
+// terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProgress(t *testing.T) {
+	tree := NewTree(TreeDescriptor{Name: "tree"})
+	tree.AddCommand(CommandDescriptor{
+		Name: "capture",
+		Handler: func(ctx *ExecContext, args []string) error {
+			ctx.SetProgress(-1, "starting")
+			ctx.SetProgress(0.25, "reading")
+			ctx.SetProgress(0.5, "reading")
+			ctx.SetProgress(1, "done")
+			ctx.Println("captured")
+			return nil
+		},
+	})
+
+	cases := []struct {
+		mode     ProgressMode
+		expected string
+	}{
+		{
+			ProgressLine,
+			"\r| starting\x1b[K" +
+				"\r[#####               ]  25% reading\x1b[K" +
+				"\r[##########          ]  50% reading\x1b[K" +
+				"\r[####################] 100% done\x1b[K" +
+				"captured\n" +
+				"\r\x1b[K",
+		},
+		{
+			ProgressLog,
+			"Progress: starting\n" +
+				"Progress: 100% done\n" +
+				"captured\n",
+		},
+		{
+			ProgressAuto,
+			"Progress: starting\n" +
+				"Progress: 100% done\n" +
+				"captured\n",
+		},
+		{
+			ProgressOff,
+			"captured\n",
+		},
+	}
+
+	for i, c := range cases {
+		var out bytes.Buffer
+		r := NewRunner(tree, strings.NewReader(""), &out)
+		r.ProgressMode = c.mode
+		r.ProgressInterval = time.Hour
+		if err := r.Execute("capture"); err != nil {
+			t.Fatalf("Case %d: unexpected error %v", i, err)
+		}
+		if out.String() != c.expected {
+			t.Errorf("Case %d: expected %q, got %q", i, c.expected, out.String())
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited is returned, wrapped in a *RateLimitError, when a command
+// is executed more often than its rate limit allows.
+var ErrRateLimited = errors.New("Rate limit exceeded")
+
+// A RateLimit bounds how often a command may be executed by a runner. At
+// most Count executions are allowed in any period of length Per. A Count
+// of 1 makes Per a cooldown between executions. The zero value sets no
+// limit.
+type RateLimit struct {
+	Count int
+	Per   time.Duration
+}
+
+// A RateLimitError is returned when a command is refused by its rate limit.
+type RateLimitError struct {
+	Command    *Command      // command refused
+	RetryAfter time.Duration // time until the command is allowed again
+}
+
+func (e *RateLimitError) Error() string {
+	d := e.RetryAfter.Round(time.Millisecond)
+	if d >= time.Second {
+		d = (e.RetryAfter + time.Second - 1).Truncate(time.Second)
+	}
+	return fmt.Sprintf("%v; retry in %v", ErrRateLimited, d)
+}
+
+func (e *RateLimitError) Unwrap() error {
+	return ErrRateLimited
+}
+
+// A rateLimiter records the recent executions of a runner's rate-limited
+// commands.
+type rateLimiter struct {
+	mu    sync.Mutex
+	times map[*Command][]time.Time
+}
+
+// allow records an execution of the command at the given time, or returns
+// a *RateLimitError if the command's rate limit refuses it.
+func (l *rateLimiter) allow(c *Command, now time.Time) error {
+	limit := c.RateLimit
+	if limit.Count <= 0 || limit.Per <= 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.times == nil {
+		l.times = make(map[*Command][]time.Time)
+	}
+
+	// Forget executions that fell out of the period.
+	times := l.times[c]
+	i := 0
+	for i < len(times) && now.Sub(times[i]) >= limit.Per {
+		i++
+	}
+	times = times[i:]
+
+	if len(times) >= limit.Count {
+		l.times[c] = times
+		return &RateLimitError{Command: c, RetryAfter: times[len(times)-limit.Count].Add(limit.Per).Sub(now)}
+	}
+	l.times[c] = append(times, now)
+	return nil
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestRateLimit(t *testing.T) {
+	c := &Command{CommandDescriptor: CommandDescriptor{
+		Name:      "erase",
+		RateLimit: RateLimit{Count: 2, Per: 10 * time.Second},
+	}}
+	var l rateLimiter
+	start := time.Now()
+
+	cases := []struct {
+		at         time.Duration
+		retryAfter time.Duration // zero if allowed
+	}{
+		{0, 0},
+		{time.Second, 0},
+		{2 * time.Second, 8 * time.Second},
+		{9 * time.Second, time.Second},
+		{10 * time.Second, 0},
+		{10 * time.Second, time.Second},
+		{11 * time.Second, 0},
+		{12 * time.Second, 8 * time.Second},
+	}
+
+	for i, tc := range cases {
+		err := l.allow(c, start.Add(tc.at))
+		var rerr *RateLimitError
+		switch {
+		case tc.retryAfter == 0 && err != nil:
+			t.Errorf("Case %d: unexpected error '%v'", i, err)
+		case tc.retryAfter != 0 && !errors.As(err, &rerr):
+			t.Errorf("Case %d: expected RateLimitError, got %v", i, err)
+		case tc.retryAfter != 0 && rerr.RetryAfter != tc.retryAfter:
+			t.Errorf("Case %d: expected retry after %v, got %v", i, tc.retryAfter, rerr.RetryAfter)
+		}
+	}
+}
+
+func TestRateLimitError(t *testing.T) {
+	cases := []struct {
+		retryAfter time.Duration
+		expected   string
+	}{
+		{7 * time.Second, "Rate limit exceeded; retry in 7s"},
+		{6100 * time.Millisecond, "Rate limit exceeded; retry in 7s"},
+		{250 * time.Millisecond, "Rate limit exceeded; retry in 250ms"},
+	}
+
+	for i, c := range cases {
+		err := &RateLimitError{RetryAfter: c.retryAfter}
+		if err.Error() != c.expected {
+			t.Errorf("Case %d: expected %q, got %q", i, c.expected, err.Error())
+		}
+		if !errors.Is(err, ErrRateLimited) {
+			t.Errorf("Case %d: expected ErrRateLimited", i)
+		}
+	}
+}
+
+func TestRunnerRateLimit(t *testing.T) {
+	tree := NewTree(TreeDescriptor{Name: "tree"})
+	count := 0
+	tree.AddCommand(CommandDescriptor{
+		Name:      "erase",
+		Args:      []Arg{{Name: "bank", Type: UintType{BitSize: 8}}},
+		RateLimit: RateLimit{Count: 1, Per: time.Hour},
+		Handler: func(ctx *ExecContext, args []string) error {
+			count++
+			return nil
+		},
+	})
+
+	r := NewRunner(tree, nil, io.Discard)
+	if err := r.Execute("erase x"); errors.Is(err, ErrRateLimited) {
+		t.Errorf("invalid arguments counted against the limit")
+	}
+	if err := r.Execute("erase 1"); err != nil {
+		t.Errorf("unexpected error '%v'", err)
+	}
+	if err := r.Execute("erase 2"); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("expected ErrRateLimited, got %v", err)
+	}
+	if count != 1 {
+		t.Errorf("expected 1 execution, got %d", count)
+	}
+
+	// Limits are kept per runner.
+	r2 := NewRunner(tree, nil, io.Discard)
+	if err := r2.Execute("erase 1"); err != nil {
+		t.Errorf("unexpected error '%v'", err)
+	}
+}
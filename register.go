@@ -0,0 +1,247 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// A Runnable is a command registered by RegisterStruct. Its Run method is
+// called when the command is executed.
+type Runnable interface {
+	Run(ctx *ExecContext) error
+}
+
+// RegisterStruct registers commands and subtrees declared by the fields of
+// the struct pointed to by v.
+//
+// Each field with a "cmd" struct tag declares a command or a subtree. The tag
+// holds the node's name followed by optional comma-separated settings:
+// "brief=...", "desc=...", "usage=..." and, for a command, "aliases=a|b".
+// A field whose pointer implements Runnable declares a command. Any other
+// struct field declares a subtree whose commands are declared by the
+// struct's own fields.
+//
+// A command struct's fields with an "arg" tag declare positional arguments,
+// and its fields with a "flag" tag declare flags. Each tag holds the
+// argument or flag name followed by optional comma-separated settings:
+// "optional" marks an argument as optional, "sensitive" masks the value of
+// an argument or flag, "brief=..." describes a flag, and "enum=a|b|c"
+// restricts a string argument or flag to a set of values.
+// Slice-typed arguments are variadic. Supported field types are strings,
+// booleans, integers, time.Duration and Range.
+//
+// When a command is executed, a copy of its struct is made, the copy's
+// argument and flag fields are set from the parsed command line, and the
+// copy's Run method is called. Fields without tags are copied unchanged, so
+// they may hold application state.
+func RegisterStruct(t *Tree, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("RegisterStruct requires a pointer to a struct")
+	}
+	return registerFields(t, rv.Elem())
+}
+
+func registerFields(t *Tree, sv reflect.Value) error {
+	st := sv.Type()
+	for i := 0; i < st.NumField(); i++ {
+		sf := st.Field(i)
+		tag, ok := sf.Tag.Lookup("cmd")
+		if !ok {
+			continue
+		}
+		if !sf.IsExported() {
+			return fmt.Errorf("field %s is not exported", sf.Name)
+		}
+
+		name, opts := parseTag(tag)
+		if name == "" {
+			name = strings.ToLower(sf.Name)
+		}
+
+		fv := sv.Field(i)
+		if r, ok := fv.Addr().Interface().(Runnable); ok {
+			if err := registerCommand(t, name, opts, r); err != nil {
+				return fmt.Errorf("field %s: %w", sf.Name, err)
+			}
+			continue
+		}
+
+		if fv.Kind() != reflect.Struct {
+			return fmt.Errorf("field %s is neither a Runnable nor a struct", sf.Name)
+		}
+		subtree := t.AddSubtree(TreeDescriptor{
+			Name:        name,
+			Brief:       opts["brief"],
+			Description: opts["desc"],
+			Usage:       opts["usage"],
+			Data:        fv.Addr().Interface(),
+		})
+		if err := registerFields(subtree, fv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// A structField maps an argument or flag name to the index of the command
+// struct field holding its value.
+type structField struct {
+	name  string
+	index int
+}
+
+func registerCommand(t *Tree, name string, opts map[string]string, r Runnable) error {
+	proto := reflect.ValueOf(r).Elem()
+	st := proto.Type()
+	args, flags, fields, err := structSpec(st)
+	if err != nil {
+		return err
+	}
+
+	var aliases []string
+	if a := opts["aliases"]; a != "" {
+		aliases = strings.Split(a, "|")
+	}
+
+	t.AddCommand(CommandDescriptor{
+		Name:        name,
+		Aliases:     aliases,
+		Brief:       opts["brief"],
+		Description: opts["desc"],
+		Usage:       opts["usage"],
+		Data:        r,
+		Args:        args,
+		Flags:       flags,
+		Handler: func(ctx *ExecContext, _ []string) error {
+			v := reflect.New(st)
+			v.Elem().Set(proto)
+			fillStruct(v.Elem(), fields, ctx.Values)
+			return v.Interface().(Runnable).Run(ctx)
+		},
+	})
+	return nil
+}
+
+// structSpec returns the argument and flag specifications declared by the
+// "arg" and "flag" tags of a struct type's fields.
+func structSpec(st reflect.Type) (args []Arg, flags []Flag, fields []structField, err error) {
+	if st.Kind() != reflect.Struct {
+		return nil, nil, nil, fmt.Errorf("%v is not a struct", st)
+	}
+	for i := 0; i < st.NumField(); i++ {
+		sf := st.Field(i)
+		argTag, isArg := sf.Tag.Lookup("arg")
+		flagTag, isFlag := sf.Tag.Lookup("flag")
+		if !isArg && !isFlag {
+			continue
+		}
+		if !sf.IsExported() {
+			return nil, nil, nil, fmt.Errorf("field %s is not exported", sf.Name)
+		}
+
+		tag := argTag
+		if isFlag {
+			tag = flagTag
+		}
+		fname, fopts := parseTag(tag)
+		if fname == "" {
+			fname = strings.ToLower(sf.Name)
+		}
+
+		typ, variadic, err := fieldArgType(sf.Type, fopts)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("field %s: %w", sf.Name, err)
+		}
+
+		if isFlag {
+			// Only bool fields become switches.
+			switch typ.(type) {
+			case BoolType:
+				typ = nil
+			case nil:
+				typ = StringType{}
+			}
+			_, sensitive := fopts["sensitive"]
+			flags = append(flags, Flag{Name: fname, Type: typ, Brief: fopts["brief"], Sensitive: sensitive})
+		} else {
+			_, optional := fopts["optional"]
+			_, sensitive := fopts["sensitive"]
+			args = append(args, Arg{Name: fname, Type: typ, Optional: optional, Variadic: variadic, Sensitive: sensitive})
+		}
+		fields = append(fields, structField{fname, i})
+	}
+	return args, flags, fields, nil
+}
+
+// fillStruct stores parsed argument values into the fields of a struct.
+func fillStruct(v reflect.Value, fields []structField, values map[string]any) {
+	for _, f := range fields {
+		if value, ok := values[f.name]; ok {
+			setField(v.Field(f.index), value)
+		}
+	}
+}
+
+// parseTag splits a struct tag into a name and a set of options.
+func parseTag(tag string) (name string, opts map[string]string) {
+	parts := strings.Split(tag, ",")
+	opts = make(map[string]string)
+	for _, p := range parts[1:] {
+		k, v, _ := strings.Cut(p, "=")
+		opts[strings.TrimSpace(k)] = v
+	}
+	return strings.TrimSpace(parts[0]), opts
+}
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	rangeType    = reflect.TypeOf(Range{})
+)
+
+// fieldArgType returns the argument type used to parse values for a struct
+// field of type t.
+func fieldArgType(t reflect.Type, opts map[string]string) (typ ArgType, variadic bool, err error) {
+	if t.Kind() == reflect.Slice {
+		typ, _, err = fieldArgType(t.Elem(), opts)
+		return typ, true, err
+	}
+
+	switch {
+	case t == durationType:
+		return DurationType{}, false, nil
+	case t == rangeType:
+		return RangeType{}, false, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		if e, ok := opts["enum"]; ok {
+			return EnumType{Values: strings.Split(e, "|")}, false, nil
+		}
+		return nil, false, nil
+	case reflect.Bool:
+		return BoolType{}, false, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return IntType{BitSize: t.Bits()}, false, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return UintType{BitSize: t.Bits()}, false, nil
+	}
+	return nil, false, fmt.Errorf("unsupported type %v", t)
+}
+
+// setField stores a parsed argument value into a struct field.
+func setField(f reflect.Value, value any) {
+	if vs, ok := value.([]any); ok {
+		s := reflect.MakeSlice(f.Type(), len(vs), len(vs))
+		for i, v := range vs {
+			s.Index(i).Set(reflect.ValueOf(v).Convert(f.Type().Elem()))
+		}
+		f.Set(s)
+		return
+	}
+	f.Set(reflect.ValueOf(value).Convert(f.Type()))
+}
@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+type dumpCmd struct {
+	Prefix string // untagged state shared by every execution
+
+	Range Range         `arg:"range"`
+	Width uint8         `arg:"width,optional"`
+	Mode  string        `flag:"mode,enum=hex|ascii,brief=output mode"`
+	Quiet bool          `flag:"quiet"`
+	Delay time.Duration `flag:"delay"`
+}
+
+func (c *dumpCmd) Run(ctx *ExecContext) error {
+	ctx.Printf("%s%x-%x width=%d mode=%s quiet=%v delay=%v", c.Prefix,
+		c.Range.Start, c.Range.End, c.Width, c.Mode, c.Quiet, c.Delay)
+	return nil
+}
+
+type pokeCmd struct {
+	Addr   uint16 `arg:"addr"`
+	Values []int8 `arg:"values"`
+}
+
+func (c *pokeCmd) Run(ctx *ExecContext) error {
+	ctx.Printf("%x %v", c.Addr, c.Values)
+	return nil
+}
+
+type registerApp struct {
+	Memory struct {
+		Dump dumpCmd `cmd:"dump,brief=Dump memory"`
+		Poke pokeCmd `cmd:",brief=Write memory"`
+	} `cmd:"mem,brief=Memory commands"`
+	Ignored int
+}
+
+func TestRegisterStruct(t *testing.T) {
+	var app registerApp
+	app.Memory.Dump.Prefix = "> "
+
+	tree := NewTree(TreeDescriptor{Name: "root"})
+	if err := RegisterStruct(tree, &app); err != nil {
+		t.Fatalf("RegisterStruct failed: %v", err)
+	}
+
+	cases := []struct {
+		line string
+		out  string
+		err  string
+	}{
+		{"mem dump 0..$ff", "> 0-ff width=0 mode= quiet=false delay=0s", ""},
+		{"mem dump 0..$ff 16 --mode a --quiet --delay 1s", "> 0-ff width=16 mode=ascii quiet=true delay=1s", ""},
+		{"mem dump 0..$ff 256", "", "Invalid argument 'width': value '256' out of range"},
+		{"mem poke $d020 1 -2", "d020 [1 -2]", ""},
+		{"mem poke $d020", "", "Missing argument 'values'"},
+	}
+
+	for i, c := range cases {
+		var out bytes.Buffer
+		r := NewRunner(tree, strings.NewReader(""), &out)
+		err := r.Execute(c.line)
+		switch {
+		case c.err != "" && (err == nil || err.Error() != c.err):
+			t.Errorf("Case %d: expected error '%s', got '%v'", i, c.err, err)
+		case c.err == "" && err != nil:
+			t.Errorf("Case %d: unexpected error '%v'", i, err)
+		case out.String() != c.out:
+			t.Errorf("Case %d: expected '%s', got '%s'", i, c.out, out.String())
+		}
+	}
+
+	if app.Memory.Dump.Width != 0 {
+		t.Errorf("Registered struct was modified by execution")
+	}
+
+	c, _, err := tree.LookupCommand("mem dump")
+	if err != nil {
+		t.Fatalf("Lookup failed: %v", err)
+	}
+	usage := "mem dump [--mode hex|ascii] [--quiet] [--delay <value>] <range> [width]"
+	if c.usage() != usage {
+		t.Errorf("Expected usage '%s', got '%s'", usage, c.usage())
+	}
+	if c.Brief != "Dump memory" || c.Data != &app.Memory.Dump {
+		t.Errorf("Unexpected command descriptor %+v", c.CommandDescriptor)
+	}
+}
+
+type openCmd struct {
+	File string `arg:"file"`
+	Mode string `flag:"mode"`
+}
+
+func (c *openCmd) Run(ctx *ExecContext) error {
+	ctx.Printf("%s mode=%s", c.File, c.Mode)
+	return nil
+}
+
+func TestRegisterStructStringFlag(t *testing.T) {
+	var app struct {
+		Open openCmd `cmd:"open"`
+	}
+	tree := NewTree(TreeDescriptor{Name: "root"})
+	if err := RegisterStruct(tree, &app); err != nil {
+		t.Fatalf("RegisterStruct failed: %v", err)
+	}
+
+	cases := []struct {
+		line string
+		out  string
+	}{
+		{"open --mode rw x.txt", "x.txt mode=rw"},
+		{"open --mode=rw x.txt", "x.txt mode=rw"},
+		{"open x.txt", "x.txt mode="},
+	}
+
+	for i, c := range cases {
+		var out bytes.Buffer
+		r := NewRunner(tree, strings.NewReader(""), &out)
+		if err := r.Execute(c.line); err != nil {
+			t.Errorf("Case %d: unexpected error '%v'", i, err)
+		}
+		if out.String() != c.out {
+			t.Errorf("Case %d: expected '%s', got '%s'", i, c.out, out.String())
+		}
+	}
+}
+
+func TestRegisterStructErrors(t *testing.T) {
+	type badType struct {
+		Cmd struct {
+			dumpCmd
+			F float64 `arg:"f"`
+		} `cmd:"bad"`
+	}
+	type badField struct {
+		Count int `cmd:"count"`
+	}
+
+	cases := []struct {
+		v   any
+		err string
+	}{
+		{registerApp{}, "RegisterStruct requires a pointer to a struct"},
+		{&badField{}, "field Count is neither a Runnable nor a struct"},
+		{&badType{}, "field Cmd: field F: unsupported type float64"},
+	}
+
+	for i, c := range cases {
+		err := RegisterStruct(NewTree(TreeDescriptor{Name: "root"}), c.v)
+		if fmt.Sprint(err) != c.err {
+			t.Errorf("Case %d: expected error '%s', got '%v'", i, c.err, err)
+		}
+	}
+}
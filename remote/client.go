@@ -0,0 +1,116 @@
+package remote
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/beevik/cmd"
+)
+
+// A Client sends requests to a server over a connection. Requests are
+// answered one at a time; a Client is safe for concurrent use.
+type Client struct {
+	conn io.ReadWriteCloser
+	enc  *json.Encoder
+	dec  *json.Decoder
+
+	mu   sync.Mutex
+	next uint64
+}
+
+// Dial connects to a server at the network address.
+func Dial(network, addr string) (*Client, error) {
+	c, err := net.Dial(network, addr)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(c), nil
+}
+
+// NewClient creates a client sending requests over the connection.
+func NewClient(conn io.ReadWriteCloser) *Client {
+	return &Client{
+		conn: conn,
+		enc:  json.NewEncoder(conn),
+		dec:  json.NewDecoder(bufio.NewReader(conn)),
+	}
+}
+
+// Close closes the client's connection.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Complete returns the completions of the line.
+func (c *Client) Complete(line string) ([]cmd.Completion, error) {
+	resp, err := c.call(OpComplete, line)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Completions, nil
+}
+
+// Completions returns the completions of the line, or none if the request
+// fails. It may be used as the completion function of a line editor.
+func (c *Client) Completions(line string) []cmd.Completion {
+	completions, _ := c.Complete(line)
+	return completions
+}
+
+// Lookup describes the command or subtree named by the line.
+func (c *Client) Lookup(line string) (*Lookup, error) {
+	resp, err := c.call(OpLookup, line)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Lookup, nil
+}
+
+// Hint describes the likely completion of the line.
+func (c *Client) Hint(line string) (*Hint, error) {
+	resp, err := c.call(OpHint, line)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Hint, nil
+}
+
+// Help returns the help text of the command or subtree named by the line,
+// or of the server's tree if the line is blank.
+func (c *Client) Help(line string) (string, error) {
+	resp, err := c.call(OpHelp, line)
+	if err != nil {
+		return "", err
+	}
+	return resp.Help, nil
+}
+
+// call sends a request and waits for its response. An error reported by
+// the server is returned as an *Error.
+func (c *Client) call(op, line string) (Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.next++
+	if err := c.enc.Encode(Request{ID: c.next, Op: op, Line: line}); err != nil {
+		return Response{}, err
+	}
+	var resp Response
+	if err := c.dec.Decode(&resp); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return Response{}, err
+	}
+	switch {
+	case resp.ID != c.next:
+		return Response{}, fmt.Errorf("response %d to request %d", resp.ID, c.next)
+	case resp.Error != "":
+		return Response{}, &Error{resp.Error}
+	}
+	return resp, nil
+}
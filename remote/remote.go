@@ -0,0 +1,70 @@
+// Package remote lets a thin client complete, look up and describe command
+// lines using a command tree owned by a server, so that a remote console
+// offers completion and help without holding the tree's handlers.
+//
+// Client and server exchange newline-delimited JSON messages. Each request
+// names an operation and a command line, and is answered by one response
+// carrying the request's ID:
+//
+//	{"id":1,"op":"complete","line":"fi"}
+//	{"id":1,"completions":[{"text":"file","space":true,"more":true}]}
+//
+// The operations are "complete", "lookup", "hint" and "help".
+package remote
+
+import (
+	"github.com/beevik/cmd"
+)
+
+// Operations of the protocol.
+const (
+	OpComplete = "complete" // complete the line, as Tree.Completions does
+	OpLookup   = "lookup"   // look up the line, as Tree.Lookup does
+	OpHint     = "hint"     // describe the line's likely completion, as Tree.Hint does
+	OpHelp     = "help"     // display help for the node named by the line
+)
+
+// A Request is a message sent by a client.
+type Request struct {
+	ID   uint64 `json:"id"`
+	Op   string `json:"op"`
+	Line string `json:"line"`
+}
+
+// A Response is a message sent by a server in reply to a request. Only the
+// field matching the request's operation is set, unless the request failed.
+type Response struct {
+	ID          uint64           `json:"id"`
+	Error       string           `json:"error,omitempty"`
+	Completions []cmd.Completion `json:"completions,omitempty"`
+	Lookup      *Lookup          `json:"lookup,omitempty"`
+	Hint        *Hint            `json:"hint,omitempty"`
+	Help        string           `json:"help,omitempty"`
+}
+
+// A Lookup describes the command or subtree named by a line.
+type Lookup struct {
+	Path  string   `json:"path"`            // full path of the node
+	Tree  bool     `json:"tree,omitempty"`  // the node is a subtree
+	Args  []string `json:"args,omitempty"`  // fields following the node's path
+	Brief string   `json:"brief,omitempty"` // brief description of the node
+	Usage string   `json:"usage,omitempty"` // usage summary of a command
+}
+
+// A Hint describes the likely completion of a partially typed line. See
+// cmd.Hint.
+type Hint struct {
+	Suffix string `json:"suffix,omitempty"`
+	Path   string `json:"path,omitempty"`
+	Brief  string `json:"brief,omitempty"`
+	Usage  string `json:"usage,omitempty"`
+}
+
+// An Error is an error reported by a server.
+type Error struct {
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
@@ -0,0 +1,120 @@
+package remote
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/beevik/cmd"
+)
+
+func buildTree() *cmd.Tree {
+	tree := cmd.NewTree(cmd.TreeDescriptor{Name: "tree"})
+	tree.AddCommand(cmd.CommandDescriptor{Name: "quit", Brief: "quit the application", MaxArgs: cmd.NoArgs})
+	file := tree.AddSubtree(cmd.TreeDescriptor{Name: "file", Brief: "file commands"})
+	file.AddCommand(cmd.CommandDescriptor{
+		Name:  "open",
+		Brief: "open a file",
+		Args:  []cmd.Arg{{Name: "path"}},
+		Handler: func(ctx *cmd.ExecContext, args []string) error {
+			panic("handler called")
+		},
+	})
+	file.AddCommand(cmd.CommandDescriptor{Name: "close", Brief: "close a file"})
+	return tree
+}
+
+func newPair(t *testing.T) *Client {
+	sc, cc := net.Pipe()
+	s := &Server{Tree: buildTree()}
+	go func() {
+		defer sc.Close()
+		s.ServeConn(sc)
+	}()
+	c := NewClient(cc)
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestComplete(t *testing.T) {
+	c := newPair(t)
+
+	cases := []struct {
+		line        string
+		completions []cmd.Completion
+	}{
+		{"fi", []cmd.Completion{{Text: "file", Space: true, More: true}}},
+		{"file ", []cmd.Completion{{Text: "file close", Space: true}, {Text: "file open", Space: true}}},
+		{"q", []cmd.Completion{{Text: "quit"}}},
+		{"x", nil},
+	}
+
+	for i, cs := range cases {
+		got, err := c.Complete(cs.line)
+		if err != nil {
+			t.Fatalf("Case %d: %v", i, err)
+		}
+		if len(got) != len(cs.completions) {
+			t.Errorf("Case %d: Complete(%q) = %v, wanted %v", i, cs.line, got, cs.completions)
+			continue
+		}
+		for j := range got {
+			if got[j] != cs.completions[j] {
+				t.Errorf("Case %d: Complete(%q)[%d] = %v, wanted %v", i, cs.line, j, got[j], cs.completions[j])
+			}
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	c := newPair(t)
+
+	l, err := c.Lookup("fi o x y")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Path != "file open" || l.Tree || strings.Join(l.Args, ",") != "x,y" ||
+		l.Brief != "open a file" || l.Usage != "file open <path>" {
+		t.Errorf("unexpected lookup: %+v", l)
+	}
+
+	l, err = c.Lookup("file")
+	if err != nil || l.Path != "file" || !l.Tree || l.Brief != "file commands" {
+		t.Errorf("unexpected lookup: %+v, %v", l, err)
+	}
+
+	var rerr *Error
+	if _, err := c.Lookup("bogus"); !errors.As(err, &rerr) || rerr.Message != "Command not found." {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestHintAndHelp(t *testing.T) {
+	c := newPair(t)
+
+	h, err := c.Hint("file op")
+	if err != nil || h.Suffix != "en" || h.Path != "file open" || h.Usage != "file open <path>" {
+		t.Errorf("unexpected hint: %+v, %v", h, err)
+	}
+
+	help, err := c.Help("file")
+	if err != nil || !strings.Contains(help, "open a file") {
+		t.Errorf("unexpected help: %q, %v", help, err)
+	}
+	help, err = c.Help("")
+	if err != nil || !strings.Contains(help, "file commands") {
+		t.Errorf("unexpected help: %q, %v", help, err)
+	}
+	if _, err := c.Help("bogus"); err == nil {
+		t.Errorf("expected error")
+	}
+}
+
+func TestUnknownOp(t *testing.T) {
+	s := &Server{Tree: buildTree()}
+	resp := s.Answer(Request{ID: 7, Op: "execute", Line: "quit"})
+	if resp.ID != 7 || resp.Error != "Unknown operation 'execute'" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+}
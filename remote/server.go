@@ -0,0 +1,121 @@
+package remote
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+
+	"github.com/beevik/cmd"
+)
+
+// A Server answers the requests of remote clients using a command tree.
+// Commands are never executed by the server.
+type Server struct {
+	Tree *cmd.Tree // command tree used to answer requests
+}
+
+// Serve accepts incoming connections on the listener, answering the
+// requests read from each connection in its own goroutine. Serve returns
+// the error that stopped the listener.
+func (s *Server) Serve(l net.Listener) error {
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go func() {
+			defer c.Close()
+			s.ServeConn(c)
+		}()
+	}
+}
+
+// ServeConn answers the requests read from the connection until its input
+// is exhausted. It returns nil at the end of the input, or the error that
+// prevented a request from being read or answered.
+func (s *Server) ServeConn(rw io.ReadWriter) error {
+	dec := json.NewDecoder(bufio.NewReader(rw))
+	enc := json.NewEncoder(rw)
+	for {
+		var req Request
+		if err := dec.Decode(&req); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if err := enc.Encode(s.Answer(req)); err != nil {
+			return err
+		}
+	}
+}
+
+// Answer returns the response to a request.
+func (s *Server) Answer(req Request) Response {
+	resp := Response{ID: req.ID}
+	switch req.Op {
+	case OpComplete:
+		resp.Completions = s.Tree.Completions(req.Line)
+	case OpLookup:
+		n, args, err := s.Tree.Lookup(req.Line)
+		if err != nil {
+			resp.Error = s.Tree.ErrorMessage(err)
+			break
+		}
+		resp.Lookup = s.newLookup(n, args)
+	case OpHint:
+		h := s.Tree.Hint(req.Line)
+		resp.Hint = &Hint{Suffix: h.Suffix, Brief: h.Brief, Usage: h.Usage}
+		if h.Node != nil {
+			resp.Hint.Path = nodePath(h.Node)
+		}
+	case OpHelp:
+		help, err := s.help(req.Line)
+		if err != nil {
+			resp.Error = s.Tree.ErrorMessage(err)
+			break
+		}
+		resp.Help = help
+	default:
+		resp.Error = fmt.Sprintf("Unknown operation '%s'", req.Op)
+	}
+	return resp
+}
+
+// help returns the help displayed for the node named by the line, or for
+// the tree if the line is blank.
+func (s *Server) help(line string) (string, error) {
+	var n cmd.Node = s.Tree
+	if strings.TrimSpace(line) != "" {
+		var err error
+		if n, _, err = s.Tree.Lookup(line); err != nil {
+			return "", err
+		}
+	}
+	buf := new(bytes.Buffer)
+	n.DisplayHelp(buf)
+	return buf.String(), nil
+}
+
+// newLookup describes a node found by a lookup.
+func (s *Server) newLookup(n cmd.Node, args []string) *Lookup {
+	path := nodePath(n)
+	h := s.Tree.Hint(path)
+	_, tree := n.(*cmd.Tree)
+	return &Lookup{Path: path, Tree: tree, Args: args, Brief: h.Brief, Usage: h.Usage}
+}
+
+// nodePath returns the full path of a node.
+func nodePath(n cmd.Node) string {
+	switch n := n.(type) {
+	case *cmd.Command:
+		return n.CanonicalLine()
+	case *cmd.Tree:
+		return n.Path()
+	}
+	return ""
+}
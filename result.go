@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// A ResultHandler is a function called when a command is executed by a
+// Runner. Instead of writing output directly, it returns a structured result
+// which the runner displays using its active renderer.
+type ResultHandler func(ctx *ExecContext, args []string) (*Result, error)
+
+// A Result holds the structured output of a command along with optional
+// hints describing how it should be rendered.
+type Result struct {
+	Value   any      // result data
+	Text    string   // optional preformatted text used by the text renderer
+	Columns []string // optional column headings used by the table renderer
+}
+
+// A Renderer displays a command's result.
+type Renderer interface {
+	Render(w io.Writer, r *Result) error
+}
+
+// The renderers provided by the cmd package.
+var (
+	TextRenderer  Renderer = textRenderer{}
+	TableRenderer Renderer = tableRenderer{}
+	JSONRenderer  Renderer = jsonRenderer{}
+)
+
+// JSONFlag is the argument which, when it appears last on a command line,
+// causes a result handler's output to be rendered as JSON.
+const JSONFlag = "--json"
+
+type textRenderer struct{}
+
+func (textRenderer) Render(w io.Writer, r *Result) error {
+	switch {
+	case r.Text != "":
+		fmt.Fprintln(w, strings.TrimSuffix(r.Text, "\n"))
+	case r.Value != nil:
+		v := reflect.ValueOf(r.Value)
+		if v.Kind() == reflect.Slice {
+			for i := 0; i < v.Len(); i++ {
+				fmt.Fprintln(w, v.Index(i).Interface())
+			}
+			return nil
+		}
+		fmt.Fprintln(w, r.Value)
+	}
+	return nil
+}
+
+type tableRenderer struct{}
+
+func (tableRenderer) Render(w io.Writer, r *Result) error {
+	headers, rows, ok := tableRows(r)
+	if !ok {
+		return TextRenderer.Render(w, r)
+	}
+
+	table := &Table{Headers: headers, Rows: rows}
+	return table.Write(w)
+}
+
+// tableRows converts a result value into table headers and rows. Slices of
+// structs use the structs' exported field names as headers, and slices of
+// slices use each inner slice as a row. It returns false if the value can't
+// be represented as a table.
+func tableRows(r *Result) (headers []string, rows [][]string, ok bool) {
+	v := reflect.ValueOf(r.Value)
+	if v.Kind() != reflect.Slice {
+		return nil, nil, false
+	}
+
+	et := v.Type().Elem()
+	for et.Kind() == reflect.Pointer {
+		et = et.Elem()
+	}
+
+	var fields []int
+	switch et.Kind() {
+	case reflect.Struct:
+		for i := 0; i < et.NumField(); i++ {
+			if et.Field(i).IsExported() {
+				fields = append(fields, i)
+				headers = append(headers, et.Field(i).Name)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+	default:
+		return nil, nil, false
+	}
+	if r.Columns != nil {
+		headers = r.Columns
+	}
+
+	for i := 0; i < v.Len(); i++ {
+		e := reflect.Indirect(v.Index(i))
+		var row []string
+		switch e.Kind() {
+		case reflect.Struct:
+			for _, f := range fields {
+				row = append(row, fmt.Sprint(e.Field(f).Interface()))
+			}
+		case reflect.Slice, reflect.Array:
+			for j := 0; j < e.Len(); j++ {
+				row = append(row, fmt.Sprint(e.Index(j).Interface()))
+			}
+		}
+		rows = append(rows, row)
+	}
+	return headers, rows, true
+}
+
+type jsonRenderer struct{}
+
+func (jsonRenderer) Render(w io.Writer, r *Result) error {
+	b, err := json.MarshalIndent(r.Value, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "%s\n", b)
+	return nil
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+type testRegister struct {
+	Name  string
+	Value int
+}
+
+func buildResultTree() *Tree {
+	tree := NewTree(TreeDescriptor{Name: "tree"})
+	tree.AddCommand(CommandDescriptor{
+		Name: "regs",
+		ResultHandler: func(ctx *ExecContext, args []string) (*Result, error) {
+			return &Result{Value: []testRegister{{"a", 1}, {"pc", 32768}}}, nil
+		},
+	})
+	tree.AddCommand(CommandDescriptor{
+		Name: "status",
+		ResultHandler: func(ctx *ExecContext, args []string) (*Result, error) {
+			return &Result{Value: map[string]any{"connected": true}, Text: "connected"}, nil
+		},
+	})
+	return tree
+}
+
+func TestRenderers(t *testing.T) {
+	cases := []struct {
+		renderer Renderer
+		line     string
+		output   string
+	}{
+		{TextRenderer, "regs", "{a 1}\n{pc 32768}\n"},
+		{TextRenderer, "status", "connected\n"},
+		{TableRenderer, "regs", "Name  Value\na     1\npc    32768\n"},
+		{TableRenderer, "status", "connected\n"},
+		{TextRenderer, "regs --json",
+			"[\n  {\n    \"Name\": \"a\",\n    \"Value\": 1\n  },\n" +
+				"  {\n    \"Name\": \"pc\",\n    \"Value\": 32768\n  }\n]\n"},
+		{TableRenderer, "status --json", "{\n  \"connected\": true\n}\n"},
+	}
+
+	for i, c := range cases {
+		out := new(bytes.Buffer)
+		r := NewRunner(buildResultTree(), strings.NewReader(""), out)
+		r.Renderer = c.renderer
+		if err := r.Execute(c.line); err != nil {
+			t.Errorf("Case %d: unexpected error: %v\n", i, err)
+		}
+		if out.String() != c.output {
+			t.Errorf("Case %d: output mismatch.\nEXPECTED:\n%q\nGOT:\n%q\n", i, c.output, out.String())
+		}
+	}
+}
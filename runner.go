@@ -0,0 +1,707 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// A Handler is a function called when a command is executed by a Runner. It
+// receives the command's execution context and the arguments that followed
+// the command on the input line.
+type Handler func(ctx *ExecContext, args []string) error
+
+// Errors returned by the Runner.
+var (
+	ErrExit       = errors.New("Exit")
+	ErrNoHandler  = errors.New("Command has no handler")
+	ErrPermission = errors.New("Permission denied")
+	ErrDisabled   = errors.New("Command is disabled")
+	ErrTimeout    = errors.New("Command timed out")
+	ErrRedirect   = errors.New("Missing redirection target")
+)
+
+// An Autocorrect value selects how a Runner handles mistyped commands.
+type Autocorrect int
+
+// Autocorrect modes.
+const (
+	AutocorrectOff     Autocorrect = iota // report mistyped commands as not found
+	AutocorrectApply                      // apply a unique correction with a notice
+	AutocorrectConfirm                    // ask the user to confirm a unique correction
+)
+
+// A Runner reads command lines from an input source, executes them against a
+// command tree, and writes their output to an output destination.
+type Runner struct {
+	Tree        *Tree                              // command tree used to look up commands
+	Prompt      string                             // prompt displayed before each input line
+	PromptFunc  func(r *Runner) string             // optional function returning the prompt
+	In          io.Reader                          // source of command lines
+	Out         io.Writer                          // destination for prompts and output
+	Err         io.Writer                          // destination for errors
+	Session     any                                // user-defined session data
+	User        string                             // identity of the session's user
+	Authorize   func(user string, c *Command) bool // optional command permission check
+	Renderer    Renderer                           // renderer for command results
+	Pager       Pager                              // optional pager for long output
+	Redirect    bool                               // allow '>' and '>>' output redirection
+	Background  bool                               // allow '&' to run commands as background jobs
+	Pipes       bool                               // allow '|' to pipe output between commands
+	Heredoc     bool                               // allow '<<MARKER' multi-line arguments
+	Autocorrect Autocorrect                        // handling of mistyped commands
+	Modal       bool                               // naming a subtree enters it
+
+	// If Continuation is true, a line ending with a backslash or containing
+	// an unterminated quoted string is continued on the next input line,
+	// which is read after displaying ContinuationPrompt.
+	Continuation       bool
+	ContinuationPrompt string
+
+	ProgressMode     ProgressMode  // display of progress reported by handlers
+	ProgressInterval time.Duration // minimum time between progress log lines
+
+	// If Transcript is not nil, each command line executed by Run is
+	// recorded in it, along with the output the line produced. A transcript
+	// may be fed back through a runner by Replay.
+	Transcript io.Writer
+
+	// If History is not nil, each command line executed by Run is added
+	// to it.
+	History *History
+
+	// If LineReader is not nil, command lines are read from it rather
+	// than from In, as when the runner's input is edited interactively.
+	LineReader LineReader
+
+	// Commands carrying any of these tags, directly or through an ancestor
+	// tree, are refused with ErrDisabled.
+	DisabledTags []string
+
+	// If DryRun is true, every command is executed in dry-run mode, as if
+	// given DryRunFlag, and commands not honoring dry runs are refused
+	// with ErrNoDryRun. This lets destructive sequences be previewed.
+	DryRun bool
+
+	// If Metrics is not nil, the execution of each permitted command is
+	// reported to it, along with its duration and whether it failed.
+	Metrics Metrics
+
+	// If Tracer is not nil, the execution of each permitted command is
+	// traced by a span started by it. Handlers may start child spans from
+	// the span held by ExecContext.Context.
+	Tracer Tracer
+
+	reader *bufio.Reader
+	jobs   jobList
+	sched  scheduleList
+	stack  []*Tree
+	vars   varStore
+	limits rateLimiter
+	undo   undoStack
+	status atomic.Int64
+	fg     foreground
+}
+
+// A LineReader reads command lines for a runner, displaying the prompt
+// preceding each line. Line editors implement this interface.
+type LineReader interface {
+	ReadLine(prompt string) (string, error)
+}
+
+// NewRunner creates a new runner that executes command lines read from 'in'
+// against the tree, writing all output and errors to 'out'.
+func NewRunner(tree *Tree, in io.Reader, out io.Writer) *Runner {
+	return &Runner{
+		Tree:               tree,
+		Prompt:             "> ",
+		ContinuationPrompt: "... ",
+		In:                 in,
+		Out:                out,
+		Err:                out,
+		Renderer:           TextRenderer,
+	}
+}
+
+// Run reads and executes command lines until the input is exhausted or a
+// command handler returns ErrExit. If the handler wraps ErrExit in a
+// StatusError, the StatusError is returned. Errors returned by command
+// handlers are displayed by DisplayError and do not stop the runner. An
+// error writing to the runner's transcript stops the runner and is
+// returned.
+func (r *Runner) Run() error {
+	for {
+		r.ReportJobs(r.Out)
+		r.ReportSchedules(r.Out)
+		line, err := r.readCommand()
+		switch {
+		case err == ErrLineTooLong:
+			r.DisplayError(r.errWriter(), err)
+			continue
+		case err == io.EOF:
+			return nil
+		case err != nil:
+			return err
+		}
+
+		switch err := r.runLine(line); {
+		case err == ErrExit:
+			return nil
+		case err != nil:
+			return err
+		}
+	}
+}
+
+// prompt returns the prompt displayed before each input line. Unless the
+// runner has a prompt function, the path of the current tree, if any,
+// precedes the runner's prompt string.
+func (r *Runner) prompt() string {
+	if r.PromptFunc != nil {
+		return r.PromptFunc(r)
+	}
+	return r.Current().Path() + r.Prompt
+}
+
+// Current returns the tree from which the runner looks up commands. Unless
+// changed by SetCurrent or Enter, it is the runner's Tree.
+func (r *Runner) Current() *Tree {
+	if len(r.stack) == 0 {
+		return r.Tree
+	}
+	return r.stack[len(r.stack)-1]
+}
+
+// SetCurrent changes the tree from which the runner looks up commands, so
+// that the commands of a subtree may be executed without typing the
+// subtree's path. Commands outside the current tree may be reached through
+// global shortcuts. SetCurrent discards the trees entered by Enter. Setting
+// a nil tree restores the runner's Tree.
+func (r *Runner) SetCurrent(t *Tree) {
+	r.stack = r.stack[:0]
+	if t != nil {
+		r.stack = append(r.stack, t)
+	}
+}
+
+// Enter makes t the runner's current tree, remembering the previous current
+// tree so that it may be restored by Leave.
+func (r *Runner) Enter(t *Tree) {
+	r.stack = append(r.stack, t)
+}
+
+// Leave restores the current tree that preceded the last call to Enter. It
+// returns false if there is no tree to leave.
+func (r *Runner) Leave() bool {
+	if len(r.stack) == 0 {
+		return false
+	}
+	r.stack = r.stack[:len(r.stack)-1]
+	return true
+}
+
+// Autocomplete returns auto-completion candidates for the line, looked up
+// from the runner's current tree.
+func (r *Runner) Autocomplete(line string) []string {
+	return r.Current().Autocomplete(line)
+}
+
+// errWriter returns the writer to which errors are displayed.
+func (r *Runner) errWriter() io.Writer {
+	if r.Err != nil {
+		return r.Err
+	}
+	return r.Out
+}
+
+// readLine displays the prompt and reads the next line of input, stripping
+// its line terminator. If the tree limits the length of lines, the remainder
+// of a longer line is discarded and ErrLineTooLong is returned.
+func (r *Runner) readLine(prompt string) (string, error) {
+	limit := r.Tree.MaxLineLength()
+	if r.LineReader != nil {
+		line, err := r.LineReader.ReadLine(prompt)
+		if err == nil && limit > 0 && len(line) > limit {
+			return "", ErrLineTooLong
+		}
+		return line, err
+	}
+
+	fmt.Fprint(r.Out, prompt)
+	return r.readInput(limit)
+}
+
+// readInput reads the next line of the runner's input, stripping its line
+// terminator. If limit isn't zero, the remainder of a line longer than limit
+// is discarded and ErrLineTooLong is returned.
+func (r *Runner) readInput(limit int) (string, error) {
+	if limit == 0 {
+		line, err := r.input().ReadString('\n')
+		if err == io.EOF && line != "" {
+			err = nil
+		}
+		return strings.TrimRight(line, "\r\n"), err
+	}
+
+	var line []byte
+	discarded := false
+	for {
+		chunk, err := r.input().ReadSlice('\n')
+		if !discarded {
+			line = append(line, chunk...)
+			if len(bytes.TrimRight(line, "\r\n")) > limit {
+				line, discarded = nil, true
+			}
+		}
+		switch {
+		case err == bufio.ErrBufferFull:
+			continue
+		case discarded && (err == nil || err == io.EOF):
+			return "", ErrLineTooLong
+		case err == io.EOF && len(line) > 0:
+			err = nil
+		}
+		return strings.TrimRight(string(line), "\r\n"), err
+	}
+}
+
+// readCommand reads the next command line, which spans several lines of
+// input if the runner allows continuation lines or heredocs.
+func (r *Runner) readCommand() (string, error) {
+	line, err := r.readContinued()
+	if err != nil || !r.Heredoc {
+		return line, err
+	}
+	return r.readHeredoc(line)
+}
+
+// readContinued reads the next command line, along with its continuation
+// lines if the runner allows them.
+func (r *Runner) readContinued() (string, error) {
+	line, err := r.readLine(r.prompt())
+	if err != nil || !r.Continuation {
+		return line, err
+	}
+	for {
+		var sep string
+		switch {
+		case strings.Count(line, "\"")%2 != 0:
+			sep = "\n"
+		case continued(line):
+			line, sep = line[:len(line)-1], " "
+		default:
+			return line, nil
+		}
+
+		next, err := r.readLine(r.ContinuationPrompt)
+		if err != nil {
+			if err == io.EOF {
+				return line, nil
+			}
+			return "", err
+		}
+		line += sep + next
+	}
+}
+
+// continued returns true if the line ends with an odd number of
+// backslashes, the last of which escapes the line terminator.
+func continued(line string) bool {
+	n := len(line) - len(strings.TrimRight(line, "\\"))
+	return n%2 != 0
+}
+
+// input returns the buffered reader wrapping the runner's input source.
+func (r *Runner) input() *bufio.Reader {
+	if r.reader == nil {
+		r.reader = bufio.NewReader(r.In)
+	}
+	return r.reader
+}
+
+// Execute looks up the command contained in the line and calls its handler.
+// If the command has a result handler, the returned result is displayed
+// using the runner's renderer, or using the JSON renderer if the line ends
+// with JSONFlag. If the line names a subtree, the subtree's help is displayed
+// instead. Blank lines are ignored.
+//
+// If the command honors dry runs and its arguments include DryRunFlag, or
+// if the runner is in dry-run mode, the command is executed with
+// ExecContext.DryRun set.
+//
+// If the command has a timeout, either its own or one inherited from a tree
+// containing it, and its handler doesn't return in time, the handler's
+// context is cancelled and Execute returns ErrTimeout. Likewise, the
+// handler's context is cancelled by Interrupt, and Execute then returns
+// ErrInterrupted.
+//
+// If the runner is modal, a line naming a subtree enters the subtree rather
+// than displaying its help, so that the subtree's commands may be executed
+// without typing its path. A line consisting of ".." (or of "exit", when no
+// command named "exit" is available) leaves the subtree.
+//
+// If the line fails while a transaction begun by Begin is in progress, the
+// transaction is rolled back and a *TransactionError is returned.
+//
+// References to session variables in the line are expanded by ExpandVars.
+// The exit status of the line, as reported by ExitCode, is remembered and
+// returned by Status.
+//
+// If the runner allows background jobs and the line ends with '&', the
+// command is started as a background job by Start.
+//
+// If the runner allows pipes, the line may be a pipeline of command lines
+// separated by unquoted '|' characters. The commands run concurrently, and
+// the output of each is read by the next from its ExecContext.In. The
+// pipeline's output is that of its last command. The first error returned
+// by a command is returned, unless it only reports that the next command
+// stopped reading.
+//
+// If the runner allows redirection and the line ends with '>' or '>>'
+// followed by a file name, the command's output is written to (or appended
+// to) the named file. Otherwise, if the runner has a pager, the command's
+// output is collected and passed to the pager once the command completes.
+//
+// If the runner allows heredocs, the first line of the line may hold a
+// "<<MARKER" field, where the marker consists of letters, digits and
+// underscores. The lines following it, up to a line holding only the
+// marker, are passed to the command as a single argument in place of the
+// field. They aren't subject to variable expansion, pipes, redirection or
+// background execution. Run and RunScript read these lines from their
+// input.
+func (r *Runner) Execute(line string) error {
+	return r.rollbackOnError(r.executeStatus(line))
+}
+
+// executeStatus executes a command line, recording its exit status.
+func (r *Runner) executeStatus(line string) error {
+	var h *heredoc
+	if r.Heredoc {
+		line, h = splitHeredoc(line)
+	}
+	err := r.executeLine(r.ExpandVars(line), h)
+	r.status.Store(int64(ExitCode(err)))
+	return err
+}
+
+func (r *Runner) executeLine(line string, h *heredoc) error {
+	if r.Background {
+		if cmdline, ok := parseBackground(line); ok {
+			j, err := r.start(cmdline, h)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(r.Out, "[%d] %s\n", j.ID, j.Line)
+			return nil
+		}
+	}
+
+	ctx, done := r.foreground()
+	defer done()
+	ctx = withHeredoc(ctx, h)
+	if r.Redirect {
+		if cmdline, target, appending, ok := parseRedirect(line); ok {
+			return r.executeRedirect(ctx, cmdline, target, appending, r.errWriter())
+		}
+	}
+
+	if r.Pager == nil {
+		return r.execute(ctx, line, r.Out, r.errWriter())
+	}
+
+	buf := new(bytes.Buffer)
+	err := r.execute(ctx, line, buf, r.errWriter())
+	if buf.Len() > 0 {
+		if perr := r.Pager.Page(r.Out, r.input(), buf.String()); perr != nil && err == nil {
+			err = perr
+		}
+	}
+	return err
+}
+
+func (r *Runner) executeRedirect(ctx context.Context, line, target string, appending bool, ew io.Writer) error {
+	if target == "" {
+		return ErrRedirect
+	}
+
+	flag := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if appending {
+		flag = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	}
+	f, err := os.OpenFile(target, flag, 0o666)
+	if err != nil {
+		return err
+	}
+
+	err = r.execute(ctx, line, f, ew)
+	if cerr := f.Close(); cerr != nil && err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// parseRedirect searches the line for an unquoted '>' or '>>' redirection
+// operator. If found, it returns the command line preceding the operator,
+// the redirection target following it, and whether the output should be
+// appended to the target.
+func parseRedirect(line string) (cmdline, target string, appending, ok bool) {
+	ix, quoted := -1, false
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '"':
+			quoted = !quoted
+		case '>':
+			if !quoted {
+				ix = i
+			}
+		}
+	}
+	if ix < 0 {
+		return line, "", false, false
+	}
+
+	cmdline = line[:ix]
+	if ix > 0 && line[ix-1] == '>' {
+		cmdline, appending = line[:ix-1], true
+	}
+
+	target, remain := nextField(stripLeadingWhitespace(line[ix+1:]))
+	if remain != "" {
+		target = ""
+	}
+	return cmdline, target, appending, true
+}
+
+// execute executes the line, writing the command's output to w and its
+// diagnostics to ew. The command's context is derived from ctx. If the
+// runner allows pipes, the line may be a pipeline.
+func (r *Runner) execute(ctx context.Context, line string, w, ew io.Writer) error {
+	if r.Pipes {
+		if stages, ok := parsePipeline(line); ok {
+			return r.executePipeline(ctx, stages, w, ew)
+		}
+	}
+	return r.executeInput(ctx, line, nil, w, ew)
+}
+
+// executeInput executes the line, passing in to the command as its input.
+func (r *Runner) executeInput(ctx context.Context, line string, in io.Reader, w, ew io.Writer) error {
+	if strings.TrimSpace(line) == "" {
+		return nil
+	}
+
+	if r.Modal {
+		switch strings.TrimSpace(line) {
+		case "..":
+			r.Leave()
+			return nil
+		case "exit":
+			if _, _, err := r.Current().Lookup(line); errors.Is(err, ErrNotFound) && r.Leave() {
+				return nil
+			}
+		}
+	}
+
+	n, args, err := r.Current().Lookup(line)
+	if errors.Is(err, ErrNotFound) && r.Autocorrect != AutocorrectOff {
+		n, args, err = r.correct(line)
+	}
+	if err != nil {
+		return err
+	}
+
+	switch n := n.(type) {
+	case *Tree:
+		if r.Modal {
+			r.Enter(n)
+			return nil
+		}
+		n.DisplayHelp(w)
+		return nil
+	case *Command:
+		return r.executeCommand(ctx, in, w, ew, n, line, args)
+	}
+	return ErrNotFound
+}
+
+// executeCommand validates the command's arguments and calls its handler.
+func (r *Runner) executeCommand(base context.Context, in io.Reader, w, ew io.Writer, c *Command, line string, args []string) (err error) {
+	if c.Handler == nil && c.ResultHandler == nil {
+		return ErrNoHandler
+	}
+	if hasAnyTag(c, r.DisabledTags) {
+		return ErrDisabled
+	}
+	if r.Authorize != nil && !r.Authorize(r.User, c) {
+		return ErrPermission
+	}
+	if r.DryRun && !c.DryRun {
+		return ErrNoDryRun
+	}
+	if r.Metrics != nil {
+		path, start := nodePath(c), time.Now()
+		defer func() { r.recordMetrics(path, start, err) }()
+	}
+	if r.Tracer != nil {
+		var span TraceSpan
+		base, span = r.startSpan(base, c, args)
+		defer func() { endSpan(span, err) }()
+	}
+
+	invoked := invokedAs(line, len(args))
+	args = heredocArgs(base, args)
+	rawArgs := args
+	renderer := r.Renderer
+	if c.ResultHandler != nil && len(args) > 0 && args[len(args)-1] == JSONFlag {
+		renderer, args = JSONRenderer, args[:len(args)-1]
+	}
+	dryRun := r.DryRun
+	if c.DryRun {
+		var found bool
+		args, found = stripDryRun(args)
+		dryRun = dryRun || found
+	}
+
+	if err := c.checkArgCount(args); err != nil {
+		return err
+	}
+	values, err := c.parseArgs(args, r.Var)
+	if err != nil {
+		return err
+	}
+	if !dryRun {
+		if err := r.limits.allow(c, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	ctx := &ExecContext{
+		In:      in,
+		Out:     w,
+		Err:     ew,
+		Command: c,
+		Runner:  r,
+		Session: r.Session,
+		Values:  values,
+		Line:    line,
+		DryRun:  dryRun,
+		invoked: invoked,
+		ctx:     base,
+	}
+	defer func() {
+		if err == nil {
+			r.pushUndo(ctx, rawArgs)
+		}
+	}()
+
+	timeout := c.timeout()
+	if timeout <= 0 {
+		return usageError(c, handlerError(ctx.Context(), callHandler(ctx, args, renderer)))
+	}
+
+	// The handler runs in its own goroutine so that the runner can report
+	// the timeout even if the handler ignores the cancelled context. Its
+	// output is cut off at the timeout, so that a handler still running
+	// doesn't write to a writer its caller may close.
+	var cancel context.CancelFunc
+	ctx.ctx, cancel = context.WithTimeout(ctx.ctx, timeout)
+	defer cancel()
+	out, errOut := &gatedWriter{w: ctx.Out}, &gatedWriter{w: ctx.Err}
+	ctx.Out, ctx.Err = out, errOut
+	done := make(chan error, 1)
+	go func() {
+		done <- callHandler(ctx, args, renderer)
+	}()
+	select {
+	case err := <-done:
+		return usageError(c, handlerError(ctx.ctx, err))
+	case <-ctx.ctx.Done():
+		err := interruptError(ctx.ctx)
+		out.close(err)
+		errOut.close(err)
+		return err
+	}
+}
+
+// A gatedWriter passes writes through to a writer until it is closed, after
+// which writes fail with the error it was closed with.
+type gatedWriter struct {
+	mu  sync.Mutex
+	w   io.Writer
+	err error
+}
+
+func (g *gatedWriter) Write(p []byte) (int, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.err != nil {
+		return 0, g.err
+	}
+	return g.w.Write(p)
+}
+
+// close stops passing writes through, waiting for a write in progress.
+func (g *gatedWriter) close(err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.err = err
+}
+
+// callHandler calls the command's handler or result handler.
+func callHandler(ctx *ExecContext, args []string, renderer Renderer) error {
+	defer ctx.finishProgress()
+	if ctx.Command.ResultHandler != nil {
+		return executeResult(ctx, args, renderer)
+	}
+	return ctx.Command.Handler(ctx, args)
+}
+
+// correct attempts to correct a mistyped command line. Depending on the
+// runner's autocorrect mode, the correction is either applied with a notice
+// or applied only after the user confirms it.
+func (r *Runner) correct(line string) (n Node, args []string, err error) {
+	n, args, ok := r.Current().Correct(line)
+	if !ok {
+		return nil, nil, ErrNotFound
+	}
+
+	path := nodePath(n)
+	switch r.Autocorrect {
+	case AutocorrectApply:
+		fmt.Fprintf(r.errWriter(), "Assuming '%s'.\n", path)
+	case AutocorrectConfirm:
+		resp, err := r.readLine(fmt.Sprintf("Did you mean '%s'? [y/N] ", path))
+		if err != nil && err != io.EOF {
+			return nil, nil, err
+		}
+		switch strings.ToLower(strings.TrimSpace(resp)) {
+		case "y", "yes":
+		default:
+			return nil, nil, ErrNotFound
+		}
+	}
+	return n, args, nil
+}
+
+func executeResult(ctx *ExecContext, args []string, renderer Renderer) error {
+	if renderer == nil {
+		renderer = TextRenderer
+	}
+
+	result, err := ctx.Command.ResultHandler(ctx, args)
+	if err != nil {
+		return err
+	}
+	if result == nil {
+		return nil
+	}
+	return renderer.Render(ctx.Out, result)
+}
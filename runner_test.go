@@ -0,0 +1,369 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func buildRunnerTree() *Tree {
+	tree := NewTree(TreeDescriptor{Name: "tree"})
+	tree.AddCommand(CommandDescriptor{
+		Name: "echo",
+		Handler: func(ctx *ExecContext, args []string) error {
+			ctx.Println(strings.Join(args, " "))
+			return nil
+		},
+	})
+	tree.AddCommand(CommandDescriptor{
+		Name: "fail",
+		Handler: func(ctx *ExecContext, args []string) error {
+			return errors.New("Failed")
+		},
+	})
+	tree.AddCommand(CommandDescriptor{
+		Name: "quit",
+		Handler: func(ctx *ExecContext, args []string) error {
+			return ErrExit
+		},
+	})
+	tree.AddCommand(CommandDescriptor{Name: "nop"})
+
+	file := tree.AddSubtree(TreeDescriptor{Name: "file", Brief: "file commands"})
+	file.AddCommand(CommandDescriptor{Name: "open", Brief: "open a file"})
+	return tree
+}
+
+func TestRunner(t *testing.T) {
+	cases := []struct {
+		input  string
+		output string
+	}{
+		{"", "> "},
+		{"echo a b\n", "> a b\n> "},
+		{"\n  \necho x", "> > > x\n> "},
+		{"e \"a  b\" c\n", "> a  b c\n> "},
+		{"fail\n", "> Failed.\n> "},
+		{"nop\n", "> Command has no handler.\n> "},
+		{"foo\n", "> Command not found.\n> "},
+		{"file\n", "> file commands:\n    open  open a file\n\n> "},
+		{"quit\necho a\n", "> "},
+	}
+
+	for i, c := range cases {
+		out := new(bytes.Buffer)
+		r := NewRunner(buildRunnerTree(), strings.NewReader(c.input), out)
+		if err := r.Run(); err != nil {
+			t.Errorf("Case %d: unexpected error: %v\n", i, err)
+		}
+		if out.String() != c.output {
+			t.Errorf("Case %d: output mismatch.\nEXPECTED:\n%q\nGOT:\n%q\n", i, c.output, out.String())
+		}
+	}
+}
+
+func TestRunnerRedirect(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "out.txt")
+
+	r := NewRunner(buildRunnerTree(), strings.NewReader(""), io.Discard)
+	r.Redirect = true
+
+	lines := []string{
+		"echo one > " + path,
+		"echo two >>" + path,
+		"echo \"a > b\" >> \"" + path + "\"",
+	}
+	for _, line := range lines {
+		if err := r.Execute(line); err != nil {
+			t.Fatalf("unexpected error for '%s': %v", line, err)
+		}
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "one\ntwo\na > b\n"; string(b) != want {
+		t.Errorf("file contents mismatch.\nEXPECTED:\n%q\nGOT:\n%q\n", want, string(b))
+	}
+
+	for _, line := range []string{"echo >", "echo > a b"} {
+		if err := r.Execute(line); err != ErrRedirect {
+			t.Errorf("expected ErrRedirect for '%s', got %v", line, err)
+		}
+	}
+
+	out := new(bytes.Buffer)
+	r = NewRunner(buildRunnerTree(), strings.NewReader(""), out)
+	r.Execute("echo a > b")
+	if out.String() != "a > b\n" {
+		t.Errorf("redirection applied when disabled: %q", out.String())
+	}
+}
+
+func TestRunnerTimeout(t *testing.T) {
+	tree := NewTree(TreeDescriptor{Name: "tree"})
+	net := tree.AddSubtree(TreeDescriptor{Name: "net", Timeout: 20 * time.Millisecond})
+	probe := func(ctx *ExecContext, args []string) error {
+		select {
+		case <-ctx.Context().Done():
+			return ctx.Context().Err()
+		case <-time.After(time.Second):
+			return nil
+		}
+	}
+	hang := make(chan struct{})
+	defer close(hang)
+	net.AddCommand(CommandDescriptor{Name: "probe", Handler: probe})
+	net.AddCommand(CommandDescriptor{
+		Name: "hang",
+		Handler: func(ctx *ExecContext, args []string) error {
+			<-hang
+			return nil
+		},
+	})
+	net.AddCommand(CommandDescriptor{
+		Name:    "ping",
+		Timeout: time.Minute,
+		Handler: func(ctx *ExecContext, args []string) error {
+			if _, ok := ctx.Context().Deadline(); !ok {
+				return errors.New("no deadline")
+			}
+			return nil
+		},
+	})
+	tree.AddCommand(CommandDescriptor{
+		Name: "local",
+		Handler: func(ctx *ExecContext, args []string) error {
+			if _, ok := ctx.Context().Deadline(); ok {
+				return errors.New("unexpected deadline")
+			}
+			return nil
+		},
+	})
+
+	cases := []struct {
+		line string
+		err  error
+	}{
+		{"net probe", ErrTimeout},
+		{"net hang", ErrTimeout},
+		{"net ping", nil},
+		{"local", nil},
+	}
+
+	r := NewRunner(tree, strings.NewReader(""), new(bytes.Buffer))
+	for i, c := range cases {
+		if err := r.Execute(c.line); err != c.err {
+			t.Errorf("Case %d: expected %v, got %v", i, c.err, err)
+		}
+	}
+}
+
+func TestRunnerTimeoutRedirect(t *testing.T) {
+	tree := NewTree(TreeDescriptor{Name: "tree"})
+	finished := make(chan error, 1)
+	tree.AddCommand(CommandDescriptor{
+		Name:    "flood",
+		Timeout: 10 * time.Millisecond,
+		Handler: func(ctx *ExecContext, args []string) error {
+			// Ignore cancellation, writing until a write fails.
+			for {
+				if _, err := io.WriteString(ctx.Out, "x\n"); err != nil {
+					finished <- err
+					return err
+				}
+				time.Sleep(time.Millisecond)
+			}
+		},
+	})
+
+	path := filepath.Join(t.TempDir(), "out")
+	r := NewRunner(tree, nil, new(bytes.Buffer))
+	r.Redirect = true
+	if err := r.Execute("flood > " + path); err != ErrTimeout {
+		t.Fatalf("expected ErrTimeout, got %v", err)
+	}
+	before, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := <-finished; err != ErrTimeout {
+		t.Errorf("expected handler write to fail with ErrTimeout, got %v", err)
+	}
+	after, _ := os.ReadFile(path)
+	if len(before) == 0 || !bytes.Equal(before, after) {
+		t.Errorf("output written after timeout: %d bytes, then %d", len(before), len(after))
+	}
+}
+
+func TestRunnerCurrent(t *testing.T) {
+	tree := buildRunnerTree()
+	file, _, _ := tree.LookupSubtree("file")
+	file.AddCommand(CommandDescriptor{
+		Name: "top",
+		Handler: func(ctx *ExecContext, args []string) error {
+			ctx.Runner.SetCurrent(nil)
+			return nil
+		},
+	})
+	file.AddCommand(CommandDescriptor{
+		Name: "echo",
+		Handler: func(ctx *ExecContext, args []string) error {
+			ctx.Printf("file: %s\n", strings.Join(args, " "))
+			return nil
+		},
+	})
+	tree.AddCommand(CommandDescriptor{
+		Name: "conf",
+		Handler: func(ctx *ExecContext, args []string) error {
+			ctx.Runner.SetCurrent(file)
+			return nil
+		},
+	})
+	tree.AddGlobalShortcut("q", "quit")
+
+	out := new(bytes.Buffer)
+	r := NewRunner(tree, strings.NewReader("echo a\nconf\necho b\nfoo\ntop\necho c\nconf\nq\n"), out)
+	r.PromptFunc = func(r *Runner) string {
+		if p := r.Current().Path(); p != "" {
+			return "(" + p + ")# "
+		}
+		return "# "
+	}
+	if err := r.Run(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := "# a\n" +
+		"# " +
+		"(file)# file: b\n" +
+		"(file)# Command not found.\n" +
+		"(file)# " +
+		"# c\n" +
+		"# " +
+		"(file)# "
+	if out.String() != expected {
+		t.Errorf("Expected:\n%q\nGot:\n%q", expected, out.String())
+	}
+}
+
+func TestRunnerModal(t *testing.T) {
+	tree := buildRunnerTree()
+	file, _, _ := tree.LookupSubtree("file")
+	file.AddCommand(CommandDescriptor{
+		Name: "show",
+		Handler: func(ctx *ExecContext, args []string) error {
+			ctx.Println("shown")
+			return nil
+		},
+	})
+
+	out := new(bytes.Buffer)
+	r := NewRunner(tree, strings.NewReader("file\nshow\n..\nfile\nexit\nexit\nfile\nquit\n"), out)
+	r.Prompt = "> "
+	r.Modal = true
+	if err := r.Run(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := "> " +
+		"file> shown\n" +
+		"file> " +
+		"> " +
+		"file> " +
+		"> Command not found.\n" +
+		"> " +
+		"file> Command not found.\n" +
+		"file> "
+	if out.String() != expected {
+		t.Errorf("Expected:\n%q\nGot:\n%q", expected, out.String())
+	}
+
+	if got := r.Autocomplete("sh"); len(got) != 1 || got[0] != "show" {
+		t.Errorf("Expected autocomplete 'show', got %v", got)
+	}
+}
+
+func TestRunnerContinuation(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected string
+	}{
+		{"echo a \\\nb\n", "> ... a b\n> "},
+		{"echo a\\\n\\\nb\n", "> ... ... a b\n> "},
+		{"echo a\\\\\n", "> a\\\\\n> "},
+		{"echo \"a\nb\" c\n", "> ... a\nb c\n> "},
+		{"echo a \\\n", "> ... a\n> "},
+	}
+
+	for i, c := range cases {
+		out := new(bytes.Buffer)
+		r := NewRunner(buildRunnerTree(), strings.NewReader(c.input), out)
+		r.Continuation = true
+		if err := r.Run(); err != nil {
+			t.Errorf("Case %d: unexpected error: %v", i, err)
+			continue
+		}
+		if out.String() != c.expected {
+			t.Errorf("Case %d: expected %q, got %q", i, c.expected, out.String())
+		}
+	}
+}
+
+func TestRunnerMaxLineLength(t *testing.T) {
+	tree := buildRunnerTree()
+	tree.SetMaxLineLength(10)
+
+	out := new(bytes.Buffer)
+	input := "echo a\necho " + strings.Repeat("x", 5000) + "\necho 123456\necho b"
+	r := NewRunner(tree, strings.NewReader(input), out)
+	r.Prompt = ""
+	if err := r.Run(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := "a\nLine too long.\nLine too long.\nb\n"
+	if out.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, out.String())
+	}
+}
+
+type fakeLineReader struct {
+	lines   []string
+	prompts []string
+}
+
+func (f *fakeLineReader) ReadLine(prompt string) (string, error) {
+	f.prompts = append(f.prompts, prompt)
+	if len(f.lines) == 0 {
+		return "", io.EOF
+	}
+	line := f.lines[0]
+	f.lines = f.lines[1:]
+	return line, nil
+}
+
+func TestRunnerLineReader(t *testing.T) {
+	out := new(bytes.Buffer)
+	r := NewRunner(buildRunnerTree(), strings.NewReader("echo ignored\n"), out)
+	r.Continuation = true
+	lr := &fakeLineReader{lines: []string{"echo a \\", "b"}}
+	r.LineReader = lr
+	if err := r.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "a b\n" {
+		t.Errorf("unexpected output: %q", out.String())
+	}
+	if got := strings.Join(lr.prompts, "|"); got != "> |... |> " {
+		t.Errorf("unexpected prompts: %q", got)
+	}
+}
@@ -0,0 +1,286 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNoSchedule is returned when referring to a scheduled command line that
+// doesn't exist.
+var ErrNoSchedule = errors.New("No such schedule")
+
+// A Schedule is a command line scheduled by Runner.ScheduleAt or
+// Runner.ScheduleEvery to be executed in the background, once or at an
+// interval. Output written by the scheduled runs is collected by the
+// runner and displayed by ReportSchedules.
+type Schedule struct {
+	ID       int           // schedule number, unique within the runner
+	Line     string        // the command line, with sensitive arguments masked
+	Interval time.Duration // time between runs, or zero for a single run
+
+	mu     sync.Mutex
+	next   time.Time
+	runs   int
+	timer  *time.Timer
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// Next returns the time of the schedule's next run. It returns the zero
+// time once a single-run schedule has run.
+func (s *Schedule) Next() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.next
+}
+
+// Runs returns the number of times the schedule's command line has run.
+func (s *Schedule) Runs() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.runs
+}
+
+// A scheduleList tracks a runner's scheduled command lines and collects
+// the output of their runs.
+type scheduleList struct {
+	mu        sync.Mutex
+	schedules []*Schedule
+	next      int
+	out       syncBuffer
+}
+
+// ScheduleAt schedules the command line to be executed once, in the
+// background, at the given time. The line is looked up immediately, and an
+// error is returned if it doesn't name a command. References to session
+// variables in the line are expanded by ExpandVars when it is scheduled.
+func (r *Runner) ScheduleAt(t time.Time, line string) (*Schedule, error) {
+	return r.schedule(t, 0, r.ExpandVars(line))
+}
+
+// ScheduleEvery schedules the command line to be executed in the
+// background at the interval, starting one interval from now. A run is
+// skipped if the previous run hasn't completed. The line is looked up and
+// expanded as by ScheduleAt.
+func (r *Runner) ScheduleEvery(interval time.Duration, line string) (*Schedule, error) {
+	if interval <= 0 {
+		return nil, &ArgError{Name: "interval", Value: interval.String(), Err: errors.New("interval must be positive")}
+	}
+	return r.schedule(time.Now().Add(interval), interval, r.ExpandVars(line))
+}
+
+func (r *Runner) schedule(at time.Time, interval time.Duration, line string) (*Schedule, error) {
+	if _, _, err := r.Current().LookupCommand(line); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Schedule{
+		Line:     r.Current().MaskLine(strings.TrimSpace(line)),
+		Interval: interval,
+		next:     at,
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+
+	r.sched.mu.Lock()
+	r.sched.next++
+	s.ID = r.sched.next
+	r.sched.schedules = append(r.sched.schedules, s)
+	r.sched.mu.Unlock()
+
+	// Runs are looked up in the tree that was current when scheduled.
+	tree := r.Current()
+	s.mu.Lock()
+	s.timer = time.AfterFunc(time.Until(at), func() { r.runSchedule(s, tree, line) })
+	s.mu.Unlock()
+	return s, nil
+}
+
+// runSchedule executes a scheduled command line, and arranges its next run.
+func (r *Runner) runSchedule(s *Schedule, tree *Tree, line string) {
+	if s.ctx.Err() != nil {
+		return
+	}
+
+	buf := new(syncBuffer)
+	err := r.executeIn(s.ctx, tree, line, buf, buf)
+	if err != nil && s.ctx.Err() == nil {
+		r.DisplayError(buf, err)
+	}
+	r.sched.out.Write([]byte(buf.String()))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.runs++
+	if s.Interval == 0 {
+		s.next = time.Time{}
+		r.removeSchedule(s.ID)
+		return
+	}
+	now := time.Now()
+	for !s.next.After(now) {
+		s.next = s.next.Add(s.Interval)
+	}
+	s.timer.Reset(time.Until(s.next))
+}
+
+// executeIn executes a command line looked up in the tree rather than the
+// runner's current tree.
+func (r *Runner) executeIn(ctx context.Context, tree *Tree, line string, w, ew io.Writer) error {
+	n, args, err := tree.Lookup(line)
+	if err != nil {
+		return err
+	}
+	c, ok := n.(*Command)
+	if !ok {
+		return ErrNotFound
+	}
+	return r.executeCommand(ctx, nil, w, ew, c, line, args)
+}
+
+// Schedules returns the runner's scheduled command lines, in the order
+// they were scheduled.
+func (r *Runner) Schedules() []*Schedule {
+	r.sched.mu.Lock()
+	defer r.sched.mu.Unlock()
+	return append([]*Schedule(nil), r.sched.schedules...)
+}
+
+// Unschedule cancels the scheduled command line with the given ID. A run
+// in progress is cancelled through its context.
+func (r *Runner) Unschedule(id int) error {
+	r.sched.mu.Lock()
+	var s *Schedule
+	for _, x := range r.sched.schedules {
+		if x.ID == id {
+			s = x
+		}
+	}
+	r.sched.mu.Unlock()
+	if s == nil {
+		return ErrNoSchedule
+	}
+
+	s.cancel()
+	s.mu.Lock()
+	s.timer.Stop()
+	s.mu.Unlock()
+	r.removeSchedule(id)
+	return nil
+}
+
+// removeSchedule stops tracking the schedule with the given ID.
+func (r *Runner) removeSchedule(id int) {
+	r.sched.mu.Lock()
+	defer r.sched.mu.Unlock()
+	for i, s := range r.sched.schedules {
+		if s.ID == id {
+			r.sched.schedules = append(r.sched.schedules[:i], r.sched.schedules[i+1:]...)
+			return
+		}
+	}
+}
+
+// DisplaySchedules displays each of the runner's scheduled command lines,
+// with its interval and the time of its next run.
+func (r *Runner) DisplaySchedules(w io.Writer) {
+	for _, s := range r.Schedules() {
+		when := "once"
+		if s.Interval > 0 {
+			when = "every " + s.Interval.String()
+		}
+		fmt.Fprintf(w, "[%d] %-12s next %s  %s\n", s.ID, when, s.Next().Format("15:04:05"), s.Line)
+	}
+}
+
+// ReportSchedules displays the output written by scheduled command lines
+// since the last report, including the messages of errors they returned.
+// Run calls ReportSchedules before displaying each prompt.
+func (r *Runner) ReportSchedules(w io.Writer) {
+	io.WriteString(w, r.sched.out.take())
+}
+
+// EveryCommand returns the descriptor of a command that schedules a
+// command line to be executed at an interval, as in "every 2s status".
+func EveryCommand() CommandDescriptor {
+	return CommandDescriptor{
+		Name:  "every",
+		Brief: "Execute a command at an interval",
+		Args: []Arg{
+			{Name: "interval", Type: DurationType{}},
+			{Name: "command", Variadic: true},
+		},
+		Handler: func(ctx *ExecContext, args []string) error {
+			s, err := ctx.Runner.ScheduleEvery(ctx.Values["interval"].(time.Duration), joinFields(args[1:]))
+			if err != nil {
+				return err
+			}
+			ctx.Printf("[%d] %s\n", s.ID, s.Line)
+			return nil
+		},
+	}
+}
+
+// AtCommand returns the descriptor of a command that schedules a command
+// line to be executed once, at a time accepted by TimeType, as in
+// "at +5m save snapshot".
+func AtCommand() CommandDescriptor {
+	return CommandDescriptor{
+		Name:  "at",
+		Brief: "Execute a command at a later time",
+		Args: []Arg{
+			{Name: "time", Type: TimeType{}},
+			{Name: "command", Variadic: true},
+		},
+		Handler: func(ctx *ExecContext, args []string) error {
+			s, err := ctx.Runner.ScheduleAt(ctx.Values["time"].(time.Time), joinFields(args[1:]))
+			if err != nil {
+				return err
+			}
+			ctx.Printf("[%d] %s\n", s.ID, s.Line)
+			return nil
+		},
+	}
+}
+
+// SchedulesCommand returns the descriptor of a command that lists the
+// runner's scheduled command lines.
+func SchedulesCommand() CommandDescriptor {
+	return CommandDescriptor{
+		Name:  "schedules",
+		Brief: "List scheduled commands",
+		Handler: func(ctx *ExecContext, args []string) error {
+			ctx.Runner.DisplaySchedules(ctx.Out)
+			return nil
+		},
+	}
+}
+
+// UnscheduleCommand returns the descriptor of a command that cancels one of
+// the runner's scheduled command lines.
+func UnscheduleCommand() CommandDescriptor {
+	return CommandDescriptor{
+		Name:  "unschedule",
+		Brief: "Cancel a scheduled command",
+		Args:  []Arg{{Name: "schedule", Type: UintType{}}},
+		Handler: func(ctx *ExecContext, args []string) error {
+			return ctx.Runner.Unschedule(int(ctx.Values["schedule"].(uint64)))
+		},
+	}
+}
+
+// joinFields joins command-line fields into a line, quoting fields that
+// contain whitespace.
+func joinFields(fields []string) string {
+	quoted := make([]string, len(fields))
+	for i, f := range fields {
+		quoted[i] = quoteField(f)
+	}
+	return strings.Join(quoted, " ")
+}
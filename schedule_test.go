@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// waitRuns waits until the schedule has run at least n times.
+func waitRuns(t *testing.T, s *Schedule, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for s.Runs() < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("schedule %d ran %d times, expected %d", s.ID, s.Runs(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSchedule(t *testing.T) {
+	var count atomic.Int32
+	tree := NewTree(TreeDescriptor{Name: "tree"})
+	tree.AddCommand(CommandDescriptor{
+		Name: "status",
+		Handler: func(ctx *ExecContext, args []string) error {
+			ctx.Printf("status %d\n", count.Add(1))
+			return nil
+		},
+	})
+	tree.AddCommand(CommandDescriptor{
+		Name:    "fail",
+		Handler: func(ctx *ExecContext, args []string) error { return errors.New("Failed") },
+	})
+	tree.AddCommand(EveryCommand())
+	tree.AddCommand(AtCommand())
+	tree.AddCommand(SchedulesCommand())
+	tree.AddCommand(UnscheduleCommand())
+
+	out := new(bytes.Buffer)
+	r := NewRunner(tree, nil, out)
+
+	if err := r.Execute("every 5ms status"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Execute("at +5ms fail"); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "[1] status\n[2] fail\n" {
+		t.Errorf("unexpected output %q", out.String())
+	}
+	every, at := r.Schedules()[0], r.Schedules()[1]
+	if every.Interval != 5*time.Millisecond || at.Interval != 0 {
+		t.Errorf("unexpected intervals %v, %v", every.Interval, at.Interval)
+	}
+
+	waitRuns(t, every, 2)
+	waitRuns(t, at, 1)
+	if err := r.Unschedule(every.ID); err != nil {
+		t.Errorf("unexpected error '%v'", err)
+	}
+	if err := r.Unschedule(at.ID); err != ErrNoSchedule {
+		t.Errorf("expected ErrNoSchedule, got %v", err)
+	}
+	if n := len(r.Schedules()); n != 0 {
+		t.Errorf("expected no schedules, got %d", n)
+	}
+
+	out.Reset()
+	r.ReportSchedules(out)
+	report := out.String()
+	for _, s := range []string{"status 1\n", "status 2\n", "Failed.\n"} {
+		if !strings.Contains(report, s) {
+			t.Errorf("report %q is missing %q", report, s)
+		}
+	}
+	out.Reset()
+	r.ReportSchedules(out)
+	if out.Len() != 0 {
+		t.Errorf("unexpected second report %q", out.String())
+	}
+}
+
+func TestScheduleErrors(t *testing.T) {
+	tree := NewTree(TreeDescriptor{Name: "tree"})
+	tree.AddCommand(CommandDescriptor{Name: "status", Handler: func(ctx *ExecContext, args []string) error { return nil }})
+	tree.AddCommand(EveryCommand())
+	tree.AddCommand(SchedulesCommand())
+
+	out := new(bytes.Buffer)
+	r := NewRunner(tree, nil, out)
+
+	cases := []struct {
+		line string
+		err  error
+	}{
+		{"every 1s bogus", ErrNotFound},
+		{"every 0s status", nil},
+		{"every x status", nil},
+		{"every 1s", ErrMissingArg},
+	}
+	for i, c := range cases {
+		err := r.Execute(c.line)
+		switch {
+		case err == nil:
+			t.Errorf("Case %d: expected an error", i)
+		case c.err != nil && !errors.Is(err, c.err):
+			t.Errorf("Case %d: expected %v, got %v", i, c.err, err)
+		}
+	}
+
+	s, err := r.ScheduleEvery(time.Hour, "status")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Unschedule(s.ID)
+	out.Reset()
+	r.Execute("schedules")
+	want := "[1] every 1h0m0s next " + s.Next().Format("15:04:05") + "  status\n"
+	if out.String() != want {
+		t.Errorf("expected %q, got %q", want, out.String())
+	}
+}
+
+func TestTimeType(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		s     string
+		after time.Time
+		until time.Time
+		err   string
+	}{
+		{"+5m", now.Add(5 * time.Minute), now.Add(6 * time.Minute), ""},
+		{now.Add(time.Hour).Format("15:04:05"), now, now.Add(25 * time.Hour), ""},
+		{now.Add(-time.Hour).Format("15:04"), now, now.Add(25 * time.Hour), ""},
+		{"2030-01-02T03:04:05Z", time.Date(2030, 1, 2, 3, 4, 4, 0, time.UTC), time.Date(2030, 1, 2, 3, 4, 6, 0, time.UTC), ""},
+		{"+x", time.Time{}, time.Time{}, "invalid time '+x'"},
+		{"25:00", time.Time{}, time.Time{}, "invalid time '25:00'"},
+	}
+
+	for i, c := range cases {
+		v, err := TimeType{}.Parse(c.s)
+		switch {
+		case c.err != "":
+			if err == nil || err.Error() != c.err {
+				t.Errorf("Case %d: expected error %q, got %v", i, c.err, err)
+			}
+		case err != nil:
+			t.Errorf("Case %d: unexpected error '%v'", i, err)
+		case v.(time.Time).Before(c.after) || v.(time.Time).After(c.until):
+			t.Errorf("Case %d: %v not between %v and %v", i, v, c.after, c.until)
+		}
+	}
+}
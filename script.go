@@ -0,0 +1,231 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Errors returned when a script's control flow is malformed.
+var (
+	ErrMissingEnd  = errors.New("Missing 'end'")
+	ErrUnexpected  = errors.New("Unexpected statement")
+	ErrRepeatCount = errors.New("Invalid repeat count")
+)
+
+// A ScriptError describes an error that stopped a script, and the line of
+// the script on which it occurred.
+type ScriptError struct {
+	Line int   // script line number
+	Err  error // the error returned by the line
+}
+
+func (e *ScriptError) Error() string {
+	return fmt.Sprintf("Line %d: %v", e.Line, e.Err)
+}
+
+func (e *ScriptError) Unwrap() error {
+	return e.Err
+}
+
+// A statement is a command line or control-flow block of a script.
+type statement struct {
+	line   int         // script line number
+	kind   string      // "" for a command line, or "if", "repeat" or "transaction"
+	text   string      // command line, condition or repeat count
+	body   []statement // statements of an if, repeat or transaction block
+	orElse []statement // statements of an if block's else branch
+}
+
+// RunScript executes the command lines read from the script, stopping at
+// the first line that returns an error, which is returned as a
+// *ScriptError. A line returning ErrExit stops the script without error,
+// and a line returning a StatusError wrapping ErrExit stops the script and
+// returns the StatusError. ExitCode maps the returned error to a process
+// exit status. Blank lines and lines beginning with '#' are ignored.
+//
+// A script may use the following control-flow blocks, which may be nested:
+//
+//	if <command>
+//	  ...
+//	else
+//	  ...
+//	end
+//
+//	repeat <count>
+//	  ...
+//	end
+//
+//	transaction
+//	  ...
+//	end
+//
+// The condition of an if block is a command line, which holds if the
+// command's exit status is 0, that is, if it returns no error. The else
+// branch is optional. A condition naming a command that can't be found or
+// is ambiguous stops the script. A repeat block executes its body count
+// times; session variables in the count are expanded before it is parsed.
+// A transaction block executes its body within a transaction, as described
+// by Runner.Begin, which is committed if the body completes and rolled back
+// if it fails.
+func (r *Runner) RunScript(script io.Reader) error {
+	stmts, err := parseScript(script, r.Heredoc)
+	if err != nil {
+		return err
+	}
+	err = r.runStatements(stmts)
+	if err == ErrExit {
+		return nil
+	}
+	return err
+}
+
+func (r *Runner) runStatements(stmts []statement) error {
+	for _, s := range stmts {
+		if err := r.runStatement(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Runner) runStatement(s statement) error {
+	switch s.kind {
+	case "if":
+		err := r.executeStatus(s.text)
+		switch {
+		case errors.Is(err, ErrExit):
+			return err
+		case errors.Is(err, ErrNotFound) || errors.Is(err, ErrAmbiguous):
+			return &ScriptError{Line: s.line, Err: err}
+		case err == nil:
+			return r.runStatements(s.body)
+		}
+		return r.runStatements(s.orElse)
+
+	case "repeat":
+		n, err := strconv.Atoi(strings.TrimSpace(r.ExpandVars(s.text)))
+		if err != nil || n < 0 {
+			return &ScriptError{Line: s.line, Err: ErrRepeatCount}
+		}
+		for range n {
+			if err := r.runStatements(s.body); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case "transaction":
+		if err := r.Begin(); err != nil {
+			return &ScriptError{Line: s.line, Err: err}
+		}
+		if err := r.runStatements(s.body); err != nil {
+			if r.InTransaction() {
+				var serr *ScriptError
+				if errors.As(err, &serr) {
+					serr.Err = &TransactionError{Err: serr.Err, Rollback: r.Abort()}
+				} else {
+					r.Abort()
+				}
+			}
+			return err
+		}
+		return r.Commit()
+	}
+
+	switch err := r.Execute(s.text); {
+	case errors.Is(err, ErrExit):
+		return err
+	case err != nil:
+		return &ScriptError{Line: s.line, Err: err}
+	}
+	return nil
+}
+
+// parseScript reads a script and parses its control-flow blocks. If
+// heredocs is true, the lines of a heredoc are made part of the command line
+// introducing it.
+func parseScript(script io.Reader, heredocs bool) ([]statement, error) {
+	type block struct {
+		s      statement
+		inElse bool
+	}
+	var top []statement
+	var stack []*block
+	add := func(s statement) {
+		switch {
+		case len(stack) == 0:
+			top = append(top, s)
+		case stack[len(stack)-1].inElse:
+			b := stack[len(stack)-1]
+			b.s.orElse = append(b.s.orElse, s)
+		default:
+			b := stack[len(stack)-1]
+			b.s.body = append(b.s.body, s)
+		}
+	}
+
+	scanner := bufio.NewScanner(script)
+	for n := 1; scanner.Scan(); n++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		word, rest, _ := strings.Cut(text, " ")
+		switch word {
+		case "if", "repeat":
+			if strings.TrimSpace(rest) == "" {
+				name := "condition"
+				if word == "repeat" {
+					name = "count"
+				}
+				return nil, &ScriptError{Line: n, Err: &ArgError{Name: name, Err: ErrMissingArg}}
+			}
+			stack = append(stack, &block{s: statement{line: n, kind: word, text: rest}})
+			continue
+		case "else":
+			if len(stack) == 0 || stack[len(stack)-1].s.kind != "if" || stack[len(stack)-1].inElse || rest != "" {
+				return nil, &ScriptError{Line: n, Err: ErrUnexpected}
+			}
+			stack[len(stack)-1].inElse = true
+			continue
+		case "transaction":
+			if rest != "" {
+				return nil, &ScriptError{Line: n, Err: ErrUnexpected}
+			}
+			stack = append(stack, &block{s: statement{line: n, kind: word}})
+			continue
+		case "end":
+			if len(stack) == 0 || rest != "" {
+				return nil, &ScriptError{Line: n, Err: ErrUnexpected}
+			}
+			b := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			add(b.s)
+			continue
+		}
+		s := statement{line: n, text: text}
+		if marker, _, ok := heredocMarker(text); heredocs && ok {
+			h := &heredoc{marker: marker}
+			for scanner.Scan() {
+				n++
+				s.text += "\n" + scanner.Text()
+				if h.isEnd(scanner.Text()) {
+					break
+				}
+			}
+		}
+		add(s)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(stack) > 0 {
+		return nil, &ScriptError{Line: stack[len(stack)-1].s.line, Err: ErrMissingEnd}
+	}
+	return top, nil
+}
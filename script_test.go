@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func newScriptTree(connected *bool, steps *int) *Tree {
+	tree := NewTree(TreeDescriptor{Name: "app"})
+	tree.AddCommand(SetCommand())
+	tree.AddCommand(EchoCommand())
+	tree.AddCommand(CommandDescriptor{
+		Name: "connected",
+		Handler: func(ctx *ExecContext, args []string) error {
+			if !*connected {
+				return errors.New("Not connected")
+			}
+			return nil
+		},
+	})
+	tree.AddCommand(CommandDescriptor{
+		Name: "step",
+		Handler: func(ctx *ExecContext, args []string) error {
+			*steps++
+			return nil
+		},
+	})
+	tree.AddCommand(CommandDescriptor{
+		Name: "fail",
+		Handler: func(ctx *ExecContext, args []string) error {
+			return errors.New("Failed")
+		},
+	})
+	tree.AddCommand(CommandDescriptor{
+		Name: "quit",
+		Handler: func(ctx *ExecContext, args []string) error {
+			return ErrExit
+		},
+	})
+	return tree
+}
+
+func TestRunScript(t *testing.T) {
+	cases := []struct {
+		script    string
+		connected bool
+		output    string
+		steps     int
+		err       error
+		line      int
+	}{
+		{"# bring-up\nrepeat 3\n  step\nend\n", false, "", 3, nil, 0},
+		{"set n 2\nrepeat $n\n repeat 2\n  step\n end\nend\n", false, "", 4, nil, 0},
+		{"if connected\necho yes\nelse\necho no\nend\n", true, "yes\n", 0, nil, 0},
+		{"if connected\necho yes\nelse\necho no\nend\n", false, "no\n", 0, nil, 0},
+		{"if connected\necho yes\nend\necho after\n", false, "after\n", 0, nil, 0},
+		{"step\nfail\nstep\n", false, "", 1, errors.New("Failed"), 2},
+		{"step\nquit\nstep\n", false, "", 1, nil, 0},
+		{"if bogus\nstep\nend\n", false, "", 0, ErrNotFound, 1},
+		{"repeat x\nstep\nend\n", false, "", 0, ErrRepeatCount, 1},
+		{"repeat 2\nstep\n", false, "", 0, ErrMissingEnd, 1},
+		{"step\nend\n", false, "", 0, ErrUnexpected, 2},
+		{"repeat 2\nelse\nend\n", false, "", 0, ErrUnexpected, 2},
+		{"if\nend\n", false, "", 0, ErrMissingArg, 1},
+	}
+	for i, c := range cases {
+		connected, steps := c.connected, 0
+		var out bytes.Buffer
+		r := NewRunner(newScriptTree(&connected, &steps), nil, &out)
+		err := r.RunScript(strings.NewReader(c.script))
+
+		var serr *ScriptError
+		switch {
+		case c.err == nil && err != nil:
+			t.Errorf("Case %d: unexpected error %v", i, err)
+			continue
+		case c.err != nil && !errors.As(err, &serr):
+			t.Errorf("Case %d: expected script error, got %v", i, err)
+			continue
+		case c.err != nil && (serr.Line != c.line || serr.Err.Error() != c.err.Error() && !errors.Is(serr, c.err)):
+			t.Errorf("Case %d: expected error '%v' on line %d, got %v", i, c.err, c.line, err)
+		}
+		if out.String() != c.output {
+			t.Errorf("Case %d: expected output %q, got %q", i, c.output, out.String())
+		}
+		if steps != c.steps {
+			t.Errorf("Case %d: expected %d steps, got %d", i, c.steps, steps)
+		}
+	}
+}
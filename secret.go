@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/beevik/cmd/internal/term"
+)
+
+// A SecretReader reads a line of input without echoing it. Line readers
+// implement this interface so that handlers may read secrets through
+// ExecContext.ReadSecret.
+type SecretReader interface {
+	ReadSecret(prompt string) (string, error)
+}
+
+// ReadSecret displays the prompt and reads a line of input without echoing
+// it, as when asking for a password or key. If the runner's line reader
+// implements SecretReader, the line is read through it. Otherwise, the line
+// is read from the runner's input, whose echo is disabled while the line is
+// read if the input is a terminal.
+func (ctx *ExecContext) ReadSecret(prompt string) (string, error) {
+	if ctx.Runner == nil {
+		return "", io.EOF
+	}
+	return ctx.Runner.readSecret(prompt)
+}
+
+func (r *Runner) readSecret(prompt string) (string, error) {
+	if sr, ok := r.LineReader.(SecretReader); ok {
+		return sr.ReadSecret(prompt)
+	}
+	if r.In == nil {
+		return "", io.EOF
+	}
+
+	fmt.Fprint(r.Out, prompt)
+	if f, ok := r.In.(*os.File); ok && term.IsTerminal(f.Fd()) {
+		if restore, err := term.DisableEcho(f.Fd()); err == nil {
+			defer func() {
+				restore()
+				fmt.Fprintln(r.Out)
+			}()
+		}
+	}
+	return r.readInput(0)
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+type fakeSecretReader struct {
+	fakeLineReader
+	secret string
+}
+
+func (f *fakeSecretReader) ReadSecret(prompt string) (string, error) {
+	f.prompts = append(f.prompts, "secret:"+prompt)
+	return f.secret, nil
+}
+
+func buildSecretTree(got *string) *Tree {
+	tree := NewTree(TreeDescriptor{Name: "tree"})
+	tree.AddCommand(CommandDescriptor{
+		Name: "login",
+		Handler: func(ctx *ExecContext, args []string) error {
+			s, err := ctx.ReadSecret("Password: ")
+			*got = s
+			return err
+		},
+	})
+	return tree
+}
+
+func TestReadSecret(t *testing.T) {
+	var got string
+	out := new(bytes.Buffer)
+	r := NewRunner(buildSecretTree(&got), strings.NewReader("login\nhunter2\n"), out)
+	if err := r.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if got != "hunter2" {
+		t.Errorf("unexpected secret %q", got)
+	}
+	if out.String() != "> Password: > " {
+		t.Errorf("unexpected output %q", out.String())
+	}
+
+	lr := &fakeSecretReader{fakeLineReader{lines: []string{"login"}}, "s3cret"}
+	r = NewRunner(buildSecretTree(&got), nil, out)
+	r.LineReader = lr
+	if err := r.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if got != "s3cret" {
+		t.Errorf("unexpected secret %q", got)
+	}
+	if p := strings.Join(lr.prompts, "|"); p != "> |secret:Password: |> " {
+		t.Errorf("unexpected prompts %q", p)
+	}
+
+	ctx := &ExecContext{}
+	if _, err := ctx.ReadSecret("Password: "); err == nil {
+		t.Error("expected error reading secret without a runner")
+	}
+}
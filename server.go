@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+)
+
+// ErrServerClosed is returned by the Server's Serve and ListenAndServe
+// methods after a call to Close.
+var ErrServerClosed = errors.New("Server closed")
+
+// A Server accepts network connections and runs an interactive command
+// session on each one. Sessions use line-oriented input, so the server can be
+// reached with a plain TCP client such as telnet or netcat.
+type Server struct {
+	Tree     *Tree                       // command tree shared by all sessions
+	TreeFunc func(c net.Conn) *Tree      // optional per-connection tree builder
+	Prompt   string                      // prompt displayed to clients
+	Greeting string                      // text sent to clients on connection
+	OnError  func(c net.Conn, err error) // optional session error callback
+
+	mu        sync.Mutex
+	listeners map[net.Listener]struct{}
+	conns     map[net.Conn]struct{}
+	closed    bool
+	wg        sync.WaitGroup
+}
+
+// ListenAndServe listens on the TCP network address and then calls Serve to
+// handle incoming connections.
+func (s *Server) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+// Serve accepts incoming connections on the listener, running a command
+// session for each connection in its own goroutine. Serve always returns a
+// non-nil error and closes the listener.
+func (s *Server) Serve(l net.Listener) error {
+	if !s.trackListener(l, true) {
+		l.Close()
+		return ErrServerClosed
+	}
+	defer s.trackListener(l, false)
+	defer l.Close()
+
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			if s.isClosed() {
+				return ErrServerClosed
+			}
+			return err
+		}
+		if !s.trackConn(c, true) {
+			c.Close()
+			return ErrServerClosed
+		}
+		go s.serveConn(c)
+	}
+}
+
+// Close immediately closes all listeners and active connections, and waits
+// for all sessions to finish.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	var err error
+	for l := range s.listeners {
+		if cerr := l.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	for c := range s.conns {
+		c.Close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	return err
+}
+
+func (s *Server) serveConn(c net.Conn) {
+	defer s.wg.Done()
+	defer s.trackConn(c, false)
+	defer c.Close()
+
+	tree := s.Tree
+	if s.TreeFunc != nil {
+		tree = s.TreeFunc(c)
+	}
+
+	if s.Greeting != "" {
+		fmt.Fprintln(c, s.Greeting)
+	}
+
+	r := NewRunner(tree, c, c)
+	if s.Prompt != "" {
+		r.Prompt = s.Prompt
+	}
+	if err := r.Run(); err != nil && s.OnError != nil && !s.isClosed() {
+		s.OnError(c, err)
+	}
+}
+
+func (s *Server) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+func (s *Server) trackListener(l net.Listener, add bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.listeners == nil {
+		s.listeners = make(map[net.Listener]struct{})
+	}
+	if add {
+		if s.closed {
+			return false
+		}
+		s.listeners[l] = struct{}{}
+	} else {
+		delete(s.listeners, l)
+	}
+	return true
+}
+
+// trackConn adds or removes an active connection. Adding a connection also
+// adds its session to the wait group, under the lock guarding the closed
+// flag, so that Close waits for every session it doesn't refuse.
+func (s *Server) trackConn(c net.Conn, add bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conns == nil {
+		s.conns = make(map[net.Conn]struct{})
+	}
+	if add {
+		if s.closed {
+			return false
+		}
+		s.conns[c] = struct{}{}
+		s.wg.Add(1)
+	} else {
+		delete(s.conns, c)
+	}
+	return true
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"testing"
+)
+
+func TestServer(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("unable to listen: %v", err)
+	}
+
+	s := &Server{Tree: buildRunnerTree(), Prompt: "$ ", Greeting: "hello"}
+	done := make(chan error)
+	go func() { done <- s.Serve(l) }()
+
+	c, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatalf("unable to dial: %v", err)
+	}
+	defer c.Close()
+
+	fmt.Fprint(c, "echo remote\r\nquit\r\n")
+	rd := bufio.NewReader(c)
+	var got []string
+	for {
+		line, err := rd.ReadString('\n')
+		if line != "" {
+			got = append(got, line)
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	want := []string{"hello\n", "$ remote\n", "$ "}
+	if len(got) != len(want) {
+		t.Fatalf("unexpected output: %q", got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("line %d: expected %q, got %q", i, want[i], got[i])
+		}
+	}
+
+	s.Close()
+	if err := <-done; err != ErrServerClosed {
+		t.Errorf("expected ErrServerClosed, got %v", err)
+	}
+}
@@ -0,0 +1,34 @@
+package cmd
+
+import "io"
+
+// A Session is a bidirectional stream belonging to an authenticated user.
+// Sessions provided by SSH server packages, such as gliderlabs/ssh, satisfy
+// this interface.
+type Session interface {
+	io.ReadWriter
+	User() string // name of the session's authenticated user
+}
+
+// NewSessionRunner creates a runner that executes command lines read from the
+// session against the tree. The runner's User is set to the session's user,
+// so it can be consulted by the runner's Authorize function.
+//
+// Lines are read as plain text, so sessions must either be line-buffered by
+// the client (for example, an SSH session without a pseudo-terminal) or be
+// wrapped by a line editor.
+func NewSessionRunner(tree *Tree, s Session) *Runner {
+	r := NewRunner(tree, s, s)
+	r.User = s.User()
+	return r
+}
+
+// ServeSession runs an interactive command session until the session's input
+// is exhausted or a command handler returns ErrExit. The authorize function,
+// if not nil, is called before each command is executed to determine
+// whether the session's user may execute it.
+func ServeSession(tree *Tree, s Session, authorize func(user string, c *Command) bool) error {
+	r := NewSessionRunner(tree, s)
+	r.Authorize = authorize
+	return r.Run()
+}
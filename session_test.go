@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+type testSession struct {
+	io.Reader
+	bytes.Buffer
+	user string
+}
+
+func (s *testSession) Read(p []byte) (int, error) {
+	return s.Reader.Read(p)
+}
+
+func (s *testSession) User() string {
+	return s.user
+}
+
+func TestServeSession(t *testing.T) {
+	authorize := func(user string, c *Command) bool {
+		return user == "admin" || c.Name != "fail"
+	}
+
+	cases := []struct {
+		user   string
+		input  string
+		output string
+	}{
+		{"admin", "echo hi\n", "> hi\n> "},
+		{"guest", "echo hi\n", "> hi\n> "},
+		{"admin", "fail\n", "> Failed.\n> "},
+		{"guest", "fail\n", "> Permission denied.\n> "},
+	}
+
+	for i, c := range cases {
+		s := &testSession{Reader: strings.NewReader(c.input), user: c.user}
+		if err := ServeSession(buildRunnerTree(), s, authorize); err != nil {
+			t.Errorf("Case %d: unexpected error: %v\n", i, err)
+		}
+		if s.String() != c.output {
+			t.Errorf("Case %d: output mismatch.\nEXPECTED:\n%q\nGOT:\n%q\n", i, c.output, s.String())
+		}
+	}
+}
@@ -0,0 +1,344 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/beevik/prefixtree/v2"
+)
+
+// ErrShortcutNotFound is returned when removing a shortcut that doesn't
+// exist.
+var ErrShortcutNotFound = errors.New("Shortcut not found")
+
+// A Shortcut is an alternative name for a command, registered on a tree by
+// AddShortcut.
+type Shortcut struct {
+	Name    string   // the shortcut, typed in place of the command's path
+	Tree    *Tree    // the tree on which the shortcut is registered
+	Command *Command // the command invoked by the shortcut
+	Global  bool     // the shortcut may be used from any tree
+}
+
+// Line returns the full line used to invoke the shortcut from the root of
+// the command tree, including the path of the tree on which the shortcut is
+// registered.
+func (s Shortcut) Line() string {
+	if p := nodePath(s.Tree); p != "" && !s.Global {
+		return p + " " + s.Name
+	}
+	return s.Name
+}
+
+// AddShortcut adds a shortcut to a command in the tree. The target is looked
+// up from the tree. The shortcut is local to the tree: it may be used only
+// when a lookup reaches the tree, either by starting at it or by naming the
+// tree's path. See AddGlobalShortcut for shortcuts usable from any tree.
+//
+// A shortcut may consist of several words, such as "bp add". Unlike
+// single-word shortcuts, multi-word shortcuts can't be abbreviated.
+//
+// If the shortcut equals the name of one of the tree's commands or subtrees
+// or another of its shortcuts, the shortcut isn't added and a *ConflictError
+// is returned. A shortcut that is a prefix of such a name, or of which such
+// a name is a prefix, is added, although lookups of abbreviated names may
+// become ambiguous or resolve unexpectedly. Validate reports these hazards.
+func (t *Tree) AddShortcut(shortcut, target string) error {
+	shortcut, err := normalizeShortcut(shortcut)
+	if err != nil {
+		return err
+	}
+
+	cmd, _, err := t.LookupCommand(target)
+	if err != nil {
+		return err
+	}
+
+	if conflict := t.shortcutConflict(shortcut, false); conflict != nil {
+		return conflict
+	}
+
+	sc := Shortcut{Name: shortcut, Tree: t, Command: cmd}
+	cmd.addShortcut(shortcut)
+	t.shortcuts = append(t.shortcuts, sc)
+	t.indexShortcut(sc)
+	t.emit(ShortcutAdded{sc})
+	return nil
+}
+
+// indexShortcut adds a shortcut registered on the tree to the tree's lookup
+// structures.
+func (t *Tree) indexShortcut(sc Shortcut) {
+	t.invalidate()
+	multiWord := strings.Contains(sc.Name, " ")
+	switch {
+	case sc.Global && multiWord:
+		t.globalPhrases = append(t.globalPhrases, sc)
+	case sc.Global:
+		if t.globals == nil {
+			t.globals = prefixtree.New[Node]()
+		}
+		t.globals.Add(sc.Name, sc.Command)
+	case multiWord:
+		t.phrases = append(t.phrases, sc)
+	default:
+		t.pt.Add(sc.Name, sc.Command)
+	}
+}
+
+// reindex rebuilds the tree's lookup structures from its commands, subtrees
+// and shortcuts.
+func (t *Tree) reindex() {
+	t.invalidate()
+	t.pt = prefixtree.New[Node]()
+	t.phrases, t.globals, t.globalPhrases = nil, nil, nil
+	for _, c := range t.commands {
+		t.pt.Add(c.Name, c)
+		for _, a := range c.Aliases {
+			t.pt.Add(a, c)
+		}
+	}
+	for _, st := range t.subtrees {
+		t.pt.Add(st.Name, st)
+	}
+	for _, sc := range t.shortcuts {
+		t.indexShortcut(sc)
+	}
+}
+
+// RemoveShortcut removes the shortcut registered on the tree, either by
+// AddShortcut or, if t is the root, by AddGlobalShortcut. To reassign a
+// shortcut to a different command, remove it and add it again.
+func (t *Tree) RemoveShortcut(shortcut string) error {
+	shortcut, err := normalizeShortcut(shortcut)
+	if err != nil {
+		return err
+	}
+	removed := t.removeShortcuts(func(sc Shortcut) bool { return sc.Name == shortcut })
+	if len(removed) == 0 {
+		return ErrShortcutNotFound
+	}
+	t.emitRemoved(removed)
+	return nil
+}
+
+// RemoveShortcut removes the shortcut to the command, from whichever tree it
+// is registered on.
+func (c *Command) RemoveShortcut(shortcut string) error {
+	shortcut, err := normalizeShortcut(shortcut)
+	if err != nil {
+		return err
+	}
+	var removed []Shortcut
+	for t := range c.shortcutTrees() {
+		removed = append(removed, t.removeShortcuts(func(sc Shortcut) bool { return sc.Name == shortcut && sc.Command == c })...)
+	}
+	if len(removed) == 0 {
+		return ErrShortcutNotFound
+	}
+	slices.SortFunc(removed, func(a, b Shortcut) int { return strings.Compare(a.Tree.Path(), b.Tree.Path()) })
+	c.parent.emitRemoved(removed)
+	return nil
+}
+
+// shortcutTrees returns the set of trees on which shortcuts to the command
+// are registered.
+func (c *Command) shortcutTrees() map[*Tree]bool {
+	trees := make(map[*Tree]bool)
+	for _, sc := range c.parent.root().sortedShortcuts() {
+		if sc.Command == c {
+			trees[sc.Tree] = true
+		}
+	}
+	return trees
+}
+
+// removeShortcuts removes the tree's shortcuts matching the predicate and
+// returns the removed shortcuts.
+func (t *Tree) removeShortcuts(match func(sc Shortcut) bool) []Shortcut {
+	var removed []Shortcut
+	kept := t.shortcuts[:0]
+	for _, sc := range t.shortcuts {
+		if match(sc) {
+			sc.Command.deleteShortcut(sc.Name)
+			removed = append(removed, sc)
+		} else {
+			kept = append(kept, sc)
+		}
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+	clear(t.shortcuts[len(kept):])
+	t.shortcuts = kept
+	t.reindex()
+	return removed
+}
+
+// emitRemoved reports the removal of shortcuts to the command tree's
+// observers.
+func (t *Tree) emitRemoved(removed []Shortcut) {
+	for _, sc := range removed {
+		t.emit(ShortcutRemoved{sc})
+	}
+}
+
+// normalizeShortcut separates the words of a shortcut by single spaces.
+func normalizeShortcut(shortcut string) (string, error) {
+	words := strings.Fields(shortcut)
+	if len(words) == 0 || strings.Contains(shortcut, "\"") {
+		return "", errors.New("invalid shortcut")
+	}
+	return strings.Join(words, " "), nil
+}
+
+// matchPhrase returns the longest multi-word shortcut whose words match the
+// leading fields of a line, along with the remainder of the line following
+// the shortcut. The line is given as its first field and the remainder. If
+// no shortcut matches, matchPhrase returns nil.
+func matchPhrase(phrases []Shortcut, field, remain string) (sc *Shortcut, rest string) {
+	best := 0
+	for i := range phrases {
+		words, f, r := phrases[i].Name, field, remain
+		n := 0
+		for words != "" {
+			var w string
+			w, words = nextField(words)
+			if n > 0 {
+				f, r = nextField(r)
+			}
+			if f != w {
+				n = 0
+				break
+			}
+			n++
+		}
+		if n > best {
+			best, sc, rest = n, &phrases[i], r
+		}
+	}
+	return sc, rest
+}
+
+// AddGlobalShortcut adds a shortcut to a command in the command tree
+// containing t. The target is looked up from the root of the command tree.
+//
+// Unlike a shortcut added by AddShortcut, which may only be used when a
+// lookup reaches the tree on which it was registered, a global shortcut may
+// be used as the first field of a lookup starting at any tree. A global
+// shortcut is matched only if no command, subtree or local shortcut of the
+// starting tree matches the field. Conflicts with the names and shortcuts of
+// the root tree are reported as described by AddShortcut.
+func (t *Tree) AddGlobalShortcut(shortcut, target string) error {
+	shortcut, err := normalizeShortcut(shortcut)
+	if err != nil {
+		return err
+	}
+
+	r := t.root()
+	cmd, _, err := r.LookupCommand(target)
+	if err != nil {
+		return err
+	}
+
+	if conflict := r.shortcutConflict(shortcut, true); conflict != nil {
+		return conflict
+	}
+
+	sc := Shortcut{Name: shortcut, Tree: r, Command: cmd, Global: true}
+	cmd.addShortcut(shortcut)
+	r.shortcuts = append(r.shortcuts, sc)
+	r.indexShortcut(sc)
+	r.emit(ShortcutAdded{sc})
+	return nil
+}
+
+// findGlobal returns the global shortcut matching the prefix, along with
+// the command it invokes.
+func (t *Tree) findGlobal(prefix string) (prefixtree.KeyValue[Node], error) {
+	r := t.root()
+	if r.globals == nil {
+		return prefixtree.KeyValue[Node]{}, prefixtree.ErrPrefixNotFound
+	}
+	return r.globals.FindKeyValue(prefix)
+}
+
+// findGlobals returns the global shortcuts matching the prefix.
+func (t *Tree) findGlobals(prefix string) []prefixtree.KeyValue[Node] {
+	r := t.root()
+	if r.globals == nil {
+		return nil
+	}
+	return r.globals.FindKeyValues(prefix)
+}
+
+// addShortcut inserts a shortcut into the command's sorted shortcut list.
+func (c *Command) addShortcut(shortcut string) {
+	i := sort.SearchStrings(c.shortcuts, shortcut)
+	c.shortcuts = append(c.shortcuts, "")
+	copy(c.shortcuts[i+1:], c.shortcuts[i:])
+	c.shortcuts[i] = shortcut
+}
+
+// deleteShortcut removes one occurrence of a shortcut from the command's
+// shortcut list.
+func (c *Command) deleteShortcut(shortcut string) {
+	for i, s := range c.shortcuts {
+		if s == shortcut {
+			c.shortcuts = append(c.shortcuts[:i], c.shortcuts[i+1:]...)
+			break
+		}
+	}
+	if len(c.shortcuts) == 0 {
+		c.shortcuts = nil
+	}
+}
+
+// Shortcuts returns all shortcuts registered on the tree and its descendant
+// subtrees, sorted by their invocation lines.
+func (t *Tree) Shortcuts() []Shortcut {
+	shortcuts := t.sortedShortcuts()
+	if len(shortcuts) == 0 {
+		return nil
+	}
+	return slices.Clone(shortcuts)
+}
+
+// DisplayShortcuts displays all shortcuts registered on the tree and its
+// descendant subtrees, along with the commands they invoke.
+func (t *Tree) DisplayShortcuts(w io.Writer) {
+	shortcuts := t.sortedShortcuts()
+	if len(shortcuts) == 0 {
+		return
+	}
+
+	maxLen := 0
+	for _, s := range shortcuts {
+		if n := displayWidth(s.Line()); n > maxLen {
+			maxLen = n
+		}
+	}
+
+	fmt.Fprintln(w, t.message(MsgShortcuts))
+	for _, s := range shortcuts {
+		fmt.Fprintf(w, "    %s  %s\n", padRight(s.Line(), maxLen), s.Command.CanonicalLine())
+	}
+	fmt.Fprintln(w)
+}
+
+// ShortcutsCommand returns the descriptor of a command that displays all
+// shortcuts in the command tree to which it is added.
+func ShortcutsCommand() CommandDescriptor {
+	return CommandDescriptor{
+		Name:  "shortcuts",
+		Brief: "List command shortcuts",
+		Handler: func(ctx *ExecContext, args []string) error {
+			ctx.Command.parent.root().DisplayShortcuts(ctx.Out)
+			return nil
+		},
+	}
+}
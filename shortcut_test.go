@@ -0,0 +1,210 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestShortcuts(t *testing.T) {
+	tree := buildTree()
+	file, _, _ := tree.LookupSubtree("file")
+	file.AddShortcut("r", "read")
+
+	var lines []string
+	for _, s := range tree.Shortcuts() {
+		lines = append(lines, s.Line()+"="+s.Command.CanonicalLine())
+	}
+	expected := "[dd=file open f=file open file r=file read xx=file open yy=file open zz=file open]"
+	if s := fmt.Sprint(lines); s != expected {
+		t.Errorf("Expected %s, got %s", expected, s)
+	}
+
+	if n := len(file.Shortcuts()); n != 1 {
+		t.Errorf("Expected 1 subtree shortcut, got %d", n)
+	}
+}
+
+func TestShortcutsCommand(t *testing.T) {
+	tree := NewTree(TreeDescriptor{Name: "tree"})
+	tree.AddCommand(ShortcutsCommand())
+	file := tree.AddSubtree(TreeDescriptor{Name: "file"})
+	file.AddCommand(CommandDescriptor{Name: "open"})
+	file.AddCommand(CommandDescriptor{Name: "close"})
+	tree.AddShortcut("o", "file open")
+	file.AddShortcut("cl", "close")
+
+	var out bytes.Buffer
+	r := NewRunner(tree, strings.NewReader(""), &out)
+	if err := r.Execute("shortcuts"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := "Shortcuts:\n" +
+		"    file cl  file close\n" +
+		"    o        file open\n" +
+		"\n"
+	if out.String() != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, out.String())
+	}
+}
+
+func TestScopedShortcuts(t *testing.T) {
+	tree := NewTree(TreeDescriptor{Name: "tree"})
+	tree.AddCommand(CommandDescriptor{Name: "quit"})
+	file := tree.AddSubtree(TreeDescriptor{Name: "file"})
+	file.AddCommand(CommandDescriptor{Name: "open"})
+	file.AddCommand(CommandDescriptor{Name: "close"})
+	file.AddCommand(CommandDescriptor{Name: "quiet"})
+	edit := tree.AddSubtree(TreeDescriptor{Name: "edit"})
+	edit.AddCommand(CommandDescriptor{Name: "cut"})
+
+	tree.AddGlobalShortcut("q", "quit")
+	tree.AddGlobalShortcut("fo", "file open")
+	file.AddShortcut("c", "close")
+
+	cases := []struct {
+		tree *Tree
+		line string
+		path string
+	}{
+		{tree, "q", "quit"},
+		{tree, "fo", "file open"},
+		{tree, "file c", "file close"},
+		{tree, "c", "<nil>"},
+		{edit, "q", "quit"},
+		{edit, "fo", "file open"},
+		{edit, "c", "edit cut"},
+		{file, "c", "file close"},
+		{file, "q", "file quiet"},
+		{file, "fo", "file open"},
+	}
+
+	for i, c := range cases {
+		path := "<nil>"
+		if cmd, _, err := c.tree.LookupCommand(c.line); err == nil {
+			path = cmd.CanonicalLine()
+		}
+		if path != c.path {
+			t.Errorf("Case %d: expected %s, got %s", i, c.path, path)
+		}
+	}
+
+	if s := fmt.Sprint(edit.Autocomplete("f")); s != "[fo]" {
+		t.Errorf("Unexpected global completion %s", s)
+	}
+
+	var lines []string
+	for _, s := range tree.Shortcuts() {
+		lines = append(lines, s.Line())
+	}
+	if s := fmt.Sprint(lines); s != "[file c fo q]" {
+		t.Errorf("Unexpected shortcut lines %s", s)
+	}
+}
+
+func TestMultiWordShortcuts(t *testing.T) {
+	tree := NewTree(TreeDescriptor{Name: "tree"})
+	tree.AddCommand(CommandDescriptor{Name: "bpx"})
+	dbg := tree.AddSubtree(TreeDescriptor{Name: "debugger"})
+	bp := dbg.AddSubtree(TreeDescriptor{Name: "breakpoint"})
+	bp.AddCommand(CommandDescriptor{
+		Name: "add",
+		Args: []Arg{{Name: "mode", Type: EnumType{Values: []string{"read", "write"}}}},
+	})
+	bp.AddCommand(CommandDescriptor{Name: "list"})
+
+	if err := tree.AddShortcut("bp  add", "debugger breakpoint add"); err != nil {
+		t.Fatalf("AddShortcut failed: %v", err)
+	}
+	tree.AddShortcut("bp list", "debugger breakpoint list")
+	dbg.AddShortcut("b l", "breakpoint list")
+	tree.AddGlobalShortcut("break add", "debugger breakpoint add")
+
+	cases := []struct {
+		tree *Tree
+		line string
+		path string
+		args string
+	}{
+		{tree, "bp add write", "debugger breakpoint add", "[write]"},
+		{tree, "bp list", "debugger breakpoint list", "[]"},
+		{tree, "bp", "bpx", "[]"},
+		{tree, "bp ad", "bpx", "[ad]"},
+		{tree, "debugger b l 1", "debugger breakpoint list", "[1]"},
+		{tree, "debugger b", "debugger breakpoint", "[]"},
+		{bp, "break add read", "debugger breakpoint add", "[read]"},
+		{tree, "break add", "debugger breakpoint add", "[]"},
+	}
+
+	for i, c := range cases {
+		n, args, err := c.tree.Lookup(c.line)
+		if err != nil {
+			t.Errorf("Case %d: unexpected error %v", i, err)
+			continue
+		}
+		if p := nodePath(n); p != c.path {
+			t.Errorf("Case %d: expected %s, got %s", i, c.path, p)
+		}
+		if a := fmt.Sprint(args); a != c.args {
+			t.Errorf("Case %d: expected args %s, got %s", i, c.args, a)
+		}
+	}
+
+	if s := fmt.Sprint(tree.Autocomplete("bp add w")); s != "[bp add write]" {
+		t.Errorf("Unexpected completion %s", s)
+	}
+	if err := tree.AddShortcut(" ", "bpx"); err == nil {
+		t.Errorf("Expected error for empty shortcut")
+	}
+}
+
+func TestRemoveShortcut(t *testing.T) {
+	tree := buildTree()
+	open, _, _ := tree.LookupCommand("file open")
+	quit, _, _ := tree.LookupCommand("quit")
+	tree.AddGlobalShortcut("q", "quit")
+	tree.AddShortcut("bye now", "quit")
+
+	cases := []struct {
+		remove func() error
+		err    error
+	}{
+		{func() error { return tree.RemoveShortcut("zz") }, nil},
+		{func() error { return tree.RemoveShortcut("zz") }, ErrShortcutNotFound},
+		{func() error { return open.RemoveShortcut("dd") }, nil},
+		{func() error { return quit.RemoveShortcut("f") }, ErrShortcutNotFound},
+		{func() error { return tree.RemoveShortcut("q") }, nil},
+		{func() error { return quit.RemoveShortcut("bye  now") }, nil},
+	}
+	for i, c := range cases {
+		if err := c.remove(); err != c.err {
+			t.Errorf("Case %d: expected %v, got %v", i, c.err, err)
+		}
+	}
+
+	if s := fmt.Sprint(open.Shortcuts()); s != "[f xx yy]" {
+		t.Errorf("Unexpected command shortcuts %s", s)
+	}
+	if quit.Shortcuts() != nil {
+		t.Errorf("Unexpected command shortcuts %v", quit.Shortcuts())
+	}
+	for _, line := range []string{"zz", "dd", "bye now"} {
+		if n, _, err := tree.Lookup(line); err == nil {
+			t.Errorf("Removed shortcut '%s' still resolves to %s", line, nodePath(n))
+		}
+	}
+
+	// Reassign a shortcut to a different command.
+	tree.RemoveShortcut("f")
+	if err := tree.AddShortcut("f", "file close"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if c, _, _ := tree.LookupCommand("f"); c == nil || c.Name != "close" {
+		t.Errorf("Reassigned shortcut resolves to %v", c)
+	}
+	if c, _, _ := tree.LookupCommand("file"); c != nil {
+		t.Errorf("Subtree lookup broken by reindex")
+	}
+}
@@ -0,0 +1,345 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// SnapshotFormat is the version of the snapshot format written by this
+// package. It is incremented whenever the format changes incompatibly.
+const SnapshotFormat = 1
+
+// ErrSnapshotFormat is returned when reading a snapshot written in a newer,
+// unsupported format.
+var ErrSnapshotFormat = errors.New("Unsupported snapshot format")
+
+// A Snapshot is a serializable record of the static structure of a command
+// tree: its subtrees, commands, arguments, flags and shortcuts, along with
+// their help text and version tags. Handlers, user-defined data, argument
+// constraints and other functions are not recorded.
+//
+// Snapshots may be serialized as JSON, by Write and ReadSnapshot, or with
+// encoding/gob. A snapshot may be compared with another by Diff or
+// Changelog, or turned back into a tree by Tree. Snapshots of equal trees
+// are equal.
+type Snapshot struct {
+	Format int           `json:"format"`
+	Root   *NodeSnapshot `json:"root"`
+}
+
+// A NodeSnapshot records a subtree or command within a snapshot.
+type NodeSnapshot struct {
+	Name        string                   `json:"name"`
+	Brief       string                   `json:"brief,omitempty"`
+	Description string                   `json:"description,omitempty"`
+	Usage       string                   `json:"usage,omitempty"`
+	Since       string                   `json:"since,omitempty"`
+	Changed     string                   `json:"changed,omitempty"`
+	Tags        []string                 `json:"tags,omitempty"`
+	Timeout     time.Duration            `json:"timeout,omitempty"`
+	Priority    int                      `json:"priority,omitempty"`
+	Aliases     []string                 `json:"aliases,omitempty"`
+	MinArgs     int                      `json:"min_args,omitempty"`
+	MaxArgs     int                      `json:"max_args,omitempty"`
+	Args        []ArgSnapshot            `json:"args,omitempty"`
+	Flags       []FlagSnapshot           `json:"flags,omitempty"`
+	Localized   map[string]LocalizedText `json:"localized,omitempty"`
+
+	// Names of the shortcuts invoking a command.
+	Shortcuts []string `json:"shortcuts,omitempty"`
+
+	// Shortcuts registered on a subtree, and its commands and subtrees.
+	Registered []ShortcutSnapshot `json:"registered,omitempty"`
+	Commands   []*NodeSnapshot    `json:"commands,omitempty"`
+	Subtrees   []*NodeSnapshot    `json:"subtrees,omitempty"`
+}
+
+// An ArgSnapshot records a command argument within a snapshot.
+type ArgSnapshot struct {
+	Name string `json:"name"`
+	TypeSnapshot
+	Optional  bool `json:"optional,omitempty"`
+	Variadic  bool `json:"variadic,omitempty"`
+	Sensitive bool `json:"sensitive,omitempty"`
+}
+
+// A FlagSnapshot records a command flag within a snapshot.
+type FlagSnapshot struct {
+	Name string `json:"name"`
+	TypeSnapshot
+	Brief     string `json:"brief,omitempty"`
+	Sensitive bool   `json:"sensitive,omitempty"`
+}
+
+// A TypeSnapshot records the type of an argument or flag within a snapshot.
+// Kind is one of "int", "uint", "range", "duration", "size", "bool", "enum"
+// or "path" for the argument types of this package, "custom" for other
+// types, or empty for an argument accepting any string or a switch.
+type TypeSnapshot struct {
+	Kind    string   `json:"type,omitempty"`
+	BitSize int      `json:"bit_size,omitempty"`
+	Values  []string `json:"values,omitempty"`
+}
+
+// A ShortcutSnapshot records a shortcut within a snapshot.
+type ShortcutSnapshot struct {
+	Name   string `json:"name"`
+	Target string `json:"target"` // command path, relative to the tree
+	Global bool   `json:"global,omitempty"`
+}
+
+// NewSnapshot records the structure of the tree and all of its descendants.
+// Commands, subtrees and shortcuts are recorded in name order.
+func NewSnapshot(t *Tree) *Snapshot {
+	return &Snapshot{Format: SnapshotFormat, Root: newTreeSnapshot(t)}
+}
+
+func newTreeSnapshot(t *Tree) *NodeSnapshot {
+	s := &NodeSnapshot{
+		Name:        t.Name,
+		Brief:       t.Brief,
+		Description: t.Description,
+		Usage:       t.Usage,
+		Since:       t.Since,
+		Tags:        slices.Clone(t.Tags),
+		Timeout:     t.Timeout,
+		Priority:    t.Priority,
+		Localized:   maps.Clone(t.Localized),
+	}
+	prefix := nodePath(t)
+	for _, sc := range t.shortcuts {
+		target := nodePath(sc.Command)
+		if prefix != "" {
+			target = strings.TrimPrefix(target, prefix+" ")
+		}
+		s.Registered = append(s.Registered, ShortcutSnapshot{sc.Name, target, sc.Global})
+	}
+	slices.SortFunc(s.Registered, func(a, b ShortcutSnapshot) int { return strings.Compare(a.Name, b.Name) })
+
+	for _, n := range t.sortedNodes() {
+		switch n := n.(type) {
+		case *Command:
+			s.Commands = append(s.Commands, newCommandSnapshot(n))
+		case *Tree:
+			s.Subtrees = append(s.Subtrees, newTreeSnapshot(n))
+		}
+	}
+	return s
+}
+
+func newCommandSnapshot(c *Command) *NodeSnapshot {
+	shortcuts := c.Shortcuts()
+	slices.Sort(shortcuts)
+	s := &NodeSnapshot{
+		Name:        c.Name,
+		Brief:       c.Brief,
+		Description: c.Description,
+		Usage:       c.Usage,
+		Since:       c.Since,
+		Changed:     c.Changed,
+		Tags:        slices.Clone(c.Tags),
+		Timeout:     c.Timeout,
+		Priority:    c.Priority,
+		Aliases:     slices.Clone(c.Aliases),
+		MinArgs:     c.MinArgs,
+		MaxArgs:     c.MaxArgs,
+		Localized:   maps.Clone(c.Localized),
+		Shortcuts:   shortcuts,
+	}
+	for _, a := range c.Args {
+		s.Args = append(s.Args, ArgSnapshot{a.Name, newTypeSnapshot(a.Type), a.Optional, a.Variadic, a.Sensitive})
+	}
+	for _, f := range c.Flags {
+		s.Flags = append(s.Flags, FlagSnapshot{f.Name, newTypeSnapshot(f.Type), f.Brief, f.Sensitive})
+	}
+	return s
+}
+
+func newTypeSnapshot(typ ArgType) TypeSnapshot {
+	switch t := typ.(type) {
+	case nil:
+		return TypeSnapshot{}
+	case IntType:
+		return TypeSnapshot{Kind: "int", BitSize: t.BitSize}
+	case UintType:
+		return TypeSnapshot{Kind: "uint", BitSize: t.BitSize}
+	case RangeType:
+		return TypeSnapshot{Kind: "range", BitSize: t.BitSize}
+	case DurationType:
+		return TypeSnapshot{Kind: "duration"}
+	case TimeType:
+		return TypeSnapshot{Kind: "time"}
+	case SizeType:
+		return TypeSnapshot{Kind: "size"}
+	case StringType:
+		return TypeSnapshot{Kind: "string"}
+	case BoolType:
+		return TypeSnapshot{Kind: "bool"}
+	case EnumType:
+		return TypeSnapshot{Kind: "enum", Values: slices.Clone(t.Values)}
+	case PathType:
+		return TypeSnapshot{Kind: "path"}
+	case ExprType:
+		if t.Signed {
+			return TypeSnapshot{Kind: "int_expr", BitSize: t.BitSize}
+		}
+		return TypeSnapshot{Kind: "expr", BitSize: t.BitSize}
+	}
+	return TypeSnapshot{Kind: "custom"}
+}
+
+// argType returns the argument type recorded by the snapshot. Custom types
+// are replaced by nil, accepting any string.
+func (s TypeSnapshot) argType() ArgType {
+	switch s.Kind {
+	case "int":
+		return IntType{BitSize: s.BitSize}
+	case "uint":
+		return UintType{BitSize: s.BitSize}
+	case "range":
+		return RangeType{BitSize: s.BitSize}
+	case "duration":
+		return DurationType{}
+	case "time":
+		return TimeType{}
+	case "size":
+		return SizeType{}
+	case "string":
+		return StringType{}
+	case "bool":
+		return BoolType{}
+	case "enum":
+		return EnumType{Values: slices.Clone(s.Values)}
+	case "path":
+		return PathType{}
+	case "expr":
+		return ExprType{BitSize: s.BitSize}
+	case "int_expr":
+		return ExprType{BitSize: s.BitSize, Signed: true}
+	}
+	return nil
+}
+
+// ReadSnapshot reads a snapshot serialized as JSON. It returns
+// ErrSnapshotFormat if the snapshot was written in a newer format.
+func ReadSnapshot(r io.Reader) (*Snapshot, error) {
+	s := new(Snapshot)
+	if err := json.NewDecoder(r).Decode(s); err != nil {
+		return nil, err
+	}
+	if s.Format > SnapshotFormat {
+		return nil, fmt.Errorf("%w: %d", ErrSnapshotFormat, s.Format)
+	}
+	if s.Root == nil {
+		return nil, errors.New("Snapshot has no root tree")
+	}
+	return s, nil
+}
+
+// Write writes the snapshot as indented JSON.
+func (s *Snapshot) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+// Tree creates a command tree with the structure recorded by the snapshot.
+// Its commands have no handlers, and arguments of custom types accept any
+// string.
+func (s *Snapshot) Tree() (*Tree, error) {
+	if s.Format > SnapshotFormat {
+		return nil, fmt.Errorf("%w: %d", ErrSnapshotFormat, s.Format)
+	}
+	t := NewTree(s.Root.treeDescriptor())
+	s.Root.addNodes(t)
+	if err := s.Root.addShortcuts(t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (s *NodeSnapshot) treeDescriptor() TreeDescriptor {
+	return TreeDescriptor{
+		Name:        s.Name,
+		Brief:       s.Brief,
+		Description: s.Description,
+		Usage:       s.Usage,
+		Since:       s.Since,
+		Tags:        slices.Clone(s.Tags),
+		Timeout:     s.Timeout,
+		Priority:    s.Priority,
+		Localized:   maps.Clone(s.Localized),
+	}
+}
+
+func (s *NodeSnapshot) commandDescriptor() CommandDescriptor {
+	d := CommandDescriptor{
+		Name:        s.Name,
+		Brief:       s.Brief,
+		Description: s.Description,
+		Usage:       s.Usage,
+		Since:       s.Since,
+		Changed:     s.Changed,
+		Tags:        slices.Clone(s.Tags),
+		Timeout:     s.Timeout,
+		Priority:    s.Priority,
+		Aliases:     slices.Clone(s.Aliases),
+		MinArgs:     s.MinArgs,
+		MaxArgs:     s.MaxArgs,
+		Localized:   maps.Clone(s.Localized),
+	}
+	for _, a := range s.Args {
+		d.Args = append(d.Args, Arg{a.Name, a.argType(), a.Optional, a.Variadic, a.Sensitive})
+	}
+	for _, f := range s.Flags {
+		d.Flags = append(d.Flags, Flag{f.Name, f.argType(), f.Brief, f.Sensitive})
+	}
+	return d
+}
+
+// addNodes adds the recorded commands and subtrees to the tree.
+func (s *NodeSnapshot) addNodes(t *Tree) {
+	for _, c := range s.Commands {
+		t.AddCommand(c.commandDescriptor())
+	}
+	for _, st := range s.Subtrees {
+		st.addNodes(t.AddSubtree(st.treeDescriptor()))
+	}
+}
+
+// addShortcuts adds the recorded shortcuts to the tree and its subtrees.
+// Conflicts that don't prevent a shortcut from being added are ignored,
+// since they were present in the recorded tree.
+func (s *NodeSnapshot) addShortcuts(t *Tree) error {
+	for _, sc := range s.Registered {
+		add := t.AddShortcut
+		if sc.Global {
+			add = t.AddGlobalShortcut
+		}
+		if err := add(sc.Name, sc.Target); err != nil && !t.hasShortcut(sc.Name) {
+			return err
+		}
+	}
+	for _, st := range s.Subtrees {
+		sub, _, err := t.LookupSubtree(st.Name)
+		if err != nil {
+			return err
+		}
+		if err := st.addShortcuts(sub); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hasShortcut returns true if a shortcut with the name is registered on the
+// tree.
+func (t *Tree) hasShortcut(name string) bool {
+	return slices.ContainsFunc(t.shortcuts, func(sc Shortcut) bool { return sc.Name == name })
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func buildSnapshotTree() *Tree {
+	tree := buildArgTree()
+	tree.AddCommand(CommandDescriptor{
+		Name:    "connect",
+		Brief:   "connect to a host",
+		Tags:    []string{"net"},
+		Timeout: 5 * time.Second,
+		Aliases: []string{"conn"},
+		Since:   "1.1",
+		Args:    []Arg{{Name: "host"}, {Name: "port", Type: UintType{BitSize: 16}, Optional: true}},
+		Flags: []Flag{
+			{Name: "password", Type: PathType{}, Sensitive: true},
+			{Name: "verbose", Brief: "log traffic"},
+		},
+		Localized: map[string]LocalizedText{"fr": {Brief: "se connecter"}},
+	})
+	tree.AddCommand(CommandDescriptor{Name: "quit", MaxArgs: NoArgs, Priority: 1})
+	dbg := tree.AddSubtree(TreeDescriptor{Name: "debug", Tags: []string{"hidden"}, Timeout: time.Second})
+	dbg.AddCommand(CommandDescriptor{Name: "dump", MinArgs: 1})
+	dbg.AddShortcut("d", "dump")
+	tree.AddShortcut("fo", "file open")
+	tree.AddGlobalShortcut("q", "quit")
+	return tree
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	tree := buildSnapshotTree()
+	snap := NewSnapshot(tree)
+
+	buf := new(bytes.Buffer)
+	if err := snap.Write(buf); err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadSnapshot(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(snap, read) {
+		t.Errorf("JSON round trip changed the snapshot")
+	}
+
+	buf.Reset()
+	var decoded Snapshot
+	if err := gob.NewEncoder(buf).Encode(snap); err != nil {
+		t.Fatal(err)
+	}
+	if err := gob.NewDecoder(buf).Decode(&decoded); err != nil {
+		t.Fatal(err)
+	}
+	if d := DiffSnapshots(snap, &decoded); len(d) != 0 {
+		t.Errorf("gob round trip changed the snapshot: %v", d)
+	}
+
+	rebuilt, err := read.Tree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(snap, NewSnapshot(rebuilt)) {
+		t.Errorf("rebuilt tree differs from the snapshot")
+	}
+	if d := Diff(tree, rebuilt); len(d) != 0 {
+		t.Errorf("rebuilt tree differs: %v", d)
+	}
+
+	c, args, err := rebuilt.LookupCommand("q")
+	if err != nil || c.Name != "quit" || len(args) != 0 {
+		t.Errorf("global shortcut not rebuilt: %v, %v", c, err)
+	}
+	c, _, _ = rebuilt.LookupCommand("conn x")
+	if _, err := c.ParseArgs([]string{"x", "70000"}); err == nil {
+		t.Errorf("argument type not rebuilt")
+	}
+}
+
+func TestSnapshotFormat(t *testing.T) {
+	cases := []struct {
+		json string
+		err  string
+	}{
+		{`{"format": 1, "root": {"name": "tree"}}`, ""},
+		{`{"format": 99, "root": {"name": "tree"}}`, "Unsupported snapshot format: 99"},
+		{`{"format": 1}`, "Snapshot has no root tree"},
+		{`{"format": `, "unexpected EOF"},
+	}
+
+	for i, c := range cases {
+		_, err := ReadSnapshot(strings.NewReader(c.json))
+		switch {
+		case c.err == "" && err != nil:
+			t.Errorf("Case %d: unexpected error '%v'", i, err)
+		case c.err != "" && (err == nil || err.Error() != c.err):
+			t.Errorf("Case %d: expected error '%s', got '%v'", i, c.err, err)
+		}
+	}
+
+	if _, err := (&Snapshot{Format: 99, Root: &NodeSnapshot{}}).Tree(); !errors.Is(err, ErrSnapshotFormat) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+)
+
+// A StatusError is an error carrying an exit status code. A handler returns
+// a StatusError to report a specific status for the command, and may wrap
+// ErrExit in a StatusError to stop the runner with a status.
+type StatusError struct {
+	Code int   // exit status code
+	Err  error // underlying error, if any
+}
+
+func (e *StatusError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("Exit status %d", e.Code)
+	}
+	return e.Err.Error()
+}
+
+func (e *StatusError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns the exit status code corresponding to an error returned
+// by a command, a script or a runner. It is 0 for a nil error or ErrExit,
+// the code of the first StatusError in the error's chain, if any, and 1
+// otherwise. It may be passed to os.Exit by programs running scripts:
+//
+//	os.Exit(cmd.ExitCode(r.RunScript(f)))
+func ExitCode(err error) int {
+	var serr *StatusError
+	switch {
+	case err == nil || err == ErrExit:
+		return 0
+	case errors.As(err, &serr):
+		return serr.Code
+	}
+	return 1
+}
+
+// Status returns the exit status code of the last command line executed by
+// Execute, as reported by ExitCode. It may be referred to in command lines
+// as the session variable $?.
+func (r *Runner) Status() int {
+	return int(r.status.Load())
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	cases := []struct {
+		err      error
+		expected int
+	}{
+		{nil, 0},
+		{ErrExit, 0},
+		{errors.New("Failed"), 1},
+		{&StatusError{Code: 3}, 3},
+		{&StatusError{Code: 4, Err: ErrExit}, 4},
+		{&ScriptError{Line: 2, Err: &StatusError{Code: 5}}, 5},
+		{fmt.Errorf("wrapped: %w", &StatusError{Code: 0}), 0},
+	}
+	for i, c := range cases {
+		if got := ExitCode(c.err); got != c.expected {
+			t.Errorf("Case %d: expected %d, got %d", i, c.expected, got)
+		}
+	}
+}
+
+func TestStatus(t *testing.T) {
+	tree := NewTree(TreeDescriptor{Name: "app"})
+	tree.AddCommand(EchoCommand())
+	tree.AddCommand(CommandDescriptor{
+		Name: "check",
+		Args: []Arg{{Name: "code", Type: IntType{}}},
+		Handler: func(ctx *ExecContext, args []string) error {
+			if code := int(ctx.Values["code"].(int64)); code != 0 {
+				return &StatusError{Code: code}
+			}
+			return nil
+		},
+	})
+	tree.AddCommand(CommandDescriptor{
+		Name: "quit",
+		Args: []Arg{{Name: "code", Type: IntType{}}},
+		Handler: func(ctx *ExecContext, args []string) error {
+			return &StatusError{Code: int(ctx.Values["code"].(int64)), Err: ErrExit}
+		},
+	})
+
+	runCases := []struct {
+		input  string
+		output string
+	}{
+		{"check 2\necho $?\n", "2\n"},
+		{"check 0\necho $?\n", "0\n"},
+		{"bogus\necho $? $?\n", "Command not found.\n1 1\n"},
+	}
+	for i, c := range runCases {
+		var out strings.Builder
+		r := NewRunner(tree, strings.NewReader(c.input), &out)
+		r.Prompt = ""
+		r.Run()
+		if out.String() != c.output {
+			t.Errorf("Case %d: expected output %q, got %q", i, c.output, out.String())
+		}
+	}
+
+	scriptCases := []struct {
+		script   string
+		output   string
+		exitCode int
+	}{
+		{"check 2\necho never\n", "", 2},
+		{"echo start\nquit 7\necho never\n", "start\n", 7},
+		{"if check 1\necho yes\nelse\necho $?\nend\n", "1\n", 0},
+		{"if check 0\nquit 3\nend\n", "", 3},
+		{"quit 0\necho never\n", "", 0},
+	}
+	for i, c := range scriptCases {
+		var out strings.Builder
+		r := NewRunner(tree, nil, &out)
+		err := r.RunScript(strings.NewReader(c.script))
+		if got := ExitCode(err); got != c.exitCode {
+			t.Errorf("Case %d: expected exit code %d, got %d (%v)", i, c.exitCode, got, err)
+		}
+		if out.String() != c.output {
+			t.Errorf("Case %d: expected output %q, got %q", i, c.output, out.String())
+		}
+	}
+}
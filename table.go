@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// A Table formats rows of data into aligned columns. Column widths are
+// computed automatically from the table's contents. If a row would exceed
+// the table's width, the text in its last column is wrapped onto additional
+// lines aligned with the start of the column.
+type Table struct {
+	Headers []string   // column headings
+	Rows    [][]string // table data
+	Width   int        // maximum line width (zero uses the help wrap width; see ExecContext.WriteTable)
+}
+
+// NewTable creates a new table with the given column headings.
+func NewTable(headers ...string) *Table {
+	return &Table{Headers: headers}
+}
+
+// AddRow appends a row to the table. Each value is formatted using its
+// default format.
+func (t *Table) AddRow(values ...any) {
+	row := make([]string, len(values))
+	for i, v := range values {
+		row[i] = fmt.Sprint(v)
+	}
+	t.Rows = append(t.Rows, row)
+}
+
+// Write outputs the formatted table.
+func (t *Table) Write(w io.Writer) error {
+	cols := len(t.Headers)
+	for _, row := range t.Rows {
+		cols = max(cols, len(row))
+	}
+	if cols == 0 {
+		return nil
+	}
+
+	widths := make([]int, cols)
+	measure := func(row []string) {
+		for i, s := range row {
+			widths[i] = max(widths[i], displayWidth(s))
+		}
+	}
+	measure(t.Headers)
+	for _, row := range t.Rows {
+		measure(row)
+	}
+
+	width := t.Width
+	if width <= 0 {
+		width = wrapWidth
+	}
+
+	// The last column is wrapped within whatever width remains after the
+	// other columns are laid out.
+	const gap = 2
+	lastCol := 0
+	for _, w := range widths[:cols-1] {
+		lastCol += w + gap
+	}
+	lastWidth := max(width-lastCol, 1)
+
+	if len(t.Headers) > 0 {
+		if err := t.writeRow(w, t.Headers, widths, lastCol, lastWidth); err != nil {
+			return err
+		}
+	}
+	for _, row := range t.Rows {
+		if err := t.writeRow(w, row, widths, lastCol, lastWidth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *Table) writeRow(w io.Writer, row []string, widths []int, lastCol, lastWidth int) error {
+	var sb strings.Builder
+	last := len(widths) - 1
+	for i := 0; i < last; i++ {
+		var s string
+		if i < len(row) {
+			s = row[i]
+		}
+		sb.WriteString(padRight(s, widths[i]+2))
+	}
+
+	var lines []string
+	if last < len(row) {
+		if displayWidth(row[last]) < lastWidth {
+			lines = []string{row[last]}
+		} else {
+			lines = wrapText(row[last], lastWidth)
+		}
+	}
+	for i, line := range lines {
+		if i > 0 {
+			sb.WriteString("\n")
+			sb.WriteString(strings.Repeat(" ", lastCol))
+		}
+		sb.WriteString(line)
+	}
+
+	_, err := fmt.Fprintln(w, strings.TrimRight(sb.String(), " "))
+	return err
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestTable(t *testing.T) {
+	long := strings.Repeat("word ", 20)
+
+	cases := []struct {
+		table  *Table
+		output string
+	}{
+		{
+			&Table{},
+			"",
+		},
+		{
+			&Table{Headers: []string{"Name", "Value"}},
+			"Name  Value\n",
+		},
+		{
+			&Table{
+				Headers: []string{"Reg", "Value"},
+				Rows:    [][]string{{"a", "$00"}, {"pc", "$8000"}, {"x"}},
+			},
+			"Reg  Value\n" +
+				"a    $00\n" +
+				"pc   $8000\n" +
+				"x\n",
+		},
+		{
+			&Table{
+				Rows:  [][]string{{"id", "1"}, {"brief", long}},
+				Width: 40,
+			},
+			"id     1\n" +
+				"brief  word word word word word word\n" +
+				"       word word word word word word\n" +
+				"       word word word word word word\n" +
+				"       word word\n",
+		},
+	}
+
+	for i, c := range cases {
+		buf := new(bytes.Buffer)
+		if err := c.table.Write(buf); err != nil {
+			t.Errorf("Case %d: unexpected error: %v\n", i, err)
+		}
+		if buf.String() != c.output {
+			t.Errorf("Case %d: output mismatch.\nEXPECTED:\n%s\nGOT:\n%s\n", i, c.output, buf.String())
+		}
+	}
+}
+
+func TestTableAddRow(t *testing.T) {
+	table := NewTable("Addr", "Enabled")
+	table.AddRow(0x8000, true)
+	table.AddRow("fffe", false)
+
+	buf := new(bytes.Buffer)
+	table.Write(buf)
+	want := "Addr   Enabled\n32768  true\nfffe   false\n"
+	if buf.String() != want {
+		t.Errorf("output mismatch.\nEXPECTED:\n%s\nGOT:\n%s\n", want, buf.String())
+	}
+}
+
+func TestWriteTableHelpWidth(t *testing.T) {
+	tree := NewTree(TreeDescriptor{Name: "tree"})
+	tree.SetHelpWidth(24)
+	tree.AddCommand(CommandDescriptor{
+		Name: "show",
+		Handler: func(ctx *ExecContext, args []string) error {
+			return ctx.WriteTable(&Table{Rows: [][]string{{"brief", strings.Repeat("word ", 6)}}})
+		},
+	})
+
+	buf := new(bytes.Buffer)
+	r := NewRunner(tree, nil, buf)
+	if err := r.Execute("show"); err != nil {
+		t.Fatal(err)
+	}
+	want := "brief  word word word\n       word word word\n"
+	if buf.String() != want {
+		t.Errorf("output mismatch.\nEXPECTED:\n%s\nGOT:\n%s\n", want, buf.String())
+	}
+}
@@ -0,0 +1,79 @@
+package cmd
+
+// HasTag returns true if the tree or any of its ancestors carries the tag.
+func (t *Tree) HasTag(tag string) bool {
+	return hasTag(t, tag)
+}
+
+// HasTag returns true if the command or any of the trees containing it
+// carries the tag.
+func (c *Command) HasTag(tag string) bool {
+	return hasTag(c, tag)
+}
+
+// hasTag returns true if the node or any of its ancestors carries the tag.
+func hasTag(n Node, tag string) bool {
+	for {
+		for _, t := range n.tags() {
+			if t == tag {
+				return true
+			}
+		}
+		p := n.Parent()
+		if p == nil {
+			return false
+		}
+		n = p
+	}
+}
+
+// hasAnyTag returns true if the node or any of its ancestors carries any of
+// the tags.
+func hasAnyTag(n Node, tags []string) bool {
+	for _, tag := range tags {
+		if hasTag(n, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterByTag returns all commands in the tree and its descendant subtrees
+// carrying the tag, either directly or through one of their ancestors.
+func (t *Tree) FilterByTag(tag string) []*Command {
+	var results []*Command
+	for _, c := range t.commands {
+		if c.HasTag(tag) {
+			results = append(results, c)
+		}
+	}
+	for _, st := range t.subtrees {
+		results = append(results, st.FilterByTag(tag)...)
+	}
+	return results
+}
+
+// SetHiddenTags hides all commands and subtrees carrying any of the tags from
+// help listings and autocompletion. Hidden commands may still be executed.
+// The setting applies to the entire command tree containing t.
+func (t *Tree) SetHiddenTags(tags ...string) {
+	t.root().hidden = tags
+}
+
+// HiddenTags returns the tags hidden from help listings and autocompletion.
+func (t *Tree) HiddenTags() []string {
+	return t.root().hidden
+}
+
+// isHidden returns true if the node is hidden from help listings and
+// autocompletion.
+func isHidden(n Node) bool {
+	var r *Tree
+	switch n := n.(type) {
+	case *Tree:
+		r = n.root()
+	case *Command:
+		r = n.parent.root()
+	}
+	return hasAnyTag(n, r.hidden)
+}
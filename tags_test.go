@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func buildTagTree() *Tree {
+	root := NewTree(TreeDescriptor{Name: "root"})
+	root.AddCommand(CommandDescriptor{Name: "list", Brief: "List items", Handler: nopHandler})
+	root.AddCommand(CommandDescriptor{Name: "lint", Brief: "Lint items", Handler: nopHandler, Tags: []string{"experimental"}})
+	root.AddCommand(CommandDescriptor{Name: "wipe", Brief: "Wipe items", Handler: nopHandler, Tags: []string{"dangerous"}})
+	net := root.AddSubtree(TreeDescriptor{Name: "net", Brief: "Network commands", Tags: []string{"network"}})
+	net.AddCommand(CommandDescriptor{Name: "ping", Brief: "Ping a host", Handler: nopHandler})
+	net.AddCommand(CommandDescriptor{Name: "probe", Brief: "Probe a host", Handler: nopHandler, Tags: []string{"experimental"}})
+	return root
+}
+
+func nopHandler(ctx *ExecContext, args []string) error {
+	return nil
+}
+
+func TestFilterByTag(t *testing.T) {
+	root := buildTagTree()
+
+	cases := []struct {
+		tag      string
+		commands string
+	}{
+		{"experimental", "[lint net probe]"},
+		{"network", "[net ping net probe]"},
+		{"dangerous", "[wipe]"},
+		{"missing", "[]"},
+	}
+
+	for i, c := range cases {
+		var paths []string
+		for _, cmd := range root.FilterByTag(c.tag) {
+			paths = append(paths, nodePath(cmd))
+		}
+		if s := fmt.Sprint(paths); s != c.commands {
+			t.Errorf("Case %d: expected %s, got %s", i, c.commands, s)
+		}
+	}
+}
+
+func TestHiddenTags(t *testing.T) {
+	root := buildTagTree()
+	root.SetHiddenTags("experimental")
+
+	var buf bytes.Buffer
+	root.DisplayHelp(&buf)
+	help := buf.String()
+	if strings.Contains(help, "lint") || !strings.Contains(help, "list") {
+		t.Errorf("Unexpected help listing:\n%s", help)
+	}
+
+	cases := []struct {
+		line    string
+		results string
+	}{
+		{"li", "[list]"},
+		{"lint", "[lint]"},
+		{"net p", "[net ping]"},
+		{"net probe", "[net probe]"},
+	}
+	for i, c := range cases {
+		if s := fmt.Sprint(root.Autocomplete(c.line)); s != c.results {
+			t.Errorf("Case %d: expected %s, got %s", i, c.results, s)
+		}
+	}
+
+	if _, _, err := root.LookupCommand("lint"); err != nil {
+		t.Errorf("Hidden command lookup failed: %v", err)
+	}
+}
+
+func TestDisabledTags(t *testing.T) {
+	root := buildTagTree()
+	r := NewRunner(root, strings.NewReader(""), new(bytes.Buffer))
+	r.DisabledTags = []string{"dangerous", "network"}
+
+	cases := []struct {
+		line string
+		err  error
+	}{
+		{"list", nil},
+		{"wipe", ErrDisabled},
+		{"net ping", ErrDisabled},
+		{"lint", nil},
+	}
+	for i, c := range cases {
+		if err := r.Execute(c.line); err != c.err {
+			t.Errorf("Case %d: expected %v, got %v", i, c.err, err)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/beevik/prefixtree/v2"
+)
+
+// A SpanKind classifies a token of an input line.
+type SpanKind int
+
+// Kinds of tokens found by Tokenize.
+const (
+	SpanCommand   SpanKind = iota // name of a command
+	SpanSubtree                   // name of a subtree
+	SpanShortcut                  // shortcut to a command
+	SpanArgument                  // unquoted argument
+	SpanQuoted                    // quoted argument
+	SpanUnknown                   // name matching nothing
+	SpanAmbiguous                 // name matching several commands or subtrees
+)
+
+var spanKindNames = []string{
+	"command", "subtree", "shortcut", "argument", "quoted", "unknown", "ambiguous",
+}
+
+func (k SpanKind) String() string {
+	if k < 0 || int(k) >= len(spanKindNames) {
+		return "invalid"
+	}
+	return spanKindNames[k]
+}
+
+// A Span is a token of an input line.
+type Span struct {
+	Kind  SpanKind // classification of the token
+	Start int      // byte offset of the token's first byte in the line
+	End   int      // byte offset following the token's last byte
+	Text  string   // the token's text, excluding any quotes
+}
+
+// Tokenize splits the line into tokens and classifies each of them as it
+// would be interpreted by Lookup, so that editors may highlight the line as
+// it is typed. Tokens are reported in order; whitespace separating them
+// isn't reported. Quoted tokens include their quotes. Once a token fails to
+// match, the tokens following it are classified as arguments. A line
+// exceeding the tree's maximum line length has no tokens.
+func (t *Tree) Tokenize(line string) []Span {
+	if t.tooLong(line) {
+		return nil
+	}
+	spans := splitSpans(line)
+
+	cur := t
+	for i := 0; i < len(spans); {
+		field, remain := spans[i].Text, line[spans[i].End:]
+		if sc, _ := matchPhrase(cur.phrases, field, stripLeadingWhitespace(remain)); sc != nil {
+			markShortcut(spans[i:], sc)
+			i += strings.Count(sc.Name, " ") + 1
+			break
+		}
+
+		kv, err := cur.pt.FindKeyValue(field)
+		global := false
+		if err == prefixtree.ErrPrefixNotFound && i == 0 {
+			if sc, _ := matchPhrase(t.root().globalPhrases, field, stripLeadingWhitespace(remain)); sc != nil {
+				markShortcut(spans, sc)
+				break
+			}
+			kv, err = t.findGlobal(field)
+			global = true
+		}
+		if err == prefixtree.ErrPrefixAmbiguous {
+			kv, err = cur.resolvePriority(field, global)
+		}
+
+		switch {
+		case err == prefixtree.ErrPrefixAmbiguous:
+			spans[i].Kind = SpanAmbiguous
+		case err != nil:
+			spans[i].Kind = SpanUnknown
+		case isShortcut(kv, cur, global):
+			spans[i].Kind = SpanShortcut
+		default:
+			if st, ok := kv.Value.(*Tree); ok {
+				spans[i].Kind = SpanSubtree
+				cur = st
+				i++
+				continue
+			}
+			spans[i].Kind = SpanCommand
+		}
+		break
+	}
+	return spans
+}
+
+// markShortcut classifies the leading spans matched by a multi-word
+// shortcut.
+func markShortcut(spans []Span, sc *Shortcut) {
+	for i := 0; i <= strings.Count(sc.Name, " "); i++ {
+		spans[i].Kind = SpanShortcut
+	}
+}
+
+// splitSpans splits the line into fields, as nextField does, and returns
+// them as argument spans.
+func splitSpans(line string) []Span {
+	var spans []Span
+	for i := 0; ; {
+		for i < len(line) {
+			r, n := utf8.DecodeRuneInString(line[i:])
+			if !unicode.IsSpace(r) {
+				break
+			}
+			i += n
+		}
+		if i == len(line) {
+			return spans
+		}
+
+		start := i
+		if line[i] == '"' {
+			text := line[i+1:]
+			if j := strings.IndexByte(text, '"'); j >= 0 {
+				text, i = text[:j], i+j+2
+			} else {
+				i = len(line)
+			}
+			spans = append(spans, Span{Kind: SpanQuoted, Start: start, End: i, Text: text})
+			continue
+		}
+
+		for i < len(line) {
+			r, n := utf8.DecodeRuneInString(line[i:])
+			if unicode.IsSpace(r) {
+				break
+			}
+			i += n
+		}
+		spans = append(spans, Span{Kind: SpanArgument, Start: start, End: i, Text: line[start:i]})
+	}
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tree := NewTree(TreeDescriptor{Name: "root"})
+	file := tree.AddSubtree(TreeDescriptor{Name: "file"})
+	file.AddCommand(CommandDescriptor{Name: "open"})
+	file.AddCommand(CommandDescriptor{Name: "close"})
+	file.AddCommand(CommandDescriptor{Name: "copy"})
+	tree.AddCommand(CommandDescriptor{Name: "quit"})
+	tree.AddShortcut("o", "file open")
+	tree.AddShortcut("bp add", "file open")
+	tree.AddGlobalShortcut("q", "quit")
+
+	cases := []struct {
+		tree     *Tree
+		line     string
+		expected string
+	}{
+		{tree, "", ""},
+		{tree, "file open a \"b c\"", "subtree:0-4 command:5-9 argument:10-11 quoted:12-17"},
+		{tree, "  fi  c x", "subtree:2-4 ambiguous:6-7 argument:8-9"},
+		{tree, "file bogus x", "subtree:0-4 unknown:5-10 argument:11-12"},
+		{tree, "o x", "shortcut:0-1 argument:2-3"},
+		{tree, "bp add \"x", "shortcut:0-2 shortcut:3-6 quoted:7-9"},
+		{file, "q", "shortcut:0-1"},
+		{tree, "\"file\" op", "subtree:0-6 command:7-9"},
+	}
+
+	for i, c := range cases {
+		var got []string
+		for _, s := range c.tree.Tokenize(c.line) {
+			got = append(got, fmt.Sprintf("%v:%d-%d", s.Kind, s.Start, s.End))
+		}
+		if strings.Join(got, " ") != c.expected {
+			t.Errorf("Case %d: expected '%s', got '%s'", i, c.expected, strings.Join(got, " "))
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// A tomlEntry is a key-value pair read from a TOML document, along with the
+// table containing it and its line number.
+type tomlEntry struct {
+	table string
+	key   string
+	value any
+	line  int
+}
+
+// parseTOML reads the subset of TOML used by configuration files: tables,
+// bare and quoted keys, strings, integers, floats, booleans and single-line
+// arrays of these values. Dotted keys, inline tables, multi-line strings
+// and dates aren't supported.
+func parseTOML(r io.Reader) ([]tomlEntry, error) {
+	var entries []tomlEntry
+	table := ""
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(stripComment(scanner.Text()))
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return nil, &ConfigError{n, errors.New("invalid table header")}
+			}
+			name, rest, err := parseKey(strings.TrimSpace(line[1 : len(line)-1]))
+			if err != nil || rest != "" {
+				return nil, &ConfigError{n, errors.New("invalid table name")}
+			}
+			if seen["["+name] {
+				return nil, &ConfigError{n, fmt.Errorf("duplicate table '%s'", name)}
+			}
+			table, seen["["+name] = name, true
+			continue
+		}
+
+		key, rest, err := parseKey(line)
+		if err != nil {
+			return nil, &ConfigError{n, err}
+		}
+		rest, ok := strings.CutPrefix(strings.TrimSpace(rest), "=")
+		if !ok {
+			return nil, &ConfigError{n, errors.New("expected '='")}
+		}
+		value, rest, err := parseValue(strings.TrimSpace(rest))
+		if err == nil && strings.TrimSpace(rest) != "" {
+			err = errors.New("unexpected text after value")
+		}
+		if err != nil {
+			return nil, &ConfigError{n, err}
+		}
+		if seen[table+"."+key] {
+			return nil, &ConfigError{n, fmt.Errorf("duplicate key '%s'", key)}
+		}
+		seen[table+"."+key] = true
+		entries = append(entries, tomlEntry{table, key, value, n})
+	}
+	return entries, scanner.Err()
+}
+
+// stripComment removes a comment from a line, ignoring '#' characters
+// within strings.
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote == 0 && (c == '"' || c == '\''):
+			quote = c
+		case quote == 0 && c == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// parseKey parses a bare or quoted key at the start of s, returning the key
+// and the text following it.
+func parseKey(s string) (key, rest string, err error) {
+	if strings.HasPrefix(s, "\"") || strings.HasPrefix(s, "'") {
+		return parseString(s)
+	}
+	i := 0
+	for i < len(s) && (isVarByte(s[i], false) || s[i] == '-') {
+		i++
+	}
+	if i == 0 {
+		return "", s, errors.New("expected key")
+	}
+	return s[:i], s[i:], nil
+}
+
+// parseValue parses the value at the start of s, returning the value and
+// the text following it.
+func parseValue(s string) (value any, rest string, err error) {
+	switch {
+	case s == "":
+		return nil, s, errors.New("expected value")
+	case s[0] == '"' || s[0] == '\'':
+		return parseString(s)
+	case s[0] == '[':
+		return parseArray(s)
+	}
+
+	end := strings.IndexAny(s, ",] \t")
+	if end < 0 {
+		end = len(s)
+	}
+	word, rest := s[:end], s[end:]
+	switch word {
+	case "true":
+		return true, rest, nil
+	case "false":
+		return false, rest, nil
+	}
+	clean := strings.ReplaceAll(word, "_", "")
+	if i, err := strconv.ParseInt(clean, 0, 64); err == nil {
+		return i, rest, nil
+	}
+	if f, err := strconv.ParseFloat(clean, 64); err == nil {
+		return f, rest, nil
+	}
+	return nil, s, fmt.Errorf("invalid value '%s'", word)
+}
+
+// parseString parses a basic (double-quoted) or literal (single-quoted)
+// string at the start of s.
+func parseString(s string) (value, rest string, err error) {
+	if s[0] == '\'' {
+		end := strings.IndexByte(s[1:], '\'')
+		if end < 0 {
+			return "", s, errors.New("unterminated string")
+		}
+		return s[1 : end+1], s[end+2:], nil
+	}
+
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '"':
+			return b.String(), s[i+1:], nil
+		case '\\':
+			if i+1 >= len(s) {
+				return "", s, errors.New("unterminated string")
+			}
+			i++
+			switch s[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case '"', '\\':
+				b.WriteByte(s[i])
+			default:
+				return "", s, fmt.Errorf("invalid escape '\\%c'", s[i])
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", s, errors.New("unterminated string")
+}
+
+// parseArray parses a single-line array at the start of s.
+func parseArray(s string) (value any, rest string, err error) {
+	var values []any
+	rest = strings.TrimSpace(s[1:])
+	for {
+		if r, ok := strings.CutPrefix(rest, "]"); ok {
+			return values, r, nil
+		}
+		var v any
+		v, rest, err = parseValue(rest)
+		if err != nil {
+			return nil, s, err
+		}
+		values = append(values, v)
+		rest = strings.TrimSpace(rest)
+		if r, ok := strings.CutPrefix(rest, ","); ok {
+			rest = strings.TrimSpace(r)
+		} else if !strings.HasPrefix(rest, "]") {
+			return nil, s, errors.New("expected ',' or ']' in array")
+		}
+	}
+}
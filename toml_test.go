@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParseTOML(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected string
+		err      string
+	}{
+		{"a = 1\nb = \"x # y\" # comment\n", ".a=1 .b=x # y", ""},
+		{"[t]\n\"file open\" = ['o', \"l\\\"d\"]\n", "t.file open=[o l\"d]", ""},
+		{"x = true\ny = -2.5\nz = 1_000\n", ".x=true .y=-2.5 .z=1000", ""},
+		{"list = [ 1, 2, ]\nempty = []\n", ".list=[1 2] .empty=[]", ""},
+		{"a = 1\na = 2\n", "", "Config line 2: duplicate key 'a'"},
+		{"[t]\n[t]\n", "", "Config line 2: duplicate table 't'"},
+		{"a 1\n", "", "Config line 1: expected '='"},
+		{"a = \"open\n", "", "Config line 1: unterminated string"},
+		{"a = [1 2]\n", "", "Config line 1: expected ',' or ']' in array"},
+		{"a = yes\n", "", "Config line 1: invalid value 'yes'"},
+		{"a = 1 2\n", "", "Config line 1: unexpected text after value"},
+		{"[t\n", "", "Config line 1: invalid table header"},
+	}
+	for i, c := range cases {
+		entries, err := parseTOML(strings.NewReader(c.input))
+		if c.err != "" {
+			if err == nil || err.Error() != c.err {
+				t.Errorf("Case %d: expected error %q, got %v", i, c.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Case %d: unexpected error %v", i, err)
+			continue
+		}
+		var got []string
+		for _, e := range entries {
+			got = append(got, fmt.Sprintf("%s.%s=%v", e.table, e.key, e.value))
+		}
+		if strings.Join(got, " ") != c.expected {
+			t.Errorf("Case %d: expected %q, got %q", i, c.expected, strings.Join(got, " "))
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// A Topic is a page of help text that isn't tied to a command, such as a
+// description of an expression syntax. Topics are added to a tree by
+// AddTopic and displayed by the tree's 'help' command.
+type Topic struct {
+	Name  string // name used to request the topic
+	Title string // one-line title shown in topic lists
+	Text  string // body of the topic, displayed as written
+
+	parent *Tree
+}
+
+// AddTopic adds a help topic to the tree. The topic is listed in an
+// "Additional help topics:" section of the tree's help, and its text is
+// displayed by GetHelp when its name, or an unambiguous prefix of it,
+// follows the path of the tree. Commands and subtrees take precedence over
+// topics of the same name.
+func (t *Tree) AddTopic(name, title, text string) *Topic {
+	topic := &Topic{Name: name, Title: title, Text: text, parent: t}
+	t.topics = append(t.topics, topic)
+	return topic
+}
+
+// Topics returns the tree's help topics.
+func (t *Tree) Topics() []*Topic {
+	return t.topics
+}
+
+// DisplayHelp displays the topic's title and text.
+func (topic *Topic) DisplayHelp(w io.Writer) {
+	if topic.Title != "" {
+		fmt.Fprintf(w, "%s\n\n", topic.Title)
+	}
+	if text := strings.TrimRight(topic.Text, "\n"); text != "" {
+		fmt.Fprintf(w, "%s\n\n", text)
+	}
+}
+
+// Parent returns the tree to which the topic was added.
+func (topic *Topic) Parent() *Tree {
+	return topic.parent
+}
+
+func (topic *Topic) name() string   { return topic.Name }
+func (topic *Topic) brief() string  { return topic.Title }
+func (topic *Topic) tags() []string { return nil }
+func (topic *Topic) priority() int  { return 0 }
+
+// lookupTopic returns the topic named by the final argument, found in the
+// subtree named by the preceding arguments. A topic may be abbreviated to
+// an unambiguous prefix of its name. It returns nil if no topic matches.
+func (t *Tree) lookupTopic(args []string) *Topic {
+	tree := t
+	if len(args) > 1 {
+		st, rest, err := t.LookupSubtree(strings.Join(args[:len(args)-1], " "))
+		if err != nil || len(rest) > 0 {
+			return nil
+		}
+		tree = st
+	}
+
+	name := args[len(args)-1]
+	var match *Topic
+	for _, topic := range tree.topics {
+		if topic.Name == name {
+			return topic
+		}
+		if strings.HasPrefix(topic.Name, name) {
+			if match != nil {
+				return nil
+			}
+			match = topic
+		}
+	}
+	return match
+}
+
+// displayTopics displays the sorted list of the tree's help topics.
+func (t *Tree) displayTopics(w io.Writer) {
+	if len(t.topics) == 0 {
+		return
+	}
+	nodes := make([]Node, len(t.topics))
+	for i, topic := range t.topics {
+		nodes[i] = topic
+	}
+	slices.SortFunc(nodes, func(a, b Node) int {
+		return strings.Compare(a.name(), b.name())
+	})
+	fmt.Fprintln(w, t.message(MsgTopicSection))
+	t.displayList(w, nodes, func(n Node) string { return n.name() })
+	fmt.Fprintln(w)
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTopics(t *testing.T) {
+	tree := NewTree(TreeDescriptor{Name: "root"})
+	tree.AddCommand(CommandDescriptor{Name: "eval", Brief: "Evaluate an expression"})
+	tree.AddTopic("expressions", "Expression syntax", "Expressions combine numbers\nwith operators.\n")
+	tree.AddTopic("environment", "Environment variables", "None.")
+	cpu := tree.AddSubtree(TreeDescriptor{Name: "cpu", Brief: "CPU commands"})
+	cpu.AddTopic("modes", "Addressing modes", "Immediate, absolute.")
+
+	var b bytes.Buffer
+	tree.DisplayHelp(&b)
+	expected := "root commands:\n" +
+		"    cpu   CPU commands\n" +
+		"    eval  Evaluate an expression\n" +
+		"\n" +
+		"Additional help topics:\n" +
+		"    environment  Environment variables\n" +
+		"    expressions  Expression syntax\n" +
+		"\n"
+	if b.String() != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, b.String())
+	}
+
+	cases := []struct {
+		args     []string
+		expected string
+		err      error
+	}{
+		{[]string{"expressions"}, "Expression syntax\n\nExpressions combine numbers\nwith operators.\n\n", nil},
+		{[]string{"env"}, "Environment variables\n\nNone.\n\n", nil},
+		{[]string{"e"}, "Description:\n   Evaluate an expression.\n\n", nil},
+		{[]string{"ex"}, "Expression syntax\n\nExpressions combine numbers\nwith operators.\n\n", nil},
+		{[]string{"cpu", "modes"}, "Addressing modes\n\nImmediate, absolute.\n\n", nil},
+		{[]string{"modes"}, "", ErrNotFound},
+	}
+	for i, c := range cases {
+		var b bytes.Buffer
+		err := tree.GetHelp(&b, c.args)
+		if err != c.err || b.String() != c.expected {
+			t.Errorf("Case %d: expected %q (%v), got %q (%v)", i, c.expected, c.err, b.String(), err)
+		}
+	}
+}
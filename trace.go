@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// A Tracer starts the spans tracing the commands executed by a runner, so
+// that their latency is reported to a tracing backend. Tracing libraries
+// such as OpenTelemetry are adapted by a small type implementing Tracer
+// and TraceSpan around their own tracer and span types.
+type Tracer interface {
+	// Start starts a span with the name and attributes, as a child of any
+	// span in the context, and returns a context holding the new span.
+	Start(ctx context.Context, name string, attrs []Attribute) (context.Context, TraceSpan)
+}
+
+// A TraceSpan traces the execution of a command.
+type TraceSpan interface {
+	// RecordError records the error returned by the command, and marks
+	// the span as failed.
+	RecordError(err error)
+
+	// End ends the span.
+	End()
+}
+
+// An Attribute is a key and value describing a span.
+type Attribute struct {
+	Key   string
+	Value string
+}
+
+// Attribute keys of the spans started by a runner.
+const (
+	AttrCommand = "cmd.command" // canonical path of the command
+	AttrArgs    = "cmd.args"    // arguments, with sensitive values masked
+	AttrUser    = "cmd.user"    // the runner's user, if any
+)
+
+// startSpan starts a span tracing the execution of the command with the
+// arguments. The span is named after the command's canonical path, and the
+// values of its sensitive arguments are masked.
+func (r *Runner) startSpan(ctx context.Context, c *Command, args []string) (context.Context, TraceSpan) {
+	path := nodePath(c)
+	attrs := []Attribute{
+		{AttrCommand, path},
+		{AttrArgs, strings.Join(c.MaskArgs(args), " ")},
+	}
+	if r.User != "" {
+		attrs = append(attrs, Attribute{AttrUser, r.User})
+	}
+	return r.Tracer.Start(ctx, path, attrs)
+}
+
+// endSpan records the error returned by a traced command, unless it is
+// ErrExit, and ends the span.
+func endSpan(span TraceSpan, err error) {
+	if err != nil && !errors.Is(err, ErrExit) {
+		span.RecordError(err)
+	}
+	span.End()
+}
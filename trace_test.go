@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+type spanKey struct{}
+
+type fakeSpan struct {
+	name  string
+	attrs []Attribute
+	err   error
+	ended bool
+}
+
+func (s *fakeSpan) RecordError(err error) { s.err = err }
+func (s *fakeSpan) End()                  { s.ended = true }
+
+type fakeTracer struct {
+	spans []*fakeSpan
+}
+
+func (t *fakeTracer) Start(ctx context.Context, name string, attrs []Attribute) (context.Context, TraceSpan) {
+	s := &fakeSpan{name: name, attrs: attrs}
+	t.spans = append(t.spans, s)
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+func TestTracer(t *testing.T) {
+	tree := NewTree(TreeDescriptor{Name: "tree"})
+	user := tree.AddSubtree(TreeDescriptor{Name: "user"})
+	var inner any
+	user.AddCommand(CommandDescriptor{
+		Name: "login",
+		Args: []Arg{{Name: "name"}, {Name: "password", Sensitive: true}},
+		Handler: func(ctx *ExecContext, args []string) error {
+			inner = ctx.Context().Value(spanKey{})
+			return nil
+		},
+	})
+	tree.AddCommand(CommandDescriptor{
+		Name:    "fail",
+		Handler: func(ctx *ExecContext, args []string) error { return errors.New("Failed") },
+	})
+	tree.AddCommand(CommandDescriptor{
+		Name:    "quit",
+		Handler: func(ctx *ExecContext, args []string) error { return ErrExit },
+	})
+
+	tracer := new(fakeTracer)
+	r := NewRunner(tree, nil, io.Discard)
+	r.Tracer = tracer
+	r.User = "bob"
+	for _, line := range []string{"user login bob hunter2", "fail 1", "quit", "bogus"} {
+		r.Execute(line)
+	}
+
+	cases := []struct {
+		name  string
+		attrs string
+		err   string
+	}{
+		{"user login", "[{cmd.command user login} {cmd.args bob ******} {cmd.user bob}]", "<nil>"},
+		{"fail", "[{cmd.command fail} {cmd.args 1} {cmd.user bob}]", "Failed"},
+		{"quit", "[{cmd.command quit} {cmd.args } {cmd.user bob}]", "<nil>"},
+	}
+	if len(tracer.spans) != len(cases) {
+		t.Fatalf("expected %d spans, got %d", len(cases), len(tracer.spans))
+	}
+	for i, c := range cases {
+		s := tracer.spans[i]
+		switch {
+		case s.name != c.name:
+			t.Errorf("Case %d: expected name %q, got %q", i, c.name, s.name)
+		case fmt.Sprint(s.attrs) != c.attrs:
+			t.Errorf("Case %d: expected attributes %s, got %v", i, c.attrs, s.attrs)
+		case fmt.Sprint(s.err) != c.err:
+			t.Errorf("Case %d: expected error %s, got %v", i, c.err, s.err)
+		case !s.ended:
+			t.Errorf("Case %d: span not ended", i)
+		}
+	}
+	if inner != tracer.spans[0] {
+		t.Errorf("span not passed to handler")
+	}
+}
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors returned when beginning or ending a transaction.
+var (
+	ErrInTransaction = errors.New("Transaction in progress")
+	ErrNoTransaction = errors.New("No transaction in progress")
+	ErrRolledBack    = errors.New("Transaction rolled back")
+)
+
+// A TransactionError is returned by a command line failing within a
+// transaction, which was then rolled back.
+type TransactionError struct {
+	Err      error // the error returned by the command line
+	Rollback error // the error returned by the rollback, if any
+}
+
+func (e *TransactionError) Error() string {
+	if e.Rollback != nil {
+		return fmt.Sprintf("%v; rollback failed: %v", e.Err, e.Rollback)
+	}
+	return fmt.Sprintf("%v; transaction rolled back", e.Err)
+}
+
+func (e *TransactionError) Unwrap() []error {
+	return []error{e.Err, ErrRolledBack}
+}
+
+// Begin begins a transaction. The undo functions registered by the
+// commands executed until the transaction is committed or aborted are
+// collected, so that the transaction can be rolled back. If a command line
+// executed by Execute fails within the transaction, the transaction is
+// rolled back and Execute returns a *TransactionError. Effects of commands
+// that register no undo functions aren't rolled back.
+func (r *Runner) Begin() error {
+	r.undo.mu.Lock()
+	defer r.undo.mu.Unlock()
+	if r.undo.inTx {
+		return ErrInTransaction
+	}
+	r.undo.inTx, r.undo.tx = true, nil
+	return nil
+}
+
+// Commit ends the transaction, keeping the effects of its commands, which
+// may then be undone one at a time by Undo.
+func (r *Runner) Commit() error {
+	r.undo.mu.Lock()
+	defer r.undo.mu.Unlock()
+	if !r.undo.inTx {
+		return ErrNoTransaction
+	}
+	if len(r.undo.tx) > 0 {
+		r.undo.undo = append(r.undo.undo, r.undo.tx...)
+		r.undo.redo = nil
+	}
+	r.undo.inTx, r.undo.tx = false, nil
+	return nil
+}
+
+// Abort ends the transaction, rolling back the effects of its commands by
+// calling their undo functions in reverse order. A failing undo function
+// doesn't stop the rollback; the errors of all failing functions are
+// returned.
+func (r *Runner) Abort() error {
+	r.undo.mu.Lock()
+	if !r.undo.inTx {
+		r.undo.mu.Unlock()
+		return ErrNoTransaction
+	}
+	entries := r.undo.tx
+	r.undo.inTx, r.undo.tx = false, nil
+	r.undo.mu.Unlock()
+
+	var errs []error
+	for i := len(entries) - 1; i >= 0; i-- {
+		undo := entries[i].undo
+		for j := len(undo) - 1; j >= 0; j-- {
+			if err := undo[j](); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// InTransaction returns true if a transaction is in progress.
+func (r *Runner) InTransaction() bool {
+	r.undo.mu.Lock()
+	defer r.undo.mu.Unlock()
+	return r.undo.inTx
+}
+
+// rollbackOnError rolls back the transaction in progress, if any, when a
+// command line fails within it. Lines refused because a transaction is in
+// progress don't roll it back.
+func (r *Runner) rollbackOnError(err error) error {
+	if err == nil || errors.Is(err, ErrExit) || errors.Is(err, ErrInTransaction) || !r.InTransaction() {
+		return err
+	}
+	return &TransactionError{Err: err, Rollback: r.Abort()}
+}
+
+// InstallTransactionCommands adds to the tree "begin", "commit" and
+// "abort" commands calling the runner's Begin, Commit and Abort methods.
+func InstallTransactionCommands(t *Tree) (begin, commit, abort *Command) {
+	add := func(name, brief string, f func(r *Runner) error) *Command {
+		return t.AddCommand(CommandDescriptor{
+			Name:    name,
+			Brief:   brief,
+			MaxArgs: NoArgs,
+			Handler: func(ctx *ExecContext, args []string) error {
+				return f(ctx.Runner)
+			},
+		})
+	}
+	begin = add("begin", "Begin a transaction", (*Runner).Begin)
+	commit = add("commit", "Commit the transaction", (*Runner).Commit)
+	abort = add("abort", "Abort the transaction, rolling it back", (*Runner).Abort)
+	return begin, commit, abort
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestTransaction(t *testing.T) {
+	mem := make([]byte, 4)
+	tree := buildUndoTree(mem)
+	InstallTransactionCommands(tree)
+
+	cases := []struct {
+		lines  string
+		mem    string
+		output string
+	}{
+		{"poke 0 1\nbegin\npoke 1 2\npoke 2 3\ncommit", "[1 2 3 0]", ""},
+		{"begin\npoke 1 2\npoke 2 3\nabort", "[0 0 0 0]", ""},
+		{"begin\npoke 1 2\nfail\npoke 2 3", "[0 0 3 0]", "Failed; transaction rolled back.\n"},
+		{"begin\npoke 1 2\npoke 256 9\npoke 2 3", "[0 0 3 0]", "Invalid argument 'addr': value '256' out of range; transaction rolled back.\n"},
+		{"begin\npoke 1 2\nbegin\nundo\ncommit", "[0 2 0 0]", "Transaction in progress.\nTransaction in progress.\n"},
+		{"commit", "[0 0 0 0]", "No transaction in progress.\n"},
+		{"poke 0 1\nbegin\npoke 1 2\npoke 2 3\ncommit\nundo\nundo\nundo", "[0 0 0 0]", "Undid: poke 2 3\nUndid: poke 1 2\nUndid: poke 0 1\n"},
+	}
+
+	for i, c := range cases {
+		clear(mem)
+		out := new(bytes.Buffer)
+		r := NewRunner(tree, strings.NewReader(c.lines), out)
+		r.Prompt = ""
+		r.Run()
+		switch {
+		case fmt.Sprint(mem) != c.mem:
+			t.Errorf("Case %d: expected memory %s, got %v", i, c.mem, mem)
+		case out.String() != c.output:
+			t.Errorf("Case %d: expected %q, got %q", i, c.output, out.String())
+		case r.InTransaction():
+			t.Errorf("Case %d: transaction still in progress", i)
+		}
+	}
+}
+
+func TestTransactionScript(t *testing.T) {
+	mem := make([]byte, 4)
+	tree := buildUndoTree(mem)
+
+	cases := []struct {
+		script string
+		mem    string
+		err    string
+	}{
+		{"poke 0 1\ntransaction\npoke 1 2\npoke 2 3\nend", "[1 2 3 0]", "<nil>"},
+		{"poke 0 1\ntransaction\npoke 1 2\nfail\nend", "[1 0 0 0]", "Line 4: Failed; transaction rolled back"},
+		{"transaction\npoke 1 2\nif bogus\nend\nend", "[0 0 0 0]", "Line 3: Command not found; transaction rolled back"},
+		{"transaction\npoke 1 2\nif fail\nelse\npoke 2 3\nend\nend", "[0 2 3 0]", "<nil>"},
+		{"transaction\ntransaction\nend\nend", "[0 0 0 0]", "Line 2: Transaction in progress; transaction rolled back"},
+		{"transaction x\nend", "[0 0 0 0]", "Line 1: Unexpected statement"},
+	}
+
+	for i, c := range cases {
+		clear(mem)
+		r := NewRunner(tree, nil, new(bytes.Buffer))
+		err := r.RunScript(strings.NewReader(c.script))
+		switch {
+		case fmt.Sprint(mem) != c.mem:
+			t.Errorf("Case %d: expected memory %s, got %v", i, c.mem, mem)
+		case fmt.Sprint(err) != c.err:
+			t.Errorf("Case %d: expected error %q, got %q", i, c.err, fmt.Sprint(err))
+		}
+		if err != nil && strings.Contains(c.err, "rolled back") && !errors.Is(err, ErrRolledBack) {
+			t.Errorf("Case %d: expected ErrRolledBack", i)
+		}
+	}
+}
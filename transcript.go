@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// A transcript records each command line executed by a runner, followed by
+// the output and error messages the line produced. In a transcript, command
+// lines are prefixed by "> ", and output lines beginning with '>' or '\'
+// are escaped by a preceding backslash:
+//
+//	> file open x
+//	opened x
+//	> bogus
+//	Command not found.
+//
+// A transcript is written by a runner whose Transcript writer is set, and is
+// fed back through a runner by Replay.
+
+// A ReplayError reports a command line whose output differed from the
+// output recorded in a transcript.
+type ReplayError struct {
+	Line  int    // transcript line number of the command line
+	Input string // the command line
+	Want  string // output recorded in the transcript
+	Got   string // output produced when replayed
+}
+
+func (e *ReplayError) Error() string {
+	return fmt.Sprintf("Transcript line %d: output of '%s' differs: want %q, got %q",
+		e.Line, e.Input, e.Want, e.Got)
+}
+
+// runLine executes a command line read by Run, displaying any error it
+// returns. The line is added to the runner's history, if any. If the runner
+// has a transcript writer, the line and all output it produces are recorded.
+// Sensitive arguments are masked in both, as described by Tree.MaskLine.
+func (r *Runner) runLine(line string) error {
+	masked := r.Current().MaskLine(line)
+	if r.History != nil {
+		r.History.Add(masked)
+	}
+	if r.Transcript == nil {
+		return r.display(line, r.errWriter())
+	}
+
+	buf := new(bytes.Buffer)
+	out, errOut := r.Out, r.Err
+	r.Out, r.Err = io.MultiWriter(out, buf), io.MultiWriter(r.errWriter(), buf)
+	err := r.display(line, r.Err)
+	r.Out, r.Err = out, errOut
+
+	if _, werr := io.WriteString(r.Transcript, encodeRecord(masked, buf.String())); werr != nil {
+		return werr
+	}
+	return err
+}
+
+// display executes a command line and displays any error it returns that
+// doesn't wrap ErrExit.
+func (r *Runner) display(line string, ew io.Writer) error {
+	err := r.Execute(line)
+	if err == nil || errors.Is(err, ErrExit) {
+		return err
+	}
+	r.DisplayError(ew, err)
+	return nil
+}
+
+// Replay executes each command line recorded in the transcript, verifying
+// that it produces the recorded output. Output produced by replayed lines is
+// not displayed. Replay stops at the first line whose output differs,
+// returning a *ReplayError.
+func (r *Runner) Replay(transcript io.Reader) error {
+	out, errOut := r.Out, r.Err
+	defer func() { r.Out, r.Err = out, errOut }()
+
+	var rec record
+	n, scanner := 0, bufio.NewScanner(transcript)
+	flush := func() error {
+		if rec.line == 0 {
+			return nil
+		}
+		buf := new(bytes.Buffer)
+		r.Out, r.Err = buf, buf
+		if err := r.display(rec.input, buf); err != nil && !errors.Is(err, ErrExit) {
+			return err
+		}
+		if got := normalizeOutput(buf.String()); got != rec.output.String() {
+			return &ReplayError{Line: rec.line, Input: rec.input, Want: rec.output.String(), Got: got}
+		}
+		return nil
+	}
+
+	for scanner.Scan() {
+		n++
+		text := scanner.Text()
+		if input, ok := strings.CutPrefix(text, "> "); ok {
+			if err := flush(); err != nil {
+				return err
+			}
+			rec = record{line: n, input: input}
+			continue
+		}
+		if rec.line == 0 {
+			return fmt.Errorf("Transcript line %d: output precedes the first command line", n)
+		}
+		rec.output.WriteString(strings.TrimPrefix(text, "\\"))
+		rec.output.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return flush()
+}
+
+// A record is a command line read from a transcript and its output.
+type record struct {
+	line   int
+	input  string
+	output strings.Builder
+}
+
+// encodeRecord returns the transcript representation of a command line and
+// its output.
+func encodeRecord(line, output string) string {
+	var b strings.Builder
+	b.WriteString("> ")
+	b.WriteString(strings.ReplaceAll(line, "\n", " "))
+	b.WriteByte('\n')
+	if output == "" {
+		return b.String()
+	}
+	for _, l := range strings.Split(strings.TrimSuffix(output, "\n"), "\n") {
+		if strings.HasPrefix(l, ">") || strings.HasPrefix(l, "\\") {
+			b.WriteByte('\\')
+		}
+		b.WriteString(l)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// normalizeOutput returns output as it would be read back from a transcript.
+func normalizeOutput(output string) string {
+	if output == "" || strings.HasSuffix(output, "\n") {
+		return output
+	}
+	return output + "\n"
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func newTranscriptTree(greeting *string) *Tree {
+	tree := NewTree(TreeDescriptor{Name: "app"})
+	tree.AddCommand(CommandDescriptor{
+		Name: "greet",
+		Handler: func(ctx *ExecContext, args []string) error {
+			ctx.Printf("%s %s\n", *greeting, strings.Join(args, " "))
+			return nil
+		},
+	})
+	tree.AddCommand(CommandDescriptor{
+		Name: "quote",
+		Handler: func(ctx *ExecContext, args []string) error {
+			ctx.Printf("> %s\n\\done", strings.Join(args, " "))
+			return nil
+		},
+	})
+	return tree
+}
+
+func TestTranscript(t *testing.T) {
+	greeting := "hello"
+	tree := newTranscriptTree(&greeting)
+
+	var out, transcript bytes.Buffer
+	r := NewRunner(tree, strings.NewReader("greet bob\nbogus\nquote x\n"), &out)
+	r.Transcript = &transcript
+	if err := r.Run(); err != nil {
+		t.Fatalf("Run: unexpected error %v", err)
+	}
+
+	expected := "> greet bob\nhello bob\n> bogus\nCommand not found.\n> quote x\n\\> x\n\\\\done\n"
+	if transcript.String() != expected {
+		t.Errorf("Transcript: expected %q, got %q", expected, transcript.String())
+	}
+	if !strings.Contains(out.String(), "hello bob\n") {
+		t.Errorf("Output: missing command output in %q", out.String())
+	}
+
+	cases := []struct {
+		greeting string
+		line     int
+	}{
+		{"hello", 0},
+		{"goodbye", 1},
+	}
+	for i, c := range cases {
+		greeting = c.greeting
+		r := NewRunner(tree, nil, nil)
+		err := r.Replay(strings.NewReader(transcript.String()))
+		var rerr *ReplayError
+		switch {
+		case c.line == 0 && err != nil:
+			t.Errorf("Case %d: unexpected error %v", i, err)
+		case c.line != 0 && !errors.As(err, &rerr):
+			t.Errorf("Case %d: expected replay error, got %v", i, err)
+		case c.line != 0 && rerr.Line != c.line:
+			t.Errorf("Case %d: expected line %d, got %d", i, c.line, rerr.Line)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Errors returned by Runner.Undo and Runner.Redo.
+var (
+	ErrNothingToUndo = errors.New("Nothing to undo")
+	ErrNothingToRedo = errors.New("Nothing to redo")
+)
+
+// An undoEntry records a command execution that may be undone.
+type undoEntry struct {
+	line    string // the command line, with sensitive values masked
+	command *Command
+	input   string   // the command line, as executed
+	args    []string // the command's arguments, as executed
+	undo    []func() error
+}
+
+// An undoStack holds a runner's undoable and redoable command executions.
+type undoStack struct {
+	mu   sync.Mutex
+	undo []*undoEntry
+	redo []*undoEntry
+	tx   []*undoEntry // entries of the transaction in progress
+	inTx bool         // a transaction is in progress
+}
+
+// OnUndo registers a function reversing the effects of the command's
+// execution, to be called by Runner.Undo. A handler may register several
+// functions, which are then called in reverse order. Functions registered
+// by a handler returning an error, or executing in dry-run mode, are
+// discarded.
+func (ctx *ExecContext) OnUndo(undo func() error) {
+	if !ctx.DryRun {
+		ctx.undo = append(ctx.undo, undo)
+	}
+}
+
+// pushUndo records the execution of the context's command with the
+// arguments, if its handler registered undo functions, and discards the
+// lines that could be redone. Within a transaction, the execution is
+// recorded by the transaction instead.
+func (r *Runner) pushUndo(ctx *ExecContext, args []string) {
+	if len(ctx.undo) == 0 {
+		return
+	}
+	fields := []string{nodePath(ctx.Command)}
+	for _, arg := range ctx.Command.MaskArgs(args) {
+		fields = append(fields, quoteField(arg))
+	}
+	e := &undoEntry{
+		line:    strings.Join(fields, " "),
+		command: ctx.Command,
+		input:   ctx.Line,
+		args:    args,
+		undo:    ctx.undo,
+	}
+
+	r.undo.mu.Lock()
+	defer r.undo.mu.Unlock()
+	if r.undo.inTx {
+		r.undo.tx = append(r.undo.tx, e)
+		return
+	}
+	r.undo.undo = append(r.undo.undo, e)
+	r.undo.redo = nil
+}
+
+// Undo reverses the most recent command execution that registered undo
+// functions with ExecContext.OnUndo, and returns its command line, with
+// sensitive values masked. The line may then be executed again by Redo.
+// If an undo function fails, its error is returned, and the execution can
+// be neither undone nor redone again. Undo returns ErrInTransaction while a
+// transaction is in progress, as does Redo.
+func (r *Runner) Undo() (string, error) {
+	r.undo.mu.Lock()
+	n := len(r.undo.undo)
+	if r.undo.inTx {
+		r.undo.mu.Unlock()
+		return "", ErrInTransaction
+	}
+	if n == 0 {
+		r.undo.mu.Unlock()
+		return "", ErrNothingToUndo
+	}
+	e := r.undo.undo[n-1]
+	r.undo.undo = r.undo.undo[:n-1]
+	r.undo.mu.Unlock()
+
+	for i := len(e.undo) - 1; i >= 0; i-- {
+		if err := e.undo[i](); err != nil {
+			return e.line, err
+		}
+	}
+
+	r.undo.mu.Lock()
+	r.undo.redo = append(r.undo.redo, e)
+	r.undo.mu.Unlock()
+	return e.line, nil
+}
+
+// Redo executes again the command line most recently reversed by Undo,
+// and returns the line, with sensitive values masked. The command's output
+// is written to the runner's output. If the command fails, its error is
+// returned and the line remains redoable.
+func (r *Runner) Redo() (string, error) {
+	ctx, done := r.foreground()
+	defer done()
+	return r.redo(ctx, r.Out, r.errWriter())
+}
+
+// redo executes again the command line most recently reversed by Undo,
+// writing its output to w and its errors to ew.
+func (r *Runner) redo(base context.Context, w, ew io.Writer) (string, error) {
+	r.undo.mu.Lock()
+	n := len(r.undo.redo)
+	if r.undo.inTx {
+		r.undo.mu.Unlock()
+		return "", ErrInTransaction
+	}
+	if n == 0 {
+		r.undo.mu.Unlock()
+		return "", ErrNothingToRedo
+	}
+	e := r.undo.redo[n-1]
+	redo := r.undo.redo[:n-1]
+	r.undo.mu.Unlock()
+
+	err := r.executeCommand(base, nil, w, ew, e.command, e.input, e.args)
+
+	// A successful execution discarded the lines that could be redone, so
+	// restore the ones preceding the redone line. A failed line remains
+	// redoable.
+	r.undo.mu.Lock()
+	r.undo.redo = redo
+	if err != nil {
+		r.undo.redo = append(r.undo.redo, e)
+	}
+	r.undo.mu.Unlock()
+	return e.line, err
+}
+
+// InstallUndoCommands adds to the tree an "undo" command calling the
+// runner's Undo method, and a "redo" command calling its Redo method. Each
+// displays the line it undid or redid.
+func InstallUndoCommands(t *Tree) (undo, redo *Command) {
+	undo = t.AddCommand(CommandDescriptor{
+		Name:    "undo",
+		Brief:   "Undo the last command",
+		MaxArgs: NoArgs,
+		Handler: func(ctx *ExecContext, args []string) error {
+			line, err := ctx.Runner.Undo()
+			if err == nil {
+				ctx.Printf("Undid: %s\n", line)
+			}
+			return err
+		},
+	})
+	redo = t.AddCommand(CommandDescriptor{
+		Name:    "redo",
+		Brief:   "Redo the last undone command",
+		MaxArgs: NoArgs,
+		Handler: func(ctx *ExecContext, args []string) error {
+			line, err := ctx.Runner.redo(ctx.Context(), ctx.Out, ctx.Err)
+			if err == nil {
+				ctx.Printf("Redid: %s\n", line)
+			}
+			return err
+		},
+	})
+	return undo, redo
+}
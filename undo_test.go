@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func buildUndoTree(mem []byte) *Tree {
+	tree := NewTree(TreeDescriptor{Name: "tree"})
+	tree.AddCommand(CommandDescriptor{
+		Name: "poke",
+		Args: []Arg{
+			{Name: "addr", Type: UintType{BitSize: 8}},
+			{Name: "value", Type: UintType{BitSize: 8}},
+		},
+		DryRun: true,
+		Handler: func(ctx *ExecContext, args []string) error {
+			addr := ctx.Values["addr"].(uint64)
+			old := mem[addr]
+			mem[addr] = byte(ctx.Values["value"].(uint64))
+			ctx.OnUndo(func() error {
+				mem[addr] = old
+				return nil
+			})
+			return nil
+		},
+	})
+	tree.AddCommand(CommandDescriptor{
+		Name: "fail",
+		Handler: func(ctx *ExecContext, args []string) error {
+			ctx.OnUndo(func() error { panic("undo called") })
+			return errors.New("Failed")
+		},
+	})
+	InstallUndoCommands(tree)
+	return tree
+}
+
+func TestUndo(t *testing.T) {
+	mem := make([]byte, 4)
+	out := new(bytes.Buffer)
+	r := NewRunner(buildUndoTree(mem), nil, out)
+
+	cases := []struct {
+		line   string
+		mem    string
+		output string
+	}{
+		{"poke 0 1", "[1 0 0 0]", ""},
+		{"poke 1 2", "[1 2 0 0]", ""},
+		{"poke 1 3", "[1 3 0 0]", ""},
+		{"poke 2 9 --dry-run", "[1 3 9 0]", ""},
+		{"fail", "[1 3 9 0]", "Failed.\n"},
+		{"undo", "[1 2 9 0]", "Undid: poke 1 3\n"},
+		{"undo", "[1 0 9 0]", "Undid: poke 1 2\n"},
+		{"redo", "[1 2 9 0]", "Redid: poke 1 2\n"},
+		{"undo", "[1 0 9 0]", "Undid: poke 1 2\n"},
+		{"undo", "[0 0 9 0]", "Undid: poke 0 1\n"},
+		{"undo", "[0 0 9 0]", "Nothing to undo.\n"},
+		{"redo", "[1 0 9 0]", "Redid: poke 0 1\n"},
+		{"redo", "[1 2 9 0]", "Redid: poke 1 2\n"},
+		{"redo", "[1 3 9 0]", "Redid: poke 1 3\n"},
+		{"redo", "[1 3 9 0]", "Nothing to redo.\n"},
+		{"undo", "[1 2 9 0]", "Undid: poke 1 3\n"},
+		{"poke 3 4", "[1 2 9 4]", ""},
+		{"redo", "[1 2 9 4]", "Nothing to redo.\n"},
+	}
+
+	for i, c := range cases {
+		out.Reset()
+		if err := r.Execute(c.line); err != nil {
+			r.DisplayError(out, err)
+		}
+		switch {
+		case fmt.Sprint(mem) != c.mem:
+			t.Errorf("Case %d: expected memory %s, got %v", i, c.mem, mem)
+		case out.String() != c.output:
+			t.Errorf("Case %d: expected %q, got %q", i, c.output, out.String())
+		}
+	}
+}
+
+func TestUndoMasked(t *testing.T) {
+	tree := NewTree(TreeDescriptor{Name: "tree"})
+	tree.AddCommand(CommandDescriptor{
+		Name: "login",
+		Args: []Arg{{Name: "user"}, {Name: "password", Sensitive: true}},
+		Handler: func(ctx *ExecContext, args []string) error {
+			ctx.OnUndo(func() error { return errors.New("Can't log out") })
+			return nil
+		},
+	})
+
+	r := NewRunner(tree, nil, new(bytes.Buffer))
+	r.Execute(`login "Bob Smith" hunter2`)
+	line, err := r.Undo()
+	if line != `login "Bob Smith" ******` || err == nil || !strings.Contains(err.Error(), "log out") {
+		t.Errorf("unexpected result %q, %v", line, err)
+	}
+	if _, err := r.Redo(); err != ErrNothingToRedo {
+		t.Errorf("expected ErrNothingToRedo, got %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrUsage reports that a command was invoked incorrectly. A handler may
+// return ErrUsage, an error wrapping it, or an error created by
+// NewUsageError. A Runner displaying such an error also displays the
+// command's usage.
+var ErrUsage = errors.New("Invalid usage")
+
+// A UsageError reports an incorrect invocation of a command. Errors wrapping
+// ErrUsage returned by a handler are converted to a UsageError identifying
+// the command.
+type UsageError struct {
+	Command *Command // the command invoked incorrectly
+	Err     error    // description of the problem (nil for the default)
+}
+
+// NewUsageError returns a usage error described by the formatted message.
+func NewUsageError(format string, a ...any) error {
+	return &UsageError{Err: fmt.Errorf(format, a...)}
+}
+
+func (e *UsageError) Error() string {
+	if e.Err == nil || e.Err == ErrUsage {
+		return ErrUsage.Error()
+	}
+	return e.Err.Error()
+}
+
+func (e *UsageError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{ErrUsage}
+	}
+	return []error{ErrUsage, e.Err}
+}
+
+// usageError converts an error wrapping ErrUsage, returned by the command's
+// handler, into a UsageError identifying the command.
+func usageError(c *Command, err error) error {
+	if err == nil || !errors.Is(err, ErrUsage) {
+		return err
+	}
+	var uerr *UsageError
+	if errors.As(err, &uerr) {
+		if uerr.Command == nil {
+			uerr.Command = c
+		}
+		return err
+	}
+	return &UsageError{Command: c, Err: err}
+}
+
+// DisplayError displays the message returned by the tree's ErrorMessage
+// method for an error returned while executing a command line. If the error
+// is a usage error, the command's usage is displayed after the message, and
+// if it is a *SubcommandError, the subtree's help is displayed after the
+// message. A StatusError without an underlying error isn't displayed.
+func (r *Runner) DisplayError(w io.Writer, err error) {
+	var status *StatusError
+	if err == nil || errors.As(err, &status) && status.Err == nil {
+		return
+	}
+	fmt.Fprintln(w, r.Tree.ErrorMessage(err))
+
+	var usage *UsageError
+	var subcmd *SubcommandError
+	switch {
+	case errors.As(err, &usage) && usage.Command != nil:
+		usage.Command.DisplayUsage(w)
+	case errors.As(err, &subcmd):
+		subcmd.Tree.DisplayHelp(w)
+	}
+}
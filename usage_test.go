@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestUsageError(t *testing.T) {
+	tree := NewTree(TreeDescriptor{Name: "app"})
+	file := tree.AddSubtree(TreeDescriptor{Name: "file"})
+	file.AddCommand(CommandDescriptor{
+		Name:  "copy",
+		Usage: "file copy <src> <dst>",
+		Handler: func(ctx *ExecContext, args []string) error {
+			switch len(args) {
+			case 0:
+				return ErrUsage
+			case 1:
+				return NewUsageError("Missing destination")
+			case 2:
+				return fmt.Errorf("%w: same file", ErrUsage)
+			}
+			return nil
+		},
+	})
+
+	cases := []struct {
+		line     string
+		expected string
+	}{
+		{"file copy", "Invalid usage.\nUsage: file copy <src> <dst>\n"},
+		{"file copy a", "Missing destination.\nUsage: file copy <src> <dst>\n"},
+		{"file copy a a", "Invalid usage: same file.\nUsage: file copy <src> <dst>\n"},
+		{"file copy a b c", ""},
+	}
+	for i, c := range cases {
+		err := NewRunner(tree, nil, nil).Execute(c.line)
+		var uerr *UsageError
+		if c.expected != "" && (!errors.Is(err, ErrUsage) || !errors.As(err, &uerr) || uerr.Command.Name != "copy") {
+			t.Errorf("Case %d: expected usage error for copy, got %v", i, err)
+		}
+
+		var out strings.Builder
+		r := NewRunner(tree, strings.NewReader(c.line), &out)
+		r.Prompt = ""
+		r.Run()
+		if out.String() != c.expected {
+			t.Errorf("Case %d: expected %q, got %q", i, c.expected, out.String())
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrVarName is returned when setting a variable whose name is invalid.
+// Variable names consist of letters, digits and underscores, and don't begin
+// with a digit.
+var ErrVarName = errors.New("Invalid variable name")
+
+// A varStore holds a runner's session variables.
+type varStore struct {
+	mu   sync.RWMutex
+	vars map[string]string
+}
+
+// SetVar sets the value of a session variable.
+func (r *Runner) SetVar(name, value string) error {
+	if !validVarName(name) {
+		return ErrVarName
+	}
+	r.vars.mu.Lock()
+	defer r.vars.mu.Unlock()
+	if r.vars.vars == nil {
+		r.vars.vars = make(map[string]string)
+	}
+	r.vars.vars[name] = value
+	return nil
+}
+
+// Var returns the value of a session variable and whether it is set.
+func (r *Runner) Var(name string) (string, bool) {
+	r.vars.mu.RLock()
+	defer r.vars.mu.RUnlock()
+	v, ok := r.vars.vars[name]
+	return v, ok
+}
+
+// UnsetVar removes a session variable.
+func (r *Runner) UnsetVar(name string) {
+	r.vars.mu.Lock()
+	defer r.vars.mu.Unlock()
+	delete(r.vars.vars, name)
+}
+
+// Vars returns a copy of the runner's session variables.
+func (r *Runner) Vars() map[string]string {
+	r.vars.mu.RLock()
+	defer r.vars.mu.RUnlock()
+	return maps.Clone(r.vars.vars)
+}
+
+// ExpandVars replaces each reference to a session variable in the line,
+// written as $NAME or ${NAME}, with the variable's value. References to
+// variables that aren't set are left unchanged. The special variable $?
+// expands to the runner's Status. Values are substituted as
+// they are, so a value containing spaces must be quoted, as in "$NAME", to
+// be passed as a single argument.
+func (r *Runner) ExpandVars(line string) string {
+	if !strings.Contains(line, "$") {
+		return line
+	}
+	r.vars.mu.RLock()
+	defer r.vars.mu.RUnlock()
+
+	var b strings.Builder
+	for {
+		i := strings.IndexByte(line, '$')
+		if i < 0 {
+			b.WriteString(line)
+			return b.String()
+		}
+		b.WriteString(line[:i])
+		name, n := varRef(line[i+1:])
+		if name == "?" {
+			b.WriteString(strconv.Itoa(r.Status()))
+		} else if v, ok := r.vars.vars[name]; ok && n > 0 {
+			b.WriteString(v)
+		} else {
+			b.WriteString(line[i : i+1+n])
+		}
+		line = line[i+1+n:]
+	}
+}
+
+// varRef parses the variable reference following a '$', returning the
+// variable's name and the length of the reference.
+func varRef(s string) (name string, n int) {
+	if strings.HasPrefix(s, "?") {
+		return "?", 1
+	}
+	if rest, ok := strings.CutPrefix(s, "{"); ok {
+		if end := strings.IndexByte(rest, '}'); end > 0 && validVarName(rest[:end]) {
+			return rest[:end], end + 2
+		}
+		return "", 0
+	}
+	for n < len(s) && isVarByte(s[n], n == 0) {
+		n++
+	}
+	return s[:n], n
+}
+
+func validVarName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		if !isVarByte(name[i], i == 0) {
+			return false
+		}
+	}
+	return true
+}
+
+func isVarByte(c byte, first bool) bool {
+	switch {
+	case c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z':
+		return true
+	case '0' <= c && c <= '9':
+		return !first
+	}
+	return false
+}
+
+// SetCommand returns the descriptor of a command that sets a session
+// variable. Given no arguments, the command lists the session variables.
+func SetCommand() CommandDescriptor {
+	return CommandDescriptor{
+		Name:  "set",
+		Brief: "Set a session variable",
+		Usage: "set [<name> <value>...]",
+		Handler: func(ctx *ExecContext, args []string) error {
+			if len(args) == 0 {
+				vars := ctx.Runner.Vars()
+				for _, name := range slices.Sorted(maps.Keys(vars)) {
+					ctx.Printf("%s=%s\n", name, vars[name])
+				}
+				return nil
+			}
+			if len(args) == 1 {
+				return &ArgError{Name: "value", Err: ErrMissingArg}
+			}
+			return ctx.Runner.SetVar(args[0], strings.Join(args[1:], " "))
+		},
+	}
+}
+
+// UnsetCommand returns the descriptor of a command that removes session
+// variables.
+func UnsetCommand() CommandDescriptor {
+	return CommandDescriptor{
+		Name:  "unset",
+		Brief: "Remove session variables",
+		Args:  []Arg{{Name: "name", Variadic: true}},
+		Handler: func(ctx *ExecContext, args []string) error {
+			for _, name := range args {
+				ctx.Runner.UnsetVar(name)
+			}
+			return nil
+		},
+	}
+}
+
+// EchoCommand returns the descriptor of a command that displays its
+// arguments, after the expansion of session variables.
+func EchoCommand() CommandDescriptor {
+	return CommandDescriptor{
+		Name:  "echo",
+		Brief: "Display a line of text",
+		Usage: "echo [<text>...]",
+		Handler: func(ctx *ExecContext, args []string) error {
+			fmt.Fprintln(ctx.Out, strings.Join(args, " "))
+			return nil
+		},
+	}
+}
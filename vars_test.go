@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestExpandVars(t *testing.T) {
+	r := NewRunner(NewTree(TreeDescriptor{Name: "app"}), nil, nil)
+	r.SetVar("addr", "0x1000")
+	r.SetVar("file", "a b.txt")
+	r.SetVar("x1", "one")
+
+	cases := []struct {
+		line     string
+		expected string
+	}{
+		{"mem read $addr 16", "mem read 0x1000 16"},
+		{"open \"$file\"", "open \"a b.txt\""},
+		{"echo ${x1}2 $x12", "echo one2 $x12"},
+		{"echo $missing $ $1 ${} ${addr", "echo $missing $ $1 ${} ${addr"},
+		{"echo $$addr", "echo $0x1000"},
+		{"echo price$", "echo price$"},
+	}
+	for i, c := range cases {
+		got := r.ExpandVars(c.line)
+		if got != c.expected {
+			t.Errorf("Case %d: expected %q, got %q", i, c.expected, got)
+		}
+	}
+
+	if err := r.SetVar("1x", "v"); err != ErrVarName {
+		t.Errorf("SetVar: expected ErrVarName, got %v", err)
+	}
+}
+
+func TestVarCommands(t *testing.T) {
+	tree := NewTree(TreeDescriptor{Name: "app"})
+	tree.AddCommand(SetCommand())
+	tree.AddCommand(UnsetCommand())
+	tree.AddCommand(EchoCommand())
+
+	in := "set name world\nset greeting hello  there\necho $greeting, $name!\nset\nunset name\necho $name\nset 9 x\n"
+	var out bytes.Buffer
+	r := NewRunner(tree, strings.NewReader(in), &out)
+	r.Prompt = ""
+	if err := r.Run(); err != nil {
+		t.Fatalf("Run: unexpected error %v", err)
+	}
+
+	expected := "hello there, world!\ngreeting=hello there\nname=world\n$name\nInvalid variable name.\n"
+	if out.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, out.String())
+	}
+}
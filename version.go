@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// VersionInfo describes a build of an application, as displayed by the
+// command installed by InstallVersionCommand.
+type VersionInfo struct {
+	Name    string // application name
+	Version string // application version
+	Commit  string // optional source revision the application was built from
+	Date    string // optional build date
+}
+
+// versionResult is the structured result of the version command.
+type versionResult struct {
+	Name     string `json:"name"`
+	Version  string `json:"version"`
+	Commit   string `json:"commit,omitempty"`
+	Date     string `json:"date,omitempty"`
+	Go       string `json:"go"`
+	Platform string `json:"platform"`
+}
+
+// InstallVersionCommand adds to the tree a "version" command that displays
+// the application's version information along with the version of the Go
+// runtime and the platform it runs on. Ending the command line with JSONFlag
+// displays the information as JSON.
+func InstallVersionCommand(t *Tree, info VersionInfo) *Command {
+	return t.AddCommand(CommandDescriptor{
+		Name:  "version",
+		Brief: "Display version information",
+		ResultHandler: func(ctx *ExecContext, args []string) (*Result, error) {
+			v := versionResult{
+				Name:     info.Name,
+				Version:  info.Version,
+				Commit:   info.Commit,
+				Date:     info.Date,
+				Go:       runtime.Version(),
+				Platform: runtime.GOOS + "/" + runtime.GOARCH,
+			}
+			return &Result{Value: v, Text: v.text()}, nil
+		},
+	})
+}
+
+// text returns the human-readable form of the version information.
+func (v versionResult) text() string {
+	var b strings.Builder
+	fmt.Fprintln(&b, strings.TrimSpace(v.Name+" "+v.Version))
+	if v.Commit != "" {
+		fmt.Fprintf(&b, "Commit:   %s\n", v.Commit)
+	}
+	if v.Date != "" {
+		fmt.Fprintf(&b, "Built:    %s\n", v.Date)
+	}
+	fmt.Fprintf(&b, "Go:       %s\n", v.Go)
+	fmt.Fprintf(&b, "Platform: %s\n", v.Platform)
+	return b.String()
+}
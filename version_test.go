@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"bytes"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestVersionCommand(t *testing.T) {
+	tree := NewTree(TreeDescriptor{Name: "root"})
+	InstallVersionCommand(tree, VersionInfo{Name: "app", Version: "1.2.3", Commit: "abc123"})
+
+	goVersion, platform := runtime.Version(), runtime.GOOS+"/"+runtime.GOARCH
+	cases := []struct {
+		line     string
+		expected string
+	}{
+		{"version", "app 1.2.3\n" +
+			"Commit:   abc123\n" +
+			"Go:       " + goVersion + "\n" +
+			"Platform: " + platform + "\n"},
+		{"version --json", "{\n" +
+			"  \"name\": \"app\",\n" +
+			"  \"version\": \"1.2.3\",\n" +
+			"  \"commit\": \"abc123\",\n" +
+			"  \"go\": \"" + goVersion + "\",\n" +
+			"  \"platform\": \"" + platform + "\"\n" +
+			"}\n"},
+	}
+
+	for i, c := range cases {
+		var out bytes.Buffer
+		r := NewRunner(tree, strings.NewReader(""), &out)
+		if err := r.Execute(c.line); err != nil {
+			t.Errorf("Case %d: unexpected error: %v", i, err)
+			continue
+		}
+		if out.String() != c.expected {
+			t.Errorf("Case %d: expected %q, got %q", i, c.expected, out.String())
+		}
+	}
+}
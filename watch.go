@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Watch executes the command line repeatedly at the interval, like the
+// Unix watch command, until the context is done. Each run's output is
+// written to w below a header naming the line and the time of the run. If
+// w is a terminal, the screen is cleared before each run, so that the
+// output is redrawn in place. Errors returned by the line are displayed
+// and don't stop the watch. Watch returns nil once interrupted by
+// Runner.Interrupt, and ErrTimeout if the context's deadline expires.
+func (r *Runner) Watch(ctx context.Context, interval time.Duration, line string, w io.Writer) error {
+	if interval <= 0 {
+		return &ArgError{Name: "interval", Value: interval.String(), Err: errors.New("interval must be positive")}
+	}
+	tree := r.Current()
+	line = r.ExpandVars(line)
+	if _, _, err := tree.LookupCommand(line); err != nil {
+		return err
+	}
+
+	redraw := isTerminal(w)
+	header := fmt.Sprintf("Every %v: %s", interval, tree.MaskLine(line))
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			if errors.Is(context.Cause(ctx), ErrInterrupted) {
+				return nil
+			}
+			return interruptError(ctx)
+		case <-timer.C:
+		}
+
+		start := time.Now()
+		buf := new(bytes.Buffer)
+		if redraw {
+			buf.WriteString("\x1b[H\x1b[2J")
+		}
+		fmt.Fprintf(buf, "%s  %s\n\n", header, start.Format("15:04:05"))
+		err := r.executeIn(ctx, tree, line, buf, buf)
+		if err != nil && ctx.Err() == nil {
+			r.DisplayError(buf, err)
+		}
+		if !redraw {
+			buf.WriteByte('\n')
+		}
+		if ctx.Err() == nil {
+			w.Write(buf.Bytes())
+		}
+		timer.Reset(time.Until(start.Add(interval)))
+	}
+}
+
+// WatchCommand returns the descriptor of a command that executes a command
+// line repeatedly at an interval until interrupted, as in "watch 2s
+// status".
+func WatchCommand() CommandDescriptor {
+	return CommandDescriptor{
+		Name:  "watch",
+		Brief: "Execute a command repeatedly until interrupted",
+		Args: []Arg{
+			{Name: "interval", Type: DurationType{}},
+			{Name: "command", Variadic: true},
+		},
+		Handler: func(ctx *ExecContext, args []string) error {
+			return ctx.Runner.Watch(ctx.Context(), ctx.Values["interval"].(time.Duration), joinFields(args[1:]), ctx.Out)
+		},
+	}
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWatch(t *testing.T) {
+	var count atomic.Int32
+	tree := NewTree(TreeDescriptor{Name: "tree"})
+	tree.AddCommand(CommandDescriptor{
+		Name: "status",
+		Handler: func(ctx *ExecContext, args []string) error {
+			if count.Add(1) == 2 {
+				return errors.New("Failed")
+			}
+			ctx.Printf("ok\n")
+			return nil
+		},
+	})
+	tree.AddCommand(WatchCommand())
+
+	out := new(syncBuffer)
+	r := NewRunner(tree, nil, out)
+	done := make(chan error)
+	go func() { done <- r.Execute("watch 5ms status") }()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for count.Load() < 3 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	for !r.Interrupt() {
+		time.Sleep(time.Millisecond)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("unexpected error '%v'", err)
+	}
+
+	got := out.String()
+	for _, s := range []string{"Every 5ms: status  ", "\n\nok\n\n", "\n\nFailed.\n\n"} {
+		if !strings.Contains(got, s) {
+			t.Errorf("output %q is missing %q", got, s)
+		}
+	}
+	if strings.Contains(got, "\x1b[2J") {
+		t.Errorf("screen cleared on a non-terminal")
+	}
+}
+
+func TestWatchErrors(t *testing.T) {
+	tree := NewTree(TreeDescriptor{Name: "tree"})
+	tree.AddCommand(CommandDescriptor{Name: "status", Handler: func(ctx *ExecContext, args []string) error { return nil }})
+	r := NewRunner(tree, nil, io.Discard)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	cases := []struct {
+		interval time.Duration
+		line     string
+		err      error
+	}{
+		{time.Second, "bogus", ErrNotFound},
+		{0, "status", nil},
+		{time.Millisecond, "status", ErrTimeout},
+	}
+	for i, c := range cases {
+		err := r.Watch(ctx, c.interval, c.line, io.Discard)
+		switch {
+		case err == nil:
+			t.Errorf("Case %d: expected an error", i)
+		case c.err != nil && !errors.Is(err, c.err):
+			t.Errorf("Case %d: expected %v, got %v", i, c.err, err)
+		}
+	}
+}
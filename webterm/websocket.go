@@ -0,0 +1,238 @@
+package webterm
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// WebSocket opcodes.
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xa
+)
+
+// WebSocket close status codes.
+const (
+	closeNormal      = 1000
+	closeProtocol    = 1002
+	closeUnsupported = 1003
+	closeTooBig      = 1009
+)
+
+// maxMessageSize is the size of the largest message accepted from a
+// browser.
+const maxMessageSize = 1 << 20
+
+// acceptGUID is the GUID appended to a client's key to compute the
+// handshake's accept header, as specified by RFC 6455.
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+var (
+	errHandshake   = errors.New("Invalid WebSocket handshake")
+	errProtocol    = errors.New("WebSocket protocol error")
+	errMessageSize = errors.New("WebSocket message too large")
+)
+
+// A conn is the server side of a WebSocket connection.
+type conn struct {
+	c  net.Conn
+	br *bufio.Reader
+	mu sync.Mutex // serializes writes
+}
+
+// upgrade completes the WebSocket handshake of the request and takes over
+// its connection. If the handshake is invalid, an error response is written
+// and errHandshake is returned.
+func upgrade(w http.ResponseWriter, r *http.Request) (*conn, error) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if r.Method != http.MethodGet ||
+		!hasToken(r.Header, "Connection", "upgrade") ||
+		!hasToken(r.Header, "Upgrade", "websocket") ||
+		r.Header.Get("Sec-WebSocket-Version") != "13" || key == "" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, errHandshake.Error(), http.StatusBadRequest)
+		return nil, errHandshake
+	}
+
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "Connection can't be upgraded", http.StatusInternalServerError)
+		return nil, errHandshake
+	}
+	c, rw, err := hj.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	rw.WriteString("HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + acceptKey(key) + "\r\n\r\n")
+	if err := rw.Flush(); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return &conn{c: c, br: rw.Reader}, nil
+}
+
+// acceptKey returns the value of the handshake's accept header for a
+// client's key.
+func acceptKey(key string) string {
+	h := sha1.Sum([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(h[:])
+}
+
+// hasToken returns true if the comma-separated list of tokens in the
+// header includes the token, ignoring case.
+func hasToken(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// readMessage reads the next text or binary message, reassembling
+// fragmented messages and answering control frames. It returns io.EOF once
+// the browser closes the connection.
+func (c *conn) readMessage() (op byte, data []byte, err error) {
+	for {
+		fin, fop, payload, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch fop {
+		case opPing:
+			c.writeFrame(opPong, payload)
+			continue
+		case opPong:
+			continue
+		case opClose:
+			code := closeNormal
+			if len(payload) >= 2 {
+				code = int(binary.BigEndian.Uint16(payload))
+			}
+			c.close(code)
+			return 0, nil, io.EOF
+		case opText, opBinary:
+			if op != 0 {
+				return 0, nil, c.fail(closeProtocol, errProtocol)
+			}
+			op = fop
+		case opContinuation:
+			if op == 0 {
+				return 0, nil, c.fail(closeProtocol, errProtocol)
+			}
+		default:
+			return 0, nil, c.fail(closeProtocol, errProtocol)
+		}
+
+		if len(data)+len(payload) > maxMessageSize {
+			return 0, nil, c.fail(closeTooBig, errMessageSize)
+		}
+		data = append(data, payload...)
+		if fin {
+			return op, data, nil
+		}
+	}
+}
+
+// readFrame reads a single frame, unmasking its payload.
+func (c *conn) readFrame() (fin bool, op byte, payload []byte, err error) {
+	var h [2]byte
+	if _, err := io.ReadFull(c.br, h[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin, op = h[0]&0x80 != 0, h[0]&0x0f
+	if h[0]&0x70 != 0 || h[1]&0x80 == 0 {
+		// Reserved bits must be clear, and browsers must mask their frames.
+		return false, 0, nil, c.fail(closeProtocol, errProtocol)
+	}
+	control := op&0x8 != 0
+	if control && (!fin || h[1]&0x7f > 125) {
+		return false, 0, nil, c.fail(closeProtocol, errProtocol)
+	}
+
+	n := uint64(h[1] & 0x7f)
+	switch n {
+	case 126:
+		var b [2]byte
+		if _, err := io.ReadFull(c.br, b[:]); err != nil {
+			return false, 0, nil, err
+		}
+		n = uint64(binary.BigEndian.Uint16(b[:]))
+	case 127:
+		var b [8]byte
+		if _, err := io.ReadFull(c.br, b[:]); err != nil {
+			return false, 0, nil, err
+		}
+		n = binary.BigEndian.Uint64(b[:])
+	}
+	if n > maxMessageSize {
+		return false, 0, nil, c.fail(closeTooBig, errMessageSize)
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(c.br, mask[:]); err != nil {
+		return false, 0, nil, err
+	}
+	payload = make([]byte, n)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return fin, op, payload, nil
+}
+
+// writeFrame writes an unfragmented, unmasked frame.
+func (c *conn) writeFrame(op byte, payload []byte) error {
+	b := make([]byte, 0, len(payload)+10)
+	b = append(b, 0x80|op)
+	switch n := len(payload); {
+	case n <= 125:
+		b = append(b, byte(n))
+	case n <= 0xffff:
+		b = append(b, 126)
+		b = binary.BigEndian.AppendUint16(b, uint16(n))
+	default:
+		b = append(b, 127)
+		b = binary.BigEndian.AppendUint64(b, uint64(n))
+	}
+	b = append(b, payload...)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err := c.c.Write(b)
+	return err
+}
+
+// close sends a close frame with the status code and closes the
+// connection.
+func (c *conn) close(code int) error {
+	c.writeFrame(opClose, binary.BigEndian.AppendUint16(nil, uint16(code)))
+	return c.c.Close()
+}
+
+// fail closes the connection with the status code, and returns the error.
+func (c *conn) fail(code int, err error) error {
+	c.close(code)
+	return err
+}
@@ -0,0 +1,319 @@
+// Package webterm bridges terminal emulators running in a browser, such as
+// xterm.js, to command runners, so that a web UI may embed the same
+// interactive shell as a terminal. Each WebSocket connection accepted by a
+// Handler is served by its own runner, whose lines are edited by a
+// lineedit.Editor.
+//
+// Browsers send binary messages holding the terminal's input, as typed, or
+// text messages holding JSON control messages:
+//
+//	{"type": "input", "data": "ls\r"}
+//	{"type": "resize", "cols": 120, "rows": 40}
+//
+// The server sends text messages holding the terminal's output. Messages
+// never split a UTF-8 encoded character, and line feeds are translated to
+// carriage return and line feed pairs. A minimal xterm.js client:
+//
+//	const ws = new WebSocket("wss://example.com/shell");
+//	term.onData(data => ws.send(JSON.stringify({type: "input", data})));
+//	term.onResize(({cols, rows}) =>
+//		ws.send(JSON.stringify({type: "resize", cols, rows})));
+//	ws.onmessage = e => term.write(e.data);
+package webterm
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/beevik/cmd"
+	"github.com/beevik/cmd/lineedit"
+)
+
+// A Handler serves interactive sessions of a command tree to browsers
+// connecting over WebSocket.
+type Handler struct {
+	// Tree is the command tree executed by each session.
+	Tree *cmd.Tree
+
+	// Setup, if not nil, is called with each new session before its runner
+	// is started, so that the runner and its editor may be configured.
+	Setup func(s *Session)
+
+	// CheckOrigin, if not nil, returns true if a browser's request to open
+	// a session is allowed. If nil, requests whose Origin header names a
+	// host other than the request's host are refused.
+	CheckOrigin func(r *http.Request) bool
+}
+
+// A Session is an interactive session served to a browser.
+type Session struct {
+	Runner  *cmd.Runner      // runner executing the session's commands
+	Editor  *lineedit.Editor // editor reading the session's command lines
+	Request *http.Request    // request that opened the session
+
+	// OnResize, if not nil, is called when the browser's terminal is
+	// resized.
+	OnResize func(cols, rows int)
+
+	conn *conn
+	in   *input
+	out  *output
+	mu   sync.Mutex
+	cols int
+	rows int
+}
+
+// A message is a control message sent by a browser.
+type message struct {
+	Type string `json:"type"`
+	Data string `json:"data,omitempty"`
+	Cols int    `json:"cols,omitempty"`
+	Rows int    `json:"rows,omitempty"`
+}
+
+var errMessage = errors.New("Invalid control message")
+
+// ServeHTTP opens a session on the WebSocket connection requested, and
+// runs it until the runner stops or the browser disconnects.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	check := h.CheckOrigin
+	if check == nil {
+		check = sameOrigin
+	}
+	if !check(r) {
+		http.Error(w, "Origin not allowed", http.StatusForbidden)
+		return
+	}
+
+	c, err := upgrade(w, r)
+	if err != nil {
+		return
+	}
+	s := newSession(h.Tree, c, r)
+	if h.Setup != nil {
+		h.Setup(s)
+	}
+	s.run()
+}
+
+// newSession creates a session on the connection.
+func newSession(tree *cmd.Tree, c *conn, r *http.Request) *Session {
+	s := &Session{
+		Request: r,
+		conn:    c,
+		in:      newInput(),
+		out:     &output{conn: c},
+	}
+	s.Runner = cmd.NewRunner(tree, s.in, s.out)
+	s.Editor = lineedit.NewTerminal(s.in, s.out)
+	s.Editor.Attach(s.Runner)
+	return s
+}
+
+// run reads the browser's messages while the runner executes the session's
+// commands, and closes the connection once either stops.
+func (s *Session) run() {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Runner.Run()
+	}()
+	go func() {
+		s.receive()
+		s.in.Close()
+		s.Runner.Interrupt()
+	}()
+	<-done
+	s.out.flush()
+	s.conn.close(closeNormal)
+}
+
+// receive handles the browser's messages until the connection closes.
+func (s *Session) receive() {
+	for {
+		op, data, err := s.conn.readMessage()
+		if err != nil {
+			return
+		}
+		if op == opBinary {
+			s.input(data)
+			continue
+		}
+
+		var m message
+		if json.Unmarshal(data, &m) != nil {
+			s.conn.fail(closeUnsupported, errMessage)
+			return
+		}
+		switch m.Type {
+		case "input":
+			s.input([]byte(m.Data))
+		case "resize":
+			s.resize(m.Cols, m.Rows)
+		}
+	}
+}
+
+// input passes the terminal's input to the runner. Ctrl-C typed while a
+// command is being executed interrupts the command.
+func (s *Session) input(data []byte) {
+	for {
+		i := bytes.IndexByte(data, 0x03)
+		if i < 0 || !s.Runner.Interrupt() {
+			break
+		}
+		s.in.Write(data[:i])
+		data = data[i+1:]
+	}
+	s.in.Write(data)
+}
+
+// resize records the terminal's size and reports it to the session.
+func (s *Session) resize(cols, rows int) {
+	if cols <= 0 || rows <= 0 {
+		return
+	}
+	s.mu.Lock()
+	s.cols, s.rows = cols, rows
+	s.mu.Unlock()
+	if s.OnResize != nil {
+		s.OnResize(cols, rows)
+	}
+}
+
+// Size returns the size of the browser's terminal, in columns and rows. It
+// returns zeros until the browser reports the size.
+func (s *Session) Size() (cols, rows int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cols, s.rows
+}
+
+// sameOrigin returns true if the request has no Origin header, or if the
+// header names the request's host.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+// An input buffers the terminal's input until the runner reads it. Writes
+// never block, so that Ctrl-C is seen while a command is being executed.
+type input struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	buf    []byte
+	closed bool
+}
+
+func newInput() *input {
+	in := new(input)
+	in.cond = sync.NewCond(&in.mu)
+	return in
+}
+
+func (in *input) Read(p []byte) (int, error) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	for len(in.buf) == 0 && !in.closed {
+		in.cond.Wait()
+	}
+	if len(in.buf) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, in.buf)
+	in.buf = in.buf[n:]
+	return n, nil
+}
+
+func (in *input) Write(p []byte) (int, error) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	if in.closed {
+		return 0, io.ErrClosedPipe
+	}
+	in.buf = append(in.buf, p...)
+	in.cond.Broadcast()
+	return len(p), nil
+}
+
+// Close ends the input once the buffered input has been read.
+func (in *input) Close() error {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.closed = true
+	in.cond.Broadcast()
+	return nil
+}
+
+// An output sends the terminal's output to the browser as text messages.
+// An incomplete UTF-8 encoded character ending a write is held until the
+// next write completes it.
+type output struct {
+	conn    *conn
+	mu      sync.Mutex
+	partial []byte
+}
+
+func (o *output) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	b := append(o.partial, p...)
+	o.partial = nil
+	if n := incomplete(b); n > 0 {
+		o.partial = append([]byte(nil), b[len(b)-n:]...)
+		b = b[:len(b)-n]
+	}
+	if len(b) == 0 {
+		return len(p), nil
+	}
+	if err := o.send(b); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// flush sends any held bytes.
+func (o *output) flush() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if len(o.partial) > 0 {
+		o.send(o.partial)
+		o.partial = nil
+	}
+}
+
+// send sends the bytes as a text message, replacing invalid UTF-8 and
+// translating line feeds.
+func (o *output) send(b []byte) error {
+	s := strings.ToValidUTF8(string(b), "�")
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.ReplaceAll(s, "\n", "\r\n")
+	return o.conn.writeFrame(opText, []byte(s))
+}
+
+// incomplete returns the length of the incomplete UTF-8 encoded character
+// ending the bytes, if any.
+func incomplete(b []byte) int {
+	for n := 1; n < utf8.UTFMax && n <= len(b); n++ {
+		c := b[len(b)-n]
+		if utf8.RuneStart(c) {
+			if c >= utf8.RuneSelf && !utf8.FullRune(b[len(b)-n:]) {
+				return n
+			}
+			return 0
+		}
+	}
+	return 0
+}
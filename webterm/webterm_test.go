@@ -0,0 +1,192 @@
+package webterm
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/beevik/cmd"
+)
+
+func buildTree() *cmd.Tree {
+	tree := cmd.NewTree(cmd.TreeDescriptor{Name: "tree"})
+	tree.AddCommand(cmd.CommandDescriptor{
+		Name: "echo",
+		Handler: func(ctx *cmd.ExecContext, args []string) error {
+			ctx.Println(strings.Join(args, " "))
+			return nil
+		},
+	})
+	tree.AddCommand(cmd.CommandDescriptor{
+		Name: "wait",
+		Handler: func(ctx *cmd.ExecContext, args []string) error {
+			ctx.Println("waiting")
+			<-ctx.Context().Done()
+			return ctx.CheckInterrupt()
+		},
+	})
+	tree.AddCommand(cmd.CommandDescriptor{
+		Name: "quit",
+		Handler: func(ctx *cmd.ExecContext, args []string) error {
+			return cmd.ErrExit
+		},
+	})
+	return tree
+}
+
+// A client is the browser side of a WebSocket connection.
+type client struct {
+	c  net.Conn
+	br *bufio.Reader
+}
+
+func dial(t *testing.T, url string) *client {
+	c, err := net.Dial("tcp", strings.TrimPrefix(url, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	c.SetDeadline(time.Now().Add(5 * time.Second))
+
+	key := "dGhlIHNhbXBsZSBub25jZQ=="
+	io.WriteString(c, "GET / HTTP/1.1\r\nHost: example.com\r\n"+
+		"Upgrade: websocket\r\nConnection: keep-alive, Upgrade\r\n"+
+		"Sec-WebSocket-Key: "+key+"\r\nSec-WebSocket-Version: 13\r\n\r\n")
+	br := bufio.NewReader(c)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols ||
+		resp.Header.Get("Sec-WebSocket-Accept") != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("unexpected handshake response: %v", resp)
+	}
+	return &client{c: c, br: br}
+}
+
+func (c *client) send(op byte, payload string) {
+	mask := []byte{1, 2, 3, 4}
+	b := []byte{0x80 | op, 0x80 | byte(len(payload))}
+	b = append(b, mask...)
+	for i := range len(payload) {
+		b = append(b, payload[i]^mask[i%4])
+	}
+	c.c.Write(b)
+}
+
+// readUntil reads the server's messages until the output includes the
+// text, returning the output.
+func (c *client) readUntil(t *testing.T, text string) string {
+	var out strings.Builder
+	for !strings.Contains(out.String(), text) {
+		var h [2]byte
+		if _, err := io.ReadFull(c.br, h[:]); err != nil {
+			t.Fatalf("expected %q, got %q: %v", text, out.String(), err)
+		}
+		n := int(h[1])
+		if n == 126 {
+			var b [2]byte
+			io.ReadFull(c.br, b[:])
+			n = int(binary.BigEndian.Uint16(b[:]))
+		}
+		payload := make([]byte, n)
+		io.ReadFull(c.br, payload)
+		switch h[0] & 0x0f {
+		case opText:
+			out.Write(payload)
+		case opClose:
+			out.WriteString("<close>")
+		}
+	}
+	return out.String()
+}
+
+func TestSession(t *testing.T) {
+	resized := make(chan [2]int, 1)
+	h := &Handler{
+		Tree: buildTree(),
+		Setup: func(s *Session) {
+			s.Runner.Prompt = "$ "
+			s.OnResize = func(cols, rows int) { resized <- [2]int{cols, rows} }
+		},
+	}
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	c := dial(t, srv.URL)
+	c.readUntil(t, "$ ")
+
+	c.send(opBinary, "echo h\xc3")
+	c.send(opBinary, "\xa9llo\r")
+	if out := c.readUntil(t, "héllo\r\n"); strings.Contains(out, "�") {
+		t.Errorf("character split: %q", out)
+	}
+
+	c.send(opText, `{"type": "input", "data": "echo 1\r"}`)
+	c.readUntil(t, "1\r\n")
+
+	c.send(opText, `{"type": "resize", "cols": 120, "rows": 40}`)
+	if got := <-resized; got != [2]int{120, 40} {
+		t.Errorf("unexpected size %v", got)
+	}
+
+	c.send(opBinary, "wait\r")
+	c.readUntil(t, "waiting\r\n")
+	c.send(opBinary, "\x03")
+	c.readUntil(t, "Interrupted.\r\n")
+
+	c.send(opBinary, "quit\r")
+	c.readUntil(t, "<close>")
+}
+
+func TestHandshake(t *testing.T) {
+	h := &Handler{Tree: buildTree()}
+
+	cases := []struct {
+		header map[string]string
+		status int
+	}{
+		{map[string]string{"Upgrade": "websocket", "Connection": "Upgrade"}, http.StatusBadRequest},
+		{map[string]string{"Origin": "http://evil.example"}, http.StatusForbidden},
+	}
+
+	for i, c := range cases {
+		r := httptest.NewRequest("GET", "http://example.com/", nil)
+		for k, v := range c.header {
+			r.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != c.status {
+			t.Errorf("Case %d: expected status %d, got %d", i, c.status, w.Code)
+		}
+	}
+}
+
+func TestIncomplete(t *testing.T) {
+	cases := []struct {
+		s string
+		n int
+	}{
+		{"abc", 0},
+		{"", 0},
+		{"é", 0},
+		{"a\xc3", 1},
+		{"a\xe2\x82", 2},
+		{"\xf0\x9f\x98", 3},
+		{"\xf0\x9f\x98\x80", 0},
+		{"a\x82", 0},
+	}
+
+	for i, c := range cases {
+		if n := incomplete([]byte(c.s)); n != c.n {
+			t.Errorf("Case %d: expected %d, got %d", i, c.n, n)
+		}
+	}
+}
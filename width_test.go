@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDisplayWidth(t *testing.T) {
+	cases := []struct {
+		s     string
+		width int
+	}{
+		{"", 0},
+		{"open", 4},
+		{"café", 4},
+		{"café", 4},
+		{"開く", 4},
+		{"ﾌｧｲﾙ", 4},
+		{"ファイル", 8},
+		{"파일", 4},
+		{"🚀go", 4},
+	}
+
+	for i, c := range cases {
+		if w := displayWidth(c.s); w != c.width {
+			t.Errorf("Case %d: displayWidth(%q) = %d, wanted %d", i, c.s, w, c.width)
+		}
+	}
+}
+
+func TestUnicodeTokenize(t *testing.T) {
+	tree := NewTree(TreeDescriptor{Name: "tree"})
+	file := tree.AddSubtree(TreeDescriptor{Name: "ファイル"})
+	file.AddCommand(CommandDescriptor{Name: "開く", Data: "open"})
+
+	lines := []string{
+		"ファイル 開く a b",
+		"ファイル 開く a b",
+		"　ファイル　開く a　b",
+		"ファ 開 a  b",
+	}
+	for i, line := range lines {
+		n, args, err := tree.Lookup(line)
+		if err != nil {
+			t.Errorf("Case %d: unexpected error: %v", i, err)
+			continue
+		}
+		if c, ok := n.(*Command); !ok || c.Data != "open" {
+			t.Errorf("Case %d: unexpected node", i)
+		}
+		if strings.Join(args, ",") != "a,b" {
+			t.Errorf("Case %d: unexpected args %q", i, args)
+		}
+	}
+}
+
+func TestUnicodeHelpAlignment(t *testing.T) {
+	tree := NewTree(TreeDescriptor{Name: "tree"})
+	tree.AddCommand(CommandDescriptor{Name: "開く", Brief: "open"})
+	tree.AddCommand(CommandDescriptor{Name: "ls", Brief: "list"})
+	tree.AddCommand(CommandDescriptor{Name: "ファイル", Brief: "file"})
+
+	buf := new(bytes.Buffer)
+	tree.DisplayHelp(buf)
+	want := "tree commands:\n" +
+		"    ls        list\n" +
+		"    ファイル  file\n" +
+		"    開く      open\n" +
+		"\n"
+	if buf.String() != want {
+		t.Errorf("unexpected result.\nEXPECTED:\n%s\nGOT:\n%s\n", want, buf.String())
+	}
+
+	table := &Table{Headers: []string{"名前", "Value"}, Rows: [][]string{{"a", "1"}}}
+	buf.Reset()
+	table.Write(buf)
+	if want := "名前  Value\na     1\n"; buf.String() != want {
+		t.Errorf("unexpected table.\nEXPECTED:\n%s\nGOT:\n%s\n", want, buf.String())
+	}
+}
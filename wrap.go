@@ -0,0 +1,162 @@
+package cmd
+
+import "strings"
+
+// An Overflow value selects how Wrap handles words too long to fit on a
+// line, such as URLs and hex dumps.
+type Overflow int
+
+// Overflow modes.
+const (
+	OverflowKeep     Overflow = iota // place the word on a line of its own
+	OverflowBreak                    // break the word across several lines
+	OverflowEllipsis                 // truncate the word, ending it with '…'
+)
+
+// WrapOptions control the wrapping of text by Wrap.
+type WrapOptions struct {
+	Width    int      // columns available to each line (zero selects 80)
+	Indent   int      // indentation of every line
+	Hanging  int      // additional indentation of lines following the first
+	Overflow Overflow // handling of words longer than a line
+}
+
+// Wrap splits the words of s into lines occupying fewer than the width's
+// columns, including their indentation, and returns the indented lines
+// separated by newlines. Words longer than a line are handled as selected
+// by the Overflow option. Help output is wrapped by Wrap, so command handlers may align
+// their output with help text by wrapping it to the tree's HelpWidth:
+//
+//	cmd.Wrap(text, cmd.WrapOptions{Width: ctx.Runner.Tree.HelpWidth(), Indent: 3})
+func Wrap(s string, o WrapOptions) string {
+	width := o.Width
+	if width <= 0 {
+		width = wrapWidth
+	}
+	first, rest := o.Indent, o.Indent+o.Hanging
+	lines := wrapLines(s, width-first, width-rest, o.Overflow)
+
+	var b strings.Builder
+	for i, line := range lines {
+		indent := rest
+		if i == 0 {
+			indent = first
+		} else {
+			b.WriteByte('\n')
+		}
+		for range indent {
+			b.WriteByte(' ')
+		}
+		b.WriteString(line)
+	}
+	return b.String()
+}
+
+// IndentWrap wraps s to the width, indenting each line by indent spaces. It
+// is shorthand for Wrap with an Indent and no Hanging indentation.
+func IndentWrap(width, indent int, s string) string {
+	return Wrap(s, WrapOptions{Width: width, Indent: indent})
+}
+
+// wrapText splits the words of s into lines occupying fewer than width
+// terminal columns.
+// Words longer than the width are placed on lines of their own.
+func wrapText(s string, width int) []string {
+	return wrapLines(s, width, width, OverflowKeep)
+}
+
+// wrapLines splits the words of s into lines, the first of which occupies
+// fewer than first terminal columns and the others fewer than rest columns.
+// Words too long for a line are handled as selected by overflow.
+func wrapLines(s string, first, rest int, overflow Overflow) []string {
+	var lines []string
+	start, end, l := -1, 0, 0
+	single := true // words of the current line are separated by single spaces
+	width := first
+
+	flush := func() {
+		line := s[start:end]
+		if !single {
+			line = strings.Join(strings.Fields(line), " ")
+		}
+		lines = append(lines, line)
+		width = rest
+	}
+
+	for i := 0; i < len(s); {
+		// Find the next word, skipping the whitespace preceding it.
+		ws := i
+		for ws < len(s) && isSpaceByte(s[ws]) {
+			ws++
+		}
+		if ws == len(s) {
+			break
+		}
+		we := ws
+		for we < len(s) && !isSpaceByte(s[we]) {
+			we++
+		}
+		i = we
+
+		n := displayWidth(s[ws:we])
+		if overflow != OverflowKeep && n >= width && width > 2 {
+			if start >= 0 {
+				flush()
+				start = -1
+			}
+			for n >= width {
+				head, tail := cutWidth(s[ws:we], width-1)
+				if overflow == OverflowEllipsis {
+					head, _ = cutWidth(s[ws:we], width-2)
+					lines = append(lines, head+"…")
+					width = rest
+					ws, n = we, 0
+					break
+				}
+				lines = append(lines, head)
+				width = rest
+				ws, n = we-len(tail), displayWidth(tail)
+			}
+			if ws == we {
+				continue
+			}
+		}
+		switch {
+		case start < 0:
+			start, end, l, single = ws, we, n, true
+		case l+1+n < width:
+			single = single && ws-end == 1 && s[end] == ' '
+			end, l = we, l+1+n
+		default:
+			flush()
+			start, end, l, single = ws, we, n, true
+		}
+	}
+	if start >= 0 {
+		flush()
+	}
+	return lines
+}
+
+// isSpaceByte returns true if the byte is an ASCII whitespace character.
+func isSpaceByte(b byte) bool {
+	switch b {
+	case ' ', '\t', '\n', '\v', '\f', '\r':
+		return true
+	}
+	return false
+}
+
+// cutWidth splits s after its longest prefix occupying at most cols terminal
+// columns. The prefix holds at least one character.
+func cutWidth(s string, cols int) (head, tail string) {
+	w := 0
+	for i, r := range s {
+		rw := runeWidth(r)
+		if w+rw > cols && i > 0 {
+			return s[:i], s[i:]
+		}
+		w += rw
+	}
+	return s, ""
+}
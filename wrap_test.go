@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWrapText(t *testing.T) {
+	cases := []struct {
+		s        string
+		width    int
+		expected []string
+	}{
+		{"", 10, nil},
+		{"   ", 10, nil},
+		{"one two three", 20, []string{"one two three"}},
+		{"one two three", 8, []string{"one two", "three"}},
+		{"  one\ttwo\n three  ", 20, []string{"one two three"}},
+		{"one  two three", 8, []string{"one two", "three"}},
+		{"a verylongword b", 5, []string{"a", "verylongword", "b"}},
+	}
+
+	for i, c := range cases {
+		lines := wrapText(c.s, c.width)
+		if strings.Join(lines, "|") != strings.Join(c.expected, "|") || len(lines) != len(c.expected) {
+			t.Errorf("Case %d: expected %q, got %q", i, c.expected, lines)
+		}
+	}
+}
+
+func TestWrap(t *testing.T) {
+	cases := []struct {
+		s        string
+		opts     WrapOptions
+		expected string
+	}{
+		{"", WrapOptions{Width: 10, Indent: 2}, ""},
+		{"one two three four", WrapOptions{Width: 12, Indent: 2}, "  one two\n  three\n  four"},
+		{"one two three four", WrapOptions{Width: 12, Indent: 2, Hanging: 2}, "  one two\n    three\n    four"},
+		{"one two three four", WrapOptions{Width: 14, Hanging: 4}, "one two three\n    four"},
+		{"a b", WrapOptions{}, "a b"},
+	}
+	for i, c := range cases {
+		if got := Wrap(c.s, c.opts); got != c.expected {
+			t.Errorf("Case %d: expected %q, got %q", i, c.expected, got)
+		}
+	}
+
+	if got := IndentWrap(12, 2, "one two three"); got != "  one two\n  three" {
+		t.Errorf("IndentWrap: got %q", got)
+	}
+}
+
+func TestWrapOverflow(t *testing.T) {
+	cases := []struct {
+		s        string
+		width    int
+		overflow Overflow
+		expected []string
+	}{
+		{"see http://example.com/abc now", 10, OverflowKeep, []string{"see", "http://example.com/abc", "now"}},
+		{"see http://example.com/abc now", 10, OverflowBreak, []string{"see", "http://ex", "ample.com", "/abc now"}},
+		{"see http://example.com/abc now", 10, OverflowEllipsis, []string{"see", "http://e…", "now"}},
+		{"0123456789", 6, OverflowBreak, []string{"01234", "56789"}},
+		{"a 日本語日本語", 6, OverflowBreak, []string{"a", "日本", "語日", "本語"}},
+		{"short words", 10, OverflowBreak, []string{"short", "words"}},
+	}
+	for i, c := range cases {
+		lines := wrapLines(c.s, c.width, c.width, c.overflow)
+		if strings.Join(lines, "|") != strings.Join(c.expected, "|") {
+			t.Errorf("Case %d: expected %q, got %q", i, c.expected, lines)
+		}
+	}
+}
+
+func TestHelpOverflow(t *testing.T) {
+	tree := NewTree(TreeDescriptor{Name: "app"})
+	tree.AddCommand(CommandDescriptor{Name: "fetch", Brief: "Fetch https://example.com/a/very/long/path/to/a/resource"})
+	tree.SetHelpWidth(40)
+	tree.SetHelpOverflow(OverflowEllipsis)
+
+	var b strings.Builder
+	tree.DisplayHelp(&b)
+	for _, line := range strings.Split(b.String(), "\n") {
+		if displayWidth(line) >= 40 {
+			t.Errorf("Line too wide: %q", line)
+		}
+	}
+	if !strings.Contains(b.String(), "…") {
+		t.Errorf("Expected truncated word in:\n%s", b.String())
+	}
+}